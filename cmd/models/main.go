@@ -0,0 +1,126 @@
+// Command models downloads and lists the GGUF models used by the examples.
+//
+//	go run ./cmd/models pull Qwen3-1.7B-Q8_0
+//	go run ./cmd/models pull hf:Qwen/Qwen3-1.7B-GGUF/Qwen3-1.7B-Q8_0.gguf
+//	go run ./cmd/models list
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/llm/modelhub"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: models [-dir DIR] <command> [args]
+
+Commands:
+  pull <model>...   download models (a known name or hf:owner/repo/file.gguf)
+  path <model>      print where a model is stored
+  list              list downloaded models
+  known             list the known model names
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	dir := flag.String("dir", modelhub.DefaultDir(), "model cache directory")
+	unverified := flag.Bool("allow-unverified", false, "download models whose checksum is unknown")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client := modelhub.NewClient(*dir)
+	client.AllowUnverified = *unverified
+	args := flag.Args()[1:]
+	switch flag.Arg(0) {
+	case "pull":
+		if len(args) == 0 {
+			log.Fatal("pull: missing model name")
+		}
+		client.Progress = printProgress
+		for _, ref := range args {
+			path, err := client.Pull(ctx, ref)
+			fmt.Println()
+			if err != nil {
+				log.Fatalf("Failed to pull %s: %v", ref, err)
+			}
+			fmt.Printf("✓ %s\n", path)
+		}
+
+	case "path":
+		if len(args) != 1 {
+			log.Fatal("path: expected one model name")
+		}
+		m, err := modelhub.Resolve(args[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(client.Path(m))
+
+	case "list":
+		models, err := client.List()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(models) == 0 {
+			fmt.Printf("No models in %s\n", client.Dir)
+		}
+		for _, m := range models {
+			fmt.Printf("%-40s %8s  %s\n", m.Name, formatBytes(m.Size), m.Path)
+		}
+
+	case "known":
+		names := make([]string, 0, len(modelhub.Known))
+		for name := range modelhub.Known {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			m := modelhub.Known[name]
+			fmt.Printf("%-40s %s/%s\n", name, m.Repo, m.File)
+		}
+
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+// printProgress redraws a progress line for the current download
+func printProgress(file string, done, total int64) {
+	if total > 0 {
+		fmt.Printf("\r%s: %s / %s (%.1f%%)", file, formatBytes(done), formatBytes(total),
+			float64(done)*100/float64(total))
+		return
+	}
+	fmt.Printf("\r%s: %s", file, formatBytes(done))
+}
+
+// formatBytes renders a size as "1.8 GB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
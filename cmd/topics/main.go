@@ -0,0 +1,146 @@
+// Command topics clusters a document set by embedding and names each
+// cluster with the chat model, to explore a corpus before building
+// retrieval over it.
+//
+//	go run ./cmd/topics -embed models/nomic-embed-text-v1.5.Q8_0.gguf docs/*.md
+//	cat notes.txt | go run ./cmd/topics -embed models/e5.gguf -k 5
+//
+// The chat model is configured with the AGENT_* environment variables.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/chains"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/llm"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: topics -embed MODEL [flags] [file...]
+
+Each file is one document. Without files, documents are read from stdin,
+separated by blank lines (or one per line with -lines).
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	embedModel := flag.String("embed", os.Getenv("EMBED_MODEL"), "GGUF embedding model (default $EMBED_MODEL)")
+	k := flag.Int("k", 0, "number of topics (0 picks it automatically)")
+	eps := flag.Float64("eps", 0, "use density clustering with this cosine distance radius, e.g. 0.3")
+	minSize := flag.Int("min-size", 3, "neighbors a document needs to start a topic with -eps")
+	samples := flag.Int("samples", 5, "documents shown to the model to name a topic")
+	lines := flag.Bool("lines", false, "read one document per line from stdin")
+	asJSON := flag.Bool("json", false, "print the topics as JSON")
+	flag.Usage = usage
+	flag.Parse()
+	if *embedModel == "" {
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	docs, err := readDocuments(flag.Args(), *lines)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(docs) < 2 {
+		log.Fatalf("Need at least 2 documents, got %d", len(docs))
+	}
+
+	embedder, err := llm.NewLlamaCppEmbedder(llm.LlamaCppEmbedderConfig{
+		ModelPath: *embedModel,
+		Normalize: true,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load embedding model: %v", err)
+	}
+	defer embedder.Close()
+
+	cfg, err := llm.ConfigFromEnv("AGENT")
+	if err != nil {
+		log.Fatal(err)
+	}
+	model, err := llm.NewChatModel(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load chat model: %v", err)
+	}
+	defer model.Close()
+
+	discovery := chains.NewTopicDiscovery(model, embedder).
+		WithClusters(*k).
+		WithSamples(*samples)
+	if *eps > 0 {
+		discovery.WithDensity(*eps, *minSize)
+	}
+
+	fmt.Fprintf(os.Stderr, "Clustering %d documents...\n", len(docs))
+	topics, err := discovery.Discover(ctx, docs, nil)
+	if err != nil {
+		log.Fatalf("Topic discovery failed: %v", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(topics); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	fmt.Println(topics.Markdown(docs))
+}
+
+// readDocuments loads one document per file, or splits stdin
+func readDocuments(files []string, lines bool) ([]string, error) {
+	if len(files) > 0 {
+		docs := make([]string, 0, len(files))
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if text := strings.TrimSpace(string(data)); text != "" {
+				docs = append(docs, text)
+			}
+		}
+		return docs, nil
+	}
+
+	if !lines {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		var docs []string
+		for _, p := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n\n") {
+			if p = strings.TrimSpace(p); p != "" {
+				docs = append(docs, p)
+			}
+		}
+		return docs, nil
+	}
+
+	var docs []string
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			docs = append(docs, line)
+		}
+	}
+	return docs, scanner.Err()
+}
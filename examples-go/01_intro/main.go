@@ -4,25 +4,39 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"path/filepath"
 
 	"github.com/mgreau/ai-agents-from-scratch-go/pkg/llm"
 )
 
 func main() {
-	// Get model path
-	modelPath, err := filepath.Abs("../../models/Qwen3-1.7B-Q8_0.gguf")
+	// Resolve the model registered as "qwen3-small"
+	modelPath, err := llm.DefaultRegistry.Path("qwen3-small")
 	if err != nil {
-		log.Fatalf("Failed to get model path: %v", err)
+		log.Fatalf("Failed to find model: %v", err)
 	}
 
-	// Create LLM instance
-	llamaLLM, err := llm.NewLlamaCppLLM(llm.LlamaCppConfig{
+	// Check the model before loading it
+	info, err := llm.InspectModel(modelPath)
+	if err != nil {
+		log.Fatalf("Failed to inspect model: %v", err)
+	}
+	fmt.Printf("Model: %s\n", info)
+
+	config := llm.LlamaCppConfig{
 		ModelPath:   modelPath,
-		ContextSize: 2048,
+		ContextSize: info.ContextSize(2048),
 		Temperature: 0.7,
 		Threads:     4,
-	})
+	}
+	for _, warning := range info.Warnings(config) {
+		log.Printf("Warning: %s", warning)
+	}
+	if estimate, err := llm.EstimateMemory(modelPath, config.ContextSize, config.GPULayers); err == nil {
+		fmt.Printf("Memory: %s\n", estimate)
+	}
+
+	// Create LLM instance
+	llamaLLM, err := llm.NewLlamaCppLLM(config)
 	if err != nil {
 		log.Fatalf("Failed to create LLM: %v", err)
 	}
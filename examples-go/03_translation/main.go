@@ -4,23 +4,23 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"path/filepath"
 
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/chains"
 	"github.com/mgreau/ai-agents-from-scratch-go/pkg/llm"
 )
 
 func main() {
-	// Get model path
-	modelPath, err := filepath.Abs("../../models/Qwen3-1.7B-Q8_0.gguf")
+	// Resolve the model registered as "qwen3-small"
+	modelPath, err := llm.DefaultRegistry.Path("qwen3-small")
 	if err != nil {
-		log.Fatalf("Failed to get model path: %v", err)
+		log.Fatalf("Failed to find model: %v", err)
 	}
 
 	// Create LLM instance with system prompt
 	systemPrompt := `You are a professional translator who specializes in technical documentation.
 	
 Always translate text accurately while preserving technical terms and maintaining the original tone.
-Provide only the translation without any additional explanation.`
+Follow the requested output format exactly, without any additional explanation.`
 
 	llamaLLM, err := llm.NewLlamaCppLLM(llm.LlamaCppConfig{
 		ModelPath:    modelPath,
@@ -34,15 +34,39 @@ Provide only the translation without any additional explanation.`
 	}
 	defer llamaLLM.Close()
 
-	// Create translation prompt
-	prompt := "Translate the following English text to French: 'Hello, how are you today?'"
+	// A document too long to translate in one prompt: the chain splits it
+	// into chunks, keeps code blocks as-is and reuses the same translation
+	// for each technical term across chunks
+	document := `# Getting Started
+
+An agent combines a language model with tools. The agent decides which tool
+to call, reads the tool result, and repeats until it can answer.
+
+## Installing
+
+Download a model in GGUF format and point the agent at it:
+
+` + "```bash\nmake download-model\n```" + `
+
+## Tools
+
+- A tool has a name, a description and a JSON schema for its arguments.
+- The agent only sees the description, so keep it short and precise.
+`
+
+	translator := chains.NewDocumentTranslator(llamaLLM, "French").
+		WithChunkSize(400).
+		WithGlossary(map[string]string{"agent": "agent"})
 
-	// Get response
 	ctx := context.Background()
-	response, err := llamaLLM.Invoke(ctx, prompt, nil)
+	translation, glossary, err := translator.TranslateDocument(ctx, document, nil)
 	if err != nil {
-		log.Fatalf("Failed to get response: %v", err)
+		log.Fatalf("Failed to translate document: %v", err)
 	}
 
-	fmt.Printf("Translation: %s\n", response)
+	fmt.Printf("Translation:\n\n%s\n", translation)
+	fmt.Println("Glossary:")
+	for term, tr := range glossary {
+		fmt.Printf("  %s => %s\n", term, tr)
+	}
 }
@@ -4,16 +4,15 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"path/filepath"
 
 	"github.com/mgreau/ai-agents-from-scratch-go/pkg/llm"
 )
 
 func main() {
-	// Get model path
-	modelPath, err := filepath.Abs("../../models/Qwen3-1.7B-Q8_0.gguf")
+	// Resolve the model registered as "qwen3-small"
+	modelPath, err := llm.DefaultRegistry.Path("qwen3-small")
 	if err != nil {
-		log.Fatalf("Failed to get model path: %v", err)
+		log.Fatalf("Failed to find model: %v", err)
 	}
 
 	// Create LLM instance optimized for reasoning
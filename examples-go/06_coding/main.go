@@ -4,16 +4,19 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"path/filepath"
+	"os"
 
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/chains"
 	"github.com/mgreau/ai-agents-from-scratch-go/pkg/llm"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/term"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/tools"
 )
 
 func main() {
-	// Get model path
-	modelPath, err := filepath.Abs("../../models/Qwen3-1.7B-Q8_0.gguf")
+	// Resolve the model registered as "qwen3-small"
+	modelPath, err := llm.DefaultRegistry.Path("qwen3-small")
 	if err != nil {
-		log.Fatalf("Failed to get model path: %v", err)
+		log.Fatalf("Failed to find model: %v", err)
 	}
 
 	// Create LLM instance
@@ -31,24 +34,25 @@ func main() {
 	}
 	defer llamaLLM.Close()
 
-	// Create coding prompt
-	prompt := "Write a simple Go function that calculates the factorial of a number using recursion."
+	// Generate code, then compile and vet it in a sandbox, feeding any
+	// errors back to the model until it builds
+	coder := chains.NewCodeGenWithRepair(llamaLLM, tools.NewGoSandbox()).WithMaxAttempts(3)
+
+	task := "Write a Go program with a function that calculates the factorial of a number using recursion, and print factorial(10) from main."
 
-	// Stream response
 	ctx := context.Background()
-	fmt.Println("AI: ")
-	
-	stream, err := llamaLLM.Stream(ctx, prompt, nil)
+	output, err := coder.Invoke(ctx, task, nil)
 	if err != nil {
-		log.Fatalf("Failed to stream response: %v", err)
+		log.Fatalf("Code generation failed: %v", err)
 	}
 
-	// Print tokens as they arrive
-	for token := range stream {
-		if err, ok := token.(error); ok {
-			log.Fatalf("Stream error: %v", err)
-		}
-		fmt.Print(token)
+	result := output.(*chains.CodeGenResult)
+	// Render the code with syntax highlighting when stdout is a terminal
+	fmt.Printf("AI (after %d attempt(s)):\n\n", result.Attempts)
+	renderer := term.NewMarkdownRenderer(os.Stdout)
+	renderer.WriteString("```go\n" + result.Code + "\n```\n")
+	renderer.Flush()
+	if !result.Compiles {
+		fmt.Printf("Code still does not compile:\n%s\n", result.Diagnostics)
 	}
-	fmt.Println()
 }
@@ -4,17 +4,17 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"path/filepath"
 
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
 	"github.com/mgreau/ai-agents-from-scratch-go/pkg/llm"
 	"github.com/mgreau/ai-agents-from-scratch-go/pkg/tools"
 )
 
 func main() {
-	// Get model path
-	modelPath, err := filepath.Abs("../../models/Qwen3-1.7B-Q8_0.gguf")
+	// Resolve the model registered as "qwen3-small"
+	modelPath, err := llm.DefaultRegistry.Path("qwen3-small")
 	if err != nil {
-		log.Fatalf("Failed to get model path: %v", err)
+		log.Fatalf("Failed to find model: %v", err)
 	}
 
 	// Create tool registry
@@ -22,47 +22,51 @@ func main() {
 	registry.Register(tools.NewGetCurrentTimeTool())
 	registry.Register(tools.NewCalculatorTool())
 
-	// Create LLM instance with system prompt
-	systemPrompt := `You are a helpful assistant with access to tools.
-When you need to use a tool, respond with the tool name and its arguments.
-Always convert times from 12-hour format to 24-hour format without seconds.`
-
+	// Create LLM instance
 	llamaLLM, err := llm.NewLlamaCppLLM(llm.LlamaCppConfig{
-		ModelPath:    modelPath,
-		ContextSize:  2048,
-		Temperature:  0.7,
-		Threads:      4,
-		SystemPrompt: systemPrompt,
+		ModelPath:   modelPath,
+		ContextSize: 2048,
+		Temperature: 0.7,
+		Threads:     4,
 	})
 	if err != nil {
 		log.Fatalf("Failed to create LLM: %v", err)
 	}
 	defer llamaLLM.Close()
 
+	// Small GGUF models are not trained for function calling, so the tools
+	// are taught with few-shot examples and a JSON grammar, and the replies
+	// come back as tool calls
+	model := llm.WithPromptedTools(llamaLLM, registry.GetFunctionDefinitions())
+
 	// User query
 	prompt := "What time is it right now?"
+	fmt.Printf("User: %s\n", prompt)
 
-	// Get response
 	ctx := context.Background()
-	response, err := llamaLLM.Invoke(ctx, prompt, nil)
-	if err != nil {
-		log.Fatalf("Failed to get response: %v", err)
+	messages := []core.Message{
+		core.NewSystemMessage(`You are a helpful assistant with access to tools.
+Always convert times from 12-hour format to 24-hour format without seconds.`, nil),
+		core.NewHumanMessage(prompt, nil),
 	}
 
-	fmt.Printf("User: %s\n", prompt)
-	fmt.Printf("AI: %s\n", response)
-
-	// In a real implementation, you would:
-	// 1. Parse the response to detect tool calls
-	// 2. Execute the requested tools
-	// 3. Send tool results back to the LLM
-	// 4. Get the final response
+	// 1. Ask the model, 2. run the tools it calls, 3. send the results back,
+	// until it answers
+	for step := 0; step < 5; step++ {
+		reply, err := model.Chat(ctx, messages)
+		if err != nil {
+			log.Fatalf("Failed to get response: %v", err)
+		}
+		messages = append(messages, reply)
+		if len(reply.ToolCalls) == 0 {
+			fmt.Printf("AI: %s\n", reply.Content)
+			return
+		}
 
-	// For demonstration, let's manually call the tool
-	fmt.Println("\n--- Tool Execution ---")
-	timeResult, err := registry.ExecuteTool(ctx, "getCurrentTime", "")
-	if err != nil {
-		log.Fatalf("Failed to execute tool: %v", err)
+		for _, result := range registry.ExecuteToolCalls(ctx, reply.ToolCalls) {
+			fmt.Printf("Tool result: %s\n", result.Content)
+			messages = append(messages, result)
+		}
 	}
-	fmt.Printf("Tool result: %s\n", timeResult)
+	fmt.Println("AI: (no answer after 5 steps)")
 }
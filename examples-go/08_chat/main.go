@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/llm"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/memory"
+)
+
+func main() {
+	// Resolve the model registered as "qwen3-small"
+	modelPath, err := llm.DefaultRegistry.Path("qwen3-small")
+	if err != nil {
+		log.Fatalf("Failed to find model: %v", err)
+	}
+
+	llamaLLM, err := llm.NewLlamaCppLLM(llm.LlamaCppConfig{
+		ModelPath:   modelPath,
+		ContextSize: 4096,
+		Temperature: 0.7,
+		Threads:     4,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create LLM: %v", err)
+	}
+	defer llamaLLM.Close()
+
+	// The session sends the system prompt and the history on every turn,
+	// so the model remembers what was said before. A Conversation as the
+	// history lets the chat be saved and resumed later.
+	conversation := memory.NewConversation()
+	chat := llm.NewChatSession(llamaLLM).
+		WithSystemPrompt("You are a friendly assistant. Keep your answers short.").
+		WithHistory(conversation).
+		WithMaxMessages(20)
+
+	ctx := context.Background()
+	for _, question := range []string{
+		"Hi! My name is Ada and I am learning Go.",
+		"Suggest a small project to practice goroutines.",
+	} {
+		fmt.Printf("You: %s\n", question)
+		reply, err := chat.Send(ctx, question)
+		if err != nil {
+			log.Fatalf("Chat failed: %v", err)
+		}
+		fmt.Printf("AI: %s\n\n", reply.Text)
+	}
+
+	// Stream the last answer token by token
+	question := "What is my name, and what am I learning?"
+	fmt.Printf("You: %s\nAI: ", question)
+	stream, err := chat.SendStream(ctx, question)
+	if err != nil {
+		log.Fatalf("Chat failed: %v", err)
+	}
+	for chunk := range stream {
+		if err, ok := chunk.(error); ok {
+			log.Fatalf("Chat failed: %v", err)
+		}
+		fmt.Print(chunk)
+	}
+	fmt.Println()
+
+	if err := conversation.Save("chat.json"); err != nil {
+		log.Fatalf("Failed to save chat: %v", err)
+	}
+	fmt.Printf("\nSaved %d messages to chat.json\n", len(chat.Messages()))
+}
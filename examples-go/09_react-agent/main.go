@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"path/filepath"
 
 	"github.com/mgreau/ai-agents-from-scratch-go/pkg/agents"
 	"github.com/mgreau/ai-agents-from-scratch-go/pkg/llm"
@@ -12,19 +11,8 @@ import (
 )
 
 func main() {
-	// Get model path
-	modelPath, err := filepath.Abs("../../models/Qwen3-1.7B-Q8_0.gguf")
-	if err != nil {
-		log.Fatalf("Failed to get model path: %v", err)
-	}
-
-	// Create LLM instance
-	llamaLLM, err := llm.NewLlamaCppLLM(llm.LlamaCppConfig{
-		ModelPath:   modelPath,
-		ContextSize: 2048,
-		Temperature: 0.7,
-		Threads:     4,
-	})
+	// Create the model registered as "qwen3-small" with its defaults
+	llamaLLM, err := llm.FromRegistry("qwen3-small")
 	if err != nil {
 		log.Fatalf("Failed to create LLM: %v", err)
 	}
@@ -2,4 +2,23 @@ module github.com/mgreau/ai-agents-from-scratch-go
 
 go 1.23
 
-require github.com/go-skynet/go-llama.cpp v0.0.0-20231009155254-aeba71ee8428
+require (
+	github.com/chromedp/chromedp v0.9.5
+	github.com/emersion/go-imap v1.2.1
+	github.com/go-skynet/go-llama.cpp v0.0.0-20231009155254-aeba71ee8428
+	go.etcd.io/bbolt v1.3.10
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/chromedp/cdproto v0.0.0-20240202021202-6d0b6a386732 // indirect
+	github.com/chromedp/sysutil v1.0.0 // indirect
+	github.com/emersion/go-sasl v0.0.0-20200509203442-7bfe0ed36a21 // indirect
+	github.com/gobwas/httphead v0.1.0 // indirect
+	github.com/gobwas/pool v0.2.1 // indirect
+	github.com/gobwas/ws v1.3.2 // indirect
+	github.com/josharian/intern v1.0.0 // indirect
+	github.com/mailru/easyjson v0.7.7 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+	golang.org/x/text v0.12.0 // indirect
+)
@@ -0,0 +1,183 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// MatchFunc finds a previously cached question that means the same as question.
+// It returns the matching candidate and true, or false when nothing is close enough.
+// Use it to plug in semantic matching (e.g. embedding similarity) on top of
+// the exact normalized-key lookup.
+type MatchFunc func(ctx context.Context, question string, candidates []string) (string, bool, error)
+
+// cachedAnswer is an answer along with when it was stored
+type cachedAnswer struct {
+	answer   string
+	storedAt time.Time
+}
+
+// AnswerCache stores verified agent answers keyed by normalized question
+type AnswerCache struct {
+	mu        sync.RWMutex
+	answers   map[string]cachedAnswer
+	matcher   MatchFunc
+	ttl       time.Duration
+	hits      int
+	misses    int
+	evictions int
+}
+
+// NewAnswerCache creates an empty answer cache
+func NewAnswerCache() *AnswerCache {
+	return &AnswerCache{
+		answers: make(map[string]cachedAnswer),
+	}
+}
+
+// WithMatcher sets an optional semantic matcher used when there is no exact hit
+func (c *AnswerCache) WithMatcher(matcher MatchFunc) *AnswerCache {
+	c.matcher = matcher
+	return c
+}
+
+// WithTTL makes answers expire after ttl; zero keeps them forever
+func (c *AnswerCache) WithTTL(ttl time.Duration) *AnswerCache {
+	c.ttl = ttl
+	return c
+}
+
+// NormalizeQuestion lowercases the question, strips punctuation and collapses whitespace
+func NormalizeQuestion(question string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(question) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// expired reports whether an entry is past its TTL
+func (c *AnswerCache) expired(entry cachedAnswer, now time.Time) bool {
+	return c.ttl > 0 && now.Sub(entry.storedAt) > c.ttl
+}
+
+// Get returns the cached answer for a question, if any
+func (c *AnswerCache) Get(ctx context.Context, question string) (string, bool, error) {
+	key := NormalizeQuestion(question)
+	now := time.Now()
+
+	c.mu.RLock()
+	entry, ok := c.answers[key]
+	ok = ok && !c.expired(entry, now)
+	matcher := c.matcher
+	candidates := make([]string, 0, len(c.answers))
+	if !ok && matcher != nil {
+		for k, e := range c.answers {
+			if !c.expired(e, now) {
+				candidates = append(candidates, k)
+			}
+		}
+	}
+	c.mu.RUnlock()
+
+	if !ok && matcher != nil && len(candidates) > 0 {
+		match, found, err := matcher(ctx, key, candidates)
+		if err != nil {
+			return "", false, err
+		}
+		if found {
+			c.mu.RLock()
+			entry, ok = c.answers[match]
+			c.mu.RUnlock()
+			// The match may have expired since the candidates were listed
+			ok = ok && !c.expired(entry, time.Now())
+		}
+	}
+
+	c.mu.Lock()
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	c.mu.Unlock()
+
+	return entry.answer, ok, nil
+}
+
+// Put stores a verified answer for a question
+func (c *AnswerCache) Put(question, answer string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.answers[NormalizeQuestion(question)] = cachedAnswer{answer: answer, storedAt: time.Now()}
+}
+
+// Invalidate removes the cached answer for a question
+func (c *AnswerCache) Invalidate(question string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.answers, NormalizeQuestion(question))
+}
+
+// GC evicts expired answers and returns how many were removed
+func (c *AnswerCache) GC() int {
+	if c.ttl <= 0 {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	evicted := 0
+	for k, e := range c.answers {
+		if c.expired(e, now) {
+			delete(c.answers, k)
+			evicted++
+		}
+	}
+	c.evictions += evicted
+	return evicted
+}
+
+// StartGC runs GC every interval until ctx is done
+func (c *AnswerCache) StartGC(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.GC()
+			}
+		}
+	}()
+}
+
+// Len returns the number of cached answers
+func (c *AnswerCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.answers)
+}
+
+// Stats returns the number of cache hits and misses so far
+func (c *AnswerCache) Stats() (hits, misses int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hits, c.misses
+}
+
+// Evictions returns the number of answers removed by GC so far
+func (c *AnswerCache) Evictions() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.evictions
+}
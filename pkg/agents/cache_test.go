@@ -0,0 +1,39 @@
+package agents
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAnswerCacheGet(t *testing.T) {
+	ctx := context.Background()
+	// The matcher always picks "old question", whether or not it was offered
+	matcher := func(ctx context.Context, question string, candidates []string) (string, bool, error) {
+		return "old question", true, nil
+	}
+	cache := NewAnswerCache().WithTTL(time.Hour).WithMatcher(matcher)
+	cache.Put("What is Go?", "A language.")
+	cache.Put("Old question", "A stale answer.")
+
+	if answer, ok, err := cache.Get(ctx, "what is go"); err != nil || !ok || answer != "A language." {
+		t.Errorf("Get() exact = %q, %v, %v; want %q", answer, ok, err, "A language.")
+	}
+	if answer, ok, _ := cache.Get(ctx, "something else"); !ok || answer != "A stale answer." {
+		t.Errorf("Get() semantic = %q, %v; want %q", answer, ok, "A stale answer.")
+	}
+
+	entry := cache.answers["old question"]
+	entry.storedAt = time.Now().Add(-2 * time.Hour)
+	cache.answers["old question"] = entry
+
+	if answer, ok, _ := cache.Get(ctx, "old question"); ok {
+		t.Errorf("Get() exact on an expired answer = %q, want a miss", answer)
+	}
+	if answer, ok, _ := cache.Get(ctx, "something else"); ok {
+		t.Errorf("Get() semantic on an expired answer = %q, want a miss", answer)
+	}
+	if hits, misses := cache.Stats(); hits != 2 || misses != 2 {
+		t.Errorf("Stats() = %d hits, %d misses; want 2, 2", hits, misses)
+	}
+}
@@ -0,0 +1,219 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/llm"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/tools"
+	"gopkg.in/yaml.v3"
+)
+
+// Memory kinds of an agent definition
+const (
+	MemoryNone        = "none"
+	MemoryAnswerCache = "answer_cache"
+)
+
+// AgentDefinition describes an agent declaratively, so new assistants can
+// be authored as data rather than Go code
+type AgentDefinition struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Persona opens the system prompt, e.g. "You are Ada, a terse SRE assistant."
+	Persona string `json:"persona,omitempty" yaml:"persona,omitempty"`
+	// Locale selects the language of the built-in prompts, e.g. "fr"
+	Locale string          `json:"locale,omitempty" yaml:"locale,omitempty"`
+	Model  ModelDefinition `json:"model" yaml:"model"`
+	// Tools lists the names of the registry tools the agent may use
+	Tools    []string         `json:"tools,omitempty" yaml:"tools,omitempty"`
+	Budgets  BudgetDefinition `json:"budgets,omitempty" yaml:"budgets,omitempty"`
+	Memory   MemoryDefinition `json:"memory,omitempty" yaml:"memory,omitempty"`
+	Policies PolicyDefinition `json:"policies,omitempty" yaml:"policies,omitempty"`
+}
+
+// ModelDefinition selects the model backend; see llm.ConfigFromEnv for the options
+type ModelDefinition struct {
+	Backend      string   `json:"backend,omitempty" yaml:"backend,omitempty"`
+	ModelPath    string   `json:"model_path,omitempty" yaml:"model_path,omitempty"`
+	BaseURL      string   `json:"base_url,omitempty" yaml:"base_url,omitempty"`
+	Model        string   `json:"model,omitempty" yaml:"model,omitempty"`
+	ContextSize  int      `json:"context_size,omitempty" yaml:"context_size,omitempty"`
+	MaxTokens    int      `json:"max_tokens,omitempty" yaml:"max_tokens,omitempty"`
+	Temperature  float32  `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+	ChatTemplate string   `json:"chat_template,omitempty" yaml:"chat_template,omitempty"`
+	Stop         []string `json:"stop,omitempty" yaml:"stop,omitempty"`
+	// APIKeyEnv names the environment variable holding the API key, so
+	// definitions can be shared without secrets
+	APIKeyEnv string `json:"api_key_env,omitempty" yaml:"api_key_env,omitempty"`
+}
+
+// BudgetDefinition limits how much work a run may do
+type BudgetDefinition struct {
+	MaxIterations int `json:"max_iterations,omitempty" yaml:"max_iterations,omitempty"`
+}
+
+// MemoryDefinition selects what the agent remembers between runs
+type MemoryDefinition struct {
+	Type string `json:"type,omitempty" yaml:"type,omitempty"` // none (default) or answer_cache
+	TTL  string `json:"ttl,omitempty" yaml:"ttl,omitempty"`   // answer_cache entry lifetime, e.g. 24h
+}
+
+// PolicyDefinition sets how the agent may act
+type PolicyDefinition struct {
+	// Simulate runs tools with side effects in simulation mode
+	Simulate bool `json:"simulate,omitempty" yaml:"simulate,omitempty"`
+	Verbose  bool `json:"verbose,omitempty" yaml:"verbose,omitempty"`
+	// CiteEvidence appends the evidence section to final answers; see
+	// ReActAgent.WithEvidence
+	CiteEvidence bool `json:"cite_evidence,omitempty" yaml:"cite_evidence,omitempty"`
+}
+
+// LoadAgentDefinition reads a definition from a JSON file, or a YAML file
+// when the extension is .yaml or .yml
+func LoadAgentDefinition(path string) (*AgentDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent definition: %w", err)
+	}
+
+	var def AgentDefinition
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &def)
+	default:
+		err = json.Unmarshal(data, &def)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse agent definition %s: %w", path, err)
+	}
+	if err := def.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid agent definition %s: %w", path, err)
+	}
+	return &def, nil
+}
+
+// Validate checks the definition without loading the model
+func (d *AgentDefinition) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if d.Budgets.MaxIterations < 0 {
+		return fmt.Errorf("budgets.max_iterations must not be negative")
+	}
+	switch d.Memory.Type {
+	case "", MemoryNone, MemoryAnswerCache:
+	default:
+		return fmt.Errorf("unknown memory type %q", d.Memory.Type)
+	}
+	if d.Memory.TTL != "" {
+		if _, err := time.ParseDuration(d.Memory.TTL); err != nil {
+			return fmt.Errorf("invalid memory.ttl: %w", err)
+		}
+	}
+	if err := d.Model.validate(); err != nil {
+		return err
+	}
+	if _, err := llm.FormatterFor(d.Model.ChatTemplate); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validate checks the backend is known and, for local backends, that the
+// model file exists
+func (m ModelDefinition) validate() error {
+	switch strings.ToLower(m.Backend) {
+	case "", llm.BackendLlamaCpp, llm.BackendProcess:
+		if m.ModelPath == "" {
+			return fmt.Errorf("model.model_path is required")
+		}
+		if _, err := os.Stat(m.ModelPath); err != nil {
+			return fmt.Errorf("model file not found: %s", m.ModelPath)
+		}
+	case llm.BackendTGI, llm.BackendLlamafile, llm.BackendOpenAI, llm.BackendServer:
+	default:
+		return fmt.Errorf("unknown model backend %q", m.Backend)
+	}
+	return nil
+}
+
+// BackendConfig converts the model section to an llm.BackendConfig
+func (d *AgentDefinition) BackendConfig() llm.BackendConfig {
+	m := d.Model
+	backend := strings.ToLower(m.Backend)
+	if backend == "" {
+		backend = llm.BackendLlamaCpp
+	}
+	apiKey := ""
+	if m.APIKeyEnv != "" {
+		apiKey = os.Getenv(m.APIKeyEnv)
+	}
+	return llm.BackendConfig{
+		Backend: backend,
+		LlamaCpp: llm.LlamaCppConfig{
+			ModelPath:    m.ModelPath,
+			ContextSize:  m.ContextSize,
+			Temperature:  m.Temperature,
+			Stop:         m.Stop,
+			ChatTemplate: m.ChatTemplate,
+		},
+		HTTP: llm.HTTPBackendConfig{
+			BaseURL:      m.BaseURL,
+			APIKey:       apiKey,
+			Model:        m.Model,
+			MaxTokens:    m.MaxTokens,
+			Temperature:  m.Temperature,
+			Stop:         m.Stop,
+			ChatTemplate: m.ChatTemplate,
+		},
+	}
+}
+
+// Build loads the model and returns a ready-to-run agent with the allowed
+// tools taken from registry. The caller closes the returned model when done.
+func (d *AgentDefinition) Build(registry *tools.ToolRegistry) (*ReActAgent, llm.ChatModel, error) {
+	if err := d.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	allowed := tools.NewToolRegistry().SetSimulation(d.Policies.Simulate)
+	for _, name := range d.Tools {
+		tool, ok := registry.Get(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("agent %s: tool not found: %s", d.Name, name)
+		}
+		allowed.Register(tool)
+	}
+
+	model, err := llm.NewChatModel(d.BackendConfig())
+	if err != nil {
+		return nil, nil, fmt.Errorf("agent %s: %w", d.Name, err)
+	}
+
+	maxIter := d.Budgets.MaxIterations
+	if maxIter == 0 {
+		maxIter = 5
+	}
+	agent := NewReActAgent(model, allowed, maxIter, d.Policies.Verbose).WithPersona(d.Persona)
+	if d.Locale != "" {
+		agent.WithLocale(d.Locale)
+	}
+	if d.Policies.CiteEvidence {
+		agent.WithEvidence(nil)
+	}
+
+	if d.Memory.Type == MemoryAnswerCache {
+		cache := NewAnswerCache()
+		if d.Memory.TTL != "" {
+			ttl, _ := time.ParseDuration(d.Memory.TTL)
+			cache.WithTTL(ttl)
+		}
+		agent.WithAnswerCache(cache)
+	}
+	return agent, model, nil
+}
@@ -0,0 +1,129 @@
+package agents
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/tools"
+)
+
+// writeFile writes content to name in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadAgentDefinition(t *testing.T) {
+	yamlDef := `
+name: ada
+persona: You are Ada, a terse SRE assistant.
+model:
+  backend: openai
+  base_url: http://localhost:8080/v1
+  model: qwen2.5
+  stop: ["Observation:"]
+tools: [getCurrentTime]
+budgets:
+  max_iterations: 3
+memory:
+  type: answer_cache
+  ttl: 24h
+policies:
+  simulate: true
+`
+	jsonDef := `{
+  "name": "ada",
+  "persona": "You are Ada, a terse SRE assistant.",
+  "model": {"backend": "openai", "base_url": "http://localhost:8080/v1", "model": "qwen2.5", "stop": ["Observation:"]},
+  "tools": ["getCurrentTime"],
+  "budgets": {"max_iterations": 3},
+  "memory": {"type": "answer_cache", "ttl": "24h"},
+  "policies": {"simulate": true}
+}`
+
+	for _, path := range []string{
+		writeFile(t, "ada.yaml", yamlDef),
+		writeFile(t, "ada.yml", yamlDef),
+		writeFile(t, "ada.json", jsonDef),
+	} {
+		t.Run(filepath.Ext(path), func(t *testing.T) {
+			def, err := LoadAgentDefinition(path)
+			if err != nil {
+				t.Fatalf("LoadAgentDefinition() error = %v", err)
+			}
+			if def.Name != "ada" || def.Model.Model != "qwen2.5" || def.Model.Stop[0] != "Observation:" {
+				t.Errorf("LoadAgentDefinition() = %+v", def)
+			}
+			if def.Budgets.MaxIterations != 3 || def.Memory.TTL != "24h" || !def.Policies.Simulate {
+				t.Errorf("LoadAgentDefinition() = %+v", def)
+			}
+			if len(def.Tools) != 1 || def.Tools[0] != "getCurrentTime" {
+				t.Errorf("Tools = %v, want [getCurrentTime]", def.Tools)
+			}
+		})
+	}
+}
+
+func TestAgentDefinitionRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		def     string
+		wantErr string
+	}{
+		{
+			name:    "unknown backend",
+			def:     "name: ada\nmodel:\n  backend: gpt-17\n",
+			wantErr: `unknown model backend "gpt-17"`,
+		},
+		{
+			name:    "missing model file",
+			def:     "name: ada\nmodel:\n  model_path: /no/such/model.gguf\n",
+			wantErr: "model file not found",
+		},
+		{
+			name:    "no model file",
+			def:     "name: ada\n",
+			wantErr: "model.model_path is required",
+		},
+		{
+			name:    "unknown chat template",
+			def:     "name: ada\nmodel:\n  backend: openai\n  chat_template: vicuna\n",
+			wantErr: "unknown chat template",
+		},
+		{
+			name:    "invalid YAML",
+			def:     "name: [ada\n",
+			wantErr: "failed to parse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadAgentDefinition(writeFile(t, "agent.yaml", tt.def))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadAgentDefinition() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAgentDefinitionBuildUnknownTool(t *testing.T) {
+	registry := tools.NewToolRegistry()
+	registry.Register(tools.NewGetCurrentTimeTool())
+
+	def := &AgentDefinition{
+		Name:  "ada",
+		Model: ModelDefinition{Backend: "openai"},
+		Tools: []string{"getCurrentTime", "launch_rockets"},
+	}
+	_, _, err := def.Build(registry)
+	if err == nil || !strings.Contains(err.Error(), "tool not found: launch_rockets") {
+		t.Errorf("Build() error = %v, want the unknown tool reported", err)
+	}
+}
@@ -0,0 +1,112 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/eval"
+)
+
+// evidenceFence opens the evidence section appended to final answers
+const evidenceFence = "```evidence\n"
+
+// Citation links a claim of the final answer to the tool observation that
+// supports it
+type Citation struct {
+	Claim string `json:"claim"`
+	// ToolCallID is the ID of the supporting call in the transcript; empty
+	// when no observation supports the claim
+	ToolCallID string  `json:"tool_call_id,omitempty"`
+	Tool       string  `json:"tool,omitempty"`
+	Supported  bool    `json:"supported"`
+	Score      float64 `json:"score"`
+}
+
+// WithEvidence appends an evidence section to final answers: a fenced
+// "evidence" block holding a JSON list of Citations, one per sentence.
+// checker matches sentences to observations; nil uses word-overlap
+// heuristics. Use ParseEvidence to split the section off.
+func (a *ReActAgent) WithEvidence(checker *eval.GroundednessChecker) *ReActAgent {
+	if checker == nil {
+		checker = eval.NewGroundednessChecker(nil)
+	}
+	a.evidence = checker
+	return a
+}
+
+// citeEvidence matches the sentences of answer to the tool observations of
+// the current transcript
+func (a *ReActAgent) citeEvidence(ctx context.Context, answer string) ([]Citation, error) {
+	toolNames := make(map[string]string)
+	for _, msg := range a.transcript {
+		if ai, ok := msg.(*core.AIMessage); ok {
+			for _, call := range ai.ToolCalls {
+				toolNames[call.ID] = call.Function.Name
+			}
+		}
+	}
+
+	var observations []string
+	var callIDs []string
+	for _, msg := range a.transcript {
+		if tm, ok := msg.(*core.ToolMessage); ok {
+			observations = append(observations, tm.Content)
+			callIDs = append(callIDs, tm.ToolCallID)
+		}
+	}
+
+	result, err := a.evidence.Check(ctx, answer, observations)
+	if err != nil {
+		return nil, fmt.Errorf("evidence check failed: %w", err)
+	}
+
+	citations := make([]Citation, len(result.Sentences))
+	for i, s := range result.Sentences {
+		citations[i] = Citation{Claim: s.Sentence, Supported: s.Supported, Score: s.Score}
+		if s.Supported && s.Evidence >= 0 {
+			citations[i].ToolCallID = callIDs[s.Evidence]
+			citations[i].Tool = toolNames[callIDs[s.Evidence]]
+		}
+	}
+	return citations, nil
+}
+
+// answerWithEvidence records the final answer with its citations in the
+// transcript and returns it followed by the evidence section
+func (a *ReActAgent) answerWithEvidence(ctx context.Context, answer string) (string, error) {
+	citations, err := a.citeEvidence(ctx, answer)
+	if err != nil {
+		return "", err
+	}
+	a.transcript = append(a.transcript, core.NewAIMessage(answer, map[string]interface{}{"evidence": citations}))
+	return appendEvidence(answer, citations)
+}
+
+// appendEvidence adds the evidence section to answer
+func appendEvidence(answer string, citations []Citation) (string, error) {
+	data, err := json.MarshalIndent(citations, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode evidence: %w", err)
+	}
+	return fmt.Sprintf("%s\n\n%s%s\n```", answer, evidenceFence, data), nil
+}
+
+// ParseEvidence splits an answer produced with WithEvidence into the answer
+// text and its citations. An answer without an evidence section is
+// returned unchanged with no citations.
+func ParseEvidence(answer string) (string, []Citation, error) {
+	idx := strings.LastIndex(answer, evidenceFence)
+	if idx == -1 {
+		return answer, nil, nil
+	}
+	body := strings.TrimSuffix(strings.TrimSpace(answer[idx+len(evidenceFence):]), "```")
+
+	var citations []Citation
+	if err := json.Unmarshal([]byte(body), &citations); err != nil {
+		return answer, nil, fmt.Errorf("failed to parse evidence: %w", err)
+	}
+	return strings.TrimSpace(answer[:idx]), citations, nil
+}
@@ -0,0 +1,63 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LoopDetectedError is returned when the agent keeps repeating the same step
+// after being told not to. Trajectory holds the scratchpad up to that point.
+type LoopDetectedError struct {
+	// Step is the repeated action and input, or the repeated thought
+	Step       string
+	Repeats    int
+	Trajectory []string
+}
+
+// Error describes the repeated step
+func (e *LoopDetectedError) Error() string {
+	return fmt.Sprintf("loop detected: %q repeated %d times", e.Step, e.Repeats)
+}
+
+// loopDetector counts identical agent steps within a run
+type loopDetector struct {
+	maxRepeats   int
+	counts       map[string]int
+	observations map[string]string
+}
+
+// newLoopDetector creates a detector allowing maxRepeats occurrences of a
+// step; 0 disables detection
+func newLoopDetector(maxRepeats int) *loopDetector {
+	return &loopDetector{
+		maxRepeats:   maxRepeats,
+		counts:       make(map[string]int),
+		observations: make(map[string]string),
+	}
+}
+
+// actionKey identifies an action by tool and whitespace-normalized input
+func actionKey(action, input string) string {
+	return action + " " + strings.Join(strings.Fields(input), " ")
+}
+
+// see records a step and returns how often it occurred in this run
+func (d *loopDetector) see(key string) int {
+	d.counts[key]++
+	return d.counts[key]
+}
+
+// exceeded reports whether a step seen n times should abort the run
+func (d *loopDetector) exceeded(n int) bool {
+	return d.maxRepeats > 0 && n > d.maxRepeats
+}
+
+// WithLoopDetection sets how often the agent may repeat the same action
+// with the same input, or the same thought, before Run fails with a
+// *LoopDetectedError (default 3, 0 disables detection). A repeated action
+// is not executed again: the agent gets the earlier observation back with
+// an instruction to move on.
+func (a *ReActAgent) WithLoopDetection(maxRepeats int) *ReActAgent {
+	a.maxRepeats = maxRepeats
+	return a
+}
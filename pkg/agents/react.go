@@ -4,31 +4,79 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/eval"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/i18n"
 	"github.com/mgreau/ai-agents-from-scratch-go/pkg/llm"
 	"github.com/mgreau/ai-agents-from-scratch-go/pkg/tools"
 )
 
 // ReActAgent implements the ReAct (Reasoning + Acting) pattern
 type ReActAgent struct {
-	llm        *llm.LlamaCppLLM
+	llm        llm.ChatModel
 	tools      *tools.ToolRegistry
 	maxIter    int
 	verbose    bool
 	scratchpad []string
+	transcript []core.Message
+	cache      *AnswerCache
+	persona    string
+	ask        tools.InputFunc
+	locale     string
+	catalog    *i18n.Catalog
+	evidence   *eval.GroundednessChecker
+	maxRepeats int
 }
 
 // NewReActAgent creates a new ReAct agent
-func NewReActAgent(llm *llm.LlamaCppLLM, toolRegistry *tools.ToolRegistry, maxIter int, verbose bool) *ReActAgent {
+func NewReActAgent(llm llm.ChatModel, toolRegistry *tools.ToolRegistry, maxIter int, verbose bool) *ReActAgent {
 	return &ReActAgent{
 		llm:        llm,
 		tools:      toolRegistry,
 		maxIter:    maxIter,
 		verbose:    verbose,
 		scratchpad: []string{},
+		locale:     i18n.DefaultLocale,
+		catalog:    i18n.Default,
+		maxRepeats: 3,
 	}
 }
 
+// WithLocale sets the language of the system prompt and observations, e.g. "fr"
+func (a *ReActAgent) WithLocale(locale string) *ReActAgent {
+	a.locale = locale
+	return a
+}
+
+// WithCatalog replaces the message catalog, to add locales or reword prompts
+func (a *ReActAgent) WithCatalog(catalog *i18n.Catalog) *ReActAgent {
+	a.catalog = catalog
+	return a
+}
+
+// WithAnswerCache enables answer caching for repeated questions
+func (a *ReActAgent) WithAnswerCache(cache *AnswerCache) *ReActAgent {
+	a.cache = cache
+	return a
+}
+
+// WithPersona replaces the opening line of the system prompt, e.g. to give
+// the agent a name, a tone or a scope
+func (a *ReActAgent) WithPersona(persona string) *ReActAgent {
+	a.persona = persona
+	return a
+}
+
+// WithInputHandler lets tools ask the user clarifying questions through ask.
+// Without a handler the question is passed to the model as the observation,
+// so it can ask the user in its final answer.
+func (a *ReActAgent) WithInputHandler(ask tools.InputFunc) *ReActAgent {
+	a.ask = ask
+	return a
+}
+
 // Run executes the ReAct loop
 func (a *ReActAgent) Run(ctx context.Context, query string) (string, error) {
 	if a.verbose {
@@ -36,24 +84,56 @@ func (a *ReActAgent) Run(ctx context.Context, query string) (string, error) {
 		fmt.Printf("Query: %s\n\n", query)
 	}
 
+	a.transcript = []core.Message{core.NewHumanMessage(query, nil)}
+	core.Emit(ctx, core.RunStarted{Runnable: "ReActAgent", Input: query, Time: time.Now()})
+
+	// Return a previously verified answer if we have one
+	if a.cache != nil {
+		answer, ok, err := a.cache.Get(ctx, query)
+		if err != nil {
+			return "", fmt.Errorf("answer cache lookup failed: %w", err)
+		}
+		if ok {
+			if a.verbose {
+				fmt.Printf("Cache hit: %s\n", answer)
+			}
+			a.transcript = append(a.transcript, core.NewAIMessage(answer, nil))
+			return answer, nil
+		}
+	}
+
 	// Build initial prompt with tools
 	systemPrompt := a.buildSystemPrompt()
 	prompt := fmt.Sprintf("%s\n\nQuestion: %s\n\nThought:", systemPrompt, query)
 
+	// Stop before the model invents its own observation
+	config := core.NewConfig().WithStop("Observation:")
+	loops := newLoopDetector(a.maxRepeats)
+
 	for i := 0; i < a.maxIter; i++ {
 		if a.verbose {
 			fmt.Printf("--- Iteration %d ---\n", i+1)
 		}
 
 		// Get LLM response
-		response, err := a.llm.Invoke(ctx, prompt, nil)
+		response, err := a.llm.Invoke(ctx, prompt, config)
 		if err != nil {
 			return "", fmt.Errorf("LLM invocation failed: %w", err)
 		}
 
-		responseStr, ok := response.(string)
-		if !ok {
-			return "", fmt.Errorf("unexpected response type")
+		var responseStr string
+		switch r := response.(type) {
+		case string:
+			responseStr = r
+		case *core.Generation:
+			responseStr = r.Text
+		case core.Message:
+			responseStr = r.GetContent()
+		case llm.DryRunResult:
+			// Dry run: report the first prompt instead of reasoning on it
+			return r.String(), nil
+		default:
+			return "", fmt.Errorf("unexpected response type %T", response)
 		}
 
 		a.scratchpad = append(a.scratchpad, responseStr)
@@ -71,10 +151,24 @@ func (a *ReActAgent) Run(ctx context.Context, query string) (string, error) {
 				fmt.Printf("Action Input: %s\n", actionInput)
 			}
 
-			// Execute tool
-			observation, err := a.tools.ExecuteTool(ctx, action, actionInput)
-			if err != nil {
-				observation = fmt.Sprintf("Error: %v", err)
+			key := actionKey(action, actionInput)
+			repeats := loops.see(key)
+			if loops.exceeded(repeats) {
+				return "", &LoopDetectedError{Step: key, Repeats: repeats, Trajectory: append([]string{}, a.scratchpad...)}
+			}
+
+			// Execute tool, unless the agent already did with this input
+			var observation string
+			if earlier, ok := loops.observations[key]; ok && a.maxRepeats > 0 {
+				observation = a.catalog.Sprintf(a.locale, i18n.ReActRepeatedAction, action, earlier)
+			} else {
+				observation, err = a.tools.ExecuteToolInteractive(ctx, action, actionInput, a.ask)
+				if req, ok := tools.AsInputRequest(err); ok {
+					observation = a.catalog.Sprintf(a.locale, i18n.ReActNeedsInput, req.Question)
+				} else if err != nil {
+					observation = a.catalog.Sprintf(a.locale, i18n.ReActToolError, err)
+				}
+				loops.observations[key] = observation
 			}
 
 			if a.verbose {
@@ -84,6 +178,7 @@ func (a *ReActAgent) Run(ctx context.Context, query string) (string, error) {
 			// Add observation to prompt for next iteration
 			prompt = fmt.Sprintf("%s\nObservation: %s\n\nThought:", prompt, observation)
 			a.scratchpad = append(a.scratchpad, observation)
+			a.recordToolCall(i, responseStr, action, actionInput, observation)
 			
 		} else if strings.Contains(responseStr, "Final Answer:") {
 			// Extract and return final answer
@@ -92,8 +187,20 @@ func (a *ReActAgent) Run(ctx context.Context, query string) (string, error) {
 				fmt.Printf("\n=== ReAct Agent Completed ===\n")
 				fmt.Printf("Final Answer: %s\n", answer)
 			}
+			if a.cache != nil {
+				a.cache.Put(query, answer)
+			}
+			if a.evidence != nil {
+				return a.answerWithEvidence(ctx, answer)
+			}
+			a.transcript = append(a.transcript, core.NewAIMessage(answer, nil))
 			return answer, nil
 		} else {
+			thought := strings.TrimSpace(responseStr)
+			if repeats := loops.see("Thought: " + thought); loops.exceeded(repeats) {
+				return "", &LoopDetectedError{Step: thought, Repeats: repeats, Trajectory: append([]string{}, a.scratchpad...)}
+			}
+
 			// Continue reasoning
 			prompt = prompt + responseStr + "\n\n"
 		}
@@ -109,23 +216,16 @@ func (a *ReActAgent) buildSystemPrompt() string {
 		toolsDesc += fmt.Sprintf("- %s: %s\n", tool.Name(), tool.Description())
 	}
 
-	return fmt.Sprintf(`You are a helpful assistant that can use tools to answer questions.
-
-Available tools:
-%s
-
-Use the following format:
-
-Question: the input question you must answer
-Thought: you should always think about what to do
-Action: the action to take, should be one of [%s]
-Action Input: the input to the action
-Observation: the result of the action
-... (this Thought/Action/Action Input/Observation can repeat N times)
-Thought: I now know the final answer
-Final Answer: the final answer to the original input question
+	persona := a.persona
+	if persona == "" {
+		persona = a.catalog.Get(a.locale, i18n.ReActPersona)
+	}
 
-Begin!`, toolsDesc, strings.Join(a.getToolNames(), ", "))
+	return fmt.Sprintf("%s\n\n%s\n%s\n\n%s",
+		persona,
+		a.catalog.Get(a.locale, i18n.ReActToolsHeading),
+		toolsDesc,
+		a.catalog.Sprintf(a.locale, i18n.ReActInstructions, strings.Join(a.getToolNames(), ", ")))
 }
 
 // parseAction extracts action and action input from response
@@ -166,3 +266,28 @@ func (a *ReActAgent) getToolNames() []string {
 func (a *ReActAgent) GetScratchpad() []string {
 	return a.scratchpad
 }
+
+// recordToolCall adds one Thought/Action/Observation step to the transcript
+func (a *ReActAgent) recordToolCall(step int, response, action, actionInput, observation string) {
+	thought := response
+	if idx := strings.Index(response, "Action:"); idx != -1 {
+		thought = response[:idx]
+	}
+
+	callID := fmt.Sprintf("call_%d", step)
+	call := core.ToolCall{
+		ID:       callID,
+		Type:     "function",
+		Function: core.ToolCallFunction{Name: action, Arguments: actionInput},
+	}
+	a.transcript = append(a.transcript,
+		core.NewAIMessage(strings.TrimSpace(thought), map[string]interface{}{"tool_calls": []core.ToolCall{call}}),
+		core.NewToolMessage(observation, callID, nil),
+	)
+}
+
+// GetTranscript returns the last run as messages with structured tool calls,
+// ready for tools.ExportFineTune
+func (a *ReActAgent) GetTranscript() []core.Message {
+	return a.transcript
+}
@@ -0,0 +1,15 @@
+package agents
+
+import (
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/llm"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/tools"
+)
+
+// NewTableQAAgent creates a ReAct agent that answers questions about a table.
+// The agent only gets the table_query tool, so numbers in its answers come
+// from operations computed in Go rather than from the model's imagination.
+func NewTableQAAgent(llm llm.ChatModel, table *tools.Table, maxIter int, verbose bool) *ReActAgent {
+	registry := tools.NewToolRegistry()
+	registry.Register(tools.NewTableQueryTool(table))
+	return NewReActAgent(llm, registry, maxIter, verbose)
+}
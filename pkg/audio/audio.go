@@ -0,0 +1,239 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// Transcriber converts speech audio to text
+type Transcriber interface {
+	Transcribe(ctx context.Context, audio io.Reader, filename string) (string, error)
+}
+
+// Synthesizer converts text to speech audio
+type Synthesizer interface {
+	Synthesize(ctx context.Context, text string) ([]byte, error)
+}
+
+// HTTPTranscriberConfig configures a speech-to-text HTTP endpoint
+type HTTPTranscriberConfig struct {
+	// URL is the full endpoint, e.g. http://localhost:8080/inference for
+	// whisper.cpp's server or http://localhost:8080/v1/audio/transcriptions
+	// for OpenAI-compatible servers
+	URL      string
+	APIKey   string
+	Model    string
+	Language string
+	Timeout  time.Duration
+}
+
+// HTTPTranscriber sends audio files to a whisper.cpp or OpenAI-compatible server
+type HTTPTranscriber struct {
+	config HTTPTranscriberConfig
+	client *http.Client
+}
+
+// NewHTTPTranscriber creates a new HTTP transcriber
+func NewHTTPTranscriber(config HTTPTranscriberConfig) *HTTPTranscriber {
+	if config.Timeout == 0 {
+		config.Timeout = 2 * time.Minute
+	}
+	if config.Model == "" {
+		config.Model = "whisper-1"
+	}
+	return &HTTPTranscriber{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// Transcribe uploads the audio and returns the recognized text
+func (t *HTTPTranscriber) Transcribe(ctx context.Context, audio io.Reader, filename string) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, audio); err != nil {
+		return "", fmt.Errorf("failed to read audio: %w", err)
+	}
+	w.WriteField("model", t.config.Model)
+	w.WriteField("response_format", "json")
+	if t.config.Language != "" {
+		w.WriteField("language", t.config.Language)
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.config.URL, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if t.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.config.APIKey)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("transcription request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("transcription server returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode transcription: %w", err)
+	}
+	return strings.TrimSpace(result.Text), nil
+}
+
+// HTTPSynthesizerConfig configures an OpenAI-compatible /v1/audio/speech endpoint
+type HTTPSynthesizerConfig struct {
+	URL     string // e.g. http://localhost:8880/v1/audio/speech
+	APIKey  string
+	Model   string
+	Voice   string
+	Format  string // mp3, wav, ...
+	Timeout time.Duration
+}
+
+// HTTPSynthesizer turns text into speech via an HTTP TTS server
+type HTTPSynthesizer struct {
+	config HTTPSynthesizerConfig
+	client *http.Client
+}
+
+// NewHTTPSynthesizer creates a new HTTP synthesizer
+func NewHTTPSynthesizer(config HTTPSynthesizerConfig) *HTTPSynthesizer {
+	if config.Timeout == 0 {
+		config.Timeout = 2 * time.Minute
+	}
+	if config.Model == "" {
+		config.Model = "tts-1"
+	}
+	if config.Voice == "" {
+		config.Voice = "alloy"
+	}
+	if config.Format == "" {
+		config.Format = "wav"
+	}
+	return &HTTPSynthesizer{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// Synthesize returns the encoded audio for text
+func (s *HTTPSynthesizer) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"model":           s.config.Model,
+		"input":           text,
+		"voice":           s.config.Voice,
+		"response_format": s.config.Format,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.APIKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("speech request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("speech server returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// TranscribeStream transcribes audio as it arrives, emitting partial transcripts.
+// Each chunk is appended to the audio received so far and the whole buffer is
+// re-transcribed, producing HumanMessage drafts (additional kwarg "draft": true).
+// A draft that fails to transcribe is skipped. When chunks is closed a final,
+// non-draft HumanMessage is emitted; if its transcription fails, it carries the
+// last draft's text and the error as additional kwarg "error".
+// wavHeader, if set, is prepended to the raw audio before each upload.
+func TranscribeStream(ctx context.Context, t Transcriber, chunks <-chan []byte, wavHeader []byte) <-chan *core.HumanMessage {
+	out := make(chan *core.HumanMessage, 1)
+
+	go func() {
+		defer close(out)
+
+		var audio bytes.Buffer
+		last := ""
+		emit := func(draft bool) bool {
+			data := append(append([]byte{}, wavHeader...), audio.Bytes()...)
+			text, err := t.Transcribe(ctx, bytes.NewReader(data), "stream.wav")
+			kwargs := map[string]interface{}{"draft": draft}
+			switch {
+			case err != nil && draft:
+				// The next chunk retries with more audio
+				return ctx.Err() == nil
+			case err != nil:
+				text = last
+				kwargs["error"] = fmt.Errorf("transcription failed: %w", err)
+			case draft && text == last:
+				return true
+			}
+			last = text
+
+			msg := core.NewHumanMessage(text, kwargs)
+			select {
+			case out <- msg:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case chunk, ok := <-chunks:
+				if !ok {
+					if audio.Len() > 0 {
+						emit(false)
+					}
+					return
+				}
+				audio.Write(chunk)
+				if !emit(true) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
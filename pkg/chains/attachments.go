@@ -0,0 +1,185 @@
+package chains
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/tools"
+)
+
+// AttachmentLoader turns an attachment into text for the prompt
+type AttachmentLoader func(ctx context.Context, a core.Attachment) (string, error)
+
+// AttachmentResolver rewrites human messages so their attachments become
+// part of the prompt: text files are inlined (or summarized when too long),
+// files with a registered loader go through it, and other binaries are
+// listed by name so the agent can hand them to a tool.
+type AttachmentResolver struct {
+	*core.BaseRunnable
+	loaders    map[string]AttachmentLoader
+	maxChars   int
+	summarizer core.Runnable
+}
+
+// NewAttachmentResolver creates a resolver with the built-in CSV loader
+func NewAttachmentResolver() *AttachmentResolver {
+	return &AttachmentResolver{
+		BaseRunnable: core.NewBaseRunnable("AttachmentResolver"),
+		loaders: map[string]AttachmentLoader{
+			"text/csv": LoadCSVAttachment,
+		},
+		maxChars: 8000,
+	}
+}
+
+// WithLoader registers a loader for a MIME type, replacing any existing one
+func (r *AttachmentResolver) WithLoader(mimeType string, loader AttachmentLoader) *AttachmentResolver {
+	r.loaders[mimeType] = loader
+	return r
+}
+
+// WithMaxChars sets how much text is inlined per attachment
+func (r *AttachmentResolver) WithMaxChars(n int) *AttachmentResolver {
+	r.maxChars = n
+	return r
+}
+
+// WithSummarizer summarizes text attachments longer than the limit with llm
+// instead of truncating them
+func (r *AttachmentResolver) WithSummarizer(llm core.Runnable) *AttachmentResolver {
+	r.summarizer = llm
+	return r
+}
+
+// LoadCSVAttachment describes a CSV file: its columns, row count and a few
+// sample rows
+func LoadCSVAttachment(ctx context.Context, a core.Attachment) (string, error) {
+	data, err := a.Read()
+	if err != nil {
+		return "", err
+	}
+	table, err := tools.ReadCSV(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	return table.Describe(5), nil
+}
+
+// Resolve returns the messages with attachments folded into the content of
+// human messages; other messages are returned as is
+func (r *AttachmentResolver) Resolve(ctx context.Context, messages []core.Message, config *core.Config) ([]core.Message, error) {
+	out := make([]core.Message, len(messages))
+	for i, msg := range messages {
+		human, ok := msg.(*core.HumanMessage)
+		if !ok || len(human.Attachments) == 0 {
+			out[i] = msg
+			continue
+		}
+
+		parts := []string{human.Content}
+		for _, a := range human.Attachments {
+			text, err := r.resolve(ctx, a, config)
+			if err != nil {
+				return nil, fmt.Errorf("attachment %s failed: %w", a.Name, err)
+			}
+			parts = append(parts, text)
+		}
+
+		resolved := core.NewHumanMessage(strings.Join(parts, "\n\n"), human.AdditionalKwargs)
+		resolved.BaseMessage.ID, resolved.BaseMessage.Timestamp = human.ID, human.Timestamp
+		out[i] = resolved
+	}
+	return out, nil
+}
+
+// resolve renders one attachment
+func (r *AttachmentResolver) resolve(ctx context.Context, a core.Attachment, config *core.Config) (string, error) {
+	mimeType := a.Type()
+	if loader, ok := r.loaders[mimeType]; ok {
+		text, err := loader(ctx, a)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("[%s (%s)]\n%s", a.Name, mimeType, strings.TrimSpace(text)), nil
+	}
+
+	data, err := a.Read()
+	if err != nil {
+		return "", err
+	}
+	if !core.IsText(mimeType, data) {
+		ref := a.Path
+		if ref == "" {
+			ref = a.Name
+		}
+		return fmt.Sprintf("[attached file: %s (%s, %d bytes) — pass it to a tool to use it]", ref, mimeType, len(data)), nil
+	}
+
+	text := string(data)
+	if r.maxChars > 0 && len(text) > r.maxChars {
+		if r.summarizer != nil {
+			return r.summarize(ctx, a.Name, text, config)
+		}
+		text = strings.ToValidUTF8(text[:r.maxChars], "") + "\n[... truncated]"
+	}
+	return fmt.Sprintf("[%s]\n%s", a.Name, text), nil
+}
+
+// summarize condenses a long text attachment with the summarizer
+func (r *AttachmentResolver) summarize(ctx context.Context, name, text string, config *core.Config) (string, error) {
+	prompt := fmt.Sprintf("Summarize the following file (%s), keeping facts, names and numbers a reader would need:\n\n%s", name, text)
+	summary, err := r.summarizer.Invoke(ctx, prompt, config)
+	if err != nil {
+		return "", fmt.Errorf("summarizing failed: %w", err)
+	}
+	return fmt.Sprintf("[%s, summarized]\n%s", name, strings.TrimSpace(fmt.Sprint(summary))), nil
+}
+
+// Invoke resolves attachments in a message list or a single human message
+func (r *AttachmentResolver) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	switch v := input.(type) {
+	case []core.Message:
+		return r.Resolve(ctx, v, config)
+	case *core.HumanMessage:
+		resolved, err := r.Resolve(ctx, []core.Message{v}, config)
+		if err != nil {
+			return nil, err
+		}
+		return resolved[0], nil
+	default:
+		return input, nil
+	}
+}
+
+// Stream emits the resolved input as a single chunk
+func (r *AttachmentResolver) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	result, err := r.Invoke(ctx, input, config)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan interface{}, 1)
+	out <- result
+	close(out)
+	return out, nil
+}
+
+// Batch resolves every input
+func (r *AttachmentResolver) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := r.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the resolver with another runnable, typically an LLM
+func (r *AttachmentResolver) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{r, other})
+}
@@ -0,0 +1,315 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// Sentiment is the polarity of a text
+type Sentiment string
+
+const (
+	SentimentPositive Sentiment = "positive"
+	SentimentNegative Sentiment = "negative"
+	SentimentNeutral  Sentiment = "neutral"
+)
+
+// SentimentResult is the output of SentimentClassifier
+type SentimentResult struct {
+	Sentiment  Sentiment `json:"sentiment"`
+	Confidence float64   `json:"confidence"`
+}
+
+// Intent is one entry of an intent catalog
+type Intent struct {
+	Name        string
+	Description string
+	Examples    []string
+}
+
+// UnknownIntent is returned when no catalog intent fits well enough
+const UnknownIntent = "unknown"
+
+// IntentResult is the output of IntentClassifier
+type IntentResult struct {
+	Intent     string  `json:"intent"`
+	Confidence float64 `json:"confidence"`
+}
+
+var (
+	labelLine      = regexp.MustCompile(`(?im)^\s*(?:label|intent|sentiment)\s*:\s*([\w-]+)`)
+	confidenceLine = regexp.MustCompile(`(?im)^\s*confidence\s*:\s*([0-9]*\.?[0-9]+)`)
+)
+
+// parseLabel reads "label: x" and "confidence: 0.x" lines from a model reply.
+// A missing confidence defaults to 0.5.
+func parseLabel(response string) (string, float64, error) {
+	m := labelLine.FindStringSubmatch(response)
+	if m == nil {
+		return "", 0, fmt.Errorf("no label in classifier response: %q", response)
+	}
+	confidence := 0.5
+	if c := confidenceLine.FindStringSubmatch(response); c != nil {
+		if v, err := strconv.ParseFloat(c[1], 64); err == nil {
+			if v > 1 {
+				v /= 100 // percentages
+			}
+			confidence = v
+		}
+	}
+	return strings.ToLower(m[1]), confidence, nil
+}
+
+// classifierMemo remembers the last classification so several branch
+// conditions testing the same input trigger a single model call
+type classifierMemo struct {
+	mu     sync.Mutex
+	text   string
+	result interface{}
+	valid  bool
+}
+
+func (m *classifierMemo) get(text string) (interface{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.valid && m.text == text {
+		return m.result, true
+	}
+	return nil, false
+}
+
+func (m *classifierMemo) put(text string, result interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.text, m.result, m.valid = text, result, true
+}
+
+// SentimentClassifier labels text as positive, negative or neutral
+type SentimentClassifier struct {
+	*core.BaseRunnable
+	llm  core.Runnable
+	memo classifierMemo
+}
+
+// NewSentimentClassifier creates a sentiment classifier backed by llm
+func NewSentimentClassifier(llm core.Runnable) *SentimentClassifier {
+	return &SentimentClassifier{
+		BaseRunnable: core.NewBaseRunnable("SentimentClassifier"),
+		llm:          llm,
+	}
+}
+
+// Classify returns the sentiment of text
+func (c *SentimentClassifier) Classify(ctx context.Context, text string, config *core.Config) (SentimentResult, error) {
+	if cached, ok := c.memo.get(text); ok {
+		return cached.(SentimentResult), nil
+	}
+
+	prompt := fmt.Sprintf(`Classify the sentiment of the message as positive, negative or neutral.
+Answer with exactly two lines:
+label: <positive|negative|neutral>
+confidence: <number between 0 and 1>
+
+Message:
+%s`, text)
+
+	response, err := c.llm.Invoke(ctx, prompt, config)
+	if err != nil {
+		return SentimentResult{}, fmt.Errorf("sentiment classification failed: %w", err)
+	}
+	label, confidence, err := parseLabel(fmt.Sprint(response))
+	if err != nil {
+		return SentimentResult{}, err
+	}
+
+	result := SentimentResult{Sentiment: SentimentNeutral, Confidence: confidence}
+	switch Sentiment(label) {
+	case SentimentPositive, SentimentNegative:
+		result.Sentiment = Sentiment(label)
+	case SentimentNeutral:
+	default:
+		// Unexpected label: treat as neutral with no confidence
+		result.Confidence = 0
+	}
+	c.memo.put(text, result)
+	return result, nil
+}
+
+// Condition returns a branch condition matching inputs with the given
+// sentiment and at least minConfidence
+func (c *SentimentClassifier) Condition(sentiment Sentiment, minConfidence float64) core.BranchCondition {
+	return func(ctx context.Context, input interface{}) (bool, error) {
+		result, err := c.Classify(ctx, routingText(input), nil)
+		if err != nil {
+			return false, err
+		}
+		return result.Sentiment == sentiment && result.Confidence >= minConfidence, nil
+	}
+}
+
+// Invoke classifies a string or message and returns a SentimentResult
+func (c *SentimentClassifier) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	return c.Classify(ctx, routingText(input), config)
+}
+
+// Stream emits the result as a single chunk
+func (c *SentimentClassifier) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		result, err := c.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		out <- result
+	}()
+	return out, nil
+}
+
+// Batch classifies each input in turn
+func (c *SentimentClassifier) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := c.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the classifier with another runnable
+func (c *SentimentClassifier) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{c, other})
+}
+
+// IntentClassifier maps a user message to one intent of a catalog
+type IntentClassifier struct {
+	*core.BaseRunnable
+	llm           core.Runnable
+	catalog       []Intent
+	minConfidence float64
+	memo          classifierMemo
+}
+
+// NewIntentClassifier creates an intent classifier over catalog
+func NewIntentClassifier(llm core.Runnable, catalog []Intent) *IntentClassifier {
+	return &IntentClassifier{
+		BaseRunnable:  core.NewBaseRunnable("IntentClassifier"),
+		llm:           llm,
+		catalog:       catalog,
+		minConfidence: 0.4,
+	}
+}
+
+// WithMinConfidence sets the confidence below which the intent is UnknownIntent
+func (c *IntentClassifier) WithMinConfidence(conf float64) *IntentClassifier {
+	c.minConfidence = conf
+	return c
+}
+
+// Classify returns the intent of text, or UnknownIntent
+func (c *IntentClassifier) Classify(ctx context.Context, text string, config *core.Config) (IntentResult, error) {
+	if cached, ok := c.memo.get(text); ok {
+		return cached.(IntentResult), nil
+	}
+
+	var catalog strings.Builder
+	for _, intent := range c.catalog {
+		fmt.Fprintf(&catalog, "- %s: %s\n", intent.Name, intent.Description)
+		for _, ex := range intent.Examples {
+			fmt.Fprintf(&catalog, "    e.g. %q\n", ex)
+		}
+	}
+
+	prompt := fmt.Sprintf(`Classify the user's message into one of these intents:
+%s- %s: none of the above
+
+Answer with exactly two lines:
+intent: <intent name>
+confidence: <number between 0 and 1>
+
+Message:
+%s`, catalog.String(), UnknownIntent, text)
+
+	response, err := c.llm.Invoke(ctx, prompt, config)
+	if err != nil {
+		return IntentResult{}, fmt.Errorf("intent classification failed: %w", err)
+	}
+	label, confidence, err := parseLabel(fmt.Sprint(response))
+	if err != nil {
+		return IntentResult{}, err
+	}
+
+	result := IntentResult{Intent: UnknownIntent, Confidence: confidence}
+	for _, intent := range c.catalog {
+		if strings.EqualFold(intent.Name, label) && confidence >= c.minConfidence {
+			result.Intent = intent.Name
+			break
+		}
+	}
+	c.memo.put(text, result)
+	return result, nil
+}
+
+// Condition returns a branch condition matching inputs classified as any of intents
+func (c *IntentClassifier) Condition(intents ...string) core.BranchCondition {
+	return func(ctx context.Context, input interface{}) (bool, error) {
+		result, err := c.Classify(ctx, routingText(input), nil)
+		if err != nil {
+			return false, err
+		}
+		for _, name := range intents {
+			if result.Intent == name {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+// Invoke classifies a string or message and returns an IntentResult
+func (c *IntentClassifier) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	return c.Classify(ctx, routingText(input), config)
+}
+
+// Stream emits the result as a single chunk
+func (c *IntentClassifier) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		result, err := c.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		out <- result
+	}()
+	return out, nil
+}
+
+// Batch classifies each input in turn
+func (c *IntentClassifier) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := c.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the classifier with another runnable
+func (c *IntentClassifier) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{c, other})
+}
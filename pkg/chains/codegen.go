@@ -0,0 +1,161 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/tools"
+)
+
+// CodeGenResult is the outcome of a generate-and-repair run
+type CodeGenResult struct {
+	Code        string
+	Compiles    bool
+	Attempts    int
+	Diagnostics string // compiler/vet output of the last failed attempt
+}
+
+// String returns the generated code
+func (r *CodeGenResult) String() string {
+	return r.Code
+}
+
+// CodeGenWithRepair asks the model for Go code, checks it with `go build` and
+// `go vet` in the sandbox, and feeds the errors back until the code compiles
+// or the attempt budget runs out.
+type CodeGenWithRepair struct {
+	*core.BaseRunnable
+	llm         core.Runnable
+	sandbox     *tools.GoSandbox
+	maxAttempts int
+}
+
+// NewCodeGenWithRepair creates a code generation chain checked by sandbox
+func NewCodeGenWithRepair(llm core.Runnable, sandbox *tools.GoSandbox) *CodeGenWithRepair {
+	return &CodeGenWithRepair{
+		BaseRunnable: core.NewBaseRunnable("CodeGenWithRepair"),
+		llm:          llm,
+		sandbox:      sandbox,
+		maxAttempts:  4,
+	}
+}
+
+// WithMaxAttempts sets how many generations (first try plus repairs) are allowed
+func (c *CodeGenWithRepair) WithMaxAttempts(n int) *CodeGenWithRepair {
+	c.maxAttempts = n
+	return c
+}
+
+// Invoke takes a task description and returns a *CodeGenResult.
+// Running out of attempts is not an error; check CodeGenResult.Compiles.
+func (c *CodeGenWithRepair) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	task, ok := input.(string)
+	if !ok {
+		return nil, fmt.Errorf("input must be a task description string")
+	}
+
+	result := &CodeGenResult{}
+	prompt := fmt.Sprintf(`Write a complete, self-contained Go source file for the following task.
+Use only the standard library. If it is a program, use package main with a main function.
+Reply with the code in a single go code block.
+
+Task: %s`, task)
+
+	for result.Attempts < c.maxAttempts {
+		result.Attempts++
+
+		response, err := c.llm.Invoke(ctx, prompt, config)
+		if err != nil {
+			return nil, fmt.Errorf("code generation failed: %w", err)
+		}
+		result.Code = ExtractGoCode(fmt.Sprint(response))
+
+		check, err := c.sandbox.Check(ctx, map[string]string{"main.go": result.Code})
+		if err != nil {
+			return nil, err
+		}
+		if check.OK() {
+			result.Compiles = true
+			result.Diagnostics = ""
+			return result, nil
+		}
+		result.Diagnostics = strings.TrimSpace(check.Output)
+		if check.TimedOut {
+			result.Diagnostics = check.Command + " timed out"
+		}
+
+		prompt = fmt.Sprintf(`The following Go code for this task does not compile.
+
+Task: %s
+
+Code:
+%s
+
+Output of %s:
+%s
+
+Fix every error and reply with the complete corrected file in a single go code block.`,
+			task, fenceGo(result.Code), check.Command, result.Diagnostics)
+	}
+
+	return result, nil
+}
+
+var goCodeBlock = regexp.MustCompile("(?s)```(?:go|golang)?[ \\t]*\\n(.*?)```")
+
+// ExtractGoCode returns the longest fenced code block in a model reply, or the
+// whole reply when it has no fences
+func ExtractGoCode(response string) string {
+	best := ""
+	for _, m := range goCodeBlock.FindAllStringSubmatch(response, -1) {
+		if len(m[1]) > len(best) {
+			best = m[1]
+		}
+	}
+	if best == "" {
+		best = response
+	}
+	return strings.TrimSpace(best) + "\n"
+}
+
+// fenceGo wraps code in a go code block for prompting
+func fenceGo(code string) string {
+	return "```go\n" + strings.TrimRight(code, "\n") + "\n```"
+}
+
+// Stream runs the repair loop and emits the final result as a single chunk
+func (c *CodeGenWithRepair) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		result, err := c.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		out <- result
+	}()
+	return out, nil
+}
+
+// Batch runs the repair loop for each task in turn, since every attempt
+// already competes for the model and the go toolchain
+func (c *CodeGenWithRepair) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := c.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the chain with another runnable
+func (c *CodeGenWithRepair) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{c, other})
+}
@@ -0,0 +1,241 @@
+package chains
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/tools"
+)
+
+// FeedDigest summarizes items published since the last run for each feed.
+// The newest item digested is kept per feed in a JSON state file, so calling
+// it from a daily job yields a briefing of only what is new. Items left out
+// by the per-feed limit come up in the next digest.
+type FeedDigest struct {
+	*core.BaseRunnable
+	llm       core.Runnable
+	feeds     []string
+	statePath string
+	maxItems  int
+
+	mu sync.Mutex
+}
+
+// NewFeedDigest creates a digest chain over feeds; statePath may be empty to keep no state
+func NewFeedDigest(llm core.Runnable, feeds []string, statePath string) *FeedDigest {
+	return &FeedDigest{
+		BaseRunnable: core.NewBaseRunnable("FeedDigest"),
+		llm:          llm,
+		feeds:        feeds,
+		statePath:    statePath,
+		maxItems:     15,
+	}
+}
+
+// feedState is what the digest remembers about a feed: the publication time
+// of the newest item digested, and the undated items already digested
+type feedState struct {
+	Last    time.Time `json:"last"`
+	Undated []string  `json:"undated,omitempty"`
+}
+
+// itemKey identifies an undated item
+func itemKey(it tools.FeedItem) string {
+	if it.Link != "" {
+		return it.Link
+	}
+	return it.Title
+}
+
+// WithMaxItems limits how many new items per feed are summarized
+func (d *FeedDigest) WithMaxItems(n int) *FeedDigest {
+	d.maxItems = n
+	return d
+}
+
+// Invoke fetches every feed and returns a Markdown digest. Input is ignored.
+func (d *FeedDigest) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, err := d.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var sections []string
+	for _, feed := range d.feeds {
+		items, err := tools.FetchFeed(ctx, feed)
+		if err != nil {
+			sections = append(sections, fmt.Sprintf("## %s\n\nCould not fetch feed: %v", feed, err))
+			continue
+		}
+
+		fresh, next := d.newItems(items, state[feed])
+		if len(fresh) == 0 {
+			continue
+		}
+
+		summary, err := d.summarize(ctx, feed, fresh, config)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, fmt.Sprintf("## %s\n\n%s", feed, summary))
+		state[feed] = next
+	}
+
+	if err := d.saveState(state); err != nil {
+		return nil, err
+	}
+
+	if len(sections) == 0 {
+		return "Nothing new since the last digest.", nil
+	}
+	return fmt.Sprintf("# Digest for %s\n\n%s\n", now.Format("2006-01-02"), strings.Join(sections, "\n\n")), nil
+}
+
+// Stream builds the digest and emits it as a single chunk
+func (d *FeedDigest) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		result, err := d.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		out <- result
+	}()
+	return out, nil
+}
+
+// Batch builds a digest per input in turn; since each run advances the
+// state, only the first is likely to list new items
+func (d *FeedDigest) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := d.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the digest with another runnable
+func (d *FeedDigest) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{d, other})
+}
+
+// newItems picks the items to digest and the feed state once they are: the
+// oldest items published after the last digest, so none are skipped when
+// there are more than maxItems, then undated items not digested before.
+// items are sorted newest first, with undated items last.
+func (d *FeedDigest) newItems(items []tools.FeedItem, state feedState) ([]tools.FeedItem, feedState) {
+	var dated, undated []tools.FeedItem
+	seen := make(map[string]bool, len(state.Undated))
+	for _, key := range state.Undated {
+		seen[key] = true
+	}
+	for _, it := range items {
+		switch {
+		case it.Published.IsZero():
+			if !seen[itemKey(it)] {
+				undated = append(undated, it)
+			}
+		case it.Published.After(state.Last):
+			dated = append(dated, it)
+		}
+	}
+
+	if len(dated) > d.maxItems {
+		dated = dated[len(dated)-d.maxItems:]
+	}
+	if len(dated) > 0 {
+		state.Last = dated[0].Published
+	}
+	if room := d.maxItems - len(dated); len(undated) > room {
+		undated = undated[:room]
+	}
+
+	// Only undated items still in the feed need remembering
+	var keys []string
+	for _, it := range items {
+		if key := itemKey(it); it.Published.IsZero() && seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	for _, it := range undated {
+		keys = append(keys, itemKey(it))
+	}
+	state.Undated = keys
+	return append(dated, undated...), state
+}
+
+// summarize asks the LLM for a short briefing of the given items
+func (d *FeedDigest) summarize(ctx context.Context, feed string, items []tools.FeedItem, config *core.Config) (string, error) {
+	var list strings.Builder
+	for _, it := range items {
+		fmt.Fprintf(&list, "- %s: %s (%s)\n", it.Title, it.Summary, it.Link)
+	}
+
+	prompt := fmt.Sprintf(`Write a short news briefing of the following new items from %s.
+Use a bullet list with one line per noteworthy item and keep the links.
+
+Items:
+%s
+Briefing:`, feed, list.String())
+
+	response, err := d.llm.Invoke(ctx, prompt, config)
+	if err != nil {
+		return "", fmt.Errorf("digest summary failed for %s: %w", feed, err)
+	}
+	return strings.TrimSpace(fmt.Sprint(response)), nil
+}
+
+// loadState reads the state of every feed
+func (d *FeedDigest) loadState() (map[string]feedState, error) {
+	state := make(map[string]feedState)
+	if d.statePath == "" {
+		return state, nil
+	}
+
+	data, err := os.ReadFile(d.statePath)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read digest state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		// State files used to hold only the time of the last run
+		var lastRun map[string]time.Time
+		if json.Unmarshal(data, &lastRun) != nil {
+			return nil, fmt.Errorf("failed to parse digest state: %w", err)
+		}
+		for feed, last := range lastRun {
+			state[feed] = feedState{Last: last}
+		}
+	}
+	return state, nil
+}
+
+// saveState persists the state of every feed
+func (d *FeedDigest) saveState(state map[string]feedState) error {
+	if d.statePath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(d.statePath, data, 0o644)
+}
@@ -0,0 +1,138 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// DefaultVerifyPrompt asks the verifier to approve the draft or rewrite it.
+// {input} and {draft} are replaced with the question and the draft answer.
+const DefaultVerifyPrompt = `You are reviewing an answer written by a smaller assistant.
+
+Question:
+{input}
+
+Draft answer:
+{draft}
+
+If the draft is correct and complete, reply with exactly APPROVED.
+Otherwise reply with the corrected answer only, without commentary.
+
+Review:`
+
+// approvedMarker is the verifier reply that keeps the draft as-is
+const approvedMarker = "APPROVED"
+
+// DraftVerify answers with a fast drafter model and has a larger verifier
+// model check the draft. The verifier only has to write a full answer when
+// the draft is wrong, so most requests cost one short reply from the slow model.
+type DraftVerify struct {
+	*core.BaseRunnable
+	drafter  core.Runnable
+	verifier core.Runnable
+	prompt   string
+}
+
+// NewDraftVerify creates a pipeline that drafts with drafter and checks with verifier
+func NewDraftVerify(drafter, verifier core.Runnable) *DraftVerify {
+	return &DraftVerify{
+		BaseRunnable: core.NewBaseRunnable("DraftVerify"),
+		drafter:      drafter,
+		verifier:     verifier,
+		prompt:       DefaultVerifyPrompt,
+	}
+}
+
+// WithVerifyPrompt sets the verification prompt; it may use {input} and {draft}
+func (d *DraftVerify) WithVerifyPrompt(prompt string) *DraftVerify {
+	d.prompt = prompt
+	return d
+}
+
+// Invoke drafts an answer, then returns it approved or refined by the verifier
+func (d *DraftVerify) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	draft, err := d.drafter.Invoke(ctx, input, config)
+	if err != nil {
+		return nil, fmt.Errorf("draft failed: %w", err)
+	}
+	draftText := strings.TrimSpace(fmt.Sprint(draft))
+
+	review, err := d.verifier.Invoke(ctx, d.verifyPrompt(input, draftText), config)
+	if err != nil {
+		return nil, fmt.Errorf("verification failed: %w", err)
+	}
+	reviewText := strings.TrimSpace(fmt.Sprint(review))
+
+	if reviewText == "" || strings.HasPrefix(strings.ToUpper(reviewText), approvedMarker) {
+		return draftText, nil
+	}
+	return reviewText, nil
+}
+
+// verifyPrompt fills the verification template for one input and draft
+func (d *DraftVerify) verifyPrompt(input interface{}, draft string) string {
+	return strings.NewReplacer("{input}", inputText(input), "{draft}", draft).Replace(d.prompt)
+}
+
+// inputText renders a chain input as the question shown to the verifier
+func inputText(input interface{}) string {
+	switch v := input.(type) {
+	case string:
+		return v
+	case []core.Message:
+		var b strings.Builder
+		for _, msg := range v {
+			fmt.Fprintf(&b, "%s: %s\n", msg.GetType(), msg.GetContent())
+		}
+		return strings.TrimSpace(b.String())
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Stream runs the pipeline and emits the final answer as a single chunk
+func (d *DraftVerify) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		result, err := d.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		out <- result
+	}()
+	return out, nil
+}
+
+// Batch runs the pipeline for every input concurrently
+func (d *DraftVerify) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	errs := make([]error, len(inputs))
+
+	done := make(chan bool, len(inputs))
+	for i, input := range inputs {
+		go func(idx int, inp interface{}) {
+			results[idx], errs[idx] = d.Invoke(ctx, inp, config)
+			done <- true
+		}(i, input)
+	}
+	for range inputs {
+		<-done
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// Pipe composes the pipeline with another runnable
+func (d *DraftVerify) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{d, other})
+}
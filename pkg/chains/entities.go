@@ -0,0 +1,437 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// EntityType is the kind of a named entity
+type EntityType string
+
+const (
+	EntityPerson       EntityType = "PERSON"
+	EntityOrganization EntityType = "ORGANIZATION"
+	EntityLocation     EntityType = "LOCATION"
+	EntityEmail        EntityType = "EMAIL"
+	EntityURL          EntityType = "URL"
+	EntityPhone        EntityType = "PHONE"
+	EntityIP           EntityType = "IP"
+	EntityDate         EntityType = "DATE"
+	EntityMoney        EntityType = "MONEY"
+	EntityPercent      EntityType = "PERCENT"
+)
+
+// Entity is a typed span of the input text. Start and End are byte offsets,
+// so text[Start:End] == Text.
+type Entity struct {
+	Text  string     `json:"text"`
+	Type  EntityType `json:"type"`
+	Start int        `json:"start"`
+	End   int        `json:"end"`
+}
+
+// MaskEntities replaces the given entity types in text with [TYPE]
+// placeholders; with no types every entity is masked
+func MaskEntities(text string, entities []Entity, types ...EntityType) string {
+	keep := make(map[EntityType]bool)
+	for _, t := range types {
+		keep[t] = true
+	}
+	sorted := append([]Entity(nil), entities...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var b strings.Builder
+	pos := 0
+	for _, e := range sorted {
+		if (len(keep) > 0 && !keep[e.Type]) || e.Start < pos {
+			continue
+		}
+		b.WriteString(text[pos:e.Start])
+		b.WriteString("[" + string(e.Type) + "]")
+		pos = e.End
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// entityRule finds one entity type with a regular expression
+type entityRule struct {
+	entityType EntityType
+	pattern    *regexp.Regexp
+}
+
+// defaultEntityRules are the built-in patterns; on overlaps the match starting
+// first (then the longest, then the earliest rule) is kept
+var defaultEntityRules = []entityRule{
+	{EntityEmail, regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	{EntityURL, regexp.MustCompile(`https?://[^\s<>"')\]]*[^\s<>"')\].,;:!?]`)},
+	{EntityIP, regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)},
+	{EntityDate, regexp.MustCompile(`(?i)\b(?:\d{4}-\d{2}-\d{2}|\d{1,2}/\d{1,2}/\d{2,4}|(?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.? \d{1,2}(?:st|nd|rd|th)?(?:,? \d{4})?|\d{1,2} (?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]* \d{4})\b`)},
+	{EntityMoney, regexp.MustCompile(`(?:[$€£¥]\s?\d[\d,]*(?:\.\d+)?(?:\s?(?:k|m|bn|million|billion))?|\b\d[\d,]*(?:\.\d+)?\s?(?:USD|EUR|GBP|dollars|euros)\b)`)},
+	{EntityPercent, regexp.MustCompile(`\b\d+(?:\.\d+)?\s?%`)},
+	{EntityPhone, regexp.MustCompile(`\+?\d{1,3}[ .-]?\(?\d{2,4}\)?[ .-]?\d{3,4}[ .-]?\d{3,4}\b`)},
+}
+
+// RuleEntityExtractor finds entities with regular expressions and an optional
+// gazetteer of known names. It is fast and deterministic, which suits
+// redaction and bulk ingestion; use LLMEntityExtractor for open-ended names.
+type RuleEntityExtractor struct {
+	*core.BaseRunnable
+	rules     []entityRule
+	gazetteer map[string]EntityType
+}
+
+// NewRuleEntityExtractor creates an extractor with the built-in pattern rules
+func NewRuleEntityExtractor() *RuleEntityExtractor {
+	return &RuleEntityExtractor{
+		BaseRunnable: core.NewBaseRunnable("RuleEntityExtractor"),
+		rules:        append([]entityRule(nil), defaultEntityRules...),
+		gazetteer:    make(map[string]EntityType),
+	}
+}
+
+// WithRule adds a pattern for an entity type, tried after the built-in rules
+func (e *RuleEntityExtractor) WithRule(entityType EntityType, expr string) *RuleEntityExtractor {
+	e.rules = append(e.rules, entityRule{entityType, regexp.MustCompile(expr)})
+	return e
+}
+
+// WithGazetteer adds known names (people, products, places...) and their types
+func (e *RuleEntityExtractor) WithGazetteer(names map[string]EntityType) *RuleEntityExtractor {
+	for name, t := range names {
+		e.gazetteer[name] = t
+	}
+	return e
+}
+
+// Extract returns the entities in text, sorted by offset and without overlaps
+func (e *RuleEntityExtractor) Extract(text string) []Entity {
+	var found []Entity
+	for _, rule := range e.rules {
+		for _, loc := range rule.pattern.FindAllStringIndex(text, -1) {
+			found = append(found, Entity{Text: text[loc[0]:loc[1]], Type: rule.entityType, Start: loc[0], End: loc[1]})
+		}
+	}
+	for name, t := range e.gazetteer {
+		found = append(found, locateEntity(text, name, t)...)
+	}
+	return dedupeEntities(found)
+}
+
+// Invoke extracts entities from a string or message and returns []Entity
+func (e *RuleEntityExtractor) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	return e.Extract(routingText(input)), nil
+}
+
+// Stream emits the entities as a single chunk
+func (e *RuleEntityExtractor) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	out <- e.Extract(routingText(input))
+	close(out)
+	return out, nil
+}
+
+// Batch extracts entities from every input
+func (e *RuleEntityExtractor) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		results[i] = e.Extract(routingText(input))
+	}
+	return results, nil
+}
+
+// Pipe composes the extractor with another runnable
+func (e *RuleEntityExtractor) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{e, other})
+}
+
+// locateEntity returns every whole-word occurrence of name in text
+func locateEntity(text, name string, t EntityType) []Entity {
+	var out []Entity
+	if name == "" {
+		return out
+	}
+	for from := 0; from < len(text); {
+		idx := strings.Index(text[from:], name)
+		if idx < 0 {
+			break
+		}
+		start := from + idx
+		end := start + len(name)
+		if isWordBoundary(text, start, end) {
+			out = append(out, Entity{Text: name, Type: t, Start: start, End: end})
+		}
+		from = end
+	}
+	return out
+}
+
+// isWordBoundary reports whether text[start:end] is not part of a longer word
+func isWordBoundary(text string, start, end int) bool {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	if start > 0 {
+		if r := []rune(text[:start]); isWord(r[len(r)-1]) {
+			return false
+		}
+	}
+	if end < len(text) {
+		if r := []rune(text[end:]); isWord(r[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// dedupeEntities sorts entities and drops those overlapping an earlier or longer one
+func dedupeEntities(entities []Entity) []Entity {
+	sort.SliceStable(entities, func(i, j int) bool {
+		if entities[i].Start != entities[j].Start {
+			return entities[i].Start < entities[j].Start
+		}
+		return entities[i].End > entities[j].End
+	})
+	out := []Entity{}
+	end := -1
+	for _, e := range entities {
+		if e.Start < end {
+			continue
+		}
+		out = append(out, e)
+		end = e.End
+	}
+	return out
+}
+
+// LLMEntityExtractor asks a model for named entities and maps them back to
+// offsets in the input. Mentions the model invents are dropped.
+type LLMEntityExtractor struct {
+	*core.BaseRunnable
+	llm   core.Runnable
+	types []EntityType
+}
+
+// NewLLMEntityExtractor creates an extractor for the given types (people,
+// organizations and locations by default)
+func NewLLMEntityExtractor(llm core.Runnable, types ...EntityType) *LLMEntityExtractor {
+	if len(types) == 0 {
+		types = []EntityType{EntityPerson, EntityOrganization, EntityLocation}
+	}
+	return &LLMEntityExtractor{
+		BaseRunnable: core.NewBaseRunnable("LLMEntityExtractor"),
+		llm:          llm,
+		types:        types,
+	}
+}
+
+// Extract returns the entities the model found in text
+func (e *LLMEntityExtractor) Extract(ctx context.Context, text string, config *core.Config) ([]Entity, error) {
+	names := make([]string, len(e.types))
+	allowed := make(map[EntityType]bool)
+	for i, t := range e.types {
+		names[i] = string(t)
+		allowed[t] = true
+	}
+
+	prompt := fmt.Sprintf(`List the named entities in the text below.
+Allowed types: %s
+Write one entity per line as TYPE: exact text as it appears. Write NONE if there are none.
+
+Text:
+%s
+
+Entities:`, strings.Join(names, ", "), text)
+
+	response, err := e.llm.Invoke(ctx, prompt, config)
+	if err != nil {
+		return nil, fmt.Errorf("entity extraction failed: %w", err)
+	}
+
+	var found []Entity
+	for _, line := range strings.Split(fmt.Sprint(response), "\n") {
+		parts := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-")), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		t := EntityType(strings.ToUpper(strings.TrimSpace(parts[0])))
+		mention := strings.Trim(strings.TrimSpace(parts[1]), `"'`)
+		if !allowed[t] || mention == "" {
+			continue
+		}
+		found = append(found, locateEntity(text, mention, t)...)
+	}
+	return dedupeEntities(found), nil
+}
+
+// Invoke extracts entities from a string or message and returns []Entity
+func (e *LLMEntityExtractor) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	return e.Extract(ctx, routingText(input), config)
+}
+
+// Stream emits the entities as a single chunk
+func (e *LLMEntityExtractor) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		result, err := e.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		out <- result
+	}()
+	return out, nil
+}
+
+// Batch extracts entities from each input in turn
+func (e *LLMEntityExtractor) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := e.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the extractor with another runnable
+func (e *LLMEntityExtractor) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{e, other})
+}
+
+// Keyword is a key phrase with its score and first occurrence in the text
+type Keyword struct {
+	Text  string  `json:"text"`
+	Score float64 `json:"score"`
+	Start int     `json:"start"`
+	End   int     `json:"end"`
+}
+
+// keywordStopwords split candidate phrases in KeywordExtractor
+var keywordStopwords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`a about above after again against all am an and any are as at be because been
+before being below between both but by can could did do does doing down during each few for from further
+had has have having he her here hers herself him himself his how i if in into is it its itself just me more
+most my myself no nor not now of off on once only or other our ours ourselves out over own same she should
+so some such than that the their theirs them themselves then there these they this those through to too under
+until up very was we were what when where which while who whom why will with would you your yours yourself
+yourselves also may might must shall use used using via per etc`) {
+		keywordStopwords[w] = true
+	}
+}
+
+// KeywordExtractor scores key phrases with RAKE: phrases are runs of words
+// between stopwords and punctuation, scored by word degree over frequency
+type KeywordExtractor struct {
+	*core.BaseRunnable
+	maxKeywords int
+}
+
+// NewKeywordExtractor creates a keyword extractor returning up to maxKeywords phrases
+func NewKeywordExtractor(maxKeywords int) *KeywordExtractor {
+	if maxKeywords <= 0 {
+		maxKeywords = 10
+	}
+	return &KeywordExtractor{
+		BaseRunnable: core.NewBaseRunnable("KeywordExtractor"),
+		maxKeywords:  maxKeywords,
+	}
+}
+
+var keywordToken = regexp.MustCompile(`[\p{L}\p{N}][\p{L}\p{N}'-]*|[.,;:!?()\[\]"\n]`)
+
+// Extract returns the top key phrases of text, best first
+func (k *KeywordExtractor) Extract(text string) []Keyword {
+	type phrase struct {
+		words      []string
+		start, end int
+	}
+	var phrases []phrase
+	var cur *phrase
+	for _, loc := range keywordToken.FindAllStringIndex(text, -1) {
+		tok := strings.ToLower(text[loc[0]:loc[1]])
+		if keywordStopwords[tok] || !unicode.IsLetter([]rune(tok)[0]) && !unicode.IsDigit([]rune(tok)[0]) {
+			cur = nil
+			continue
+		}
+		if cur == nil {
+			phrases = append(phrases, phrase{start: loc[0]})
+			cur = &phrases[len(phrases)-1]
+		}
+		cur.words = append(cur.words, tok)
+		cur.end = loc[1]
+	}
+
+	freq := make(map[string]float64)
+	degree := make(map[string]float64)
+	for _, p := range phrases {
+		for _, w := range p.words {
+			freq[w]++
+			degree[w] += float64(len(p.words))
+		}
+	}
+
+	best := make(map[string]Keyword)
+	for _, p := range phrases {
+		key := strings.Join(p.words, " ")
+		if _, seen := best[key]; seen {
+			continue
+		}
+		score := 0.0
+		for _, w := range p.words {
+			score += degree[w] / freq[w]
+		}
+		best[key] = Keyword{Text: text[p.start:p.end], Score: score, Start: p.start, End: p.end}
+	}
+
+	keywords := make([]Keyword, 0, len(best))
+	for _, kw := range best {
+		keywords = append(keywords, kw)
+	}
+	sort.Slice(keywords, func(i, j int) bool {
+		if keywords[i].Score != keywords[j].Score {
+			return keywords[i].Score > keywords[j].Score
+		}
+		return keywords[i].Start < keywords[j].Start
+	})
+	if len(keywords) > k.maxKeywords {
+		keywords = keywords[:k.maxKeywords]
+	}
+	return keywords
+}
+
+// Invoke extracts keywords from a string or message and returns []Keyword
+func (k *KeywordExtractor) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	return k.Extract(routingText(input)), nil
+}
+
+// Stream emits the keywords as a single chunk
+func (k *KeywordExtractor) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	out <- k.Extract(routingText(input))
+	close(out)
+	return out, nil
+}
+
+// Batch extracts keywords from every input
+func (k *KeywordExtractor) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		results[i] = k.Extract(routingText(input))
+	}
+	return results, nil
+}
+
+// Pipe composes the extractor with another runnable
+func (k *KeywordExtractor) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{k, other})
+}
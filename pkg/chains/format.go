@@ -0,0 +1,226 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// Formatter post-processes the text produced by a chain
+type Formatter func(text string) string
+
+// FormattedRunnable applies formatters, in order, to the output of a runnable.
+// String outputs and the content of AI messages are formatted; other outputs
+// pass through unchanged.
+type FormattedRunnable struct {
+	*core.BaseRunnable
+	inner      core.Runnable
+	formatters []Formatter
+}
+
+// WithFormatters wraps r so its output goes through formatters before it is
+// returned or streamed
+func WithFormatters(r core.Runnable, formatters ...Formatter) *FormattedRunnable {
+	return &FormattedRunnable{
+		BaseRunnable: core.NewBaseRunnable(r.Name()),
+		inner:        r,
+		formatters:   formatters,
+	}
+}
+
+// WithFormatter registers one more formatter, applied after the existing ones
+func (f *FormattedRunnable) WithFormatter(formatter Formatter) *FormattedRunnable {
+	f.formatters = append(f.formatters, formatter)
+	return f
+}
+
+// format applies the formatters to text
+func (f *FormattedRunnable) format(text string) string {
+	for _, formatter := range f.formatters {
+		text = formatter(text)
+	}
+	return text
+}
+
+// formatOutput applies the formatters to a string, AI message or generation
+func (f *FormattedRunnable) formatOutput(output interface{}) interface{} {
+	switch v := output.(type) {
+	case string:
+		return f.format(v)
+	case *core.AIMessage:
+		msg := core.NewAIMessage(f.format(v.Content), v.AdditionalKwargs)
+		msg.BaseMessage.ID, msg.BaseMessage.Timestamp = v.ID, v.Timestamp
+		msg.ToolCalls = v.ToolCalls
+		return msg
+	case *core.Generation:
+		gen := *v
+		gen.Text = f.format(v.Text)
+		return &gen
+	default:
+		return output
+	}
+}
+
+// Invoke runs the wrapped runnable and formats its output
+func (f *FormattedRunnable) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	output, err := f.inner.Invoke(ctx, input, config)
+	if err != nil {
+		return nil, err
+	}
+	return f.formatOutput(output), nil
+}
+
+// Stream collects the wrapped runnable's text chunks and emits the formatted
+// text as a single chunk, since most formatters need the whole output
+func (f *FormattedRunnable) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	chunks, err := f.inner.Stream(ctx, input, config)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		var text strings.Builder
+		for chunk := range chunks {
+			switch v := chunk.(type) {
+			case error:
+				out <- v
+				return
+			case string:
+				text.WriteString(v)
+			case core.Message:
+				text.WriteString(v.GetContent())
+			default:
+				text.WriteString(fmt.Sprint(v))
+			}
+		}
+		out <- f.format(text.String())
+	}()
+	return out, nil
+}
+
+// Batch runs the wrapped runnable's batch and formats every output
+func (f *FormattedRunnable) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	outputs, err := f.inner.Batch(ctx, inputs, config)
+	if err != nil {
+		return nil, err
+	}
+	for i, output := range outputs {
+		outputs[i] = f.formatOutput(output)
+	}
+	return outputs, nil
+}
+
+// Pipe composes the formatted runnable with another runnable
+func (f *FormattedRunnable) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{f, other})
+}
+
+var (
+	trailingSpace = regexp.MustCompile(`(?m)[ \t]+$`)
+	codeBlock     = regexp.MustCompile("(?s)```([\\w+-]*)[^\\n]*\\n(.*?)```")
+	mdHeading     = regexp.MustCompile(`(?m)^#{1,6}[ \t]+`)
+	mdStrong      = regexp.MustCompile(`(\*\*|__)([^\s*_].*?)(\*\*|__)`)
+	mdEmphasis    = regexp.MustCompile(`(^|[^\w*])[*_]([^*_\s][^*_\n]*?)[*_]([^\w*]|$)`)
+	mdLink        = regexp.MustCompile(`!?\[([^\]]*)\]\(([^)]*)\)`)
+	mdInlineCode  = regexp.MustCompile("`([^`]*)`")
+	mdBullet      = regexp.MustCompile(`(?m)^([ \t]*)[*+-][ \t]+`)
+	mdQuote       = regexp.MustCompile(`(?m)^>[ \t]?`)
+	mdRule        = regexp.MustCompile(`(?m)^[ \t]*([-*_][ \t]*){3,}$\n?`)
+	largeNumber   = regexp.MustCompile(`\b(\d{5,})(\.\d+)?\b`)
+)
+
+// TrimWhitespace removes trailing spaces on every line and blank lines at
+// both ends of the text
+func TrimWhitespace(text string) string {
+	return strings.TrimSpace(trailingSpace.ReplaceAllString(text, ""))
+}
+
+// ExtractCodeBlock returns a formatter that keeps only the content of the
+// first fenced code block in the given language ("" matches any block).
+// Text without a matching block is returned unchanged.
+func ExtractCodeBlock(language string) Formatter {
+	return func(text string) string {
+		for _, m := range codeBlock.FindAllStringSubmatch(text, -1) {
+			if language == "" || strings.EqualFold(m[1], language) {
+				return strings.TrimRight(m[2], "\n")
+			}
+		}
+		return text
+	}
+}
+
+// MarkdownToPlainText strips Markdown markup, keeping the text: headings,
+// emphasis, links (as "text (url)"), inline code, quotes and rules. Fenced
+// code blocks are kept without their fences.
+func MarkdownToPlainText(text string) string {
+	// Set code blocks aside so their content is not treated as markup
+	var blocks []string
+	text = codeBlock.ReplaceAllStringFunc(text, func(block string) string {
+		blocks = append(blocks, codeBlock.FindStringSubmatch(block)[2])
+		return fmt.Sprintf("\x00%d\x00", len(blocks)-1)
+	})
+
+	text = mdRule.ReplaceAllString(text, "")
+	text = mdHeading.ReplaceAllString(text, "")
+	text = mdQuote.ReplaceAllString(text, "")
+	text = mdBullet.ReplaceAllString(text, "$1- ")
+	text = mdLink.ReplaceAllStringFunc(text, func(link string) string {
+		m := mdLink.FindStringSubmatch(link)
+		if m[1] == "" || m[1] == m[2] {
+			return m[2]
+		}
+		return fmt.Sprintf("%s (%s)", m[1], m[2])
+	})
+	text = mdInlineCode.ReplaceAllString(text, "$1")
+	text = mdStrong.ReplaceAllString(text, "$2")
+	text = mdEmphasis.ReplaceAllString(text, "$1$2$3")
+	for i, block := range blocks {
+		text = strings.Replace(text, fmt.Sprintf("\x00%d\x00", i), block, 1)
+	}
+	return text
+}
+
+// numberSeparators holds the thousands and decimal separators of a locale
+var numberSeparators = map[string][2]string{
+	"en": {",", "."},
+	"fr": {" ", ","},
+	"de": {".", ","},
+	"es": {".", ","},
+	"it": {".", ","},
+	"pt": {".", ","},
+	"nl": {".", ","},
+}
+
+// LocaleNumbers returns a formatter that groups the digits of plain numbers
+// of five digits or more using the locale's separators ("en", "fr", "de",
+// ...; regional tags such as "fr-CA" use their language). Shorter numbers
+// are left alone so years and codes are not altered.
+func LocaleNumbers(locale string) Formatter {
+	lang := strings.ToLower(strings.SplitN(strings.ReplaceAll(locale, "_", "-"), "-", 2)[0])
+	seps, ok := numberSeparators[lang]
+	if !ok {
+		seps = numberSeparators["en"]
+	}
+	return func(text string) string {
+		return largeNumber.ReplaceAllStringFunc(text, func(number string) string {
+			m := largeNumber.FindStringSubmatch(number)
+			digits := m[1]
+			var b strings.Builder
+			for i, d := range digits {
+				if i > 0 && (len(digits)-i)%3 == 0 {
+					b.WriteString(seps[0])
+				}
+				b.WriteRune(d)
+			}
+			if m[2] != "" {
+				b.WriteString(seps[1] + m[2][1:])
+			}
+			return b.String()
+		})
+	}
+}
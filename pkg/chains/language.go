@@ -0,0 +1,307 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// LanguageDetection is the result of DetectLanguage
+type LanguageDetection struct {
+	Language   string  // ISO 639-1 code, or "und" when undetermined
+	Confidence float64 // 0..1; margin over the runner-up, low for short texts
+}
+
+// String returns the language code
+func (d LanguageDetection) String() string {
+	return d.Language
+}
+
+// languageSamples are short texts made of each language's most frequent
+// words; their character trigrams are the detection profiles
+var languageSamples = map[string]string{
+	"en": `the of and to in is you that it he was for on are as with his they at be this have from or one had by but not what all were we when your can said there use an each which she do how their if will up other about out many then them these so some her would make like him into time has look two more write go see number no way could people my than first been call who its now find long down day did get come made may part over new sound take only little work know place year live me back give most very after thing our just name good sentence man think say great where help through much before line right too mean old any same tell boy follow came want show also around form three small set put end does another well large must big even such because turn here why ask went men read need land different home us move try kind hand picture again change off play spell air away animal house point page letter mother answer found study still learn should world`,
+	"fr": `le de un être et à il avoir ne je son que se qui ce dans en du elle au pour pas plus par sur faire avec tout mais on nous comme ou si leur y dire devoir avant deux même prendre aussi où celui donner bien grand aller falloir voir autre sans votre trouver quel premier moins pouvoir notre mettre encore savoir homme aucun temps très rien petit peu jour vous cette ces les des une est sont était été fait parce chose monde toujours peut mes tes nos vos aujourd'hui quelque beaucoup pendant après vers chez sous entre depuis contre année enfant femme vie main pays heure question maison travail fois point partie façon raison merci bonjour`,
+	"es": `el la de que y a en un ser se no haber por con su para como estar tener le lo todo pero más hacer o poder decir este ir otro ese la si me ya ver porque dar cuando él muy sin vez mucho saber qué sobre mi alguno mismo yo también hasta año dos querer entre así primero desde grande eso ni nos llegar pasar tiempo ella sí día uno bien poco deber entonces poner cosa tanto hombre parecer nuestro tan donde ahora parte después vida quedar siempre creer hablar llevar dejar nada cada seguir menos nuevo encontrar algo solo los las del una está son fue han muy usted gracias hola mujer mundo país casa trabajo`,
+	"de": `der die und in den von zu das mit sich des auf für ist im dem nicht ein die eine als auch es an werden aus er hat dass sie nach wird bei einer um am sind noch wie einem über einen so zum war haben nur oder aber vor zur bis mehr durch man sein wurde sei in prozent hatte kann gegen vom können schon wenn habe seine ihre dann unter wir soll ich eines jahr zwei jahren diese dieser wieder keine seiner worden und will zwischen immer was sagte gibt alle diesem seit muss wurden beim doch jedoch geht ihr heute mann frau kind haus arbeit welt danke bitte guten tag`,
+	"it": `il di che e la a per un in è non una sono con mi si ho ma lo ha le io cosa ti del da ci se questo come al bene della hai qui no più tu sei lei gli anche mio solo mia già era ne molto fare nel tutto quando sì chi cosa dove perché noi suo hanno ora così sua allora grazie signore questa niente prima quello sempre proprio siamo fatto casa tempo voglio dire vita uomo giorno anni donna lavoro mondo paese ciao buongiorno essere avere andare potere dovere volere sapere vedere venire`,
+	"pt": `o de a que e do da em um para é com não uma os no se na por mais as dos como mas foi ao ele das tem à seu sua ou ser quando muito há nos já está eu também só pelo pela até isso ela entre era depois sem mesmo aos ter seus quem nas me esse eles estão você tinha foram essa num nem suas meu às minha têm numa pelos elas havia seja qual será nós tenho lhe deles essas esses pelas este fosse dele obrigado olá bom dia casa trabalho mundo tempo vida homem mulher ano coisa fazer`,
+	"nl": `de en van ik te dat die in een hij het niet zijn is was op aan met als voor had er maar om hem dan zou of wat mijn men dit zo door over ze zich bij ook tot je mij uit der daar haar naar heb hoe heeft hebben deze u want nog zal me zij nu ge geen omdat iets worden toch al waren veel meer doen toen moet ben zonder kan hun dus alles onder ja eens hier wie werd altijd doch wordt wezen kunnen ons zelf tegen na reeds wil kon niets uw iemand geweest andere dank goedemorgen huis werk wereld tijd leven man vrouw jaar`,
+}
+
+// scriptLanguages maps scripts used by a single major language to its code
+var scriptLanguages = []struct {
+	table    *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+// trigramProfile is an L2-normalized character trigram frequency vector
+type trigramProfile map[string]float64
+
+var languageProfiles = buildProfiles()
+
+func buildProfiles() map[string]trigramProfile {
+	profiles := make(map[string]trigramProfile, len(languageSamples))
+	for lang, sample := range languageSamples {
+		profiles[lang] = newTrigramProfile(trigrams(sample))
+	}
+	return profiles
+}
+
+// newTrigramProfile counts grams and normalizes the counts to unit length
+func newTrigramProfile(grams []string) trigramProfile {
+	p := make(trigramProfile)
+	for _, g := range grams {
+		p[g]++
+	}
+	norm := 0.0
+	for _, c := range p {
+		norm += c * c
+	}
+	norm = math.Sqrt(norm)
+	for g := range p {
+		p[g] /= norm
+	}
+	return p
+}
+
+// trigrams returns the padded, lower-cased character trigrams of the words in text
+func trigrams(text string) []string {
+	var out []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			out = append(out, string(runes[i:i+3]))
+		}
+	}
+	return out
+}
+
+// DetectLanguage guesses the language of text. Non-Latin scripts are
+// recognized by their characters; Latin-script languages (en, fr, es, de,
+// it, pt, nl) are scored with character trigram profiles.
+func DetectLanguage(text string) LanguageDetection {
+	scripts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.table, r) {
+				scripts[s.language]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return LanguageDetection{Language: "und"}
+	}
+
+	// Japanese mixes kana with Han characters, so any kana means Japanese
+	if scripts["ja"] > 0 && scripts["ja"]+scripts["zh"] > letters/2 {
+		return LanguageDetection{Language: "ja", Confidence: float64(scripts["ja"]+scripts["zh"]) / float64(letters)}
+	}
+	for lang, n := range scripts {
+		if n > letters/2 {
+			return LanguageDetection{Language: lang, Confidence: float64(n) / float64(letters)}
+		}
+	}
+
+	grams := trigrams(text)
+	if len(grams) == 0 {
+		return LanguageDetection{Language: "und"}
+	}
+
+	// Cosine similarity between the text and each language profile
+	textProfile := newTrigramProfile(grams)
+	best, bestScore, second := "", 0.0, 0.0
+	for lang, p := range languageProfiles {
+		score := 0.0
+		for g, w := range textProfile {
+			score += w * p[g]
+		}
+		switch {
+		case score > bestScore || (score == bestScore && lang < best):
+			second = bestScore
+			best, bestScore = lang, score
+		case score > second:
+			second = score
+		}
+	}
+	if bestScore == 0 {
+		return LanguageDetection{Language: "und"}
+	}
+	// Confidence is the relative margin over the runner-up language
+	return LanguageDetection{Language: best, Confidence: (bestScore - second) / (bestScore + second)}
+}
+
+// SupportedLanguages returns the codes DetectLanguage can return, besides "und"
+func SupportedLanguages() []string {
+	langs := make([]string, 0, len(languageProfiles)+len(scriptLanguages))
+	seen := make(map[string]bool)
+	for lang := range languageProfiles {
+		langs = append(langs, lang)
+		seen[lang] = true
+	}
+	for _, s := range scriptLanguages {
+		if !seen[s.language] {
+			langs = append(langs, s.language)
+			seen[s.language] = true
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// LanguageDetector is a Runnable returning the LanguageDetection of its input
+type LanguageDetector struct {
+	*core.BaseRunnable
+}
+
+// NewLanguageDetector creates a language detection runnable
+func NewLanguageDetector() *LanguageDetector {
+	return &LanguageDetector{BaseRunnable: core.NewBaseRunnable("LanguageDetector")}
+}
+
+// Invoke detects the language of a string or of the last message in a conversation
+func (d *LanguageDetector) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	return DetectLanguage(routingText(input)), nil
+}
+
+// Stream emits the detection as a single chunk
+func (d *LanguageDetector) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	out <- DetectLanguage(routingText(input))
+	close(out)
+	return out, nil
+}
+
+// Batch detects the language of every input
+func (d *LanguageDetector) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		results[i] = DetectLanguage(routingText(input))
+	}
+	return results, nil
+}
+
+// Pipe composes the detector with another runnable
+func (d *LanguageDetector) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{d, other})
+}
+
+// routingText returns the text to classify: the string itself, or the content
+// of the last message of a conversation
+func routingText(input interface{}) string {
+	switch v := input.(type) {
+	case string:
+		return v
+	case []core.Message:
+		if len(v) == 0 {
+			return ""
+		}
+		return v[len(v)-1].GetContent()
+	case core.Message:
+		return v.GetContent()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// LanguageRouter sends each input to the runnable registered for its detected
+// language, e.g. models configured with a system prompt in that language.
+// By default the best guess is always used; set WithMinConfidence to send
+// ambiguous (usually very short) inputs to the fallback instead.
+type LanguageRouter struct {
+	*core.BaseRunnable
+	routes        map[string]core.Runnable
+	fallback      core.Runnable
+	minConfidence float64
+}
+
+// NewLanguageRouter creates a router using fallback for unlisted languages
+func NewLanguageRouter(routes map[string]core.Runnable, fallback core.Runnable) *LanguageRouter {
+	return &LanguageRouter{
+		BaseRunnable: core.NewBaseRunnable("LanguageRouter"),
+		routes:       routes,
+		fallback:     fallback,
+	}
+}
+
+// WithMinConfidence sets the confidence needed to route away from the fallback
+func (r *LanguageRouter) WithMinConfidence(c float64) *LanguageRouter {
+	r.minConfidence = c
+	return r
+}
+
+// Route returns the runnable that should handle input
+func (r *LanguageRouter) Route(input interface{}) core.Runnable {
+	det := DetectLanguage(routingText(input))
+	if target, ok := r.routes[det.Language]; ok && det.Confidence >= r.minConfidence {
+		return target
+	}
+	return r.fallback
+}
+
+// Invoke runs the input through the runnable for its language
+func (r *LanguageRouter) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	return r.Route(input).Invoke(ctx, input, config)
+}
+
+// Stream streams from the runnable for the input's language
+func (r *LanguageRouter) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	return r.Route(input).Stream(ctx, input, config)
+}
+
+// Batch routes every input independently
+func (r *LanguageRouter) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := r.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the router with another runnable
+func (r *LanguageRouter) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{r, other})
+}
+
+// LocalizedPrompts holds one prompt per language code, for callers that
+// build prompts themselves instead of routing between models
+type LocalizedPrompts struct {
+	Prompts  map[string]string
+	Fallback string
+}
+
+// For returns the prompt in the language of text
+func (p LocalizedPrompts) For(text string) string {
+	if prompt, ok := p.Prompts[DetectLanguage(text).Language]; ok {
+		return prompt
+	}
+	return p.Fallback
+}
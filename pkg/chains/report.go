@@ -0,0 +1,395 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// ReportSection is one section of a report template
+type ReportSection struct {
+	Title string `json:"title"`
+	// Instructions tell the LLM what the section must cover
+	Instructions string `json:"instructions"`
+	// Query is sent to the retriever; the title is used when empty
+	Query string `json:"query,omitempty"`
+}
+
+// ReportTemplate describes the structure of a report
+type ReportTemplate struct {
+	Title    string          `json:"title"`
+	Sections []ReportSection `json:"sections"`
+}
+
+// Source is a retrieved passage a report section can cite
+type Source struct {
+	Title string
+	URL   string
+	Text  string
+}
+
+// SourceRetriever finds the sources relevant to a section query
+type SourceRetriever func(ctx context.Context, query string) ([]Source, error)
+
+// ReportFormat selects how a report is rendered
+type ReportFormat string
+
+const (
+	ReportMarkdown ReportFormat = "markdown"
+	ReportHTML     ReportFormat = "html"
+)
+
+// SectionResult is a generated report section. Body cites references by
+// their number in Report.References, e.g. "[2]".
+type SectionResult struct {
+	Title  string
+	Anchor string
+	Body   string
+}
+
+// Report is a generated report
+type Report struct {
+	Title      string
+	Sections   []SectionResult
+	References []Source
+}
+
+// ReportGenerator fills a report template: each section is written by the
+// LLM from its instructions and the sources retrieved for it, sections run
+// in parallel, and the result is assembled into one document with a table
+// of contents and a numbered reference list.
+type ReportGenerator struct {
+	*core.BaseRunnable
+	llm         core.Runnable
+	template    ReportTemplate
+	retriever   SourceRetriever
+	format      ReportFormat
+	concurrency int
+}
+
+// NewReportGenerator creates a report chain for template
+func NewReportGenerator(llm core.Runnable, template ReportTemplate) *ReportGenerator {
+	return &ReportGenerator{
+		BaseRunnable: core.NewBaseRunnable("ReportGenerator"),
+		llm:          llm,
+		template:     template,
+		format:       ReportMarkdown,
+		concurrency:  4,
+	}
+}
+
+// WithRetriever sets where section sources come from; without one,
+// sections are written from the input alone
+func (r *ReportGenerator) WithRetriever(retriever SourceRetriever) *ReportGenerator {
+	r.retriever = retriever
+	return r
+}
+
+// WithFormat sets the output format of Invoke (default Markdown)
+func (r *ReportGenerator) WithFormat(format ReportFormat) *ReportGenerator {
+	r.format = format
+	return r
+}
+
+// WithConcurrency limits how many sections are generated at once
+func (r *ReportGenerator) WithConcurrency(n int) *ReportGenerator {
+	if n > 0 {
+		r.concurrency = n
+	}
+	return r
+}
+
+// Invoke generates the report about input and returns it rendered in the
+// configured format
+func (r *ReportGenerator) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	report, err := r.Generate(ctx, input, config)
+	if err != nil {
+		return nil, err
+	}
+	if r.format == ReportHTML {
+		return report.HTML(), nil
+	}
+	return report.Markdown(), nil
+}
+
+// sectionDraft is a section written against its own sources
+type sectionDraft struct {
+	body    string
+	sources []Source
+}
+
+// Generate writes every section and assembles the report
+func (r *ReportGenerator) Generate(ctx context.Context, input interface{}, config *core.Config) (*Report, error) {
+	subject := inputText(input)
+	sections := r.template.Sections
+	drafts := make([]sectionDraft, len(sections))
+	errs := make([]error, len(sections))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, r.concurrency)
+	for i, section := range sections {
+		wg.Add(1)
+		go func(idx int, section ReportSection) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[idx] = ctx.Err()
+				return
+			}
+			drafts[idx], errs[idx] = r.writeSection(ctx, subject, section, config)
+		}(i, section)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("report section %q failed: %w", sections[i].Title, err)
+		}
+	}
+	return assembleReport(r.template, drafts), nil
+}
+
+// writeSection retrieves the sources of one section and has the LLM write it
+func (r *ReportGenerator) writeSection(ctx context.Context, subject string, section ReportSection, config *core.Config) (sectionDraft, error) {
+	var sources []Source
+	if r.retriever != nil {
+		query := section.Query
+		if query == "" {
+			query = section.Title
+		}
+		var err error
+		if sources, err = r.retriever(ctx, query); err != nil {
+			return sectionDraft{}, fmt.Errorf("retrieval failed: %w", err)
+		}
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "You are writing the %q section of a report titled %q.\n", section.Title, r.template.Title)
+	if subject != "" {
+		fmt.Fprintf(&prompt, "\nSubject of the report:\n%s\n", subject)
+	}
+	fmt.Fprintf(&prompt, "\nSection instructions:\n%s\n", section.Instructions)
+	if len(sources) > 0 {
+		prompt.WriteString("\nSources:\n")
+		for i, s := range sources {
+			fmt.Fprintf(&prompt, "[%d] %s\n%s\n\n", i+1, s.Title, strings.TrimSpace(s.Text))
+		}
+		prompt.WriteString("Base the section on the sources and cite them by number, e.g. [1], after the sentences they support.\n")
+	}
+	prompt.WriteString("\nWrite only the section body in Markdown, without its heading.\n\nSection:")
+
+	response, err := r.llm.Invoke(ctx, prompt.String(), config)
+	if err != nil {
+		return sectionDraft{}, err
+	}
+	return sectionDraft{body: strings.TrimSpace(fmt.Sprint(response)), sources: sources}, nil
+}
+
+// citationRef matches a numeric citation such as "[3]"
+var citationRef = regexp.MustCompile(`\[(\d+)\]`)
+
+// sectionCitation matches a citation in a section draft with the space
+// before it, so dropping the citation leaves no gap
+var sectionCitation = regexp.MustCompile(`\s?\[(\d+)\]`)
+
+// assembleReport numbers the sources cited across sections and rewrites
+// each section's local citations to those numbers. Citations of unknown
+// sources are dropped.
+func assembleReport(template ReportTemplate, drafts []sectionDraft) *Report {
+	report := &Report{Title: template.Title}
+	numbers := make(map[string]int)
+	anchors := make(map[string]int)
+
+	for i, draft := range drafts {
+		body := sectionCitation.ReplaceAllStringFunc(draft.body, func(ref string) string {
+			space := ref[:strings.Index(ref, "[")]
+			n, _ := strconv.Atoi(sectionCitation.FindStringSubmatch(ref)[1])
+			if n < 1 || n > len(draft.sources) {
+				return ""
+			}
+			source := draft.sources[n-1]
+			key := source.URL
+			if key == "" {
+				key = source.Title + "\x00" + source.Text
+			}
+			if _, ok := numbers[key]; !ok {
+				report.References = append(report.References, source)
+				numbers[key] = len(report.References)
+			}
+			return fmt.Sprintf("%s[%d]", space, numbers[key])
+		})
+
+		title := template.Sections[i].Title
+		anchor := slugify(title)
+		if anchor == "" {
+			anchor = fmt.Sprintf("section-%d", i+1)
+		}
+		if anchors[anchor]++; anchors[anchor] > 1 {
+			anchor = fmt.Sprintf("%s-%d", anchor, anchors[anchor]-1)
+		}
+		report.Sections = append(report.Sections, SectionResult{Title: title, Anchor: anchor, Body: body})
+	}
+	return report
+}
+
+// slugify turns a heading into a Markdown anchor, e.g. "Key Risks" becomes "key-risks"
+func slugify(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, c := range strings.ToLower(title) {
+		switch {
+		case c >= 'a' && c <= 'z' || c >= '0' && c <= '9':
+			b.WriteRune(c)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// Markdown renders the report as Markdown
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n## Contents\n\n", r.Title)
+	for _, s := range r.Sections {
+		fmt.Fprintf(&b, "- [%s](#%s)\n", s.Title, s.Anchor)
+	}
+	if len(r.References) > 0 {
+		b.WriteString("- [References](#references)\n")
+	}
+	for _, s := range r.Sections {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", s.Title, s.Body)
+	}
+	if len(r.References) > 0 {
+		b.WriteString("\n## References\n\n")
+		for i, s := range r.References {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, s.reference())
+		}
+	}
+	return b.String()
+}
+
+// reference renders a source in the reference list
+func (s Source) reference() string {
+	switch {
+	case s.URL == "":
+		return s.Title
+	case s.Title == "":
+		return "<" + s.URL + ">"
+	default:
+		return fmt.Sprintf("[%s](%s)", s.Title, s.URL)
+	}
+}
+
+// HTML renders the report as a standalone HTML document. Section bodies are
+// converted from Markdown paragraphs and bullet lists; citations link to
+// the reference list.
+func (r *Report) HTML() string {
+	var b strings.Builder
+	title := html.EscapeString(r.Title)
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", title)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n<nav>\n<h2>Contents</h2>\n<ul>\n", title)
+	for _, s := range r.Sections {
+		fmt.Fprintf(&b, "<li><a href=\"#%s\">%s</a></li>\n", s.Anchor, html.EscapeString(s.Title))
+	}
+	if len(r.References) > 0 {
+		b.WriteString("<li><a href=\"#references\">References</a></li>\n")
+	}
+	b.WriteString("</ul>\n</nav>\n")
+
+	for _, s := range r.Sections {
+		fmt.Fprintf(&b, "<section id=\"%s\">\n<h2>%s</h2>\n%s</section>\n", s.Anchor, html.EscapeString(s.Title), bodyHTML(s.Body))
+	}
+	if len(r.References) > 0 {
+		b.WriteString("<section id=\"references\">\n<h2>References</h2>\n<ol>\n")
+		for i, s := range r.References {
+			label := html.EscapeString(s.Title)
+			if label == "" {
+				label = html.EscapeString(s.URL)
+			}
+			if s.URL != "" {
+				label = fmt.Sprintf("<a href=\"%s\">%s</a>", html.EscapeString(s.URL), label)
+			}
+			fmt.Fprintf(&b, "<li id=\"ref-%d\">%s</li>\n", i+1, label)
+		}
+		b.WriteString("</ol>\n</section>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// bodyHTML converts the paragraphs and bullet lists of a section body
+func bodyHTML(body string) string {
+	var b strings.Builder
+	for _, block := range strings.Split(body, "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		if len(lines) == 0 || lines[0] == "" {
+			continue
+		}
+		if bulletLine(lines[0]) {
+			b.WriteString("<ul>\n")
+			for _, line := range lines {
+				fmt.Fprintf(&b, "<li>%s</li>\n", inlineHTML(strings.TrimSpace(line)[2:]))
+			}
+			b.WriteString("</ul>\n")
+			continue
+		}
+		fmt.Fprintf(&b, "<p>%s</p>\n", inlineHTML(strings.Join(lines, " ")))
+	}
+	return b.String()
+}
+
+// bulletLine reports whether a line is a Markdown bullet
+func bulletLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ")
+}
+
+// inlineHTML escapes text and links its citations to the reference list
+func inlineHTML(text string) string {
+	return citationRef.ReplaceAllString(html.EscapeString(text), `<a href="#ref-$1">[$1]</a>`)
+}
+
+// Stream generates the report and emits it as a single chunk
+func (r *ReportGenerator) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		result, err := r.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		out <- result
+	}()
+	return out, nil
+}
+
+// Batch generates a report for each input in turn; sections already run
+// in parallel
+func (r *ReportGenerator) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := r.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the report chain with another runnable
+func (r *ReportGenerator) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{r, other})
+}
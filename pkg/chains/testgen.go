@@ -0,0 +1,151 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/tools"
+)
+
+// TestGenResult holds the generated test file and the report of its last run
+type TestGenResult struct {
+	TestFile string
+	Passed   bool
+	Attempts int
+	Report   string // `go test -v` output of the last run
+}
+
+// String returns the generated test file
+func (r *TestGenResult) String() string {
+	return r.TestFile
+}
+
+// TestGenChain writes table-driven tests for a Go source file, runs them in
+// the sandbox and feeds failures back to the model until they pass or the
+// attempt budget runs out.
+type TestGenChain struct {
+	*core.BaseRunnable
+	llm         core.Runnable
+	sandbox     *tools.GoSandbox
+	maxAttempts int
+}
+
+// NewTestGenChain creates a test generation chain checked by sandbox
+func NewTestGenChain(llm core.Runnable, sandbox *tools.GoSandbox) *TestGenChain {
+	return &TestGenChain{
+		BaseRunnable: core.NewBaseRunnable("TestGenChain"),
+		llm:          llm,
+		sandbox:      sandbox,
+		maxAttempts:  4,
+	}
+}
+
+// WithMaxAttempts sets how many generations (first try plus fixes) are allowed
+func (c *TestGenChain) WithMaxAttempts(n int) *TestGenChain {
+	c.maxAttempts = n
+	return c
+}
+
+// Invoke takes the contents of a Go source file and returns a *TestGenResult.
+// Tests that still fail after the last attempt are reported, not returned as
+// an error: they may point at a real bug in the source.
+func (c *TestGenChain) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	source, ok := input.(string)
+	if !ok {
+		return nil, fmt.Errorf("input must be Go source code")
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "source.go", source, parser.PackageClauseOnly)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Go source: %w", err)
+	}
+	pkg := file.Name.Name
+
+	result := &TestGenResult{}
+	prompt := fmt.Sprintf(`Write table-driven unit tests for the following Go file.
+Use package %s and only the standard library "testing" package (plus any
+standard packages you need). Cover normal cases, edge cases and errors.
+Reply with the complete _test.go file in a single go code block.
+
+%s`, pkg, fenceGo(source))
+
+	for result.Attempts < c.maxAttempts {
+		result.Attempts++
+
+		response, err := c.llm.Invoke(ctx, prompt, config)
+		if err != nil {
+			return nil, fmt.Errorf("test generation failed: %w", err)
+		}
+		result.TestFile = ExtractGoCode(fmt.Sprint(response))
+
+		run, err := c.sandbox.Run(ctx, map[string]string{
+			"source.go":      source,
+			"source_test.go": result.TestFile,
+		}, "test", "-v", "-count=1", "./...")
+		if err != nil {
+			return nil, err
+		}
+		result.Report = strings.TrimSpace(run.Output)
+		if run.TimedOut {
+			result.Report += "\n" + run.Command + " timed out"
+		}
+		if run.OK() {
+			result.Passed = true
+			return result, nil
+		}
+
+		prompt = fmt.Sprintf(`These Go tests do not pass.
+
+Source file:
+%s
+
+Test file:
+%s
+
+Output of %s:
+%s
+
+If a test expects the wrong result, fix the test. If it fails to compile,
+fix the compile errors. Do not remove tests that expose a real bug in the
+source. Reply with the complete corrected _test.go file in a single go code block.`,
+			fenceGo(source), fenceGo(result.TestFile), run.Command, result.Report)
+	}
+
+	return result, nil
+}
+
+// Stream runs the test loop and emits the final result as a single chunk
+func (c *TestGenChain) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		result, err := c.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		out <- result
+	}()
+	return out, nil
+}
+
+// Batch generates tests for each source file in turn
+func (c *TestGenChain) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := c.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the chain with another runnable
+func (c *TestGenChain) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{c, other})
+}
@@ -0,0 +1,267 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/embeddings"
+)
+
+// Topic is a group of similar documents named by the LLM
+type Topic struct {
+	Label       string `json:"label"`
+	Description string `json:"description"`
+	// Documents are indices into the discovered documents, the most
+	// representative first
+	Documents []int `json:"documents"`
+}
+
+// TopicMap is the result of TopicDiscovery
+type TopicMap struct {
+	Topics []Topic `json:"topics"`
+	// Unassigned lists the documents density clustering left out as outliers
+	Unassigned []int `json:"unassigned,omitempty"`
+}
+
+// TopicDiscovery embeds a document set, clusters it and has the LLM name
+// each cluster, to see what a corpus is about before building retrieval
+// over it. Clustering uses k-means with k picked by silhouette score
+// unless a fixed k or density clustering is configured.
+type TopicDiscovery struct {
+	*core.BaseRunnable
+	llm       core.Runnable
+	embedder  embeddings.Embedder
+	k         int
+	maxK      int
+	eps       float64
+	minSize   int
+	samples   int
+	batchSize int
+	seed      int64
+}
+
+// NewTopicDiscovery creates a topic discovery chain
+func NewTopicDiscovery(llm core.Runnable, embedder embeddings.Embedder) *TopicDiscovery {
+	return &TopicDiscovery{
+		BaseRunnable: core.NewBaseRunnable("TopicDiscovery"),
+		llm:          llm,
+		embedder:     embedder,
+		maxK:         10,
+		samples:      5,
+		batchSize:    32,
+		seed:         1,
+	}
+}
+
+// WithClusters fixes the number of topics; 0 picks it automatically
+// between 2 and 10
+func (t *TopicDiscovery) WithClusters(k int) *TopicDiscovery {
+	t.k = k
+	return t
+}
+
+// WithDensity switches to density clustering: documents with minSize
+// neighbors within cosine distance eps form topics, the rest stay
+// unassigned
+func (t *TopicDiscovery) WithDensity(eps float64, minSize int) *TopicDiscovery {
+	t.eps = eps
+	t.minSize = minSize
+	return t
+}
+
+// WithSamples sets how many documents per cluster the LLM reads to name it
+func (t *TopicDiscovery) WithSamples(n int) *TopicDiscovery {
+	if n > 0 {
+		t.samples = n
+	}
+	return t
+}
+
+// WithBatchSize sets how many documents are embedded per call
+func (t *TopicDiscovery) WithBatchSize(n int) *TopicDiscovery {
+	t.batchSize = n
+	return t
+}
+
+// WithSeed sets the seed of k-means, for reproducible topics
+func (t *TopicDiscovery) WithSeed(seed int64) *TopicDiscovery {
+	t.seed = seed
+	return t
+}
+
+// Invoke discovers the topics of the input documents and returns them as
+// Markdown. Input is a []string of documents, or a string whose
+// blank-line separated paragraphs are the documents.
+func (t *TopicDiscovery) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	var docs []string
+	switch v := input.(type) {
+	case []string:
+		docs = v
+	default:
+		docs = splitParagraphs(inputText(input))
+	}
+	topics, err := t.Discover(ctx, docs, config)
+	if err != nil {
+		return nil, err
+	}
+	return topics.Markdown(docs), nil
+}
+
+// Discover clusters docs and labels every cluster
+func (t *TopicDiscovery) Discover(ctx context.Context, docs []string, config *core.Config) (*TopicMap, error) {
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("no documents to cluster")
+	}
+	vectors, err := embeddings.EmbedInBatches(ctx, t.embedder, docs, t.batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("embedding documents failed: %w", err)
+	}
+
+	var clustering *embeddings.Clustering
+	switch {
+	case t.eps > 0:
+		clustering, err = embeddings.DensityClusters(vectors, t.eps, t.minSize)
+	case t.k > 0:
+		clustering, err = embeddings.KMeans(vectors, t.k, 0, t.seed)
+	default:
+		clustering, err = embeddings.AutoKMeans(vectors, t.maxK, t.seed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("clustering failed: %w", err)
+	}
+
+	result := &TopicMap{Unassigned: clustering.Members(embeddings.Noise)}
+	for c := range clustering.Centroids {
+		members := clustering.Closest(vectors, c, len(docs))
+		if len(members) == 0 {
+			continue
+		}
+		label, description, err := t.label(ctx, docs, members, config)
+		if err != nil {
+			return nil, fmt.Errorf("labeling topic %d failed: %w", c+1, err)
+		}
+		result.Topics = append(result.Topics, Topic{Label: label, Description: description, Documents: members})
+	}
+	return result, nil
+}
+
+var (
+	topicLabelLine       = regexp.MustCompile(`(?im)^\s*label\s*:\s*(.+)$`)
+	topicDescriptionLine = regexp.MustCompile(`(?im)^\s*description\s*:\s*(.+)$`)
+	blankLines           = regexp.MustCompile(`\n\s*\n`)
+)
+
+// label has the LLM name the cluster from its most central documents
+func (t *TopicDiscovery) label(ctx context.Context, docs []string, members []int, config *core.Config) (string, string, error) {
+	var prompt strings.Builder
+	prompt.WriteString("The following documents were grouped together because they are about the same topic.\n\n")
+	for i, idx := range members {
+		if i == t.samples {
+			break
+		}
+		text := strings.TrimSpace(docs[idx])
+		if runes := []rune(text); len(runes) > 500 {
+			text = string(runes[:500]) + "..."
+		}
+		fmt.Fprintf(&prompt, "Document %d:\n%s\n\n", i+1, text)
+	}
+	prompt.WriteString(`Name the topic they share. Answer with exactly two lines:
+label: <a short name of 2 to 5 words>
+description: <one sentence describing the topic>`)
+
+	response, err := t.llm.Invoke(ctx, prompt.String(), config)
+	if err != nil {
+		return "", "", err
+	}
+	text := fmt.Sprint(response)
+	m := topicLabelLine.FindStringSubmatch(text)
+	if m == nil {
+		// Small models sometimes answer with the name alone
+		line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+		return strings.Trim(line, "\"'*# "), "", nil
+	}
+	label := strings.Trim(strings.TrimSpace(m[1]), "\"'*")
+	description := ""
+	if d := topicDescriptionLine.FindStringSubmatch(text); d != nil {
+		description = strings.TrimSpace(d[1])
+	}
+	return label, description, nil
+}
+
+// splitParagraphs returns the non-empty blank-line separated paragraphs of text
+func splitParagraphs(text string) []string {
+	var paragraphs []string
+	for _, p := range blankLines.Split(text, -1) {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	return paragraphs
+}
+
+// Markdown renders the topics with the opening of their documents
+func (m *TopicMap) Markdown(docs []string) string {
+	var b strings.Builder
+	for _, topic := range m.Topics {
+		fmt.Fprintf(&b, "## %s (%d)\n\n", topic.Label, len(topic.Documents))
+		if topic.Description != "" {
+			fmt.Fprintf(&b, "%s\n\n", topic.Description)
+		}
+		for _, idx := range topic.Documents {
+			fmt.Fprintf(&b, "- %s\n", preview(docs[idx]))
+		}
+		b.WriteString("\n")
+	}
+	if len(m.Unassigned) > 0 {
+		fmt.Fprintf(&b, "## Unassigned (%d)\n\n", len(m.Unassigned))
+		for _, idx := range m.Unassigned {
+			fmt.Fprintf(&b, "- %s\n", preview(docs[idx]))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// preview returns the first line of doc, shortened to 80 characters
+func preview(doc string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(doc), "\n")
+	if runes := []rune(line); len(runes) > 80 {
+		line = string(runes[:80]) + "..."
+	}
+	return line
+}
+
+// Stream runs Invoke and emits the topics as a single chunk
+func (t *TopicDiscovery) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	ch := make(chan interface{}, 1)
+	go func() {
+		defer close(ch)
+		result, err := t.Invoke(ctx, input, config)
+		if err != nil {
+			ch <- err
+			return
+		}
+		ch <- result
+	}()
+	return ch, nil
+}
+
+// Batch discovers the topics of each input separately
+func (t *TopicDiscovery) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := t.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the chain with another runnable
+func (t *TopicDiscovery) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{t, other})
+}
@@ -0,0 +1,253 @@
+package chains
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// glossaryMarker separates the translation from the new glossary entries in a reply
+const glossaryMarker = "=== GLOSSARY ==="
+
+// DocumentTranslator translates long Markdown documents chunk by chunk.
+// Fenced code blocks are kept verbatim, and a glossary of the translations
+// chosen for key terms is carried from one chunk to the next so the same
+// term is translated the same way throughout the document.
+type DocumentTranslator struct {
+	*core.BaseRunnable
+	llm      core.Runnable
+	language string
+	maxChars int
+	glossary map[string]string
+}
+
+// NewDocumentTranslator creates a translator into the target language
+func NewDocumentTranslator(llm core.Runnable, language string) *DocumentTranslator {
+	return &DocumentTranslator{
+		BaseRunnable: core.NewBaseRunnable("DocumentTranslator"),
+		llm:          llm,
+		language:     language,
+		maxChars:     1500,
+		glossary:     make(map[string]string),
+	}
+}
+
+// WithChunkSize sets the approximate number of characters per translated chunk
+func (t *DocumentTranslator) WithChunkSize(maxChars int) *DocumentTranslator {
+	t.maxChars = maxChars
+	return t
+}
+
+// WithGlossary seeds the glossary with required term translations
+func (t *DocumentTranslator) WithGlossary(glossary map[string]string) *DocumentTranslator {
+	for term, translation := range glossary {
+		t.glossary[term] = translation
+	}
+	return t
+}
+
+// Invoke translates a Markdown document and returns the translated text
+func (t *DocumentTranslator) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	text, ok := input.(string)
+	if !ok {
+		return nil, fmt.Errorf("input must be a string")
+	}
+	translated, _, err := t.TranslateDocument(ctx, text, config)
+	if err != nil {
+		return nil, err
+	}
+	return translated, nil
+}
+
+// TranslateDocument translates text and also returns the glossary built along
+// the way, which can seed the next document of the same project
+func (t *DocumentTranslator) TranslateDocument(ctx context.Context, text string, config *core.Config) (string, map[string]string, error) {
+	glossary := make(map[string]string, len(t.glossary))
+	for term, translation := range t.glossary {
+		glossary[term] = translation
+	}
+
+	var out []string
+	for i, chunk := range chunkMarkdown(text, t.maxChars) {
+		if chunk.verbatim {
+			out = append(out, chunk.text)
+			continue
+		}
+
+		response, err := t.llm.Invoke(ctx, t.prompt(chunk.text, glossary), config)
+		if err != nil {
+			return "", nil, fmt.Errorf("translation of chunk %d failed: %w", i+1, err)
+		}
+		translation, terms := splitGlossary(fmt.Sprint(response))
+		for term, tr := range terms {
+			// The first choice wins so later chunks cannot drift
+			if _, exists := glossary[term]; !exists {
+				glossary[term] = tr
+			}
+		}
+		out = append(out, translation)
+	}
+
+	return strings.Join(out, "\n\n") + "\n", glossary, nil
+}
+
+// prompt builds the translation request for one chunk
+func (t *DocumentTranslator) prompt(chunk string, glossary map[string]string) string {
+	var terms strings.Builder
+	lower := strings.ToLower(chunk)
+	keys := make([]string, 0, len(glossary))
+	for term := range glossary {
+		keys = append(keys, term)
+	}
+	sort.Strings(keys)
+	for _, term := range keys {
+		// Only terms that occur in this chunk, to keep the prompt short
+		if strings.Contains(lower, strings.ToLower(term)) {
+			fmt.Fprintf(&terms, "- %s => %s\n", term, glossary[term])
+		}
+	}
+	if terms.Len() == 0 {
+		terms.WriteString("(none yet)\n")
+	}
+
+	return fmt.Sprintf(`Translate the following Markdown excerpt into %s.
+Keep the Markdown structure exactly: headings, lists, tables, links, inline code
+and line breaks. Do not translate inline code, URLs or product names.
+Use these established term translations:
+%s
+Reply with the translation, then a line "%s", then one line
+"term => translation" for each new technical term you translated.
+
+Excerpt:
+%s`, t.language, terms.String(), glossaryMarker, chunk)
+}
+
+// splitGlossary separates the translation from the glossary entries of a reply
+func splitGlossary(response string) (string, map[string]string) {
+	terms := make(map[string]string)
+	translation := response
+	if idx := strings.Index(response, glossaryMarker); idx != -1 {
+		translation = response[:idx]
+		for _, line := range strings.Split(response[idx+len(glossaryMarker):], "\n") {
+			line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-"))
+			parts := strings.SplitN(line, "=>", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			term, tr := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+			if term != "" && tr != "" {
+				terms[term] = tr
+			}
+		}
+	}
+	return strings.TrimSpace(translation), terms
+}
+
+// markdownChunk is a run of Markdown blocks translated (or kept) together
+type markdownChunk struct {
+	text     string
+	verbatim bool
+}
+
+// chunkMarkdown splits text at blank lines into chunks of about maxChars,
+// never splitting inside a fenced code block. Code blocks become their own
+// verbatim chunks.
+func chunkMarkdown(text string, maxChars int) []markdownChunk {
+	var chunks []markdownChunk
+	var blocks []string
+	var cur []string
+	inFence := false
+
+	flushBlock := func() {
+		if len(cur) > 0 {
+			blocks = append(blocks, strings.Join(cur, "\n"))
+			cur = nil
+		}
+	}
+	flushChunk := func() {
+		flushBlock()
+		if len(blocks) > 0 {
+			chunks = append(chunks, markdownChunk{text: strings.Join(blocks, "\n\n")})
+			blocks = nil
+		}
+	}
+	size := func() int {
+		n := 0
+		for _, b := range blocks {
+			n += len(b) + 2
+		}
+		for _, l := range cur {
+			n += len(l) + 1
+		}
+		return n
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			if !inFence {
+				flushChunk()
+				inFence = true
+				cur = append(cur, line)
+				continue
+			}
+			cur = append(cur, line)
+			chunks = append(chunks, markdownChunk{text: strings.Join(cur, "\n"), verbatim: true})
+			cur = nil
+			inFence = false
+		case inFence:
+			cur = append(cur, line)
+		case trimmed == "":
+			flushBlock()
+			if size() >= maxChars {
+				flushChunk()
+			}
+		default:
+			cur = append(cur, line)
+		}
+	}
+	if inFence {
+		// Unterminated fence: keep what we have untouched
+		chunks = append(chunks, markdownChunk{text: strings.Join(cur, "\n"), verbatim: true})
+		cur = nil
+	}
+	flushChunk()
+	return chunks
+}
+
+// Stream translates the document and emits it as a single chunk
+func (t *DocumentTranslator) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		result, err := t.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		out <- result
+	}()
+	return out, nil
+}
+
+// Batch translates each document in turn
+func (t *DocumentTranslator) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := t.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the translator with another runnable
+func (t *DocumentTranslator) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{t, other})
+}
@@ -0,0 +1,65 @@
+package chains
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChunkMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxChars int
+		want     []markdownChunk
+	}{
+		{
+			name:     "small document stays whole",
+			text:     "# Title\n\nFirst paragraph.\n\nSecond paragraph.",
+			maxChars: 1000,
+			want:     []markdownChunk{{text: "# Title\n\nFirst paragraph.\n\nSecond paragraph."}},
+		},
+		{
+			name:     "split at blank lines",
+			text:     "one one one\n\ntwo two two\n\nthree",
+			maxChars: 10,
+			want:     []markdownChunk{{text: "one one one"}, {text: "two two two"}, {text: "three"}},
+		},
+		{
+			name:     "code block is its own verbatim chunk",
+			text:     "Run this:\n\n```go\nfmt.Println(1)\n\nfmt.Println(2)\n```\n\nDone.",
+			maxChars: 1000,
+			want: []markdownChunk{
+				{text: "Run this:"},
+				{text: "```go\nfmt.Println(1)\n\nfmt.Println(2)\n```", verbatim: true},
+				{text: "Done."},
+			},
+		},
+		{
+			name:     "code block larger than maxChars is not split",
+			text:     "~~~\naaaaaaaaaa\n\nbbbbbbbbbb\n~~~",
+			maxChars: 5,
+			want:     []markdownChunk{{text: "~~~\naaaaaaaaaa\n\nbbbbbbbbbb\n~~~", verbatim: true}},
+		},
+		{
+			name:     "unterminated fence kept verbatim",
+			text:     "Intro\n```\ncode\n\nmore code",
+			maxChars: 1000,
+			want:     []markdownChunk{{text: "Intro"}, {text: "```\ncode\n\nmore code", verbatim: true}},
+		},
+		{
+			name:     "windows line endings",
+			text:     "a\r\nb\r\n\r\nc",
+			maxChars: 1,
+			want:     []markdownChunk{{text: "a\nb"}, {text: "c"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := chunkMarkdown(tt.text, tt.maxChars)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunkMarkdown() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Attachment is a file sent along with a human message, either by path or
+// as inline data
+type Attachment struct {
+	Name     string `json:"name"`
+	Path     string `json:"path,omitempty"`
+	Data     []byte `json:"data,omitempty"`
+	MIMEType string `json:"mime_type,omitempty"`
+}
+
+// FileAttachment references a file on disk; the MIME type is guessed from
+// the extension
+func FileAttachment(path string) Attachment {
+	return Attachment{
+		Name:     filepath.Base(path),
+		Path:     path,
+		MIMEType: mime.TypeByExtension(filepath.Ext(path)),
+	}
+}
+
+// DataAttachment carries the file content inline; an empty mimeType is
+// detected from the content
+func DataAttachment(name string, data []byte, mimeType string) Attachment {
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(filepath.Ext(name))
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	return Attachment{Name: name, Data: data, MIMEType: mimeType}
+}
+
+// Read returns the attachment content
+func (a Attachment) Read() ([]byte, error) {
+	if a.Data != nil || a.Path == "" {
+		return a.Data, nil
+	}
+	data, err := os.ReadFile(a.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment %s: %w", a.Name, err)
+	}
+	return data, nil
+}
+
+// Type returns the MIME type without parameters, detecting it from the
+// content when unknown
+func (a Attachment) Type() string {
+	t := a.MIMEType
+	if t == "" {
+		if data, err := a.Read(); err == nil {
+			t = http.DetectContentType(data)
+		}
+	}
+	if mediaType, _, err := mime.ParseMediaType(t); err == nil {
+		return mediaType
+	}
+	return t
+}
+
+// IsText reports whether data looks like text that can be put in a prompt
+func IsText(mimeType string, data []byte) bool {
+	switch {
+	case strings.HasPrefix(mimeType, "text/"),
+		mimeType == "application/json",
+		mimeType == "application/xml",
+		mimeType == "application/yaml",
+		mimeType == "application/x-yaml":
+		return true
+	case strings.HasPrefix(mimeType, "image/"),
+		strings.HasPrefix(mimeType, "audio/"),
+		strings.HasPrefix(mimeType, "video/"):
+		return false
+	}
+	return utf8.Valid(data)
+}
+
+// WithAttachments adds attachments to the message
+func (m *HumanMessage) WithAttachments(attachments ...Attachment) *HumanMessage {
+	m.Attachments = append(m.Attachments, attachments...)
+	return m
+}
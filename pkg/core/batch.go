@@ -0,0 +1,129 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync"
+)
+
+// BatchResult is the outcome of processing one streamed input
+type BatchResult struct {
+	Index  int
+	Input  interface{}
+	Output interface{}
+	Err    error
+}
+
+// BatchStream runs runnable over inputs as they arrive, with at most concurrency
+// invocations in flight. Inputs are only read when a worker is free, so a slow
+// consumer or model applies backpressure all the way to the producer and the
+// full input set never has to be held in memory.
+//
+// Results are delivered in completion order; use BatchResult.Index to restore
+// input order. The output channel is closed once inputs is closed and drained,
+// or when ctx is done.
+func BatchStream(ctx context.Context, runnable Runnable, inputs <-chan interface{}, concurrency int, config *Config) <-chan BatchResult {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	type job struct {
+		index int
+		input interface{}
+	}
+
+	jobs := make(chan job)
+	out := make(chan BatchResult)
+
+	// Number inputs in arrival order
+	go func() {
+		defer close(jobs)
+		index := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case input, ok := <-inputs:
+				if !ok {
+					return
+				}
+				select {
+				case jobs <- job{index: index, input: input}:
+					index++
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				output, err := runnable.Invoke(ctx, j.input, config)
+				select {
+				case out <- BatchResult{Index: j.index, Input: j.input, Output: output, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// LineInputs streams the lines of r as inputs for BatchStream.
+// The returned function reports any read error once the channel is closed.
+func LineInputs(ctx context.Context, r io.Reader) (<-chan interface{}, func() error) {
+	out := make(chan interface{})
+	var scanErr error
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer close(out)
+
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			select {
+			case out <- scanner.Text():
+			case <-ctx.Done():
+				scanErr = ctx.Err()
+				return
+			}
+		}
+		scanErr = scanner.Err()
+	}()
+
+	return out, func() error {
+		<-done
+		return scanErr
+	}
+}
+
+// SliceInputs streams a slice as inputs for BatchStream
+func SliceInputs(ctx context.Context, items []interface{}) <-chan interface{} {
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		for _, item := range items {
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,291 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned while a circuit breaker rejects calls
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects every call until the cooldown has passed
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe call through to test recovery
+	BreakerHalfOpen
+)
+
+// String returns the state name
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+// BreakerStateFunc is called when a breaker changes state
+type BreakerStateFunc func(name string, from, to BreakerState)
+
+// CircuitBreaker stops calling a failing dependency for a while once the
+// failure rate over its recent calls crosses a threshold, so a dead backend
+// fails fast instead of adding a timeout to every call.
+type CircuitBreaker struct {
+	mu          sync.Mutex
+	name        string
+	failureRate float64
+	window      int
+	minCalls    int
+	cooldown    time.Duration
+	results     []bool // ring of recent outcomes, true = failure
+	next        int
+	state       BreakerState
+	openedAt    time.Time
+	probing     bool
+	listeners   []BreakerStateFunc
+	now         func() time.Time
+}
+
+// NewCircuitBreaker creates a closed breaker that opens when at least half of
+// the last 20 calls failed (once 5 calls were seen) and probes after 30s
+func NewCircuitBreaker(name string) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:        name,
+		failureRate: 0.5,
+		window:      20,
+		minCalls:    5,
+		cooldown:    30 * time.Second,
+		now:         time.Now,
+	}
+}
+
+// WithFailureRate sets the failure rate (0-1) at which the breaker opens
+func (b *CircuitBreaker) WithFailureRate(rate float64) *CircuitBreaker {
+	b.failureRate = rate
+	return b
+}
+
+// WithWindow sets how many recent calls are considered, and how many must
+// have been seen before the breaker may open
+func (b *CircuitBreaker) WithWindow(size, minCalls int) *CircuitBreaker {
+	b.window = size
+	b.minCalls = minCalls
+	return b
+}
+
+// WithCooldown sets how long the breaker stays open before probing
+func (b *CircuitBreaker) WithCooldown(cooldown time.Duration) *CircuitBreaker {
+	b.cooldown = cooldown
+	return b
+}
+
+// OnStateChange registers fn to be called on every state change
+func (b *CircuitBreaker) OnStateChange(fn BreakerStateFunc) *CircuitBreaker {
+	b.listeners = append(b.listeners, fn)
+	return b
+}
+
+// Name returns the breaker name
+func (b *CircuitBreaker) Name() string {
+	return b.name
+}
+
+// State returns the current state, moving from open to half-open once the
+// cooldown has passed
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	state, notify := b.refresh()
+	b.mu.Unlock()
+	notify()
+	return state
+}
+
+// refresh applies the cooldown; callers hold the lock and call notify after
+// releasing it
+func (b *CircuitBreaker) refresh() (BreakerState, func()) {
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen, b.transition(BreakerHalfOpen)
+	}
+	return b.state, func() {}
+}
+
+// transition changes state and returns the function notifying listeners
+func (b *CircuitBreaker) transition(to BreakerState) func() {
+	from := b.state
+	if from == to {
+		return func() {}
+	}
+	b.state = to
+	switch to {
+	case BreakerOpen:
+		b.openedAt = b.now()
+	case BreakerClosed:
+		b.results = b.results[:0]
+		b.next = 0
+	}
+	b.probing = false
+	listeners := append([]BreakerStateFunc{}, b.listeners...)
+	return func() {
+		for _, fn := range listeners {
+			fn(b.name, from, to)
+		}
+	}
+}
+
+// Allow reports whether a call may proceed. Every allowed call must be
+// followed by Record.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	state, notify := b.refresh()
+	var err error
+	switch state {
+	case BreakerOpen:
+		err = fmt.Errorf("%s: %w", b.name, ErrCircuitOpen)
+	case BreakerHalfOpen:
+		if b.probing {
+			err = fmt.Errorf("%s: %w (probe in flight)", b.name, ErrCircuitOpen)
+		} else {
+			b.probing = true
+		}
+	}
+	b.mu.Unlock()
+	notify()
+	return err
+}
+
+// Record reports the outcome of an allowed call. Cancellations by the caller
+// are not counted as failures.
+func (b *CircuitBreaker) Record(err error) {
+	failed := err != nil && !errors.Is(err, context.Canceled)
+
+	b.mu.Lock()
+	notify := func() {}
+	switch b.state {
+	case BreakerHalfOpen:
+		if failed {
+			notify = b.transition(BreakerOpen)
+		} else {
+			notify = b.transition(BreakerClosed)
+		}
+	case BreakerClosed:
+		if len(b.results) < b.window {
+			b.results = append(b.results, failed)
+		} else {
+			b.results[b.next] = failed
+			b.next = (b.next + 1) % b.window
+		}
+		if len(b.results) >= b.minCalls {
+			failures := 0
+			for _, f := range b.results {
+				if f {
+					failures++
+				}
+			}
+			if float64(failures)/float64(len(b.results)) >= b.failureRate {
+				notify = b.transition(BreakerOpen)
+			}
+		}
+	}
+	b.mu.Unlock()
+	notify()
+}
+
+// Execute runs fn through the breaker
+func (b *CircuitBreaker) Execute(fn func() error) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.Record(err)
+	return err
+}
+
+// BreakerRunnable guards a runnable, such as an LLM backend, with a circuit breaker
+type BreakerRunnable struct {
+	*BaseRunnable
+	inner   Runnable
+	breaker *CircuitBreaker
+}
+
+// WithCircuitBreaker wraps r so calls fail fast while breaker is open
+func WithCircuitBreaker(r Runnable, breaker *CircuitBreaker) *BreakerRunnable {
+	return &BreakerRunnable{
+		BaseRunnable: NewBaseRunnable(r.Name()),
+		inner:        r,
+		breaker:      breaker,
+	}
+}
+
+// Invoke calls the wrapped runnable unless the breaker is open
+func (br *BreakerRunnable) Invoke(ctx context.Context, input interface{}, config *Config) (interface{}, error) {
+	var output interface{}
+	err := br.breaker.Execute(func() error {
+		var err error
+		output, err = br.inner.Invoke(ctx, input, config)
+		return err
+	})
+	return output, err
+}
+
+// Stream forwards the wrapped runnable's chunks; an error chunk counts as a failure
+func (br *BreakerRunnable) Stream(ctx context.Context, input interface{}, config *Config) (<-chan interface{}, error) {
+	if err := br.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	chunks, err := br.inner.Stream(ctx, input, config)
+	if err != nil {
+		br.breaker.Record(err)
+		return nil, err
+	}
+
+	out := make(chan interface{}, cap(chunks))
+	go func() {
+		defer close(out)
+		var streamErr error
+		for chunk := range chunks {
+			if err, ok := chunk.(error); ok && streamErr == nil {
+				streamErr = err
+			}
+			out <- chunk
+		}
+		br.breaker.Record(streamErr)
+	}()
+	return out, nil
+}
+
+// Batch runs the wrapped runnable's batch as a single guarded call
+func (br *BreakerRunnable) Batch(ctx context.Context, inputs []interface{}, config *Config) ([]interface{}, error) {
+	var outputs []interface{}
+	err := br.breaker.Execute(func() error {
+		var err error
+		outputs, err = br.inner.Batch(ctx, inputs, config)
+		return err
+	})
+	return outputs, err
+}
+
+// Pipe composes the guarded runnable with another runnable
+func (br *BreakerRunnable) Pipe(other Runnable) Runnable {
+	return NewRunnableSequence([]Runnable{br, other})
+}
+
+// Close closes the wrapped runnable if it holds resources, so a guarded
+// llm.ChatModel can still be closed through the wrapper
+func (br *BreakerRunnable) Close() {
+	if c, ok := br.inner.(interface{ Close() }); ok {
+		c.Close()
+	}
+}
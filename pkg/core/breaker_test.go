@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// scriptedRunnable fails or succeeds in the order of its script and counts
+// its calls
+type scriptedRunnable struct {
+	*BaseRunnable
+	script []error
+	calls  int
+}
+
+func (s *scriptedRunnable) Invoke(ctx context.Context, input interface{}, config *Config) (interface{}, error) {
+	err := s.script[s.calls]
+	s.calls++
+	if err != nil {
+		return nil, err
+	}
+	return "ok", nil
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	down := errors.New("backend down")
+	model := &scriptedRunnable{BaseRunnable: NewBaseRunnable("model"), script: []error{nil, down, down, down, nil}}
+
+	var changes []string
+	breaker := NewCircuitBreaker("model").
+		WithWindow(4, 4).
+		WithCooldown(time.Minute).
+		OnStateChange(func(name string, from, to BreakerState) {
+			changes = append(changes, from.String()+">"+to.String())
+		})
+	now := time.Unix(0, 0)
+	breaker.now = func() time.Time { return now }
+	guarded := WithCircuitBreaker(model, breaker)
+
+	invoke := func() error {
+		_, err := guarded.Invoke(ctx, "hi", nil)
+		return err
+	}
+
+	// Three failures out of four calls open the breaker
+	for i := 0; i < 4; i++ {
+		invoke()
+	}
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("State() = %v after 3 of 4 calls failed, want open", state)
+	}
+	if err := invoke(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Invoke() while open error = %v, want ErrCircuitOpen", err)
+	}
+	if model.calls != 4 {
+		t.Errorf("model called %d times, want 4: an open breaker must not call it", model.calls)
+	}
+
+	now = now.Add(time.Minute)
+	if state := breaker.State(); state != BreakerHalfOpen {
+		t.Fatalf("State() = %v after the cooldown, want half-open", state)
+	}
+	if err := invoke(); err != nil {
+		t.Fatalf("probe error = %v", err)
+	}
+	if state := breaker.State(); state != BreakerClosed {
+		t.Errorf("State() = %v after a successful probe, want closed", state)
+	}
+
+	want := []string{"closed>open", "open>half-open", "half-open>closed"}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("state changes = %v, want %v", changes, want)
+	}
+}
+
+func TestCircuitBreakerFailedProbe(t *testing.T) {
+	breaker := NewCircuitBreaker("dep").
+		WithWindow(2, 2).
+		WithCooldown(time.Minute)
+	now := time.Unix(0, 0)
+	breaker.now = func() time.Time { return now }
+	fail := func() error { return errors.New("still down") }
+
+	breaker.Execute(fail)
+	breaker.Execute(fail)
+	now = now.Add(time.Minute)
+
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("Allow() for the probe error = %v", err)
+	}
+	// Only one probe at a time
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second Allow() while probing error = %v, want ErrCircuitOpen", err)
+	}
+	breaker.Record(errors.New("still down"))
+	if state := breaker.State(); state != BreakerOpen {
+		t.Errorf("State() = %v after a failed probe, want open", state)
+	}
+}
+
+func TestCircuitBreakerIgnoresCancellation(t *testing.T) {
+	breaker := NewCircuitBreaker("dep").WithWindow(2, 2)
+	for i := 0; i < 4; i++ {
+		breaker.Execute(func() error { return context.Canceled })
+	}
+	if state := breaker.State(); state != BreakerClosed {
+		t.Errorf("State() = %v after cancelled calls, want closed", state)
+	}
+}
+
+// closingRunnable records whether it was closed
+type closingRunnable struct {
+	*BaseRunnable
+	closed bool
+}
+
+func (c *closingRunnable) Close() { c.closed = true }
+
+func TestBreakerRunnableClose(t *testing.T) {
+	inner := &closingRunnable{BaseRunnable: NewBaseRunnable("model")}
+	guarded := WithCircuitBreaker(inner, NewCircuitBreaker("model"))
+	guarded.Close()
+	if !inner.closed {
+		t.Error("Close() did not close the wrapped runnable")
+	}
+
+	// A runnable without Close is left alone
+	WithCircuitBreaker(NewBaseRunnable("plain"), NewCircuitBreaker("plain")).Close()
+}
@@ -0,0 +1,266 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Middleware wraps a chain step, e.g. with a retry or a circuit breaker
+type Middleware func(Runnable) Runnable
+
+// Retry is a middleware that invokes the step up to attempts times until
+// it succeeds, stopping early when the context is done
+func Retry(attempts int) Middleware {
+	return func(r Runnable) Runnable {
+		return &retryRunnable{BaseRunnable: NewBaseRunnable(r.Name()), inner: r, attempts: attempts}
+	}
+}
+
+// retryRunnable is the runnable produced by Retry
+type retryRunnable struct {
+	*BaseRunnable
+	inner    Runnable
+	attempts int
+}
+
+// Invoke calls the wrapped runnable until it succeeds or runs out of attempts
+func (r *retryRunnable) Invoke(ctx context.Context, input interface{}, config *Config) (interface{}, error) {
+	var err error
+	for i := 0; i < r.attempts || i == 0; i++ {
+		var output interface{}
+		if output, err = r.inner.Invoke(ctx, input, config); err == nil {
+			return output, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+// Stream retries opening the stream; failures after the first chunk are
+// not retried
+func (r *retryRunnable) Stream(ctx context.Context, input interface{}, config *Config) (<-chan interface{}, error) {
+	var err error
+	for i := 0; i < r.attempts || i == 0; i++ {
+		var out <-chan interface{}
+		if out, err = r.inner.Stream(ctx, input, config); err == nil {
+			return out, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+// Batch retries every input independently
+func (r *retryRunnable) Batch(ctx context.Context, inputs []interface{}, config *Config) ([]interface{}, error) {
+	return invokeAll(ctx, r, inputs, config)
+}
+
+// Pipe composes the retried runnable with another runnable
+func (r *retryRunnable) Pipe(other Runnable) Runnable {
+	return NewRunnableSequence([]Runnable{r, other})
+}
+
+// StepError reports which step of a Chain failed
+type StepError struct {
+	Step  string
+	Index int
+	Err   error
+}
+
+// Error names the failing step
+func (e *StepError) Error() string {
+	return fmt.Sprintf("step '%s' failed: %v", e.Step, e.Err)
+}
+
+// Unwrap returns the step's error
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// chainStep is a named, wrapped step of a chain
+type chainStep struct {
+	name     string
+	runnable Runnable
+}
+
+// ChainBuilder assembles a Chain step by step:
+//
+//	chain, err := core.NewChain().Prompt(tmpl).LLM(model, core.Retry(3)).Parser(parser).Build()
+type ChainBuilder struct {
+	name  string
+	steps []chainStep
+	err   error
+}
+
+// NewChain starts an empty chain
+func NewChain() *ChainBuilder {
+	return &ChainBuilder{name: "Chain"}
+}
+
+// Named sets the chain's name
+func (b *ChainBuilder) Named(name string) *ChainBuilder {
+	b.name = name
+	return b
+}
+
+// Step appends a runnable under name, wrapped by the middleware in order
+// (the first one is outermost)
+func (b *ChainBuilder) Step(name string, r Runnable, middleware ...Middleware) *ChainBuilder {
+	if b.err != nil {
+		return b
+	}
+	if r == nil {
+		b.err = fmt.Errorf("step '%s' has no runnable", name)
+		return b
+	}
+	for _, s := range b.steps {
+		if s.name == name {
+			b.err = fmt.Errorf("duplicate step name '%s'", name)
+			return b
+		}
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		r = middleware[i](r)
+	}
+	b.steps = append(b.steps, chainStep{name: name, runnable: r})
+	return b
+}
+
+// Prompt appends a step named "prompt"
+func (b *ChainBuilder) Prompt(r Runnable, middleware ...Middleware) *ChainBuilder {
+	return b.Step("prompt", r, middleware...)
+}
+
+// LLM appends a step named "llm"
+func (b *ChainBuilder) LLM(r Runnable, middleware ...Middleware) *ChainBuilder {
+	return b.Step("llm", r, middleware...)
+}
+
+// Parser appends a step named "parser"
+func (b *ChainBuilder) Parser(r Runnable, middleware ...Middleware) *ChainBuilder {
+	return b.Step("parser", r, middleware...)
+}
+
+// Build returns the chain, or the first error found while adding steps
+func (b *ChainBuilder) Build() (*Chain, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.steps) == 0 {
+		return nil, errors.New("chain has no steps")
+	}
+	steps := make([]chainStep, len(b.steps))
+	copy(steps, b.steps)
+	return &Chain{BaseRunnable: NewBaseRunnable(b.name), steps: steps}, nil
+}
+
+// Chain runs named steps in sequence, like RunnableSequence, but reports
+// failures as a *StepError naming the step
+type Chain struct {
+	*BaseRunnable
+	steps []chainStep
+}
+
+// Steps returns the step names in order
+func (c *Chain) Steps() []string {
+	names := make([]string, len(c.steps))
+	for i, s := range c.steps {
+		names[i] = s.name
+	}
+	return names
+}
+
+// run invokes the steps in [0, end)
+func (c *Chain) run(ctx context.Context, input interface{}, config *Config, end int) (interface{}, error) {
+	output := input
+	for i, s := range c.steps[:end] {
+		var err error
+		if output, err = s.runnable.Invoke(ctx, output, config); err != nil {
+			return nil, &StepError{Step: s.name, Index: i, Err: err}
+		}
+	}
+	return output, nil
+}
+
+// Invoke runs every step in order
+func (c *Chain) Invoke(ctx context.Context, input interface{}, config *Config) (interface{}, error) {
+	if config == nil {
+		config = NewConfig()
+	}
+	return c.run(ctx, input, config, len(c.steps))
+}
+
+// Stream invokes all steps but the last and streams the last one; error
+// chunks are wrapped in a *StepError too
+func (c *Chain) Stream(ctx context.Context, input interface{}, config *Config) (<-chan interface{}, error) {
+	if config == nil {
+		config = NewConfig()
+	}
+	last := len(c.steps) - 1
+	output, err := c.run(ctx, input, config, last)
+	if err != nil {
+		return nil, err
+	}
+
+	step := c.steps[last]
+	chunks, err := step.runnable.Stream(ctx, output, config)
+	if err != nil {
+		return nil, &StepError{Step: step.name, Index: last, Err: err}
+	}
+
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		for chunk := range chunks {
+			if err, ok := chunk.(error); ok {
+				chunk = &StepError{Step: step.name, Index: last, Err: err}
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Batch runs the chain for every input concurrently
+func (c *Chain) Batch(ctx context.Context, inputs []interface{}, config *Config) ([]interface{}, error) {
+	return invokeAll(ctx, c, inputs, config)
+}
+
+// Pipe composes the chain with another runnable
+func (c *Chain) Pipe(other Runnable) Runnable {
+	return NewRunnableSequence([]Runnable{c, other})
+}
+
+// invokeAll invokes r for every input concurrently and returns the first
+// error in input order
+func invokeAll(ctx context.Context, r Runnable, inputs []interface{}, config *Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	errs := make([]error, len(inputs))
+
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		wg.Add(1)
+		go func(idx int, inp interface{}) {
+			defer wg.Done()
+			results[idx], errs[idx] = r.Invoke(ctx, inp, config)
+		}(i, input)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
@@ -1,6 +1,9 @@
 package core
 
-import "context"
+import (
+	"context"
+	"math"
+)
 
 // Config holds configuration for Runnable execution
 type Config struct {
@@ -9,7 +12,21 @@ type Config struct {
 	Metadata  map[string]interface{}
 	MaxRetries int
 	Timeout   int
-}
+	// Stop lists strings that end generation for this invocation, in
+	// addition to the model's own stop sequences
+	Stop      []string
+	// LogitBias adjusts how likely tokens are for this invocation. Keys are
+	// token ids ("15043") or text, whose first token is biased; values are
+	// added to the token's logit, and BanToken forbids it.
+	LogitBias map[string]float32
+	// Grammar is a GBNF grammar the output must follow for this
+	// invocation, e.g. to force valid JSON; backends that cannot
+	// constrain sampling ignore it
+	Grammar   string
+}
+
+// BanToken is the LogitBias value that keeps a token from being generated
+var BanToken = float32(math.Inf(-1))
 
 // NewConfig creates a new Config with default values
 func NewConfig() *Config {
@@ -52,6 +69,21 @@ func (c *Config) WithTimeout(timeout int) *Config {
 	return c
 }
 
+// WithStop sets per-invocation stop sequences
+func (c *Config) WithStop(stop ...string) *Config {
+	c.Stop = stop
+	return c
+}
+
+// WithLogitBias biases a token id or the first token of a text
+func (c *Config) WithLogitBias(token string, bias float32) *Config {
+	if c.LogitBias == nil {
+		c.LogitBias = make(map[string]float32)
+	}
+	c.LogitBias[token] = bias
+	return c
+}
+
 // Callback interface for observability
 type Callback interface {
 	OnStart(ctx context.Context, runnable Runnable, input interface{}) error
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Event types published on an EventBus
+const (
+	EventRunStarted     = "run_started"
+	EventToolDenied     = "tool_denied"
+	EventBudgetExceeded = "budget_exceeded"
+	EventModelSwapped   = "model_swapped"
+)
+
+// Event is a notification published on an EventBus
+type Event interface {
+	EventType() string
+}
+
+// RunStarted is published when an agent or chain starts a run
+type RunStarted struct {
+	Runnable string
+	Input    interface{}
+	Time     time.Time
+}
+
+// EventType returns EventRunStarted
+func (RunStarted) EventType() string { return EventRunStarted }
+
+// ToolDenied is published when a tool call is refused, by a human or a policy
+type ToolDenied struct {
+	Tool   string
+	Args   map[string]interface{}
+	Reason string
+	Time   time.Time
+}
+
+// EventType returns EventToolDenied
+func (ToolDenied) EventType() string { return EventToolDenied }
+
+// BudgetExceeded is published when a token, cost or time budget runs out
+type BudgetExceeded struct {
+	Budget string // e.g. "tokens", "cost", "iterations"
+	Limit  float64
+	Used   float64
+	Time   time.Time
+}
+
+// EventType returns EventBudgetExceeded
+func (BudgetExceeded) EventType() string { return EventBudgetExceeded }
+
+// ModelSwapped is published when a different model takes over a request
+type ModelSwapped struct {
+	From   string
+	To     string
+	Reason string
+	Time   time.Time
+}
+
+// EventType returns EventModelSwapped
+func (ModelSwapped) EventType() string { return EventModelSwapped }
+
+// EventHandler receives published events
+type EventHandler func(ctx context.Context, event Event)
+
+// EventBus delivers events from emitters to any number of subscribers, so
+// components can report what happened without knowing who is listening
+type EventBus struct {
+	mu     sync.RWMutex
+	subs   []subscription
+	nextID int
+}
+
+type subscription struct {
+	id      int
+	handler EventHandler
+	types   map[string]bool // nil means every type
+}
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers handler for the given event types, or for every event
+// when none are given. It returns a function that removes the subscription.
+func (b *EventBus) Subscribe(handler EventHandler, types ...string) func() {
+	b.mu.Lock()
+	sub := subscription{id: b.nextID, handler: handler}
+	b.nextID++
+	if len(types) > 0 {
+		sub.types = make(map[string]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subs {
+			if s.id == sub.id {
+				b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish calls the matching handlers in subscription order. Handlers run on
+// the publisher's goroutine and should hand slow work off.
+func (b *EventBus) Publish(ctx context.Context, event Event) {
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.types == nil || sub.types[event.EventType()] {
+			sub.handler(ctx, event)
+		}
+	}
+}
+
+type eventBusKey struct{}
+
+// WithEventBus returns a context whose components publish their events on bus
+func WithEventBus(ctx context.Context, bus *EventBus) context.Context {
+	return context.WithValue(ctx, eventBusKey{}, bus)
+}
+
+// EventBusFromContext returns the bus set by WithEventBus, or nil
+func EventBusFromContext(ctx context.Context) *EventBus {
+	bus, _ := ctx.Value(eventBusKey{}).(*EventBus)
+	return bus
+}
+
+// Emit publishes event on the bus in ctx, if there is one
+func Emit(ctx context.Context, event Event) {
+	if bus := EventBusFromContext(ctx); bus != nil {
+		bus.Publish(ctx, event)
+	}
+}
@@ -0,0 +1,63 @@
+package core
+
+import "time"
+
+// Generation is a model response with the metadata needed for cost
+// tracking and evaluation. String returns the text, so code that prints
+// or fmt.Sprint's a model output keeps working.
+type Generation struct {
+	Text  string `json:"text"`
+	Model string `json:"model"`
+	// PromptTokens and CompletionTokens are 0 when the backend does not
+	// report them
+	PromptTokens     int           `json:"prompt_tokens"`
+	CompletionTokens int           `json:"completion_tokens"`
+	Duration         time.Duration `json:"duration"`
+	// FinishReason is "stop" (end of turn or stop sequence), "length"
+	// (token limit) or "tool_calls"
+	FinishReason string     `json:"finish_reason"`
+	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+	// Candidates are the answers this one was picked from, itself included,
+	// when a wrapper such as llm.BestOfN sampled several
+	Candidates []Candidate `json:"candidates,omitempty"`
+}
+
+// Candidate is one sampled answer and the score it was ranked by
+type Candidate struct {
+	Text  string  `json:"text"`
+	Score float64 `json:"score"`
+}
+
+// Finish reasons reported in Generation.FinishReason
+const (
+	FinishStop      = "stop"
+	FinishLength    = "length"
+	FinishToolCalls = "tool_calls"
+)
+
+// String returns the generated text
+func (g *Generation) String() string {
+	return g.Text
+}
+
+// TokensPerSecond returns the completion speed, or 0 when unknown
+func (g *Generation) TokensPerSecond() float64 {
+	if g.Duration <= 0 || g.CompletionTokens == 0 {
+		return 0
+	}
+	return float64(g.CompletionTokens) / g.Duration.Seconds()
+}
+
+// Message returns the generation as an AI message, with the metadata in
+// its additional kwargs
+func (g *Generation) Message() *AIMessage {
+	msg := NewAIMessage(g.Text, map[string]interface{}{
+		"model":             g.Model,
+		"prompt_tokens":     g.PromptTokens,
+		"completion_tokens": g.CompletionTokens,
+		"tokens_per_second": g.TokensPerSecond(),
+		"finish_reason":     g.FinishReason,
+	})
+	msg.ToolCalls = append(msg.ToolCalls, g.ToolCalls...)
+	return msg
+}
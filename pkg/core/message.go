@@ -119,6 +119,7 @@ func (m *SystemMessage) String() string {
 // HumanMessage represents user input
 type HumanMessage struct {
 	*BaseMessage
+	Attachments []Attachment `json:"attachments,omitempty"`
 }
 
 // NewHumanMessage creates a new human message
@@ -152,6 +153,9 @@ func (m *HumanMessage) ToJSON() ([]byte, error) {
 	for k, v := range m.AdditionalKwargs {
 		data[k] = v
 	}
+	if len(m.Attachments) > 0 {
+		data["attachments"] = m.Attachments
+	}
 	return json.Marshal(data)
 }
 
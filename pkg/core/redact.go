@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RedactedPlaceholder replaces the value of a redacted field
+const RedactedPlaceholder = "[REDACTED]"
+
+// patternRule replaces every match of pattern with a labelled placeholder
+type patternRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// Redactor scrubs sensitive data from messages before they leave the process
+// through traces, logs or exports. It never modifies the messages the agent
+// is working with: every method returns a redacted copy. This is separate from
+// runtime guardrails, which decide what the model may see or say.
+type Redactor struct {
+	patterns []patternRule
+	fields   map[string]bool
+}
+
+// NewRedactor creates a redactor with no rules
+func NewRedactor() *Redactor {
+	return &Redactor{fields: make(map[string]bool)}
+}
+
+// NewDefaultRedactor creates a redactor for common secrets and personal data
+func NewDefaultRedactor() *Redactor {
+	return NewRedactor().
+		WithPattern("email", `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`).
+		WithPattern("bearer", `(?i)bearer\s+[A-Za-z0-9._~+/=-]+`).
+		WithPattern("api_key", `\b(sk|pk|ghp|xox[bap])[-_][A-Za-z0-9_-]{16,}\b`).
+		WithPattern("card", `\b(?:\d[ -]?){13,16}\b`).
+		WithPattern("phone", `\+?\d{1,3}[ .-]?\(?\d{2,4}\)?[ .-]?\d{3,4}[ .-]?\d{3,4}\b`).
+		WithFields("password", "secret", "token", "api_key", "apikey", "authorization")
+}
+
+// WithPattern redacts text matching expr, replaced by [REDACTED:name].
+// It panics if expr does not compile, like regexp.MustCompile.
+func (r *Redactor) WithPattern(name, expr string) *Redactor {
+	r.patterns = append(r.patterns, patternRule{name: name, pattern: regexp.MustCompile(expr)})
+	return r
+}
+
+// WithFields redacts the whole value of these keys (case-insensitive) in
+// message kwargs, tool call arguments and map values
+func (r *Redactor) WithFields(keys ...string) *Redactor {
+	for _, k := range keys {
+		r.fields[strings.ToLower(k)] = true
+	}
+	return r
+}
+
+// RedactText applies the pattern rules to s
+func (r *Redactor) RedactText(s string) string {
+	for _, rule := range r.patterns {
+		s = rule.pattern.ReplaceAllString(s, "[REDACTED:"+rule.name+"]")
+	}
+	return s
+}
+
+// RedactValue returns a redacted copy of a Runnable input or output
+func (r *Redactor) RedactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.RedactText(val)
+	case Message:
+		return r.RedactMessage(val)
+	case []Message:
+		return r.RedactMessages(val)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			if r.fields[strings.ToLower(k)] {
+				out[k] = RedactedPlaceholder
+				continue
+			}
+			out[k] = r.RedactValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = r.RedactValue(item)
+		}
+		return out
+	case error:
+		return fmt.Errorf("%s", r.RedactText(val.Error()))
+	case bool, int, int64, float32, float64:
+		return val
+	default:
+		return r.RedactText(fmt.Sprint(val))
+	}
+}
+
+// RedactMessages returns redacted copies of messages
+func (r *Redactor) RedactMessages(messages []Message) []Message {
+	out := make([]Message, len(messages))
+	for i, msg := range messages {
+		out[i] = r.RedactMessage(msg)
+	}
+	return out
+}
+
+// RedactMessage returns a redacted copy of msg with the same ID and timestamp
+func (r *Redactor) RedactMessage(msg Message) Message {
+	switch m := msg.(type) {
+	case *SystemMessage:
+		c := NewSystemMessage(r.RedactText(m.Content), r.redactKwargs(m.AdditionalKwargs))
+		c.BaseMessage.ID, c.BaseMessage.Timestamp = m.ID, m.Timestamp
+		return c
+	case *HumanMessage:
+		c := NewHumanMessage(r.RedactText(m.Content), r.redactKwargs(m.AdditionalKwargs))
+		c.BaseMessage.ID, c.BaseMessage.Timestamp = m.ID, m.Timestamp
+		c.Attachments = m.Attachments
+		return c
+	case *AIMessage:
+		c := NewAIMessage(r.RedactText(m.Content), r.redactKwargs(m.AdditionalKwargs))
+		c.BaseMessage.ID, c.BaseMessage.Timestamp = m.ID, m.Timestamp
+		c.ToolCalls = make([]ToolCall, len(m.ToolCalls))
+		for i, call := range m.ToolCalls {
+			c.ToolCalls[i] = r.redactToolCall(call)
+		}
+		return c
+	case *ToolMessage:
+		c := NewToolMessage(r.RedactText(m.Content), m.ToolCallID, r.redactKwargs(m.AdditionalKwargs))
+		c.BaseMessage.ID, c.BaseMessage.Timestamp = m.ID, m.Timestamp
+		return c
+	default:
+		// Unknown message types are reduced to their redacted text
+		return NewHumanMessage(r.RedactText(msg.GetContent()), nil)
+	}
+}
+
+// redactKwargs redacts message kwargs, leaving tool calls to redactToolCall
+func (r *Redactor) redactKwargs(kwargs map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(kwargs))
+	for k, v := range kwargs {
+		if k == "tool_calls" {
+			continue
+		}
+		if r.fields[strings.ToLower(k)] {
+			out[k] = RedactedPlaceholder
+			continue
+		}
+		out[k] = r.RedactValue(v)
+	}
+	return out
+}
+
+// redactToolCall redacts the arguments of a tool call
+func (r *Redactor) redactToolCall(call ToolCall) ToolCall {
+	c := call
+	if call.Args != nil {
+		c.Args = r.RedactValue(call.Args).(map[string]interface{})
+	}
+	if call.Function.Arguments != "" {
+		var args map[string]interface{}
+		if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+			c.Function.Arguments = r.RedactText(call.Function.Arguments)
+			return c
+		}
+		data, err := json.Marshal(r.RedactValue(args))
+		if err != nil {
+			c.Function.Arguments = RedactedPlaceholder
+			return c
+		}
+		c.Function.Arguments = string(data)
+	}
+	return c
+}
+
+// RedactingCallback forwards events to another callback with inputs, outputs
+// and errors redacted, so tracing never sees the raw conversation
+type RedactingCallback struct {
+	Redactor *Redactor
+	Next     Callback
+}
+
+// NewRedactingCallback wraps next so it only receives redacted data
+func NewRedactingCallback(redactor *Redactor, next Callback) *RedactingCallback {
+	return &RedactingCallback{Redactor: redactor, Next: next}
+}
+
+// OnStart forwards the start event with the input redacted
+func (rc *RedactingCallback) OnStart(ctx context.Context, runnable Runnable, input interface{}) error {
+	return rc.Next.OnStart(ctx, runnable, rc.Redactor.RedactValue(input))
+}
+
+// OnEnd forwards the end event with the output redacted
+func (rc *RedactingCallback) OnEnd(ctx context.Context, runnable Runnable, output interface{}) error {
+	return rc.Next.OnEnd(ctx, runnable, rc.Redactor.RedactValue(output))
+}
+
+// OnError forwards the error event with the message redacted
+func (rc *RedactingCallback) OnError(ctx context.Context, runnable Runnable, err error) error {
+	if err != nil {
+		err = fmt.Errorf("%s", rc.Redactor.RedactText(err.Error()))
+	}
+	return rc.Next.OnError(ctx, runnable, err)
+}
@@ -2,7 +2,9 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 )
 
 // Runnable is the base interface for all composable components.
@@ -183,7 +185,10 @@ func (rs *RunnableSequence) Pipe(other Runnable) Runnable {
 // RunnableParallel executes multiple runnables in parallel
 type RunnableParallel struct {
 	*BaseRunnable
-	runnables map[string]Runnable
+	runnables    map[string]Runnable
+	optional     map[string]interface{}
+	timeouts     map[string]time.Duration
+	minSuccesses int
 }
 
 // NewRunnableParallel creates a new parallel runnable
@@ -191,9 +196,32 @@ func NewRunnableParallel(runnables map[string]Runnable) *RunnableParallel {
 	return &RunnableParallel{
 		BaseRunnable: NewBaseRunnable("RunnableParallel"),
 		runnables:    runnables,
+		optional:     make(map[string]interface{}),
+		timeouts:     make(map[string]time.Duration),
 	}
 }
 
+// WithOptional marks a branch as optional: if it fails, its output is
+// fallback instead of failing the whole invocation
+func (rp *RunnableParallel) WithOptional(key string, fallback interface{}) *RunnableParallel {
+	rp.optional[key] = fallback
+	return rp
+}
+
+// WithBranchTimeout limits how long a branch may run; a timeout counts as
+// a failure of that branch
+func (rp *RunnableParallel) WithBranchTimeout(key string, timeout time.Duration) *RunnableParallel {
+	rp.timeouts[key] = timeout
+	return rp
+}
+
+// WithMinSuccesses tolerates failures of any branch as long as at least n
+// branches succeed; failed branches yield their optional fallback, or nil
+func (rp *RunnableParallel) WithMinSuccesses(n int) *RunnableParallel {
+	rp.minSuccesses = n
+	return rp
+}
+
 // Invoke runs all runnables in parallel
 func (rp *RunnableParallel) Invoke(ctx context.Context, input interface{}, config *Config) (interface{}, error) {
 	if config == nil {
@@ -206,25 +234,125 @@ func (rp *RunnableParallel) Invoke(ctx context.Context, input interface{}, confi
 		err   error
 	}
 
+	// Cancel the remaining branches once the invocation has failed
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	results := make(chan result, len(rp.runnables))
 
 	// Run all runnables in parallel
 	for key, runnable := range rp.runnables {
 		go func(k string, r Runnable) {
-			output, err := r.Invoke(ctx, input, config)
+			branchCtx := ctx
+			if timeout, ok := rp.timeouts[k]; ok {
+				var cancel context.CancelFunc
+				branchCtx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			output, err := r.Invoke(branchCtx, input, config)
 			results <- result{key: k, value: output, err: err}
 		}(key, runnable)
 	}
 
 	// Collect results
 	output := make(map[string]interface{})
+	var failures []error
+	successes := 0
 	for i := 0; i < len(rp.runnables); i++ {
 		res := <-results
-		if res.err != nil {
+		if res.err == nil {
+			output[res.key] = res.value
+			successes++
+			continue
+		}
+
+		fallback, optional := rp.optional[res.key]
+		if !optional && rp.minSuccesses == 0 {
 			return nil, res.err
 		}
-		output[res.key] = res.value
+		output[res.key] = fallback
+		failures = append(failures, fmt.Errorf("branch %s failed: %w", res.key, res.err))
 	}
 
+	if successes < rp.minSuccesses {
+		return nil, fmt.Errorf("only %d of %d branches succeeded, %d required: %w",
+			successes, len(rp.runnables), rp.minSuccesses, errors.Join(failures...))
+	}
 	return output, nil
 }
+
+// BranchCondition decides whether a branch handles the input
+type BranchCondition func(ctx context.Context, input interface{}) (bool, error)
+
+// Branch pairs a condition with the runnable to use when it matches
+type Branch struct {
+	Condition BranchCondition
+	Runnable  Runnable
+}
+
+// RunnableBranch routes the input to the first branch whose condition matches,
+// or to the fallback when none does
+type RunnableBranch struct {
+	*BaseRunnable
+	branches []Branch
+	fallback Runnable
+}
+
+// NewRunnableBranch creates a branch evaluated in order, with a required fallback
+func NewRunnableBranch(fallback Runnable, branches ...Branch) *RunnableBranch {
+	return &RunnableBranch{
+		BaseRunnable: NewBaseRunnable("RunnableBranch"),
+		branches:     branches,
+		fallback:     fallback,
+	}
+}
+
+// route returns the runnable selected for input
+func (rb *RunnableBranch) route(ctx context.Context, input interface{}) (Runnable, error) {
+	for _, b := range rb.branches {
+		ok, err := b.Condition(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("branch condition failed: %w", err)
+		}
+		if ok {
+			return b.Runnable, nil
+		}
+	}
+	return rb.fallback, nil
+}
+
+// Invoke runs the selected branch
+func (rb *RunnableBranch) Invoke(ctx context.Context, input interface{}, config *Config) (interface{}, error) {
+	target, err := rb.route(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return target.Invoke(ctx, input, config)
+}
+
+// Stream streams from the selected branch
+func (rb *RunnableBranch) Stream(ctx context.Context, input interface{}, config *Config) (<-chan interface{}, error) {
+	target, err := rb.route(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return target.Stream(ctx, input, config)
+}
+
+// Batch routes every input independently
+func (rb *RunnableBranch) Batch(ctx context.Context, inputs []interface{}, config *Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := rb.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the branch with another runnable
+func (rb *RunnableBranch) Pipe(other Runnable) Runnable {
+	return NewRunnableSequence([]Runnable{rb, other})
+}
@@ -0,0 +1,175 @@
+package embeddings
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// CacheStats reports how effective the embedding cache has been
+type CacheStats struct {
+	Hits    int
+	Misses  int
+	Entries int
+}
+
+// CacheMetricsFunc receives the hits and misses of each lookup; kind is
+// "doc" for EmbedDocuments and "query" for EmbedQuery
+type CacheMetricsFunc func(kind string, hits, misses int)
+
+// CachedEmbedder wraps an Embedder with batching and a content-hash cache,
+// so re-indexing an unchanged corpus does not recompute any vectors.
+// Returned vectors are copies the caller may modify.
+type CachedEmbedder struct {
+	embedder  Embedder
+	batchSize int
+	metrics   CacheMetricsFunc
+
+	mu      sync.RWMutex
+	vectors map[string][]float32
+	hits    int
+	misses  int
+}
+
+// NewCachedEmbedder creates a cached embedder sending at most batchSize texts per call
+func NewCachedEmbedder(embedder Embedder, batchSize int) *CachedEmbedder {
+	if batchSize <= 0 {
+		batchSize = 32
+	}
+	return &CachedEmbedder{
+		embedder:  embedder,
+		batchSize: batchSize,
+		vectors:   make(map[string][]float32),
+	}
+}
+
+// WithMetrics sets a function called after every lookup, e.g. to export
+// hit and miss counters to a metrics system
+func (c *CachedEmbedder) WithMetrics(metrics CacheMetricsFunc) *CachedEmbedder {
+	c.metrics = metrics
+	return c
+}
+
+// report passes the outcome of a lookup to the metrics function
+func (c *CachedEmbedder) report(kind string, hits, misses int) {
+	if c.metrics != nil {
+		c.metrics(kind, hits, misses)
+	}
+}
+
+// EmbedDocuments embeds texts, only sending cache misses to the wrapped embedder
+func (c *CachedEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	keys := make([]string, len(texts))
+	results := make([][]float32, len(texts))
+
+	// Collect unique texts we have not embedded yet
+	var missing []string
+	seen := make(map[string]bool)
+
+	c.mu.RLock()
+	for i, text := range texts {
+		keys[i] = contentHash("doc", text)
+		if vec, ok := c.vectors[keys[i]]; ok {
+			results[i] = clone(vec)
+			continue
+		}
+		if !seen[keys[i]] {
+			seen[keys[i]] = true
+			missing = append(missing, text)
+		}
+	}
+	c.mu.RUnlock()
+
+	if len(missing) > 0 {
+		vectors, err := EmbedInBatches(ctx, c.embedder, missing, c.batchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		for i, text := range missing {
+			c.vectors[contentHash("doc", text)] = vectors[i]
+		}
+		c.mu.Unlock()
+	}
+
+	hits, misses := 0, 0
+	c.mu.Lock()
+	for i := range texts {
+		if results[i] != nil {
+			hits++
+			continue
+		}
+		misses++
+		results[i] = clone(c.vectors[keys[i]])
+	}
+	c.hits += hits
+	c.misses += misses
+	c.mu.Unlock()
+
+	c.report("doc", hits, misses)
+	return results, nil
+}
+
+// EmbedQuery embeds a query, reusing a cached vector when available.
+// Queries are cached separately from documents since embedders may
+// format them differently.
+func (c *CachedEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	key := contentHash("query", text)
+
+	c.mu.RLock()
+	vec, ok := c.vectors[key]
+	c.mu.RUnlock()
+	if ok {
+		c.mu.Lock()
+		c.hits++
+		c.mu.Unlock()
+		c.report("query", 1, 0)
+		return clone(vec), nil
+	}
+
+	vec, err := c.embedder.EmbedQuery(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.vectors[key] = vec
+	c.misses++
+	c.mu.Unlock()
+
+	c.report("query", 0, 1)
+	return clone(vec), nil
+}
+
+// Stats returns cache hit/miss counters
+func (c *CachedEmbedder) Stats() CacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return CacheStats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Entries: len(c.vectors),
+	}
+}
+
+// Clear drops all cached vectors and resets the counters
+func (c *CachedEmbedder) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.vectors = make(map[string][]float32)
+	c.hits = 0
+	c.misses = 0
+}
+
+// clone copies a vector so callers cannot change the cached one
+func clone(vec []float32) []float32 {
+	return append([]float32(nil), vec...)
+}
+
+// contentHash builds the cache key for a text
+func contentHash(kind, text string) string {
+	sum := sha256.Sum256([]byte(kind + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
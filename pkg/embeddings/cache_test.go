@@ -0,0 +1,78 @@
+package embeddings
+
+import (
+	"context"
+	"testing"
+)
+
+// countingEmbedder returns a vector holding the text length and counts the texts it embeds
+type countingEmbedder struct {
+	embedded int
+}
+
+func (e *countingEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text))}
+	}
+	e.embedded += len(texts)
+	return vectors, nil
+}
+
+func (e *countingEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	e.embedded++
+	return []float32{float32(len(text))}, nil
+}
+
+func TestCachedEmbedder(t *testing.T) {
+	ctx := context.Background()
+
+	type lookup struct {
+		kind         string
+		hits, misses int
+	}
+	var lookups []lookup
+	embedder := &countingEmbedder{}
+	cache := NewCachedEmbedder(embedder, 2).WithMetrics(func(kind string, hits, misses int) {
+		lookups = append(lookups, lookup{kind, hits, misses})
+	})
+
+	first, err := cache.EmbedDocuments(ctx, []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatalf("EmbedDocuments() error = %v", err)
+	}
+	first[0][0] = 42
+
+	second, err := cache.EmbedDocuments(ctx, []string{"a", "dddd"})
+	if err != nil {
+		t.Fatalf("EmbedDocuments() error = %v", err)
+	}
+	if second[0][0] != 1 {
+		t.Errorf("cached vector = %v, want it unchanged by the caller", second[0])
+	}
+
+	query, err := cache.EmbedQuery(ctx, "a")
+	if err != nil {
+		t.Fatalf("EmbedQuery() error = %v", err)
+	}
+	query[0] = 42
+	if query, _ = cache.EmbedQuery(ctx, "a"); query[0] != 1 {
+		t.Errorf("cached query vector = %v, want it unchanged by the caller", query)
+	}
+
+	want := []lookup{{"doc", 0, 3}, {"doc", 1, 1}, {"query", 0, 1}, {"query", 1, 0}}
+	if len(lookups) != len(want) {
+		t.Fatalf("metrics got %+v, want %+v", lookups, want)
+	}
+	for i := range want {
+		if lookups[i] != want[i] {
+			t.Errorf("lookup %d = %+v, want %+v", i, lookups[i], want[i])
+		}
+	}
+	if embedder.embedded != 5 {
+		t.Errorf("embedded %d texts, want 5", embedder.embedded)
+	}
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 5 {
+		t.Errorf("Stats() = %+v, want 2 hits and 5 misses", stats)
+	}
+}
@@ -0,0 +1,326 @@
+package embeddings
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Noise is the cluster of points DensityClusters leaves unassigned
+const Noise = -1
+
+// Clustering is the result of grouping vectors
+type Clustering struct {
+	// Labels holds the cluster of each vector, or Noise
+	Labels []int
+	// Centroids holds the mean direction of each cluster, unit length
+	Centroids [][]float32
+}
+
+// Members returns the indices of the vectors in cluster
+func (c *Clustering) Members(cluster int) []int {
+	var members []int
+	for i, label := range c.Labels {
+		if label == cluster {
+			members = append(members, i)
+		}
+	}
+	return members
+}
+
+// Closest returns up to n members of cluster, nearest to its centroid first
+func (c *Clustering) Closest(vectors [][]float32, cluster, n int) []int {
+	members := c.Members(cluster)
+	centroid := c.Centroids[cluster]
+	sort.SliceStable(members, func(i, j int) bool {
+		return dot(unit(vectors[members[i]]), centroid) > dot(unit(vectors[members[j]]), centroid)
+	})
+	if len(members) > n {
+		members = members[:n]
+	}
+	return members
+}
+
+// KMeans groups vectors into k clusters by cosine similarity, using
+// k-means++ seeding and at most maxIter refinement rounds (default 100).
+// The seed makes the result reproducible.
+func KMeans(vectors [][]float32, k, maxIter int, seed int64) (*Clustering, error) {
+	points, err := unitVectors(vectors)
+	if err != nil {
+		return nil, err
+	}
+	if k <= 0 || k > len(points) {
+		return nil, fmt.Errorf("k must be between 1 and %d, got %d", len(points), k)
+	}
+	if maxIter <= 0 {
+		maxIter = 100
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	centroids := seedCentroids(points, k, rng)
+	labels := make([]int, len(points))
+	for i := range labels {
+		labels[i] = -1
+	}
+
+	for iter := 0; iter < maxIter; iter++ {
+		changed := false
+		for i, p := range points {
+			best := nearest(p, centroids)
+			if labels[i] != best {
+				labels[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+		centroids = meanCentroids(points, labels, k)
+		// Reseed clusters that lost all their points on the farthest point
+		for c, centroid := range centroids {
+			if centroid == nil {
+				far := farthest(points, labels, centroids)
+				centroids[c] = points[far]
+				labels[far] = c
+			}
+		}
+	}
+	return &Clustering{Labels: labels, Centroids: centroids}, nil
+}
+
+// AutoKMeans runs KMeans for every k from 2 to maxK and keeps the
+// clustering with the best silhouette score, for when the number of topics
+// is unknown
+func AutoKMeans(vectors [][]float32, maxK int, seed int64) (*Clustering, error) {
+	if maxK > len(vectors)-1 {
+		maxK = len(vectors) - 1
+	}
+	if maxK < 2 {
+		return KMeans(vectors, 1, 0, seed)
+	}
+	var best *Clustering
+	bestScore := math.Inf(-1)
+	for k := 2; k <= maxK; k++ {
+		clustering, err := KMeans(vectors, k, 0, seed)
+		if err != nil {
+			return nil, err
+		}
+		if score := Silhouette(vectors, clustering.Labels); score > bestScore {
+			best, bestScore = clustering, score
+		}
+	}
+	return best, nil
+}
+
+// DensityClusters is a light HDBSCAN-style grouping: points with at least
+// minSize neighbors within cosine distance eps seed clusters, which grow
+// through their dense neighbors; points reachable from no dense point are
+// labeled Noise. Unlike KMeans it finds the number of clusters itself and
+// does not force outliers into a topic.
+func DensityClusters(vectors [][]float32, eps float64, minSize int) (*Clustering, error) {
+	points, err := unitVectors(vectors)
+	if err != nil {
+		return nil, err
+	}
+	if minSize < 1 {
+		minSize = 1
+	}
+
+	neighbors := make([][]int, len(points))
+	for i := range points {
+		for j := range points {
+			if i != j && 1-dot(points[i], points[j]) <= eps {
+				neighbors[i] = append(neighbors[i], j)
+			}
+		}
+	}
+
+	const unvisited = -2
+	labels := make([]int, len(points))
+	for i := range labels {
+		labels[i] = unvisited
+	}
+	clusters := 0
+	for i := range points {
+		if labels[i] != unvisited {
+			continue
+		}
+		if len(neighbors[i]) < minSize {
+			labels[i] = Noise
+			continue
+		}
+		labels[i] = clusters
+		queue := append([]int(nil), neighbors[i]...)
+		for len(queue) > 0 {
+			j := queue[0]
+			queue = queue[1:]
+			if labels[j] == Noise {
+				labels[j] = clusters // border point
+			}
+			if labels[j] != unvisited {
+				continue
+			}
+			labels[j] = clusters
+			if len(neighbors[j]) >= minSize {
+				queue = append(queue, neighbors[j]...)
+			}
+		}
+		clusters++
+	}
+	return &Clustering{Labels: labels, Centroids: meanCentroids(points, labels, clusters)}, nil
+}
+
+// Silhouette scores how well separated the clusters are, from -1 to 1;
+// Noise points are ignored
+func Silhouette(vectors [][]float32, labels []int) float64 {
+	points := make([][]float32, len(vectors))
+	for i, v := range vectors {
+		points[i] = unit(v)
+	}
+
+	total, counted := 0.0, 0
+	for i, p := range points {
+		if labels[i] == Noise {
+			continue
+		}
+		sums := make(map[int]float64)
+		sizes := make(map[int]int)
+		for j, q := range points {
+			if i == j || labels[j] == Noise {
+				continue
+			}
+			sums[labels[j]] += 1 - dot(p, q)
+			sizes[labels[j]]++
+		}
+		if sizes[labels[i]] == 0 {
+			continue // singleton clusters score 0
+		}
+		a := sums[labels[i]] / float64(sizes[labels[i]])
+		b := math.Inf(1)
+		for c, sum := range sums {
+			if c != labels[i] {
+				b = math.Min(b, sum/float64(sizes[c]))
+			}
+		}
+		if math.IsInf(b, 1) {
+			continue
+		}
+		total += (b - a) / math.Max(a, b)
+		counted++
+	}
+	if counted == 0 {
+		return 0
+	}
+	return total / float64(counted)
+}
+
+// unitVectors checks that vectors share one dimension and normalizes them
+func unitVectors(vectors [][]float32) ([][]float32, error) {
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("no vectors to cluster")
+	}
+	points := make([][]float32, len(vectors))
+	for i, v := range vectors {
+		if len(v) != len(vectors[0]) {
+			return nil, fmt.Errorf("vector %d has %d dimensions, expected %d", i, len(v), len(vectors[0]))
+		}
+		points[i] = unit(v)
+	}
+	return points, nil
+}
+
+// seedCentroids picks k starting centroids, each new one with probability
+// proportional to its squared distance from those already picked
+func seedCentroids(points [][]float32, k int, rng *rand.Rand) [][]float32 {
+	centroids := [][]float32{points[rng.Intn(len(points))]}
+	dist := make([]float64, len(points))
+	for len(centroids) < k {
+		total := 0.0
+		for i, p := range points {
+			d := 1 - dot(p, centroids[nearest(p, centroids)])
+			dist[i] = d * d
+			total += dist[i]
+		}
+		if total == 0 {
+			// Every point sits on a centroid; duplicates are all that is left
+			centroids = append(centroids, points[rng.Intn(len(points))])
+			continue
+		}
+		target := rng.Float64() * total
+		i := 0
+		for ; i < len(points)-1; i++ {
+			target -= dist[i]
+			if target <= 0 {
+				break
+			}
+		}
+		centroids = append(centroids, points[i])
+	}
+	return centroids
+}
+
+// meanCentroids returns the normalized mean of each cluster; a cluster
+// without points gets a nil centroid
+func meanCentroids(points [][]float32, labels []int, k int) [][]float32 {
+	sums := make([][]float32, k)
+	for i, p := range points {
+		c := labels[i]
+		if c < 0 {
+			continue
+		}
+		if sums[c] == nil {
+			sums[c] = make([]float32, len(p))
+		}
+		for d, x := range p {
+			sums[c][d] += x
+		}
+	}
+	for c, sum := range sums {
+		if sum != nil {
+			sums[c] = Normalize(sum)
+		}
+	}
+	return sums
+}
+
+// nearest returns the index of the centroid most similar to p
+func nearest(p []float32, centroids [][]float32) int {
+	best, bestSim := 0, math.Inf(-1)
+	for c, centroid := range centroids {
+		if centroid == nil {
+			continue
+		}
+		if sim := dot(p, centroid); sim > bestSim {
+			best, bestSim = c, sim
+		}
+	}
+	return best
+}
+
+// farthest returns the point least similar to its own centroid
+func farthest(points [][]float32, labels []int, centroids [][]float32) int {
+	worst, worstSim := 0, math.Inf(1)
+	for i, p := range points {
+		if centroids[labels[i]] == nil {
+			continue
+		}
+		if sim := dot(p, centroids[labels[i]]); sim < worstSim {
+			worst, worstSim = i, sim
+		}
+	}
+	return worst
+}
+
+// unit returns a unit-length copy of v, leaving the caller's vector as is
+func unit(v []float32) []float32 {
+	return Normalize(append([]float32(nil), v...))
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
@@ -0,0 +1,42 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+)
+
+// Embedder turns text into vectors for retrieval and similarity search
+type Embedder interface {
+	EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error)
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+}
+
+// EmbedInBatches embeds texts in chunks of at most batchSize per call to the embedder
+func EmbedInBatches(ctx context.Context, embedder Embedder, texts []string, batchSize int) ([][]float32, error) {
+	if batchSize <= 0 {
+		batchSize = len(texts)
+	}
+
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		end := start + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		batch, err := embedder.EmbedDocuments(ctx, texts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("embedding batch %d-%d failed: %w", start, end, err)
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("embedder returned %d vectors for %d texts", len(batch), end-start)
+		}
+		vectors = append(vectors, batch...)
+	}
+
+	return vectors, nil
+}
@@ -0,0 +1,84 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// TruncatedEmbedder reduces vectors to the first n dimensions and renormalizes them.
+// This is meant for Matryoshka-trained models, whose leading dimensions carry
+// most of the signal, letting you trade some recall for memory and speed.
+type TruncatedEmbedder struct {
+	embedder   Embedder
+	dimensions int
+}
+
+// NewTruncatedEmbedder creates an embedder producing vectors of the given dimensionality
+func NewTruncatedEmbedder(embedder Embedder, dimensions int) (*TruncatedEmbedder, error) {
+	if dimensions <= 0 {
+		return nil, fmt.Errorf("dimensions must be positive, got %d", dimensions)
+	}
+	return &TruncatedEmbedder{
+		embedder:   embedder,
+		dimensions: dimensions,
+	}, nil
+}
+
+// Dimensions returns the output vector size
+func (t *TruncatedEmbedder) Dimensions() int {
+	return t.dimensions
+}
+
+// EmbedDocuments embeds and truncates each document vector
+func (t *TruncatedEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := t.embedder.EmbedDocuments(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([][]float32, len(vectors))
+	for i, vec := range vectors {
+		if out[i], err = Truncate(vec, t.dimensions); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// EmbedQuery embeds and truncates the query vector
+func (t *TruncatedEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vec, err := t.embedder.EmbedQuery(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return Truncate(vec, t.dimensions)
+}
+
+// Truncate keeps the first n dimensions of vec and rescales the result to unit length
+func Truncate(vec []float32, n int) ([]float32, error) {
+	if n > len(vec) {
+		return nil, fmt.Errorf("cannot truncate %d-dimensional vector to %d dimensions", len(vec), n)
+	}
+
+	out := make([]float32, n)
+	copy(out, vec[:n])
+	return Normalize(out), nil
+}
+
+// Normalize scales vec in place to unit length and returns it
+func Normalize(vec []float32) []float32 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vec
+	}
+
+	norm := float32(math.Sqrt(sum))
+	for i := range vec {
+		vec[i] /= norm
+	}
+	return vec
+}
@@ -0,0 +1,205 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// SentenceCheck is the verdict for a single sentence of an answer
+type SentenceCheck struct {
+	Sentence  string  `json:"sentence"`
+	Supported bool    `json:"supported"`
+	Score     float64 `json:"score"`
+	Evidence  int     `json:"evidence"` // index of the best supporting chunk, -1 if none
+}
+
+// GroundingResult summarizes how well an answer is supported by its context
+type GroundingResult struct {
+	Score     float64         `json:"score"` // fraction of supported sentences
+	Grounded  bool            `json:"grounded"`
+	Sentences []SentenceCheck `json:"sentences"`
+}
+
+// Flagged returns the sentences that are not supported by the context
+func (r *GroundingResult) Flagged() []SentenceCheck {
+	var flagged []SentenceCheck
+	for _, s := range r.Sentences {
+		if !s.Supported {
+			flagged = append(flagged, s)
+		}
+	}
+	return flagged
+}
+
+// GroundednessChecker verifies that each claim in an answer is supported by retrieved chunks.
+// Without a judge it uses word-overlap heuristics; with a judge LLM it asks
+// the model for an entailment verdict on each sentence.
+type GroundednessChecker struct {
+	judge          core.Runnable
+	minOverlap     float64
+	minGroundScore float64
+}
+
+// NewGroundednessChecker creates a checker; judge may be nil to use heuristics only
+func NewGroundednessChecker(judge core.Runnable) *GroundednessChecker {
+	return &GroundednessChecker{
+		judge:          judge,
+		minOverlap:     0.5,
+		minGroundScore: 1.0,
+	}
+}
+
+// WithMinOverlap sets the word-overlap ratio a sentence needs to count as supported
+func (g *GroundednessChecker) WithMinOverlap(ratio float64) *GroundednessChecker {
+	g.minOverlap = ratio
+	return g
+}
+
+// WithThreshold sets the fraction of supported sentences required to be grounded
+func (g *GroundednessChecker) WithThreshold(score float64) *GroundednessChecker {
+	g.minGroundScore = score
+	return g
+}
+
+// Check scores answer against the retrieved context chunks
+func (g *GroundednessChecker) Check(ctx context.Context, answer string, contexts []string) (*GroundingResult, error) {
+	sentences := SplitSentences(answer)
+	result := &GroundingResult{Sentences: make([]SentenceCheck, 0, len(sentences))}
+
+	supported := 0
+	for _, sentence := range sentences {
+		check := g.checkOverlap(sentence, contexts)
+
+		if g.judge != nil {
+			ok, err := g.askJudge(ctx, sentence, contexts)
+			if err != nil {
+				return nil, err
+			}
+			check.Supported = ok
+		}
+
+		if check.Supported {
+			supported++
+		}
+		result.Sentences = append(result.Sentences, check)
+	}
+
+	if len(sentences) > 0 {
+		result.Score = float64(supported) / float64(len(sentences))
+	}
+	result.Grounded = len(sentences) > 0 && result.Score >= g.minGroundScore
+
+	return result, nil
+}
+
+// checkOverlap finds the chunk sharing the most content words with the sentence
+func (g *GroundednessChecker) checkOverlap(sentence string, contexts []string) SentenceCheck {
+	check := SentenceCheck{Sentence: sentence, Evidence: -1}
+
+	words := contentWords(sentence)
+	if len(words) == 0 {
+		// Nothing checkable (e.g. "Sure!"), don't penalize it
+		check.Supported = true
+		check.Score = 1
+		return check
+	}
+
+	for i, chunk := range contexts {
+		chunkWords := make(map[string]bool)
+		for _, w := range contentWords(chunk) {
+			chunkWords[w] = true
+		}
+
+		found := 0
+		for _, w := range words {
+			if chunkWords[w] {
+				found++
+			}
+		}
+
+		score := float64(found) / float64(len(words))
+		if score > check.Score {
+			check.Score = score
+			check.Evidence = i
+		}
+	}
+
+	check.Supported = check.Score >= g.minOverlap
+	return check
+}
+
+// askJudge asks the judge LLM whether the context entails the sentence
+func (g *GroundednessChecker) askJudge(ctx context.Context, sentence string, contexts []string) (bool, error) {
+	prompt := fmt.Sprintf(`Decide whether the claim is fully supported by the context.
+
+Context:
+%s
+
+Claim: %s
+
+Answer with exactly one word: SUPPORTED or UNSUPPORTED.`, strings.Join(contexts, "\n---\n"), sentence)
+
+	response, err := g.judge.Invoke(ctx, prompt, nil)
+	if err != nil {
+		return false, fmt.Errorf("grounding judge failed: %w", err)
+	}
+
+	verdict := strings.ToUpper(fmt.Sprint(response))
+	return strings.Contains(verdict, "SUPPORTED") && !strings.Contains(verdict, "UNSUPPORTED"), nil
+}
+
+// SplitSentences splits text on sentence-ending punctuation and newlines
+func SplitSentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			sentences = append(sentences, s)
+		}
+		current.Reset()
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+		if (r == '.' || r == '!' || r == '?') && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+			flush()
+		}
+	}
+	flush()
+
+	return sentences
+}
+
+// stopWords are ignored when comparing sentences to context
+var stopWords = map[string]bool{
+	"the": true, "and": true, "that": true, "this": true, "with": true,
+	"from": true, "have": true, "has": true, "was": true, "were": true,
+	"are": true, "for": true, "not": true, "but": true, "its": true,
+	"which": true, "there": true, "their": true, "they": true, "been": true,
+}
+
+// contentWords returns lowercase numbers and words of 3+ characters that are not stop words
+func contentWords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	words := make([]string, 0, len(fields))
+	for _, f := range fields {
+		isNumber := strings.IndexFunc(f, unicode.IsDigit) >= 0
+		if (isNumber || len([]rune(f)) >= 3) && !stopWords[f] {
+			words = append(words, f)
+		}
+	}
+	return words
+}
@@ -0,0 +1,170 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/embeddings"
+)
+
+// Verdict grades an answer against its reference
+type Verdict string
+
+const (
+	VerdictCorrect   Verdict = "correct"
+	VerdictPartial   Verdict = "partial"
+	VerdictIncorrect Verdict = "incorrect"
+)
+
+// CrossEncoder scores how well two texts match by reading them together,
+// e.g. a reranker model; scores are expected in [0, 1]
+type CrossEncoder interface {
+	Score(ctx context.Context, a, b string) (float64, error)
+}
+
+// SimilarityResult is the semantic similarity of an answer to its reference
+type SimilarityResult struct {
+	// Score is the cross-encoder score when one is set, the cosine
+	// similarity of the embeddings otherwise
+	Score     float64 `json:"score"`
+	Embedding float64 `json:"embedding"`
+	Verdict   Verdict `json:"verdict"`
+	// Exact is true when the texts match after normalizing case, spacing
+	// and punctuation; no model is called then
+	Exact bool `json:"exact,omitempty"`
+}
+
+// SimilarityScorer grades answers by meaning rather than wording: an
+// answer is correct when its score against the reference reaches the
+// correct threshold, partial above the partial threshold, incorrect below.
+// Embedding cosine similarity is used unless a cross-encoder is set, which
+// is slower but better at telling paraphrases from related statements.
+type SimilarityScorer struct {
+	embedder embeddings.Embedder
+	encoder  CrossEncoder
+	correct  float64
+	partial  float64
+}
+
+// NewSimilarityScorer creates a scorer comparing embeddings from embedder
+func NewSimilarityScorer(embedder embeddings.Embedder) *SimilarityScorer {
+	return &SimilarityScorer{
+		embedder: embedder,
+		correct:  0.85,
+		partial:  0.7,
+	}
+}
+
+// WithCrossEncoder scores pairs with encoder instead of embeddings; the
+// embedder may then be nil
+func (s *SimilarityScorer) WithCrossEncoder(encoder CrossEncoder) *SimilarityScorer {
+	s.encoder = encoder
+	return s
+}
+
+// WithThresholds sets the minimum scores of a correct and a partial answer
+// (default 0.85 and 0.7). Embedding models differ in how their scores
+// spread, so calibrate them on a few graded answers.
+func (s *SimilarityScorer) WithThresholds(correct, partial float64) *SimilarityScorer {
+	s.correct = correct
+	s.partial = partial
+	return s
+}
+
+// Score compares answer to reference
+func (s *SimilarityScorer) Score(ctx context.Context, answer, reference string) (*SimilarityResult, error) {
+	results, err := s.ScoreAll(ctx, []string{answer}, []string{reference})
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// ScoreAll compares each answer to the reference at the same index,
+// embedding all texts in one call
+func (s *SimilarityScorer) ScoreAll(ctx context.Context, answers, references []string) ([]*SimilarityResult, error) {
+	if len(answers) != len(references) {
+		return nil, fmt.Errorf("got %d answers for %d references", len(answers), len(references))
+	}
+
+	results := make([]*SimilarityResult, len(answers))
+	var texts []string
+	var pending []int
+	for i := range answers {
+		if normalizeAnswer(answers[i]) == normalizeAnswer(references[i]) {
+			results[i] = &SimilarityResult{Score: 1, Embedding: 1, Exact: true, Verdict: VerdictCorrect}
+			continue
+		}
+		results[i] = &SimilarityResult{}
+		pending = append(pending, i)
+		texts = append(texts, answers[i], references[i])
+	}
+
+	if s.embedder != nil && len(texts) > 0 {
+		vectors, err := s.embedder.EmbedDocuments(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("similarity embedding failed: %w", err)
+		}
+		if len(vectors) != len(texts) {
+			return nil, fmt.Errorf("embedder returned %d vectors for %d texts", len(vectors), len(texts))
+		}
+		for j, i := range pending {
+			results[i].Embedding = cosine(vectors[2*j], vectors[2*j+1])
+			results[i].Score = results[i].Embedding
+		}
+	}
+
+	for _, i := range pending {
+		if s.encoder != nil {
+			score, err := s.encoder.Score(ctx, answers[i], references[i])
+			if err != nil {
+				return nil, fmt.Errorf("cross-encoder failed: %w", err)
+			}
+			results[i].Score = score
+		} else if s.embedder == nil {
+			return nil, fmt.Errorf("similarity scorer needs an embedder or a cross-encoder")
+		}
+		results[i].Verdict = s.verdict(results[i].Score)
+	}
+	return results, nil
+}
+
+// verdict grades a score against the thresholds
+func (s *SimilarityScorer) verdict(score float64) Verdict {
+	switch {
+	case score >= s.correct:
+		return VerdictCorrect
+	case score >= s.partial:
+		return VerdictPartial
+	default:
+		return VerdictIncorrect
+	}
+}
+
+// normalizeAnswer lowercases text and drops its punctuation, so
+// "Paris." and "paris" compare equal
+func normalizeAnswer(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// cosine returns the cosine similarity of two vectors, 0 if either is empty
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,114 @@
+// Package i18n holds the localized text of built-in prompts, so agents can
+// work in the user's language without forking the prompt builders.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is used for keys missing from the requested locale
+const DefaultLocale = "en"
+
+// Message keys of the built-in catalog
+const (
+	// ReActPersona opens the ReAct system prompt
+	ReActPersona = "react.persona"
+	// ReActInstructions explains the ReAct format; arguments are the tool
+	// list and the comma-separated tool names. The Thought/Action/Action
+	// Input/Observation/Final Answer keywords must stay in English in every
+	// locale, since the agent parses them.
+	ReActInstructions = "react.instructions"
+	// ReActToolsHeading introduces the tool list
+	ReActToolsHeading = "react.tools_heading"
+	// ReActToolError is the observation for a failed tool call; the
+	// argument is the error
+	ReActToolError = "react.tool_error"
+	// ReActNeedsInput is the observation for a tool asking the user a
+	// question; the argument is the question
+	ReActNeedsInput = "react.needs_input"
+	// ReActRepeatedAction is the observation for an action the agent
+	// already took with the same input; arguments are the action and the
+	// earlier observation
+	ReActRepeatedAction = "react.repeated_action"
+)
+
+// Catalog maps locales to message templates
+type Catalog struct {
+	mu       sync.RWMutex
+	messages map[string]map[string]string
+}
+
+// NewCatalog creates a catalog with the built-in English, French and
+// Spanish messages
+func NewCatalog() *Catalog {
+	c := &Catalog{messages: make(map[string]map[string]string)}
+	for locale, messages := range builtin {
+		c.Register(locale, messages)
+	}
+	return c
+}
+
+// Default is the catalog used when none is configured
+var Default = NewCatalog()
+
+// Register adds or overrides messages for a locale
+func (c *Catalog) Register(locale string, messages map[string]string) {
+	locale = normalize(locale)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]string, len(messages))
+	}
+	for key, text := range messages {
+		c.messages[locale][key] = text
+	}
+}
+
+// Get returns the template for key in locale, trying the region-less
+// language ("fr" for "fr-CA") and then DefaultLocale
+func (c *Catalog) Get(locale, key string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, l := range fallbacks(locale) {
+		if text, ok := c.messages[l][key]; ok {
+			return text
+		}
+	}
+	return key
+}
+
+// Sprintf formats the template for key in locale with args
+func (c *Catalog) Sprintf(locale, key string, args ...interface{}) string {
+	return fmt.Sprintf(c.Get(locale, key), args...)
+}
+
+// Locales returns the locales with registered messages
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	locales := make([]string, 0, len(c.messages))
+	for l := range c.messages {
+		locales = append(locales, l)
+	}
+	return locales
+}
+
+// normalize turns "fr_CA" or "FR-ca" into "fr-ca"
+func normalize(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+}
+
+// fallbacks lists the locales to try for locale, most specific first
+func fallbacks(locale string) []string {
+	locale = normalize(locale)
+	var out []string
+	if locale != "" {
+		out = append(out, locale)
+		if i := strings.Index(locale, "-"); i > 0 {
+			out = append(out, locale[:i])
+		}
+	}
+	return append(out, DefaultLocale)
+}
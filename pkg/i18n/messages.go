@@ -0,0 +1,62 @@
+package i18n
+
+// builtin holds the messages shipped with the package
+var builtin = map[string]map[string]string{
+	"en": {
+		ReActPersona:      "You are a helpful assistant that can use tools to answer questions.",
+		ReActToolsHeading: "Available tools:",
+		ReActInstructions: `Use the following format:
+
+Question: the input question you must answer
+Thought: you should always think about what to do
+Action: the action to take, should be one of [%s]
+Action Input: the input to the action
+Observation: the result of the action
+... (this Thought/Action/Action Input/Observation can repeat N times)
+Thought: I now know the final answer
+Final Answer: the final answer to the original input question
+
+Begin!`,
+		ReActToolError:      "Error: %v",
+		ReActNeedsInput:     "The tool needs more information from the user: %s",
+		ReActRepeatedAction: "You already ran %s with this input and got: %s\nDo not repeat it. Use this result, try a different action, or give the Final Answer.",
+	},
+	"fr": {
+		ReActPersona:      "Tu es un assistant serviable qui peut utiliser des outils pour répondre aux questions. Réponds toujours en français.",
+		ReActToolsHeading: "Outils disponibles :",
+		ReActInstructions: `Utilise le format suivant, en gardant les mots-clés en anglais :
+
+Question: la question à laquelle tu dois répondre
+Thought: réfléchis toujours à ce que tu dois faire
+Action: l'action à effectuer, parmi [%s]
+Action Input: l'entrée de l'action
+Observation: le résultat de l'action
+... (ce cycle Thought/Action/Action Input/Observation peut se répéter N fois)
+Thought: je connais maintenant la réponse finale
+Final Answer: la réponse finale à la question posée
+
+Commence !`,
+		ReActToolError:      "Erreur : %v",
+		ReActNeedsInput:     "L'outil a besoin d'informations supplémentaires de l'utilisateur : %s",
+		ReActRepeatedAction: "Tu as déjà exécuté %s avec cette entrée et obtenu : %s\nNe le répète pas. Utilise ce résultat, essaie une autre action ou donne la Final Answer.",
+	},
+	"es": {
+		ReActPersona:      "Eres un asistente útil que puede usar herramientas para responder preguntas. Responde siempre en español.",
+		ReActToolsHeading: "Herramientas disponibles:",
+		ReActInstructions: `Usa el siguiente formato, manteniendo las palabras clave en inglés:
+
+Question: la pregunta que debes responder
+Thought: piensa siempre qué debes hacer
+Action: la acción a realizar, una de [%s]
+Action Input: la entrada de la acción
+Observation: el resultado de la acción
+... (este ciclo Thought/Action/Action Input/Observation puede repetirse N veces)
+Thought: ahora conozco la respuesta final
+Final Answer: la respuesta final a la pregunta original
+
+¡Comienza!`,
+		ReActToolError:      "Error: %v",
+		ReActNeedsInput:     "La herramienta necesita más información del usuario: %s",
+		ReActRepeatedAction: "Ya ejecutaste %s con esta entrada y obtuviste: %s\nNo lo repitas. Usa este resultado, prueba otra acción o da la Final Answer.",
+	},
+}
@@ -0,0 +1,217 @@
+// Package langchain adapts langchaingo (github.com/tmc/langchaingo) components
+// to this module's interfaces and back.
+//
+// It does not import langchaingo. Tools are matched structurally: any
+// langchaingo tools.Tool satisfies Tool below. Models are bridged through
+// plain functions, so callers wire in llms.GenerateFromSinglePrompt on one
+// side, or Generate on the other, without this module pinning a langchaingo
+// version:
+//
+//	runnable := langchain.FromLLM("openai", func(ctx context.Context, prompt string) (string, error) {
+//		return llms.GenerateFromSinglePrompt(ctx, model, prompt)
+//	})
+//
+// This module has no retriever interface yet, so langchaingo retrievers are
+// used by wrapping them in a Tool.
+package langchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/llm"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/tools"
+)
+
+// Tool is the method set of langchaingo's tools.Tool
+type Tool interface {
+	Name() string
+	Description() string
+	Call(ctx context.Context, input string) (string, error)
+}
+
+// GenerateFunc produces a completion for a prompt, e.g. a closure around
+// langchaingo's llms.GenerateFromSinglePrompt
+type GenerateFunc func(ctx context.Context, prompt string) (string, error)
+
+// FromTool wraps a langchaingo tool as a tools.Tool. langchaingo tools take a
+// single string, so the agent passes it as the "input" argument.
+func FromTool(t Tool) tools.Tool {
+	return &langChainTool{
+		BaseTool: tools.NewBaseTool(
+			t.Name(),
+			t.Description(),
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"input": map[string]interface{}{
+						"type":        "string",
+						"description": "Input for the tool",
+					},
+				},
+				"required": []string{"input"},
+			},
+		),
+		tool: t,
+	}
+}
+
+// langChainTool is a langchaingo tool seen as a tools.Tool
+type langChainTool struct {
+	*tools.BaseTool
+	tool Tool
+}
+
+// Execute calls the wrapped tool with the "input" argument, or with all
+// arguments as JSON when the model passed structured ones
+func (t *langChainTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	if input, ok := args["input"].(string); ok && len(args) == 1 {
+		return t.tool.Call(ctx, input)
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	return t.tool.Call(ctx, string(data))
+}
+
+// ToTool exposes a tools.Tool with langchaingo's tool method set, so it can
+// be handed to langchaingo agents. The string input is parsed as a JSON
+// object of arguments; anything else is passed as {"input": <string>}.
+func ToTool(t tools.Tool) Tool {
+	return &nativeTool{tool: t}
+}
+
+// nativeTool is a tools.Tool seen as a langchaingo tool
+type nativeTool struct {
+	tool tools.Tool
+}
+
+func (t *nativeTool) Name() string { return t.tool.Name() }
+
+// Description includes the argument schema, since langchaingo agents only
+// see the description
+func (t *nativeTool) Description() string {
+	schema, err := json.Marshal(t.tool.ArgsSchema())
+	if err != nil {
+		return t.tool.Description()
+	}
+	return fmt.Sprintf("%s Input is a JSON object matching this schema: %s", t.tool.Description(), schema)
+}
+
+func (t *nativeTool) Call(ctx context.Context, input string) (string, error) {
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(input)), &args); err != nil {
+		args = map[string]interface{}{"input": input}
+	}
+	return t.tool.Execute(ctx, args)
+}
+
+// LLM runs a GenerateFunc as an llm.ChatModel
+type LLM struct {
+	*core.BaseRunnable
+	generate  GenerateFunc
+	formatter llm.PromptFormatter
+}
+
+var _ llm.ChatModel = (*LLM)(nil)
+
+// FromLLM wraps a langchaingo model, through generate, as an llm.ChatModel.
+// Conversations are rendered in the System:/User:/Assistant: layout used by
+// LlamaCppLLM.
+func FromLLM(name string, generate GenerateFunc) *LLM {
+	formatter, _ := llm.FormatterFor(llm.TemplatePlain)
+	return &LLM{
+		BaseRunnable: core.NewBaseRunnable(name),
+		generate:     generate,
+		formatter:    formatter,
+	}
+}
+
+// Invoke generates a completion for a string or a conversation and returns
+// it as a *core.Generation. langchaingo's single-prompt call reports no
+// token usage, so only the text, model name and duration are set.
+func (l *LLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	prompt := fmt.Sprint(input)
+	if messages, ok := input.([]core.Message); ok {
+		prompt = l.formatter.Format(messages)
+	}
+
+	start := time.Now()
+	text, err := l.generate(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return &core.Generation{
+		Text:         text,
+		Model:        l.Name(),
+		Duration:     time.Since(start),
+		FinishReason: core.FinishStop,
+	}, nil
+}
+
+// Stream generates the completion and emits it as a single chunk
+func (l *LLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		result, err := l.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		out <- result
+	}()
+	return out, nil
+}
+
+// Batch generates a completion for every input concurrently
+func (l *LLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	errs := make([]error, len(inputs))
+
+	done := make(chan bool, len(inputs))
+	for i, input := range inputs {
+		go func(idx int, inp interface{}) {
+			results[idx], errs[idx] = l.Invoke(ctx, inp, config)
+			done <- true
+		}(i, input)
+	}
+	for range inputs {
+		<-done
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// Pipe composes the model with another runnable
+func (l *LLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{l, other})
+}
+
+// Close does nothing; the langchaingo model is owned by the caller
+func (l *LLM) Close() {}
+
+// Generate turns a core.Runnable into a GenerateFunc, the building block for
+// implementing langchaingo's llms.Model on top of a local model
+func Generate(r core.Runnable) GenerateFunc {
+	return func(ctx context.Context, prompt string) (string, error) {
+		output, err := r.Invoke(ctx, prompt, nil)
+		if err != nil {
+			return "", err
+		}
+		if msg, ok := output.(core.Message); ok {
+			return msg.GetContent(), nil
+		}
+		return fmt.Sprint(output), nil
+	}
+}
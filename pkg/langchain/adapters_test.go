@@ -0,0 +1,42 @@
+package langchain
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+func TestLLMInvoke(t *testing.T) {
+	var prompt string
+	model := FromLLM("langchain", func(ctx context.Context, p string) (string, error) {
+		prompt = p
+		return "Paris", nil
+	})
+
+	output, err := model.Invoke(context.Background(), []core.Message{
+		core.NewSystemMessage("Be brief.", nil),
+		core.NewHumanMessage("Capital of France?", nil),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+
+	want := "System: Be brief.\n\nUser: Capital of France?\n\nAssistant:"
+	if prompt != want {
+		t.Errorf("prompt = %q, want %q", prompt, want)
+	}
+	gen, ok := output.(*core.Generation)
+	if !ok {
+		t.Fatalf("Invoke() returned %T, want *core.Generation", output)
+	}
+	if gen.Text != "Paris" || gen.Model != "langchain" || gen.FinishReason != core.FinishStop {
+		t.Errorf("Invoke() = %+v", gen)
+	}
+
+	text, err := Generate(model)(context.Background(), "Capital of France?")
+	if err != nil || !strings.Contains(text, "Paris") {
+		t.Errorf("Generate() = %q, %v, want the generated text", text, err)
+	}
+}
@@ -0,0 +1,242 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// Scorer rates a candidate answer for a prompt; higher is better
+type Scorer func(ctx context.Context, prompt, candidate string) (float64, error)
+
+// Candidate is one sampled answer and its score
+type Candidate struct {
+	Output string  `json:"output"`
+	Score  float64 `json:"score"`
+	// generation is the model's result, when it returned a *core.Generation
+	generation *core.Generation
+}
+
+// BestOfNLLM samples n answers from a model and keeps the highest scoring one.
+// The model should sample with a non-zero temperature, otherwise every
+// candidate comes out the same.
+type BestOfNLLM struct {
+	*core.BaseRunnable
+	llm    core.Runnable
+	n      int
+	scorer Scorer
+}
+
+var _ ChatModel = (*BestOfNLLM)(nil)
+
+// BestOfN wraps llm so that each request samples n candidates and returns the
+// best according to scorer. Invoke returns the winning answer as a
+// *core.Generation listing every candidate and its score.
+func BestOfN(llm core.Runnable, n int, scorer Scorer) *BestOfNLLM {
+	if n <= 0 {
+		n = 1
+	}
+	return &BestOfNLLM{
+		BaseRunnable: core.NewBaseRunnable("BestOfN"),
+		llm:          llm,
+		n:            n,
+		scorer:       scorer,
+	}
+}
+
+// Sample generates and scores the candidates concurrently and returns them
+// with the index of the best one.
+// Wrap the model in a QueuedLLM if it cannot serve parallel requests.
+func (b *BestOfNLLM) Sample(ctx context.Context, input interface{}, config *core.Config) ([]Candidate, int, error) {
+	prompt := promptText(input)
+	candidates := make([]Candidate, b.n)
+	errs := make([]error, b.n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < b.n; i++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			output, err := b.llm.Invoke(ctx, input, config)
+			if err != nil {
+				errs[idx] = fmt.Errorf("candidate %d failed: %w", idx, err)
+				return
+			}
+			gen, _ := output.(*core.Generation)
+			text := strings.TrimSpace(outputText(output))
+			score, err := b.scorer(ctx, prompt, text)
+			if err != nil {
+				errs[idx] = fmt.Errorf("scoring candidate %d failed: %w", idx, err)
+				return
+			}
+			candidates[idx] = Candidate{Output: text, Score: score, generation: gen}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	best := 0
+	for i, c := range candidates {
+		if c.Score > candidates[best].Score {
+			best = i
+		}
+	}
+
+	return candidates, best, nil
+}
+
+// Invoke returns the best of n sampled answers as a *core.Generation, with
+// all n in its Candidates
+func (b *BestOfNLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	candidates, best, err := b.Sample(ctx, input, config)
+	if err != nil {
+		return nil, err
+	}
+	winner := candidates[best]
+	gen := &core.Generation{Text: winner.Output, FinishReason: core.FinishStop}
+	if winner.generation != nil {
+		result := *winner.generation
+		result.Text = winner.Output
+		gen = &result
+	}
+	gen.Candidates = make([]core.Candidate, len(candidates))
+	for i, c := range candidates {
+		gen.Candidates[i] = core.Candidate{Text: c.Output, Score: c.Score}
+	}
+	return gen, nil
+}
+
+// Stream selects the best candidate and emits it as a single chunk
+func (b *BestOfNLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		result, err := b.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		out <- result
+	}()
+	return out, nil
+}
+
+// Batch runs best-of-n for every input concurrently
+func (b *BestOfNLLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	errs := make([]error, len(inputs))
+
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		wg.Add(1)
+		go func(idx int, inp interface{}) {
+			defer wg.Done()
+			results[idx], errs[idx] = b.Invoke(ctx, inp, config)
+		}(i, input)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// Pipe composes best-of-n sampling with another runnable
+func (b *BestOfNLLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{b, other})
+}
+
+// Close closes the wrapped model if it holds resources
+func (b *BestOfNLLM) Close() {
+	if m, ok := b.llm.(ChatModel); ok {
+		m.Close()
+	}
+}
+
+// promptText renders a model input as plain text for scorers
+func promptText(input interface{}) string {
+	switch v := input.(type) {
+	case string:
+		return v
+	case []core.Message:
+		var b strings.Builder
+		for _, msg := range v {
+			fmt.Fprintf(&b, "%s: %s\n", msg.GetType(), msg.GetContent())
+		}
+		return strings.TrimSpace(b.String())
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// outputText is the text of a model result; messages are read by content,
+// since their String form starts with a timestamp
+func outputText(output interface{}) string {
+	if msg, ok := output.(core.Message); ok {
+		return msg.GetContent()
+	}
+	return fmt.Sprint(output)
+}
+
+var judgeScorePattern = regexp.MustCompile(`-?\d+(\.\d+)?`)
+
+// JudgeScorer asks a judge model to grade each candidate from 1 to 10
+func JudgeScorer(judge core.Runnable) Scorer {
+	return func(ctx context.Context, prompt, candidate string) (float64, error) {
+		request := fmt.Sprintf(`Rate how well the answer responds to the request, from 1 (useless) to 10 (perfect).
+Reply with the number only.
+
+Request:
+%s
+
+Answer:
+%s
+
+Score:`, prompt, candidate)
+
+		response, err := judge.Invoke(ctx, request, nil)
+		if err != nil {
+			return 0, err
+		}
+		match := judgeScorePattern.FindString(outputText(response))
+		if match == "" {
+			return 0, fmt.Errorf("judge returned no score: %q", response)
+		}
+		return strconv.ParseFloat(match, 64)
+	}
+}
+
+// LogprobSource reports the per-token log probabilities of a completion
+type LogprobSource interface {
+	Logprobs(ctx context.Context, prompt, completion string) ([]float64, error)
+}
+
+// LogprobScorer scores a candidate by the summed log probability its model
+// assigns to it. The go-llama.cpp bindings do not expose logprobs, so src has
+// to come from a backend that does (e.g. a llama.cpp server).
+func LogprobScorer(src LogprobSource) Scorer {
+	return func(ctx context.Context, prompt, candidate string) (float64, error) {
+		logprobs, err := src.Logprobs(ctx, prompt, candidate)
+		if err != nil {
+			return 0, err
+		}
+		sum := 0.0
+		for _, lp := range logprobs {
+			sum += lp
+		}
+		return sum, nil
+	}
+}
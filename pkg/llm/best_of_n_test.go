@@ -0,0 +1,42 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+func TestBestOfNInvoke(t *testing.T) {
+	answers := map[string]float64{"short": 1, "a better answer": 3, "medium one": 2}
+	replies := []string{"short", "a better answer", "medium one"}
+	model := newStubModel(func(call int, input interface{}) (interface{}, error) {
+		return &core.Generation{Text: replies[call], Model: "stub", FinishReason: core.FinishStop}, nil
+	})
+	scorer := func(ctx context.Context, prompt, candidate string) (float64, error) {
+		return answers[candidate], nil
+	}
+
+	best := BestOfN(model, 3, scorer)
+	output, err := best.Invoke(context.Background(), "question", nil)
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	gen := output.(*core.Generation)
+	if gen.Text != "a better answer" || gen.Model != "stub" {
+		t.Errorf("Invoke() = %q from %q, want %q from %q", gen.Text, gen.Model, "a better answer", "stub")
+	}
+	if len(gen.Candidates) != 3 {
+		t.Fatalf("Candidates = %+v, want all 3", gen.Candidates)
+	}
+	for _, c := range gen.Candidates {
+		if want, ok := answers[c.Text]; !ok || c.Score != want {
+			t.Errorf("candidate %+v, want one of the replies with its score", c)
+		}
+	}
+
+	best.Close()
+	if n := model.closeCount(); n != 1 {
+		t.Errorf("wrapped model closed %d times, want 1", n)
+	}
+}
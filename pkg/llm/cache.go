@@ -0,0 +1,521 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+	bolt "go.etcd.io/bbolt"
+)
+
+// CacheBackend stores cached responses by key. Entries older than the
+// backend's TTL are treated as missing.
+type CacheBackend interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte) error
+}
+
+// MemoryCache keeps responses in memory for the life of the process
+type MemoryCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value  []byte
+	stored time.Time
+}
+
+// NewMemoryCache creates an in-memory cache; a ttl of 0 keeps entries forever
+func NewMemoryCache(ttl time.Duration) *MemoryCache {
+	return &MemoryCache{ttl: ttl, entries: make(map[string]memoryEntry)}
+}
+
+// Get returns the response stored under key unless it expired
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if m.ttl > 0 && time.Since(entry.stored) > m.ttl {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set stores value under key
+func (m *MemoryCache) Set(key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = memoryEntry{value: value, stored: time.Now()}
+	return nil
+}
+
+// FileCache keeps responses as one file per entry in a directory, so they
+// survive restarts, e.g. between runs of the examples. Unlike BoltCache,
+// several processes may share the directory at the same time.
+type FileCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewFileCache creates a cache in dir; a ttl of 0 keeps entries forever
+func NewFileCache(dir string, ttl time.Duration) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	return &FileCache{dir: dir, ttl: ttl}, nil
+}
+
+func (f *FileCache) path(key string) string {
+	return filepath.Join(f.dir, key+".json")
+}
+
+// Get returns the response stored under key unless it expired
+func (f *FileCache) Get(key string) ([]byte, bool) {
+	path := f.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if f.ttl > 0 && time.Since(info.ModTime()) > f.ttl {
+		os.Remove(path)
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Set stores value under key, replacing the file atomically so concurrent
+// readers never see a partial entry
+func (f *FileCache) Set(key string, value []byte) error {
+	tmp, err := os.CreateTemp(f.dir, "entry-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(key))
+}
+
+// Prune deletes expired entries and returns how many were removed
+func (f *FileCache) Prune() (int, error) {
+	if f.ttl <= 0 {
+		return 0, nil
+	}
+	matches, err := filepath.Glob(filepath.Join(f.dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, path := range matches {
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > f.ttl {
+			if os.Remove(path) == nil {
+				removed++
+			}
+		}
+	}
+	return removed, nil
+}
+
+// boltBucket holds the responses of a BoltCache
+var boltBucket = []byte("responses")
+
+// BoltCache keeps responses in a bbolt database file, so they survive
+// restarts. bbolt locks the file, so only one process can open it at a
+// time; use FileCache to share a cache between processes.
+type BoltCache struct {
+	db  *bolt.DB
+	ttl time.Duration
+}
+
+// NewBoltCache opens or creates the database at path; a ttl of 0 keeps
+// entries forever. It fails after a few seconds when another process has
+// the database open.
+func NewBoltCache(path string, ttl time.Duration) (*BoltCache, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache dir: %w", err)
+	}
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open cache %s: %w", path, err)
+	}
+	return &BoltCache{db: db, ttl: ttl}, nil
+}
+
+// Entries are stored as the time they were written, in Unix nanoseconds,
+// followed by the response
+const boltStampSize = 8
+
+// expired reports whether an entry written at stamp is past the TTL
+func (b *BoltCache) expired(entry []byte) bool {
+	stored := time.Unix(0, int64(binary.BigEndian.Uint64(entry[:boltStampSize])))
+	return b.ttl > 0 && time.Since(stored) > b.ttl
+}
+
+// Get returns the response stored under key unless it expired
+func (b *BoltCache) Get(key string) ([]byte, bool) {
+	var value []byte
+	expired := false
+	b.db.View(func(tx *bolt.Tx) error {
+		entry := tx.Bucket(boltBucket).Get([]byte(key))
+		if len(entry) < boltStampSize {
+			return nil
+		}
+		if b.expired(entry) {
+			expired = true
+			return nil
+		}
+		// bbolt's memory is only valid during the transaction
+		value = append([]byte(nil), entry[boltStampSize:]...)
+		return nil
+	})
+	if expired {
+		b.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(boltBucket).Delete([]byte(key))
+		})
+	}
+	return value, value != nil
+}
+
+// Set stores value under key
+func (b *BoltCache) Set(key string, value []byte) error {
+	entry := make([]byte, boltStampSize+len(value))
+	binary.BigEndian.PutUint64(entry, uint64(time.Now().UnixNano()))
+	copy(entry[boltStampSize:], value)
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), entry)
+	})
+}
+
+// Prune deletes expired entries and returns how many were removed
+func (b *BoltCache) Prune() (int, error) {
+	if b.ttl <= 0 {
+		return 0, nil
+	}
+	removed := 0
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltBucket).Cursor()
+		for k, v := c.First(); k != nil; {
+			if len(v) >= boltStampSize && !b.expired(v) {
+				k, v = c.Next()
+				continue
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			removed++
+			// Delete moves the cursor to the next entry
+			k, v = c.Seek(k)
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// Close closes the database file
+func (b *BoltCache) Close() error {
+	return b.db.Close()
+}
+
+// cacheIdentity is implemented by the backends to describe the model and
+// sampling settings that determine their output
+type cacheIdentity interface {
+	cacheIdentity() string
+}
+
+// cacheIdentity leaves out settings that do not change the output, such as
+// threads and GPU offload
+func (l *LlamaCppLLM) cacheIdentity() string {
+	c := l.loadConfig
+	return fmt.Sprintf("llamacpp|%s|%d|%d|%g|%g|%d|%q|%q|%s|%s|%d|%g|%g|%g|%g|%g|%d|%g|%g",
+		filepath.Base(l.modelPath), l.contextSize, l.maxTokens, l.temperature, l.topP, l.topK, l.systemPrompt, l.stop,
+		c.ChatTemplate, filepath.Base(c.LoraPath), c.Mirostat, c.MirostatTau, c.MirostatEta,
+		c.TypicalP, c.TailFreeZ, c.RepeatPenalty, c.RepeatLastN, c.FrequencyPenalty, c.PresencePenalty)
+}
+
+// identity describes the model and sampling settings of an HTTP backend
+func (c HTTPBackendConfig) identity(kind string) string {
+	return fmt.Sprintf("%s|%s|%s|%d|%g|%g|%d|%q|%q|%s",
+		kind, c.BaseURL, c.Model, c.MaxTokens, c.Temperature, c.TopP, c.TopK, c.Stop, c.SystemPrompt, c.ChatTemplate)
+}
+
+func (l *LlamafileLLM) cacheIdentity() string { return l.config.identity("llamafile") }
+func (t *TGILLM) cacheIdentity() string       { return t.config.identity("tgi") }
+
+func (o *OpenAIChatLLM) cacheIdentity() string {
+	tools, _ := json.Marshal(o.tools)
+	return o.config.identity("openai") + "|" + string(tools)
+}
+
+func (l *LlamaServerLLM) cacheIdentity() string {
+	tools, _ := json.Marshal(l.chat.tools)
+	return l.config.identity("llama-server") + "|" + string(tools)
+}
+
+// cacheIdentity ignores the port, which changes on every start
+func (p *ProcessLLM) cacheIdentity() string {
+	config := p.llm.config
+	config.BaseURL = ""
+	return config.identity("process|" + filepath.Base(p.config.ModelPath))
+}
+
+// cachedResponse is what the cache stores for one completion
+type cachedResponse struct {
+	Generation *core.Generation `json:"generation"`
+}
+
+// CachedLLM answers repeated requests from a cache instead of generating
+// again. Requests match when they go to the same model with the same
+// sampling settings and the same prompt, stop sequences, grammar and logit
+// bias.
+// Errors and dry runs are not cached.
+type CachedLLM struct {
+	*core.BaseRunnable
+	llm     core.Runnable
+	backend CacheBackend
+	// ownsBackend closes the backend along with the model, for caches
+	// opened by NewChatModel
+	ownsBackend bool
+	mu          sync.Mutex
+	hits        int
+	misses      int
+}
+
+var _ ChatModel = (*CachedLLM)(nil)
+
+// WithCache wraps llm so that its completions are stored in backend
+func WithCache(llm core.Runnable, backend CacheBackend) *CachedLLM {
+	return &CachedLLM{
+		BaseRunnable: core.NewBaseRunnable("CachedLLM"),
+		llm:          llm,
+		backend:      backend,
+	}
+}
+
+// Stats returns how many requests were answered from the cache and how
+// many were generated
+func (c *CachedLLM) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// key hashes everything that determines the completion of input
+func (c *CachedLLM) key(input interface{}, config *core.Config) string {
+	h := sha256.New()
+	if id, ok := c.llm.(cacheIdentity); ok && id.cacheIdentity() != "" {
+		fmt.Fprintln(h, id.cacheIdentity())
+	} else {
+		fmt.Fprintf(h, "%T|%s\n", c.llm, c.llm.Name())
+	}
+
+	switch v := input.(type) {
+	case []core.Message:
+		for _, msg := range v {
+			// Only what the model sees counts; ids and timestamps differ
+			// between otherwise identical messages
+			fmt.Fprintf(h, "%s\x00%s\x00", msg.GetType(), msg.GetContent())
+			switch m := msg.(type) {
+			case *core.AIMessage:
+				for _, call := range m.ToolCalls {
+					args, _ := json.Marshal(call.Args)
+					fmt.Fprintf(h, "call\x00%s\x00%s\x00%s\x00%s\x00", call.ID, call.Function.Name, call.Function.Arguments, args)
+				}
+			case *core.HumanMessage:
+				for _, a := range m.Attachments {
+					fmt.Fprintf(h, "attachment\x00%s\x00%s\x00%x\x00", a.Name, a.Path, sha256.Sum256(a.Data))
+				}
+			case *core.ToolMessage:
+				fmt.Fprintf(h, "result\x00%s\x00", m.ToolCallID)
+			}
+		}
+	default:
+		fmt.Fprintf(h, "%T\x00%s\x00", v, promptText(v))
+	}
+
+	var bias []string
+	if config != nil {
+		fmt.Fprintf(h, "%q\x00", config.Stop)
+		if config.Grammar != "" {
+			fmt.Fprintf(h, "grammar\x00%s\x00", config.Grammar)
+		}
+		for token, value := range config.LogitBias {
+			bias = append(bias, fmt.Sprintf("%q=%g", token, value))
+		}
+	} else {
+		fmt.Fprint(h, "[]\x00")
+	}
+	sort.Strings(bias)
+	fmt.Fprint(h, strings.Join(bias, ","))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lookup returns the cached generation for key
+func (c *CachedLLM) lookup(key string) (*core.Generation, bool) {
+	data, ok := c.backend.Get(key)
+	if !ok {
+		c.count(false)
+		return nil, false
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil || cached.Generation == nil {
+		c.count(false)
+		return nil, false
+	}
+	c.count(true)
+	return cached.Generation, true
+}
+
+func (c *CachedLLM) count(hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+// store saves gen under key; a failing backend only costs a cache miss later
+func (c *CachedLLM) store(key string, gen *core.Generation) {
+	data, err := json.Marshal(cachedResponse{Generation: gen})
+	if err != nil {
+		return
+	}
+	c.backend.Set(key, data)
+}
+
+// Invoke returns the cached completion of input, generating and caching it
+// on a miss. A hit returns a copy with a zero Duration.
+func (c *CachedLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	if IsDryRun(ctx) {
+		return c.llm.Invoke(ctx, input, config)
+	}
+	key := c.key(input, config)
+	if gen, ok := c.lookup(key); ok {
+		gen.Duration = 0
+		return gen, nil
+	}
+
+	output, err := c.llm.Invoke(ctx, input, config)
+	if err != nil {
+		return nil, err
+	}
+	switch v := output.(type) {
+	case *core.Generation:
+		c.store(key, v)
+	case string:
+		c.store(key, &core.Generation{Text: v})
+	}
+	return output, nil
+}
+
+// Stream emits a cached completion as a single chunk, or streams from the
+// model and caches the text once the stream ends without error
+func (c *CachedLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	if IsDryRun(ctx) {
+		return c.llm.Stream(ctx, input, config)
+	}
+	key := c.key(input, config)
+	if gen, ok := c.lookup(key); ok {
+		out := make(chan interface{}, 1)
+		out <- gen.Text
+		close(out)
+		return out, nil
+	}
+
+	chunks, err := c.llm.Stream(ctx, input, config)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		var text strings.Builder
+		failed := false
+		for chunk := range chunks {
+			switch v := chunk.(type) {
+			case string:
+				text.WriteString(v)
+			default:
+				// Errors and other chunks, such as dry-run results, are not cached
+				failed = true
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				go func() {
+					for range chunks {
+					}
+				}()
+				return
+			}
+		}
+		if !failed && text.Len() > 0 && ctx.Err() == nil {
+			c.store(key, &core.Generation{Text: text.String()})
+		}
+	}()
+	return out, nil
+}
+
+// Batch answers every input, generating only those not in the cache
+func (c *CachedLLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := c.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the cached model with another runnable
+func (c *CachedLLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{c, other})
+}
+
+// Close closes the wrapped model if it holds resources, and the cache
+// backend when NewChatModel opened it
+func (c *CachedLLM) Close() {
+	if m, ok := c.llm.(ChatModel); ok {
+		m.Close()
+	}
+	if b, ok := c.backend.(interface{ Close() error }); ok && c.ownsBackend {
+		b.Close()
+	}
+}
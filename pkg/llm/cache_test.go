@@ -0,0 +1,194 @@
+package llm
+
+import (
+	"context"
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+	bolt "go.etcd.io/bbolt"
+)
+
+func toolCallMessage(id, args string) *core.AIMessage {
+	return core.NewAIMessage("", map[string]interface{}{
+		"tool_calls": []core.ToolCall{{ID: id, Type: "function", Function: core.ToolCallFunction{Name: "search", Arguments: args}}},
+	})
+}
+
+func TestCacheKey(t *testing.T) {
+	// Every call builds fresh messages, with new ids and timestamps
+	conversation := func(args, result string) []core.Message {
+		return []core.Message{
+			core.NewHumanMessage("find go tutorials", nil),
+			toolCallMessage("call_1", args),
+			core.NewToolMessage(result, "call_1", nil),
+		}
+	}
+	withAttachment := func(data string) []core.Message {
+		msg := core.NewHumanMessage("describe this", nil)
+		msg.Attachments = []core.Attachment{{Name: "a.png", Data: []byte(data)}}
+		return []core.Message{msg}
+	}
+
+	tests := []struct {
+		name      string
+		a, b      interface{}
+		configA   *core.Config
+		configB   *core.Config
+		wantEqual bool
+	}{
+		{
+			name:      "same conversation built twice",
+			a:         conversation(`{"q":"go"}`, "3 results"),
+			b:         conversation(`{"q":"go"}`, "3 results"),
+			wantEqual: true,
+		},
+		{
+			name: "different tool call arguments",
+			a:    conversation(`{"q":"go"}`, "3 results"),
+			b:    conversation(`{"q":"rust"}`, "3 results"),
+		},
+		{
+			name: "different tool call ids",
+			a:    []core.Message{toolCallMessage("call_1", "{}")},
+			b:    []core.Message{toolCallMessage("call_2", "{}")},
+		},
+		{
+			name: "different tool results",
+			a:    conversation(`{"q":"go"}`, "3 results"),
+			b:    conversation(`{"q":"go"}`, "no results"),
+		},
+		{
+			name: "different attachment data",
+			a:    withAttachment("one"),
+			b:    withAttachment("two"),
+		},
+		{
+			name: "string and message input",
+			a:    "hello",
+			b:    []core.Message{core.NewHumanMessage("hello", nil)},
+		},
+		{
+			name:    "different stop sequences",
+			a:       "hello",
+			b:       "hello",
+			configA: &core.Config{Stop: []string{"\n"}},
+			configB: &core.Config{Stop: []string{"."}},
+		},
+		{
+			name:      "logit bias in any order",
+			a:         "hello",
+			b:         "hello",
+			configA:   &core.Config{LogitBias: map[string]float32{"yes": 5, "no": -5}},
+			configB:   &core.Config{LogitBias: map[string]float32{"no": -5, "yes": 5}},
+			wantEqual: true,
+		},
+	}
+
+	cached := WithCache(newStubModel(nil), NewMemoryCache(0))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := cached.key(tt.a, tt.configA)
+			b := cached.key(tt.b, tt.configB)
+			if (a == b) != tt.wantEqual {
+				t.Errorf("key equal = %v, want %v", a == b, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestCachedLLMInvoke(t *testing.T) {
+	ctx := context.Background()
+	model := newStubModel(func(call int, input interface{}) (interface{}, error) {
+		return &core.Generation{Text: []string{"first", "second"}[call], FinishReason: core.FinishStop}, nil
+	})
+	cached := WithCache(model, NewMemoryCache(0))
+
+	for i := 0; i < 2; i++ {
+		output, err := cached.Invoke(ctx, []core.Message{core.NewHumanMessage("hi", nil)}, nil)
+		if err != nil {
+			t.Fatalf("Invoke() error = %v", err)
+		}
+		if gen := output.(*core.Generation); gen.Text != "first" {
+			t.Errorf("Invoke() #%d = %q, want %q", i, gen.Text, "first")
+		}
+	}
+	if n := len(model.calls()); n != 1 {
+		t.Errorf("model called %d times, want 1", n)
+	}
+	if hits, misses := cached.Stats(); hits != 1 || misses != 1 {
+		t.Errorf("Stats() = %d hits, %d misses; want 1, 1", hits, misses)
+	}
+}
+
+func TestBoltCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "llm-cache.db")
+	cache, err := NewBoltCache(path, time.Hour)
+	if err != nil {
+		t.Fatalf("NewBoltCache() error = %v", err)
+	}
+	if err := cache.Set("fresh", []byte("Paris")); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	// Backdate an entry past the TTL
+	stale := make([]byte, boltStampSize+len("Lyon"))
+	binary.BigEndian.PutUint64(stale, uint64(time.Now().Add(-2*time.Hour).UnixNano()))
+	copy(stale[boltStampSize:], "Lyon")
+	for _, key := range []string{"stale", "stale2"} {
+		err := cache.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(boltBucket).Put([]byte(key), stale)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, ok := cache.Get("missing"); ok {
+		t.Error("Get(missing) found an entry")
+	}
+	if _, ok := cache.Get("stale"); ok {
+		t.Error("Get(stale) returned an expired entry")
+	}
+	if n, err := cache.Prune(); err != nil || n != 1 {
+		t.Errorf("Prune() = %d, %v, want 1 expired entry removed", n, err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Entries survive reopening the database
+	cache, err = NewBoltCache(path, time.Hour)
+	if err != nil {
+		t.Fatalf("NewBoltCache() reopen error = %v", err)
+	}
+	defer cache.Close()
+	if value, ok := cache.Get("fresh"); !ok || string(value) != "Paris" {
+		t.Errorf("Get(fresh) after reopening = %q, %v, want %q", value, ok, "Paris")
+	}
+}
+
+func TestCachedLLMClosesOwnedBackend(t *testing.T) {
+	cache, err := NewBoltCache(filepath.Join(t.TempDir(), "llm-cache.db"), 0)
+	if err != nil {
+		t.Fatalf("NewBoltCache() error = %v", err)
+	}
+	defer cache.Close()
+
+	model := newStubModel(nil)
+	WithCache(model, cache).Close()
+	if n := model.closeCount(); n != 1 {
+		t.Errorf("wrapped model closed %d times, want 1", n)
+	}
+	if err := cache.Set("key", []byte("value")); err != nil {
+		t.Errorf("a caller's backend was closed with the model: %v", err)
+	}
+
+	owned := WithCache(newStubModel(nil), cache)
+	owned.ownsBackend = true
+	owned.Close()
+	if err := cache.Set("key", []byte("value")); err == nil {
+		t.Error("an owned backend is still open after Close()")
+	}
+}
@@ -0,0 +1,186 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// ChatHistory stores the messages of a ChatSession. *memory.Conversation
+// implements it, so a session can be saved, edited and branched.
+type ChatHistory interface {
+	Append(messages ...core.Message) error
+	Messages() []core.Message
+}
+
+// messageHistory is the in-memory ChatHistory of a new session
+type messageHistory struct {
+	mu       sync.Mutex
+	messages []core.Message
+}
+
+func (h *messageHistory) Append(messages ...core.Message) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages = append(h.messages, messages...)
+	return nil
+}
+
+func (h *messageHistory) Messages() []core.Message {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]core.Message{}, h.messages...)
+}
+
+// ChatSession is a multi-turn chat with any model: it keeps the history,
+// prepends the system prompt and sends the conversation on every turn, so
+// applications do not thread the messages around Invoke themselves. Turns
+// are serialized; a turn that fails leaves the history unchanged.
+//
+//	chat := llm.NewChatSession(model).WithSystemPrompt("You are a helpful assistant.")
+//	reply, err := chat.Send(ctx, "My name is Ada.")
+//	reply, err = chat.Send(ctx, "What is my name?")
+type ChatSession struct {
+	model        core.Runnable
+	history      ChatHistory
+	systemPrompt string
+	config       *core.Config
+	maxMessages  int
+	mu           sync.Mutex
+}
+
+// NewChatSession starts an empty chat with model
+func NewChatSession(model core.Runnable) *ChatSession {
+	return &ChatSession{model: model, history: &messageHistory{}}
+}
+
+// WithSystemPrompt sets the system message sent before the history; it is
+// not stored in the history itself
+func (s *ChatSession) WithSystemPrompt(prompt string) *ChatSession {
+	s.systemPrompt = prompt
+	return s
+}
+
+// WithHistory continues the conversation stored in history, e.g. a
+// *memory.Conversation loaded from disk
+func (s *ChatSession) WithHistory(history ChatHistory) *ChatSession {
+	s.history = history
+	return s
+}
+
+// WithConfig sets the invocation config of every turn, e.g. stop sequences
+// or callbacks
+func (s *ChatSession) WithConfig(config *core.Config) *ChatSession {
+	s.config = config
+	return s
+}
+
+// WithMaxMessages sends only the latest n messages of the history to the
+// model (0 = all), so long chats stay within the context window; the
+// history itself keeps every message
+func (s *ChatSession) WithMaxMessages(n int) *ChatSession {
+	s.maxMessages = n
+	return s
+}
+
+// Messages returns the conversation so far, without the system prompt
+func (s *ChatSession) Messages() []core.Message {
+	return s.history.Messages()
+}
+
+// prompt assembles the messages sent for a turn ending with question
+func (s *ChatSession) prompt(question *core.HumanMessage) []core.Message {
+	history := s.history.Messages()
+	if s.maxMessages > 0 && len(history) > s.maxMessages {
+		history = history[len(history)-s.maxMessages:]
+		// Start the window on a user turn, so no reply or tool result is
+		// sent without what it answers
+		for len(history) > 0 && history[0].GetType() != core.MessageTypeHuman {
+			history = history[1:]
+		}
+	}
+
+	var messages []core.Message
+	if s.systemPrompt != "" {
+		messages = append(messages, core.NewSystemMessage(s.systemPrompt, nil))
+	}
+	messages = append(messages, history...)
+	return append(messages, question)
+}
+
+// Send adds text as a user message, generates the reply and adds it to the
+// history
+func (s *ChatSession) Send(ctx context.Context, text string) (*core.Generation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	question := core.NewHumanMessage(text, nil)
+	output, err := s.model.Invoke(ctx, s.prompt(question), s.config)
+	if err != nil {
+		return nil, fmt.Errorf("chat failed: %w", err)
+	}
+
+	var gen *core.Generation
+	switch v := output.(type) {
+	case *core.Generation:
+		gen = v
+	case string:
+		gen = &core.Generation{Text: v}
+	default:
+		// Dry runs report the prompt without changing the history
+		return &core.Generation{Text: fmt.Sprint(output), Model: s.model.Name()}, nil
+	}
+	if err := s.history.Append(question, gen.Message()); err != nil {
+		return nil, fmt.Errorf("failed to record chat turn: %w", err)
+	}
+	return gen, nil
+}
+
+// SendStream adds text as a user message and streams the reply, which is
+// added to the history once the stream ends without error. The next turn
+// waits until the stream is drained.
+func (s *ChatSession) SendStream(ctx context.Context, text string) (<-chan interface{}, error) {
+	s.mu.Lock()
+	question := core.NewHumanMessage(text, nil)
+	chunks, err := s.model.Stream(ctx, s.prompt(question), s.config)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("chat failed: %w", err)
+	}
+
+	out := make(chan interface{})
+	go func() {
+		defer s.mu.Unlock()
+		defer close(out)
+		var reply strings.Builder
+		failed := false
+		for chunk := range chunks {
+			switch v := chunk.(type) {
+			case string:
+				reply.WriteString(v)
+			default:
+				// Errors and dry-run results do not make a reply
+				failed = true
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				go func() {
+					for range chunks {
+					}
+				}()
+				return
+			}
+		}
+		if failed || ctx.Err() != nil {
+			return
+		}
+		if err := s.history.Append(question, core.NewAIMessage(reply.String(), nil)); err != nil {
+			out <- fmt.Errorf("failed to record chat turn: %w", err)
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,183 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// continuePrompt asks the model to pick up a truncated answer
+const continuePrompt = "Your previous answer was cut off. Continue it exactly where it stopped, without repeating or summarizing what you already wrote."
+
+// ContinuingLLM resumes answers cut off by the token limit. When the model
+// reports FinishLength, the end of the partial answer is sent back as an
+// assistant turn with a request to continue, and the pieces are stitched
+// together, dropping text the model repeats at the seam. Models that do not
+// return a *core.Generation are passed through.
+type ContinuingLLM struct {
+	*core.BaseRunnable
+	llm              core.Runnable
+	maxContinuations int
+	maxOverlap       int
+	maxResend        int
+}
+
+var _ ChatModel = (*ContinuingLLM)(nil)
+
+// WithContinuation wraps llm so truncated answers are continued up to
+// maxContinuations times
+func WithContinuation(llm core.Runnable, maxContinuations int) *ContinuingLLM {
+	return &ContinuingLLM{
+		BaseRunnable:     core.NewBaseRunnable("ContinuingLLM"),
+		llm:              llm,
+		maxContinuations: maxContinuations,
+		maxOverlap:       400,
+		maxResend:        2000,
+	}
+}
+
+// WithMaxOverlap sets how many characters at the seam are checked for
+// repeated text (default 400)
+func (c *ContinuingLLM) WithMaxOverlap(n int) *ContinuingLLM {
+	c.maxOverlap = n
+	return c
+}
+
+// WithMaxResend sets how many characters of the partial answer are sent
+// back with each continuation request (default 2000). Only the end is
+// needed to pick up the answer, and resending all of it would soon fill
+// the context window of the prompt.
+func (c *ContinuingLLM) WithMaxResend(n int) *ContinuingLLM {
+	c.maxResend = n
+	return c
+}
+
+// Invoke generates an answer, continuing it while it is truncated. The
+// result is a *core.Generation whose token counts and duration cover every
+// request; its FinishReason is FinishLength if the answer is still
+// truncated after the last continuation, or a continuation request failed.
+func (c *ContinuingLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	output, err := c.llm.Invoke(ctx, input, config)
+	if err != nil {
+		return nil, err
+	}
+	gen, ok := output.(*core.Generation)
+	if !ok || gen.FinishReason != core.FinishLength || c.maxContinuations <= 0 {
+		return output, nil
+	}
+
+	var messages []core.Message
+	switch v := input.(type) {
+	case string:
+		messages = []core.Message{core.NewHumanMessage(v, nil)}
+	case []core.Message:
+		messages = v
+	default:
+		return output, nil
+	}
+
+	result := *gen
+	for i := 0; i < c.maxContinuations && result.FinishReason == core.FinishLength; i++ {
+		followUp := append(append([]core.Message{}, messages...),
+			core.NewAIMessage(tail(result.Text, c.maxResend), nil),
+			core.NewHumanMessage(continuePrompt, nil),
+		)
+		output, err := c.llm.Invoke(ctx, followUp, config)
+		if err != nil {
+			// Keep the answer so far; it is reported as truncated
+			break
+		}
+		next, ok := output.(*core.Generation)
+		if !ok {
+			next = &core.Generation{Text: fmt.Sprint(output), FinishReason: core.FinishStop}
+		}
+
+		result.Text = stitch(result.Text, next.Text, c.maxOverlap)
+		result.PromptTokens += next.PromptTokens
+		result.CompletionTokens += next.CompletionTokens
+		result.Duration += next.Duration
+		result.FinishReason = next.FinishReason
+		result.ToolCalls = append(result.ToolCalls, next.ToolCalls...)
+	}
+	return &result, nil
+}
+
+// tail returns the last n bytes or so of text, starting at a word boundary
+// when there is one
+func tail(text string, n int) string {
+	if n <= 0 || len(text) <= n {
+		return text
+	}
+	start := len(text) - n
+	for start < len(text) && !utf8.RuneStart(text[start]) {
+		start++
+	}
+	if i := strings.IndexAny(text[start:], " \t\n"); i >= 0 && i < n/2 {
+		start += i + 1
+	}
+	return text[start:]
+}
+
+// stitch appends next to prev, dropping the longest prefix of next (up to
+// maxOverlap bytes) that repeats the end of prev
+func stitch(prev, next string, maxOverlap int) string {
+	trimmed := strings.TrimLeft(next, " \t\n")
+	n := maxOverlap
+	if n > len(prev) {
+		n = len(prev)
+	}
+	if n > len(trimmed) {
+		n = len(trimmed)
+	}
+	// Short matches are likely coincidental ("the", a space, ...)
+	for k := n; k >= 8; k-- {
+		if strings.HasSuffix(prev, trimmed[:k]) {
+			return prev + trimmed[k:]
+		}
+	}
+	return prev + next
+}
+
+// Stream generates the whole answer and emits it as a single chunk, since
+// truncation is only known once generation ends
+func (c *ContinuingLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		result, err := c.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		out <- result
+	}()
+	return out, nil
+}
+
+// Batch continues every input in turn
+func (c *ContinuingLLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := c.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the continuing model with another runnable
+func (c *ContinuingLLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{c, other})
+}
+
+// Close closes the wrapped model if it holds resources
+func (c *ContinuingLLM) Close() {
+	if m, ok := c.llm.(ChatModel); ok {
+		m.Close()
+	}
+}
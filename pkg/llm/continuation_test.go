@@ -0,0 +1,181 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// truncating answers with replies in order, each a string or an error.
+// Answers that do not end with a period are reported as cut off by the
+// token limit.
+func truncating(replies ...interface{}) *stubModel {
+	return newStubModel(func(call int, input interface{}) (interface{}, error) {
+		if call >= len(replies) {
+			return nil, fmt.Errorf("no reply left for call %d", call)
+		}
+		if err, ok := replies[call].(error); ok {
+			return nil, err
+		}
+		text := replies[call].(string)
+		gen := &core.Generation{Text: text, FinishReason: core.FinishStop}
+		if !strings.HasSuffix(text, ".") {
+			gen.FinishReason = core.FinishLength
+		}
+		return gen, nil
+	})
+}
+
+func TestStitch(t *testing.T) {
+	tests := []struct {
+		name       string
+		prev, next string
+		want       string
+	}{
+		{
+			name: "no overlap",
+			prev: "The quick brown",
+			next: " fox jumps.",
+			want: "The quick brown fox jumps.",
+		},
+		{
+			name: "repeated end dropped",
+			prev: "The quick brown fox",
+			next: "quick brown fox jumps.",
+			want: "The quick brown fox jumps.",
+		},
+		{
+			name: "repeat after leading whitespace",
+			prev: "The quick brown fox",
+			next: "\n quick brown fox jumps.",
+			want: "The quick brown fox jumps.",
+		},
+		{
+			name: "short coincidental overlap kept",
+			prev: "I saw the",
+			next: "the end.",
+			want: "I saw thethe end.",
+		},
+		{
+			name: "overlap longer than maxOverlap kept",
+			prev: "0123456789abcdefghij",
+			next: "0123456789abcdefghij!",
+			want: "0123456789abcdefghij0123456789abcdefghij!",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stitch(tt.prev, tt.next, 16); got != tt.want {
+				t.Errorf("stitch(%q, %q) = %q, want %q", tt.prev, tt.next, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTail(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{text: "short", n: 10, want: "short"},
+		{text: "anything", n: 0, want: "anything"},
+		{text: "one two three four", n: 12, want: "three four"},
+		{text: "onetwothreefour", n: 4, want: "four"},
+		{text: "café au lait", n: 9, want: "au lait"},
+		{text: "ééééé", n: 5, want: "éé"},
+	}
+
+	for _, tt := range tests {
+		if got := tail(tt.text, tt.n); got != tt.want {
+			t.Errorf("tail(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestContinuingLLM(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("continues until the answer is complete", func(t *testing.T) {
+		model := truncating("Go is a language", "a language designed at Google", " in 2007.")
+		output, err := WithContinuation(model, 3).Invoke(ctx, "What is Go?", nil)
+		if err != nil {
+			t.Fatalf("Invoke() error = %v", err)
+		}
+		gen := output.(*core.Generation)
+		if want := "Go is a language designed at Google in 2007."; gen.Text != want {
+			t.Errorf("Text = %q, want %q", gen.Text, want)
+		}
+		if gen.FinishReason != core.FinishStop {
+			t.Errorf("FinishReason = %v, want %v", gen.FinishReason, core.FinishStop)
+		}
+		if n := len(model.calls()); n != 3 {
+			t.Errorf("model called %d times, want 3", n)
+		}
+	})
+
+	t.Run("resends only the end of the answer", func(t *testing.T) {
+		model := truncating("alpha beta gamma delta", " epsilon.")
+		continuing := WithContinuation(model, 1).WithMaxResend(12)
+		if _, err := continuing.Invoke(ctx, "Greek letters", nil); err != nil {
+			t.Fatalf("Invoke() error = %v", err)
+		}
+		followUp := model.calls()[1].([]core.Message)
+		if len(followUp) != 3 {
+			t.Fatalf("follow-up has %d messages, want 3", len(followUp))
+		}
+		if got := followUp[1].GetContent(); got != "gamma delta" {
+			t.Errorf("resent %q, want %q", got, "gamma delta")
+		}
+		if got := followUp[2].GetContent(); got != continuePrompt {
+			t.Errorf("last message = %q, want the continue prompt", got)
+		}
+	})
+
+	t.Run("still truncated after the last continuation", func(t *testing.T) {
+		model := truncating("one", " two", " three")
+		output, err := WithContinuation(model, 1).Invoke(ctx, "count", nil)
+		if err != nil {
+			t.Fatalf("Invoke() error = %v", err)
+		}
+		gen := output.(*core.Generation)
+		if gen.Text != "one two" || gen.FinishReason != core.FinishLength {
+			t.Errorf("Invoke() = %q (%v), want %q (%v)", gen.Text, gen.FinishReason, "one two", core.FinishLength)
+		}
+	})
+
+	t.Run("failed continuation keeps the partial answer", func(t *testing.T) {
+		model := truncating("partial answer", errors.New("server gone"))
+		output, err := WithContinuation(model, 2).Invoke(ctx, "explain", nil)
+		if err != nil {
+			t.Fatalf("Invoke() error = %v", err)
+		}
+		gen := output.(*core.Generation)
+		if gen.Text != "partial answer" || gen.FinishReason != core.FinishLength {
+			t.Errorf("Invoke() = %q (%v), want %q (%v)", gen.Text, gen.FinishReason, "partial answer", core.FinishLength)
+		}
+		if n := len(model.calls()); n != 2 {
+			t.Errorf("model called %d times, want 2", n)
+		}
+	})
+
+	t.Run("first request failing is an error", func(t *testing.T) {
+		model := truncating(errors.New("server gone"))
+		if _, err := WithContinuation(model, 2).Invoke(ctx, "explain", nil); err == nil {
+			t.Error("Invoke() error = nil, want an error")
+		}
+	})
+}
+
+func TestContinuingLLMClose(t *testing.T) {
+	model := truncating()
+	WithContinuation(model, 1).Close()
+	if n := model.closeCount(); n != 1 {
+		t.Errorf("wrapped model closed %d times, want 1", n)
+	}
+}
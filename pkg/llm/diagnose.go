@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Errors reported when a model cannot be loaded; test for them with
+// errors.Is
+var (
+	// ErrIncompatibleModel means the file is not a GGUF model this build
+	// of llama.cpp can load
+	ErrIncompatibleModel = errors.New("incompatible model")
+	// ErrInsufficientMemory means the model and its context do not fit in
+	// the available memory
+	ErrInsufficientMemory = errors.New("insufficient memory")
+	// ErrLoadTimeout means the model did not finish loading within
+	// LlamaCppConfig.LoadTimeout
+	ErrLoadTimeout = errors.New("model load timed out")
+)
+
+// legacyMagics are the file magics of the pre-GGUF formats
+var legacyMagics = map[string]string{
+	"lmgg": "GGML", "fmgg": "GGMF", "tjgg": "GGJT",
+}
+
+// DiagnoseModel checks that config.ModelPath is a GGUF model llama.cpp can
+// load and that it fits in memory with the configured context, so problems
+// are reported before a load that would hang or be killed. The errors wrap
+// ErrIncompatibleModel or ErrInsufficientMemory; with an OnMemoryWarning
+// callback, a model that may not fit is reported to it instead.
+func DiagnoseModel(config LlamaCppConfig) (*ModelInfo, error) {
+	if err := checkMagic(config.ModelPath); err != nil {
+		return nil, err
+	}
+	info, err := InspectModel(config.ModelPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIncompatibleModel, err)
+	}
+	if info.FileSize > 0 && info.Parameters > 0 && uint64(info.FileSize) < info.Parameters/8 {
+		return nil, fmt.Errorf("%w: %s is %s but declares %s weights; the download is probably incomplete",
+			ErrIncompatibleModel, config.ModelPath, formatBytes(uint64(info.FileSize)), formatParameters(info.Parameters))
+	}
+	if strings.HasPrefix(info.Quantization, "type ") {
+		return nil, fmt.Errorf("%w: quantization %s is newer than the bundled llama.cpp; use the process backend with a recent llama-server",
+			ErrIncompatibleModel, info.Quantization)
+	}
+
+	estimate := estimateMemory(info, info.ContextSize(config.ContextSize), config.GPULayers)
+	if err := estimate.Check(); err != nil {
+		if config.OnMemoryWarning == nil {
+			return info, err
+		}
+		config.OnMemoryWarning(estimate)
+	}
+	return info, nil
+}
+
+// checkMagic reports files that are not GGUF, naming legacy formats
+func checkMagic(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open model: %w", err)
+	}
+	defer f.Close()
+	var magic [4]byte
+	if _, err := io.ReadFull(f, magic[:]); err != nil {
+		return fmt.Errorf("%w: %s is too short to be a model", ErrIncompatibleModel, path)
+	}
+	switch m := string(magic[:]); {
+	case m == "GGUF":
+		return nil
+	case legacyMagics[m] != "":
+		return fmt.Errorf("%w: %s uses the legacy %s format; download a GGUF version of the model",
+			ErrIncompatibleModel, path, legacyMagics[m])
+	default:
+		return fmt.Errorf("%w: %s is not a GGUF file (magic %q)", ErrIncompatibleModel, path, m)
+	}
+}
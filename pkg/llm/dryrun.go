@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+type dryRunKey struct{}
+
+// WithDryRun returns a context in which models return a DryRunResult with
+// the fully assembled prompt instead of generating
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun reports whether ctx requests a dry run
+func IsDryRun(ctx context.Context) bool {
+	dry, _ := ctx.Value(dryRunKey{}).(bool)
+	return dry
+}
+
+// DryRunResult is exactly what a model would have received
+type DryRunResult struct {
+	Model     string `json:"model"`
+	Prompt    string `json:"prompt"`
+	Tokens    int    `json:"tokens"`
+	Estimated bool   `json:"estimated"` // true when Tokens is a heuristic, not the model's tokenizer
+}
+
+// String renders the prompt followed by its token count
+func (r DryRunResult) String() string {
+	approx := ""
+	if r.Estimated {
+		approx = "~"
+	}
+	return fmt.Sprintf("%s\n--- %s: %s%d prompt tokens", r.Prompt, r.Model, approx, r.Tokens)
+}
+
+// EstimateTokens approximates the token count of text (about 4 characters
+// per token for English with common BPE vocabularies)
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// estimatedDryRun builds a DryRunResult for a backend without a local tokenizer
+func estimatedDryRun(model, prompt string) DryRunResult {
+	return DryRunResult{Model: model, Prompt: prompt, Tokens: EstimateTokens(prompt), Estimated: true}
+}
+
+// dryRunStream emits result as the only chunk
+func dryRunStream(result DryRunResult) <-chan interface{} {
+	out := make(chan interface{}, 1)
+	out <- result
+	close(out)
+	return out
+}
+
+// dryRun tokenizes prompt with the loaded model
+func (l *LlamaCppLLM) dryRun(prompt string) DryRunResult {
+	count, _, err := l.model.TokenizeString(prompt)
+	if err != nil {
+		return estimatedDryRun(l.Name(), prompt)
+	}
+	return DryRunResult{Model: l.Name(), Prompt: prompt, Tokens: int(count)}
+}
@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	llama "github.com/go-skynet/go-llama.cpp"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/embeddings"
+)
+
+// LlamaCppEmbedderConfig holds configuration for a GGUF embedding model
+type LlamaCppEmbedderConfig struct {
+	ModelPath   string
+	ContextSize int
+	Threads     int
+	GPULayers   int
+	BatchSize   int
+	// QueryPrefix and DocumentPrefix are prepended for models trained with
+	// instructions, e.g. "query: "/"passage: " for e5 or
+	// "search_query: "/"search_document: " for nomic-embed
+	QueryPrefix    string
+	DocumentPrefix string
+	// Normalize rescales every vector to unit length, so dot product equals
+	// cosine similarity
+	Normalize bool
+}
+
+// LlamaCppEmbedder computes embeddings locally with a GGUF embedding model
+type LlamaCppEmbedder struct {
+	model  *llama.LLama
+	config LlamaCppEmbedderConfig
+	mu     sync.Mutex // the model evaluates one text at a time
+}
+
+var _ embeddings.Embedder = (*LlamaCppEmbedder)(nil)
+
+// NewLlamaCppEmbedder loads an embedding model
+func NewLlamaCppEmbedder(config LlamaCppEmbedderConfig) (*LlamaCppEmbedder, error) {
+	if config.ContextSize == 0 {
+		config.ContextSize = 512
+	}
+	if config.Threads == 0 {
+		config.Threads = 4
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = config.ContextSize
+	}
+
+	if _, err := os.Stat(config.ModelPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("model file not found: %s", config.ModelPath)
+	}
+
+	opts := append(modelOptions(LlamaCppConfig{
+		ContextSize: config.ContextSize,
+		GPULayers:   config.GPULayers,
+		BatchSize:   config.BatchSize,
+	}), llama.EnableEmbeddings)
+	model, err := llama.New(config.ModelPath, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load model: %w", err)
+	}
+	return &LlamaCppEmbedder{model: model, config: config}, nil
+}
+
+// embed computes the vector of a single text
+func (e *LlamaCppEmbedder) embed(text string) ([]float32, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	vec, err := e.model.Embeddings(text, llama.SetThreads(e.config.Threads))
+	if err != nil {
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+	if e.config.Normalize {
+		vec = embeddings.Normalize(vec)
+	}
+	return vec, nil
+}
+
+// EmbedDocuments embeds each document in turn
+func (e *LlamaCppEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		vec, err := e.embed(e.config.DocumentPrefix + text)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = vec
+	}
+	return vectors, nil
+}
+
+// EmbedQuery embeds a search query
+func (e *LlamaCppEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return e.embed(e.config.QueryPrefix + text)
+}
+
+// Close releases model resources
+func (e *LlamaCppEmbedder) Close() {
+	if e.model != nil {
+		e.model.Free()
+	}
+}
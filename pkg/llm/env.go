@@ -0,0 +1,320 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Backend names accepted by ConfigFromEnv and NewChatModel
+const (
+	BackendLlamaCpp  = "llamacpp"
+	BackendTGI       = "tgi"
+	BackendLlamafile = "llamafile"
+	BackendOpenAI    = "openai"
+	BackendProcess   = "process"
+	BackendServer    = "llama-server"
+)
+
+// BackendConfig selects a backend and holds the settings for each kind
+type BackendConfig struct {
+	Backend  string
+	LlamaCpp LlamaCppConfig
+	HTTP     HTTPBackendConfig
+	// ServerBinary is the llama-server or llamafile executable of the
+	// process backend, which uses the llamacpp model settings;
+	// ServerDownload fetches llama-server when none is installed
+	ServerBinary   string
+	ServerDownload bool
+	// CacheDir, when set, caches completions on disk for CacheTTL (0 =
+	// forever), so re-running a program does not regenerate them. They are
+	// kept in a BoltCache, which one process can open at a time.
+	CacheDir string
+	CacheTTL time.Duration
+	// RequestsPerMinute and TokensPerMinute rate-limit the backend, e.g.
+	// to the quota of a hosted endpoint (0 = unlimited)
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// ConfigFromEnv reads the LLM configuration from environment variables named
+// <PREFIX>_<OPTION>, e.g. with prefix "AGENT":
+//
+//	AGENT_BACKEND        llamacpp (default), tgi, llamafile, openai, process or llama-server
+//	AGENT_MODEL_PATH     GGUF file for llamacpp and process
+//	AGENT_SERVER_BINARY  llama-server or llamafile for process (default: found on PATH)
+//	AGENT_SERVER_DOWNLOAD download the latest llama-server release when none is found
+//	AGENT_CACHE_DIR      cache completions in this directory   AGENT_CACHE_TTL (e.g. 24h)
+//	AGENT_REQUESTS_PER_MINUTE   AGENT_TOKENS_PER_MINUTE   rate limits (default: none)
+//	AGENT_CONTEXT_SIZE   AGENT_THREADS   AGENT_GPU_LAYERS   AGENT_SESSION_DIR
+//	AGENT_MAIN_GPU       AGENT_TENSOR_SPLIT   AGENT_MMAP   AGENT_MLOCK   AGENT_BATCH_SIZE
+//	AGENT_LOAD_TIMEOUT   give up loading a llamacpp model after this long (default 10m)
+//	AGENT_MEMORY_WARN    load a llamacpp model that may not fit in memory with a warning instead of refusing it
+//	AGENT_BASE_URL       server URL for tgi, llamafile, openai and llama-server
+//	AGENT_MODEL          AGENT_API_KEY   AGENT_MAX_TOKENS   AGENT_TIMEOUT (e.g. 90s)
+//	AGENT_TEMPERATURE    AGENT_TOP_P   AGENT_TOP_K   AGENT_STOP (comma-separated)
+//	AGENT_SYSTEM_PROMPT  AGENT_CHAT_TEMPLATE (plain, chatml, llama3, mistral)
+//	AGENT_LORA_PATH      AGENT_LORA_BASE   AGENT_LORA_SCALE
+//	AGENT_MIROSTAT       AGENT_MIROSTAT_TAU   AGENT_MIROSTAT_ETA   AGENT_TYPICAL_P   AGENT_TFS_Z
+//	AGENT_REPEAT_PENALTY AGENT_REPEAT_LAST_N   AGENT_FREQUENCY_PENALTY   AGENT_PRESENCE_PENALTY
+//
+// Unset variables keep the backend defaults. All invalid values are reported
+// together.
+func ConfigFromEnv(prefix string) (BackendConfig, error) {
+	env := envReader{prefix: strings.TrimSuffix(prefix, "_")}
+
+	cfg := BackendConfig{Backend: strings.ToLower(env.str("BACKEND"))}
+	if cfg.Backend == "" {
+		cfg.Backend = BackendLlamaCpp
+	}
+	switch cfg.Backend {
+	case BackendLlamaCpp, BackendTGI, BackendLlamafile, BackendOpenAI, BackendProcess, BackendServer:
+	default:
+		env.fail("BACKEND", fmt.Errorf("unknown backend %q", cfg.Backend))
+	}
+
+	temperature := env.number("TEMPERATURE", 0, 2)
+	topP := env.number("TOP_P", 0, 1)
+	topK := env.integer("TOP_K", 0)
+	systemPrompt := env.str("SYSTEM_PROMPT")
+	stop := env.list("STOP")
+	template := strings.ToLower(env.str("CHAT_TEMPLATE"))
+	if _, err := FormatterFor(template); err != nil {
+		env.fail("CHAT_TEMPLATE", err)
+	}
+
+	cfg.LlamaCpp = LlamaCppConfig{
+		ModelPath:        env.str("MODEL_PATH"),
+		ContextSize:      env.integer("CONTEXT_SIZE", 0),
+		MaxTokens:        env.integer("MAX_TOKENS", 0),
+		Temperature:      temperature,
+		TopP:             topP,
+		TopK:             topK,
+		Threads:          env.integer("THREADS", 0),
+		SystemPrompt:     systemPrompt,
+		SessionDir:       env.str("SESSION_DIR"),
+		GPULayers:        env.integer("GPU_LAYERS", 0),
+		MainGPU:          env.str("MAIN_GPU"),
+		TensorSplit:      env.str("TENSOR_SPLIT"),
+		UseMMap:          env.boolean("MMAP"),
+		BatchSize:        env.integer("BATCH_SIZE", 0),
+		LoadTimeout:      env.duration("LOAD_TIMEOUT"),
+		Stop:             stop,
+		ChatTemplate:     template,
+		LoraPath:         env.str("LORA_PATH"),
+		LoraBase:         env.str("LORA_BASE"),
+		LoraScale:        env.number("LORA_SCALE", 0, 1),
+		Mirostat:         env.integer("MIROSTAT", 0),
+		MirostatTau:      env.number("MIROSTAT_TAU", 0, 20),
+		MirostatEta:      env.number("MIROSTAT_ETA", 0, 1),
+		TypicalP:         env.number("TYPICAL_P", 0, 1),
+		TailFreeZ:        env.number("TFS_Z", 0, 1),
+		RepeatPenalty:    env.number("REPEAT_PENALTY", 0, 10),
+		RepeatLastN:      env.integer("REPEAT_LAST_N", -1),
+		FrequencyPenalty: env.number("FREQUENCY_PENALTY", -2, 2),
+		PresencePenalty:  env.number("PRESENCE_PENALTY", -2, 2),
+	}
+	if mlock := env.boolean("MLOCK"); mlock != nil {
+		cfg.LlamaCpp.UseMLock = *mlock
+	}
+	if warn := env.boolean("MEMORY_WARN"); warn != nil && *warn {
+		cfg.LlamaCpp.OnMemoryWarning = WarnMemory
+	}
+	cfg.HTTP = HTTPBackendConfig{
+		BaseURL:      env.str("BASE_URL"),
+		APIKey:       env.str("API_KEY"),
+		Model:        env.str("MODEL"),
+		MaxTokens:    env.integer("MAX_TOKENS", 0),
+		Temperature:  temperature,
+		TopP:         topP,
+		TopK:         topK,
+		Stop:         stop,
+		SystemPrompt: systemPrompt,
+		Timeout:      env.duration("TIMEOUT"),
+		ChatTemplate: template,
+	}
+	cfg.ServerBinary = env.str("SERVER_BINARY")
+	if download := env.boolean("SERVER_DOWNLOAD"); download != nil {
+		cfg.ServerDownload = *download
+	}
+	cfg.CacheDir = env.str("CACHE_DIR")
+	cfg.CacheTTL = env.duration("CACHE_TTL")
+	cfg.RequestsPerMinute = env.integer("REQUESTS_PER_MINUTE", 0)
+	cfg.TokensPerMinute = env.integer("TOKENS_PER_MINUTE", 0)
+
+	switch cfg.Backend {
+	case BackendLlamaCpp, BackendProcess:
+		if cfg.LlamaCpp.ModelPath == "" {
+			env.fail("MODEL_PATH", fmt.Errorf("required for the %s backend", cfg.Backend))
+		}
+	case BackendTGI, BackendLlamafile, BackendOpenAI, BackendServer:
+		if cfg.HTTP.BaseURL == "" {
+			env.fail("BASE_URL", fmt.Errorf("required for the %s backend", cfg.Backend))
+		}
+	}
+
+	if len(env.errs) > 0 {
+		return BackendConfig{}, fmt.Errorf("invalid LLM environment: %w", errors.Join(env.errs...))
+	}
+	return cfg, nil
+}
+
+// NewChatModel creates the backend selected by cfg, wrapped in a rate
+// limiter when limits are set and in a response cache when cfg.CacheDir
+// is set
+func NewChatModel(cfg BackendConfig) (ChatModel, error) {
+	model, err := newBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.RequestsPerMinute > 0 || cfg.TokensPerMinute > 0 {
+		limited := WithRateLimit(model, NewRateLimiter(cfg.RequestsPerMinute, cfg.TokensPerMinute))
+		if cfg.HTTP.MaxTokens > 0 {
+			limited.WithCompletionEstimate(cfg.HTTP.MaxTokens)
+		}
+		model = limited
+	}
+	if cfg.CacheDir == "" {
+		return model, nil
+	}
+	cache, err := NewBoltCache(filepath.Join(cfg.CacheDir, "llm-cache.db"), cfg.CacheTTL)
+	if err != nil {
+		model.Close()
+		return nil, err
+	}
+	cached := WithCache(model, cache)
+	cached.ownsBackend = true
+	return cached, nil
+}
+
+// newBackend creates the backend selected by cfg
+func newBackend(cfg BackendConfig) (ChatModel, error) {
+	switch cfg.Backend {
+	case BackendLlamaCpp, "":
+		l, err := NewLlamaCppLLM(cfg.LlamaCpp)
+		if err != nil {
+			return nil, err
+		}
+		return l, nil
+	case BackendTGI:
+		return NewTGILLM(cfg.HTTP), nil
+	case BackendLlamafile:
+		return NewLlamafileLLM(cfg.HTTP), nil
+	case BackendOpenAI:
+		return NewOpenAIChatLLM(cfg.HTTP), nil
+	case BackendServer:
+		return NewLlamaServerLLM(cfg.HTTP), nil
+	case BackendProcess:
+		p, err := NewProcessLLM(ProcessConfig{
+			Binary:      cfg.ServerBinary,
+			ModelPath:   cfg.LlamaCpp.ModelPath,
+			ContextSize: cfg.LlamaCpp.ContextSize,
+			Threads:     cfg.LlamaCpp.Threads,
+			GPULayers:   cfg.LlamaCpp.GPULayers,
+			Download:    cfg.ServerDownload,
+			HTTP:        cfg.HTTP,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q", cfg.Backend)
+	}
+}
+
+// envReader reads prefixed variables and collects parse errors
+type envReader struct {
+	prefix string
+	errs   []error
+}
+
+func (e *envReader) name(key string) string {
+	if e.prefix == "" {
+		return key
+	}
+	return e.prefix + "_" + key
+}
+
+func (e *envReader) fail(key string, err error) {
+	e.errs = append(e.errs, fmt.Errorf("%s: %w", e.name(key), err))
+}
+
+func (e *envReader) str(key string) string {
+	return strings.TrimSpace(os.Getenv(e.name(key)))
+}
+
+func (e *envReader) integer(key string, min int) int {
+	s := e.str(key)
+	if s == "" {
+		return 0
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		e.fail(key, err)
+		return 0
+	}
+	if v < min {
+		e.fail(key, fmt.Errorf("must be at least %d, got %d", min, v))
+		return 0
+	}
+	return v
+}
+
+func (e *envReader) number(key string, min, max float64) float32 {
+	s := e.str(key)
+	if s == "" {
+		return 0
+	}
+	v, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		e.fail(key, err)
+		return 0
+	}
+	if v < min || v > max {
+		e.fail(key, fmt.Errorf("must be between %g and %g, got %g", min, max, v))
+		return 0
+	}
+	return float32(v)
+}
+
+// boolean returns nil when the variable is unset
+func (e *envReader) boolean(key string) *bool {
+	s := e.str(key)
+	if s == "" {
+		return nil
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		e.fail(key, err)
+		return nil
+	}
+	return &v
+}
+
+func (e *envReader) duration(key string) time.Duration {
+	s := e.str(key)
+	if s == "" {
+		return 0
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		e.fail(key, err)
+		return 0
+	}
+	return v
+}
+
+func (e *envReader) list(key string) []string {
+	var out []string
+	for _, item := range strings.Split(e.str(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
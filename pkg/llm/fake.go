@@ -0,0 +1,321 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// FakeResponse is one scripted answer of a FakeLLM
+type FakeResponse struct {
+	Text      string
+	ToolCalls []core.ToolCall
+	// Err makes the call fail instead of answering
+	Err error
+	// Delay is added to the FakeLLM latency for this answer
+	Delay time.Duration
+}
+
+// FakeCall records one request made to a FakeLLM
+type FakeCall struct {
+	Input  interface{}
+	Prompt string
+	Config *core.Config
+	Time   time.Time
+}
+
+// fakeRule answers prompts containing a substring
+type fakeRule struct {
+	contains string
+	response FakeResponse
+}
+
+// FakeLLM is a scripted model for testing agents and chains without
+// loading a GGUF. It answers with its scripted responses in order, or with
+// the first rule matching the prompt, records every call, and can simulate
+// latency, tool calls and failures. It is safe for concurrent use.
+//
+//	model := llm.NewFakeLLM("Thought: I should add.").
+//		RespondWithToolCall("calculator", map[string]interface{}{"expression": "2+2"}).
+//		Respond("The answer is 4.")
+type FakeLLM struct {
+	*core.BaseRunnable
+
+	mu         sync.Mutex
+	script     []FakeResponse
+	rules      []fakeRule
+	fallback   *FakeResponse
+	latency    time.Duration
+	tokenDelay time.Duration
+	calls      []FakeCall
+	toolCalls  int
+}
+
+var _ ChatModel = (*FakeLLM)(nil)
+
+// NewFakeLLM creates a fake model answering with responses in order
+func NewFakeLLM(responses ...string) *FakeLLM {
+	f := &FakeLLM{BaseRunnable: core.NewBaseRunnable("FakeLLM")}
+	for _, text := range responses {
+		f.Respond(text)
+	}
+	return f
+}
+
+// Respond appends a text answer to the script
+func (f *FakeLLM) Respond(text string) *FakeLLM {
+	return f.RespondWith(FakeResponse{Text: text})
+}
+
+// RespondWithToolCall appends an answer calling tool name with args
+func (f *FakeLLM) RespondWithToolCall(name string, args map[string]interface{}) *FakeLLM {
+	return f.RespondWith(FakeResponse{ToolCalls: []core.ToolCall{f.toolCall(name, args)}})
+}
+
+// RespondWithError appends a failing call to the script
+func (f *FakeLLM) RespondWithError(err error) *FakeLLM {
+	return f.RespondWith(FakeResponse{Err: err})
+}
+
+// RespondWith appends any scripted answer
+func (f *FakeLLM) RespondWith(response FakeResponse) *FakeLLM {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.script = append(f.script, response)
+	return f
+}
+
+// When answers prompts containing substring with text, before the script
+// is consulted; rules are tried in the order they were added
+func (f *FakeLLM) When(substring, text string) *FakeLLM {
+	return f.WhenRespondWith(substring, FakeResponse{Text: text})
+}
+
+// WhenRespondWith answers prompts containing substring with response
+func (f *FakeLLM) WhenRespondWith(substring string, response FakeResponse) *FakeLLM {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, fakeRule{contains: substring, response: response})
+	return f
+}
+
+// WithDefault sets the answer once the script is used up; without one,
+// further calls fail
+func (f *FakeLLM) WithDefault(text string) *FakeLLM {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fallback = &FakeResponse{Text: text}
+	return f
+}
+
+// WithLatency delays every answer by d, e.g. to test timeouts
+func (f *FakeLLM) WithLatency(d time.Duration) *FakeLLM {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.latency = d
+	return f
+}
+
+// WithTokenDelay sets the pause between streamed chunks
+func (f *FakeLLM) WithTokenDelay(d time.Duration) *FakeLLM {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tokenDelay = d
+	return f
+}
+
+// toolCall builds a tool call with a sequential ID
+func (f *FakeLLM) toolCall(name string, args map[string]interface{}) core.ToolCall {
+	f.mu.Lock()
+	f.toolCalls++
+	id := fmt.Sprintf("call_%d", f.toolCalls)
+	f.mu.Unlock()
+	arguments, _ := json.Marshal(args)
+	return core.ToolCall{
+		ID:       id,
+		Type:     "function",
+		Function: core.ToolCallFunction{Name: name, Arguments: string(arguments)},
+		Args:     args,
+	}
+}
+
+// Calls returns the requests made so far, oldest first
+func (f *FakeLLM) Calls() []FakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeCall(nil), f.calls...)
+}
+
+// CallCount returns how many requests were made
+func (f *FakeLLM) CallCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.calls)
+}
+
+// LastPrompt returns the prompt of the latest request, or ""
+func (f *FakeLLM) LastPrompt() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.calls) == 0 {
+		return ""
+	}
+	return f.calls[len(f.calls)-1].Prompt
+}
+
+// Remaining returns how many scripted answers are left
+func (f *FakeLLM) Remaining() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.script)
+}
+
+// Reset forgets the recorded calls
+func (f *FakeLLM) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+// next records the call and picks its answer
+func (f *FakeLLM) next(input interface{}, config *core.Config) (FakeResponse, time.Duration, error) {
+	prompt := promptText(input)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, FakeCall{Input: input, Prompt: prompt, Config: config, Time: time.Now()})
+
+	for _, rule := range f.rules {
+		if strings.Contains(prompt, rule.contains) {
+			return rule.response, f.latency + rule.response.Delay, nil
+		}
+	}
+	if len(f.script) > 0 {
+		response := f.script[0]
+		f.script = f.script[1:]
+		return response, f.latency + response.Delay, nil
+	}
+	if f.fallback != nil {
+		return *f.fallback, f.latency, nil
+	}
+	return FakeResponse{}, 0, fmt.Errorf("fake model has no response left for call %d", len(f.calls))
+}
+
+// sleep waits for d unless ctx is done first
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Invoke returns the next answer as a *core.Generation
+func (f *FakeLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	if IsDryRun(ctx) {
+		return estimatedDryRun(f.Name(), promptText(input)), nil
+	}
+	response, delay, err := f.next(input, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := sleep(ctx, delay); err != nil {
+		return nil, err
+	}
+	if response.Err != nil {
+		return nil, response.Err
+	}
+
+	gen := &core.Generation{
+		Text:             response.Text,
+		Model:            "fake",
+		PromptTokens:     EstimateTokens(promptText(input)),
+		CompletionTokens: EstimateTokens(response.Text),
+		Duration:         delay,
+		FinishReason:     core.FinishStop,
+		ToolCalls:        response.ToolCalls,
+	}
+	if len(response.ToolCalls) > 0 {
+		gen.FinishReason = core.FinishToolCalls
+	}
+	return gen, nil
+}
+
+// Stream emits the text of the next answer word by word. Tool calls are
+// not streamed; a scripted error is sent as the last chunk.
+func (f *FakeLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	if IsDryRun(ctx) {
+		return dryRunStream(estimatedDryRun(f.Name(), promptText(input))), nil
+	}
+	response, delay, err := f.next(input, config)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	tokenDelay := f.tokenDelay
+	f.mu.Unlock()
+
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		send := func(chunk interface{}) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		if err := sleep(ctx, delay); err != nil {
+			send(err)
+			return
+		}
+		if response.Err != nil {
+			send(response.Err)
+			return
+		}
+		for i, word := range strings.SplitAfter(response.Text, " ") {
+			if i > 0 {
+				if err := sleep(ctx, tokenDelay); err != nil {
+					send(err)
+					return
+				}
+			}
+			if word != "" && !send(word) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Batch answers every input in turn
+func (f *FakeLLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := f.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the fake model with another runnable
+func (f *FakeLLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{f, other})
+}
+
+// Close does nothing; FakeLLM holds no resources
+func (f *FakeLLM) Close() {}
@@ -0,0 +1,240 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// FallbackLLM answers with the first of several backends that succeeds,
+// e.g. a local model first and a remote endpoint when it runs out of
+// memory. Each attempt is reported to the config's callbacks (OnStart, then
+// OnEnd for the backend that served the request or OnError for one that
+// failed), and moving to the next backend publishes core.ModelSwapped.
+type FallbackLLM struct {
+	*core.BaseRunnable
+	llms     []core.Runnable
+	timeout  time.Duration
+	fallback func(error) bool
+}
+
+var _ ChatModel = (*FallbackLLM)(nil)
+
+// WithFallbacks wraps primary so that fallbacks are tried in order when it
+// fails
+func WithFallbacks(primary core.Runnable, fallbacks ...core.Runnable) *FallbackLLM {
+	return &FallbackLLM{
+		BaseRunnable: core.NewBaseRunnable("Fallback"),
+		llms:         append([]core.Runnable{primary}, fallbacks...),
+		fallback:     func(error) bool { return true },
+	}
+}
+
+// WithAttemptTimeout bounds each backend: Invoke must return, and Stream
+// must produce its first chunk, within d before the next backend is tried
+func (f *FallbackLLM) WithAttemptTimeout(d time.Duration) *FallbackLLM {
+	f.timeout = d
+	return f
+}
+
+// WithFallbackOn sets which errors move on to the next backend; others are
+// returned right away. By default every error does.
+func (f *FallbackLLM) WithFallbackOn(shouldFallback func(error) bool) *FallbackLLM {
+	f.fallback = shouldFallback
+	return f
+}
+
+// callbacks returns the callback manager of config
+func callbacks(config *core.Config) *core.CallbackManager {
+	if config == nil {
+		return core.NewCallbackManager(nil)
+	}
+	return core.NewCallbackManager(config.Callbacks)
+}
+
+// next reports whether to try the backend after index i following err,
+// and announces the swap if so. Callbacks only observe: their errors do
+// not change which backend answers.
+func (f *FallbackLLM) next(ctx context.Context, i int, err error) bool {
+	if ctx.Err() != nil || i+1 >= len(f.llms) || !f.fallback(err) {
+		return false
+	}
+	core.Emit(ctx, core.ModelSwapped{
+		From:   f.llms[i].Name(),
+		To:     f.llms[i+1].Name(),
+		Reason: err.Error(),
+		Time:   time.Now(),
+	})
+	return true
+}
+
+// Invoke tries each backend in turn until one answers
+func (f *FallbackLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	cm := callbacks(config)
+	var errs []error
+	for i, llm := range f.llms {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if f.timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, f.timeout)
+		}
+		cm.HandleStart(ctx, llm, input)
+		output, err := llm.Invoke(attemptCtx, input, config)
+		timedOut := errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+		cancel()
+		if err == nil {
+			cm.HandleEnd(ctx, llm, output)
+			return output, nil
+		}
+
+		if timedOut {
+			err = fmt.Errorf("no answer within %s: %w", f.timeout, err)
+		}
+		err = fmt.Errorf("%s: %w", llm.Name(), err)
+		cm.HandleError(ctx, llm, err)
+		errs = append(errs, err)
+		if !f.next(ctx, i, err) {
+			break
+		}
+	}
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+	return nil, fmt.Errorf("all backends failed: %w", errors.Join(errs...))
+}
+
+// Stream streams from the first backend that produces output. A backend
+// that fails before its first chunk is replaced by the next one; once
+// chunks were emitted, a later error is forwarded as-is, since switching
+// backends mid-answer would repeat or garble text.
+func (f *FallbackLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		cm := callbacks(config)
+		var errs []error
+		for i, llm := range f.llms {
+			started, err := f.streamFrom(ctx, llm, input, config, cm, out)
+			if started {
+				return
+			}
+			err = fmt.Errorf("%s: %w", llm.Name(), err)
+			cm.HandleError(ctx, llm, err)
+			errs = append(errs, err)
+			if !f.next(ctx, i, err) {
+				break
+			}
+		}
+		err := errs[0]
+		if len(errs) > 1 {
+			err = fmt.Errorf("all backends failed: %w", errors.Join(errs...))
+		}
+		select {
+		case out <- err:
+		case <-ctx.Done():
+		}
+	}()
+	return out, nil
+}
+
+// streamFrom forwards the stream of one backend to out and reports whether
+// it started; when it did not, err says why
+func (f *FallbackLLM) streamFrom(ctx context.Context, llm core.Runnable, input interface{}, config *core.Config, cm *core.CallbackManager, out chan<- interface{}) (bool, error) {
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var timedOut atomic.Bool
+	var timer *time.Timer
+	if f.timeout > 0 {
+		timer = time.AfterFunc(f.timeout, func() {
+			timedOut.Store(true)
+			cancel()
+		})
+		defer timer.Stop()
+	}
+	// failed explains an attempt that ended before its first chunk
+	failed := func(err error) error {
+		if timedOut.Load() {
+			return fmt.Errorf("no output within %s", f.timeout)
+		}
+		return err
+	}
+
+	cm.HandleStart(ctx, llm, input)
+	chunks, err := llm.Stream(attemptCtx, input, config)
+	if err != nil {
+		return false, failed(err)
+	}
+	// Drain what is left if we stop reading early, so the producer exits
+	defer func() {
+		go func() {
+			for range chunks {
+			}
+		}()
+	}()
+
+	var text strings.Builder
+	started := false
+	for chunk := range chunks {
+		if err, ok := chunk.(error); ok {
+			if !started {
+				return false, failed(err)
+			}
+			cm.HandleError(ctx, llm, err)
+			select {
+			case out <- err:
+			case <-ctx.Done():
+			}
+			return true, err
+		}
+		if !started {
+			if timer != nil && !timer.Stop() {
+				return false, failed(ctx.Err())
+			}
+			started = true
+		}
+		if s, ok := chunk.(string); ok {
+			text.WriteString(s)
+		}
+		select {
+		case out <- chunk:
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+	if !started && timedOut.Load() {
+		return false, failed(nil)
+	}
+	cm.HandleEnd(ctx, llm, text.String())
+	return true, nil
+}
+
+// Batch answers every input in turn, each with its own fallbacks
+func (f *FallbackLLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := f.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the fallback chain with another runnable
+func (f *FallbackLLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{f, other})
+}
+
+// Close closes every backend that holds resources
+func (f *FallbackLLM) Close() {
+	for _, llm := range f.llms {
+		if m, ok := llm.(ChatModel); ok {
+			m.Close()
+		}
+	}
+}
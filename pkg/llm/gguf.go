@@ -0,0 +1,223 @@
+package llm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// GGUF metadata value types
+const (
+	ggufUint8 uint32 = iota
+	ggufInt8
+	ggufUint16
+	ggufInt16
+	ggufUint32
+	ggufInt32
+	ggufFloat32
+	ggufBool
+	ggufString
+	ggufArray
+	ggufUint64
+	ggufInt64
+	ggufFloat64
+)
+
+// maxGGUFArray is the largest array kept in the metadata; longer ones, such
+// as the tokenizer vocabulary, are skipped
+const maxGGUFArray = 1024
+
+// ReadGGUFMetadata reads the key/value metadata from the header of a GGUF
+// model file (version 2 or later) without loading any tensor data. Numbers
+// are returned as uint64, int64 or float64; arrays longer than 1024
+// elements are left out.
+func ReadGGUFMetadata(path string) (map[string]interface{}, error) {
+	header, err := readGGUFHeader(path, false)
+	if err != nil {
+		return nil, err
+	}
+	return header.metadata, nil
+}
+
+// ggufHeader is the decoded header of a GGUF file
+type ggufHeader struct {
+	metadata map[string]interface{}
+	// arrayLens holds the length of every array, including skipped ones
+	arrayLens map[string]uint64
+	// elements is the number of weights per tensor, read only on request
+	elements []uint64
+}
+
+// readGGUFHeader reads the metadata and, with tensors, the tensor shapes
+func readGGUFHeader(path string, tensors bool) (*ggufHeader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open model: %w", err)
+	}
+	defer f.Close()
+
+	r := &ggufReader{r: bufio.NewReader(f)}
+	var magic [4]byte
+	r.read(&magic)
+	if r.err == nil && string(magic[:]) != "GGUF" {
+		return nil, fmt.Errorf("%s is not a GGUF file", path)
+	}
+	version := r.u32()
+	if r.err == nil && version < 2 {
+		return nil, fmt.Errorf("unsupported GGUF version %d", version)
+	}
+	tensorCount := r.u64()
+	count := r.u64()
+
+	header := &ggufHeader{
+		metadata:  make(map[string]interface{}),
+		arrayLens: make(map[string]uint64),
+	}
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		key := r.str()
+		var value interface{}
+		if t := r.u32(); t == ggufArray {
+			elem, n := r.u32(), r.u64()
+			header.arrayLens[key] = n
+			value = r.array(elem, n)
+		} else {
+			value = r.value(t)
+		}
+		if value != nil {
+			header.metadata[key] = value
+		}
+	}
+
+	// Tensor infos follow the metadata: name, dimensions, type and offset
+	for i := uint64(0); tensors && i < tensorCount && r.err == nil; i++ {
+		r.str()
+		dims := r.u32()
+		if dims > 8 {
+			return nil, fmt.Errorf("tensor %d has %d dimensions", i, dims)
+		}
+		n := uint64(1)
+		for d := uint32(0); d < dims; d++ {
+			n *= r.u64()
+		}
+		r.u32() // type
+		r.u64() // offset
+		header.elements = append(header.elements, n)
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("failed to read GGUF metadata: %w", r.err)
+	}
+	return header, nil
+}
+
+// ggufReader decodes little-endian GGUF values, keeping the first error
+type ggufReader struct {
+	r   io.Reader
+	err error
+}
+
+func (g *ggufReader) read(v interface{}) {
+	if g.err == nil {
+		g.err = binary.Read(g.r, binary.LittleEndian, v)
+	}
+}
+
+func (g *ggufReader) u32() uint32 {
+	var v uint32
+	g.read(&v)
+	return v
+}
+
+func (g *ggufReader) u64() uint64 {
+	var v uint64
+	g.read(&v)
+	return v
+}
+
+func (g *ggufReader) str() string {
+	n := g.u64()
+	if g.err != nil {
+		return ""
+	}
+	if n > 1<<24 {
+		g.err = fmt.Errorf("string length %d out of range", n)
+		return ""
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(g.r, buf); err != nil {
+		g.err = err
+	}
+	return string(buf)
+}
+
+// value reads one value of type t
+func (g *ggufReader) value(t uint32) interface{} {
+	switch t {
+	case ggufUint8:
+		var v uint8
+		g.read(&v)
+		return uint64(v)
+	case ggufInt8:
+		var v int8
+		g.read(&v)
+		return int64(v)
+	case ggufUint16:
+		var v uint16
+		g.read(&v)
+		return uint64(v)
+	case ggufInt16:
+		var v int16
+		g.read(&v)
+		return int64(v)
+	case ggufUint32:
+		return uint64(g.u32())
+	case ggufInt32:
+		var v int32
+		g.read(&v)
+		return int64(v)
+	case ggufFloat32:
+		return float64(math.Float32frombits(g.u32()))
+	case ggufBool:
+		var v uint8
+		g.read(&v)
+		return v != 0
+	case ggufString:
+		return g.str()
+	case ggufUint64:
+		return g.u64()
+	case ggufInt64:
+		var v int64
+		g.read(&v)
+		return v
+	case ggufFloat64:
+		return math.Float64frombits(g.u64())
+	case ggufArray:
+		return g.array(g.u32(), g.u64())
+	default:
+		if g.err == nil {
+			g.err = fmt.Errorf("unknown GGUF value type %d", t)
+		}
+		return nil
+	}
+}
+
+// array reads n values of type elem, returning nil for arrays longer than
+// maxGGUFArray
+func (g *ggufReader) array(elem uint32, n uint64) interface{} {
+	var values []interface{}
+	if n <= maxGGUFArray {
+		values = make([]interface{}, 0, n)
+	}
+	for i := uint64(0); i < n && g.err == nil; i++ {
+		v := g.value(elem)
+		if values != nil {
+			values = append(values, v)
+		}
+	}
+	if values == nil {
+		return nil
+	}
+	return values
+}
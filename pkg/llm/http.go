@@ -0,0 +1,187 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPBackendConfig configures an LLM served over HTTP
+type HTTPBackendConfig struct {
+	BaseURL      string // e.g. http://localhost:8080
+	APIKey       string
+	Model        string // model name, for servers hosting several
+	MaxTokens    int
+	Temperature  float32
+	TopP         float32
+	TopK         int
+	Stop         []string
+	SystemPrompt string
+	Timeout      time.Duration // for non-streaming requests
+	// ChatTemplate is the prompt layout of completion backends (TGI,
+	// llamafile): "plain" (default), "chatml", "llama3" or "mistral"
+	ChatTemplate string
+}
+
+// withDefaults fills in the sampling defaults used by LlamaCppLLM
+func (c HTTPBackendConfig) withDefaults() HTTPBackendConfig {
+	if c.MaxTokens == 0 {
+		c.MaxTokens = 512
+	}
+	if c.Temperature == 0 {
+		c.Temperature = 0.7
+	}
+	if c.TopP == 0 {
+		c.TopP = 0.9
+	}
+	if c.TopK == 0 {
+		c.TopK = 40
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 2 * time.Minute
+	}
+	return c
+}
+
+// completionPrompt renders input with the configured chat template and
+// returns it with the stop sequences, including the template's end-of-turn
+// markers
+func (c HTTPBackendConfig) completionPrompt(input interface{}) (string, []string, error) {
+	formatter, err := FormatterFor(c.ChatTemplate)
+	if err != nil {
+		return "", nil, err
+	}
+	prompt, err := buildPrompt(input, c.SystemPrompt, formatter)
+	if err != nil {
+		return "", nil, err
+	}
+	return prompt, append(append([]string{}, c.Stop...), formatter.Stop()...), nil
+}
+
+// post sends a JSON request and returns the response once the status is OK.
+// Streaming requests rely on ctx alone, since a client timeout would cut
+// long generations short.
+func (c HTTPBackendConfig) post(ctx context.Context, path string, body interface{}, stream bool) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.BaseURL, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	client := &http.Client{}
+	if !stream {
+		client.Timeout = c.Timeout
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("backend request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		statusErr := &StatusError{Code: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(msg))}
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			statusErr.RetryAfter = time.Duration(secs) * time.Second
+		}
+		return nil, statusErr
+	}
+	return resp, nil
+}
+
+// StatusError is returned when an HTTP backend answers with an error status
+type StatusError struct {
+	Code   int
+	Status string
+	Body   string
+	// RetryAfter is the delay the server asked for, if any
+	RetryAfter time.Duration
+}
+
+// Error reports the status and the start of the response body
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("backend returned %s: %s", e.Status, e.Body)
+}
+
+// postJSON sends a JSON request and decodes the JSON response
+func (c HTTPBackendConfig) postJSON(ctx context.Context, path string, body interface{}, out interface{}) error {
+	resp, err := c.post(ctx, path, body, false)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// readEvents calls fn with the data of each server-sent event until the
+// stream ends or fn returns false
+func readEvents(r io.Reader, fn func(data []byte) (bool, error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" || data == "[DONE]" {
+			continue
+		}
+		more, err := fn([]byte(data))
+		if err != nil || !more {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// streamEvents posts a streaming request and forwards the token extracted
+// from each event by token to out. token returns false once generation is done.
+func (c HTTPBackendConfig) streamEvents(ctx context.Context, path string, body interface{}, token func(data []byte) (string, bool, error)) (<-chan interface{}, error) {
+	resp, err := c.post(ctx, path, body, true)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan interface{}, 10)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+
+		err := readEvents(resp.Body, func(data []byte) (bool, error) {
+			text, more, err := token(data)
+			if err != nil {
+				return false, err
+			}
+			if text != "" {
+				select {
+				case <-ctx.Done():
+					return false, ctx.Err()
+				case out <- text:
+				}
+			}
+			return more, nil
+		})
+		if err != nil {
+			out <- fmt.Errorf("streaming failed: %w", err)
+		}
+	}()
+	return out, nil
+}
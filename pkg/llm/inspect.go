@@ -0,0 +1,140 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+)
+
+// ModelInfo describes a GGUF model, as read from its header
+type ModelInfo struct {
+	Path         string `json:"path"`
+	Name         string `json:"name,omitempty"`
+	Architecture string `json:"architecture"`
+	// Parameters is the number of weights, summed over all tensors
+	Parameters uint64 `json:"parameters"`
+	// Quantization is the file type, e.g. "Q4_K_M" or "F16"
+	Quantization string `json:"quantization"`
+	// ContextLength is the context size the model was trained with
+	ContextLength int `json:"context_length"`
+	// ChatTemplate is the matching built-in template ("plain" when the
+	// model's template is missing or not recognized); RawChatTemplate is
+	// the model's own Jinja template
+	ChatTemplate    string `json:"chat_template"`
+	RawChatTemplate string `json:"-"`
+	VocabSize       int    `json:"vocab_size"`
+	FileSize        int64  `json:"file_size"`
+	// Layers, EmbeddingLength and the attention head counts size the KV
+	// cache; they are 0 when the model does not declare them
+	Layers          int `json:"layers,omitempty"`
+	EmbeddingLength int `json:"embedding_length,omitempty"`
+	HeadCount       int `json:"head_count,omitempty"`
+	HeadCountKV     int `json:"head_count_kv,omitempty"`
+}
+
+// ggufFileTypes names the values of general.file_type
+var ggufFileTypes = map[uint64]string{
+	0: "F32", 1: "F16", 2: "Q4_0", 3: "Q4_1", 7: "Q8_0", 8: "Q5_0", 9: "Q5_1",
+	10: "Q2_K", 11: "Q3_K_S", 12: "Q3_K_M", 13: "Q3_K_L", 14: "Q4_K_S", 15: "Q4_K_M",
+	16: "Q5_K_S", 17: "Q5_K_M", 18: "Q6_K", 19: "IQ2_XXS", 20: "IQ2_XS", 21: "Q2_K_S",
+	22: "IQ3_XS", 23: "IQ3_XXS", 24: "IQ1_S", 25: "IQ4_NL", 26: "IQ3_S", 27: "IQ3_M",
+	28: "IQ2_S", 29: "IQ2_M", 30: "IQ4_XS", 31: "IQ1_M", 32: "BF16",
+}
+
+// InspectModel reads the description of a GGUF model from its header,
+// without loading it
+func InspectModel(path string) (*ModelInfo, error) {
+	header, err := readGGUFHeader(path, true)
+	if err != nil {
+		return nil, err
+	}
+	meta := header.metadata
+
+	info := &ModelInfo{Path: path}
+	info.Name, _ = meta["general.name"].(string)
+	info.Architecture, _ = meta["general.architecture"].(string)
+	info.RawChatTemplate, _ = meta["tokenizer.chat_template"].(string)
+	info.ChatTemplate = chatTemplateName(info.RawChatTemplate)
+
+	for _, n := range header.elements {
+		info.Parameters += n
+	}
+	if n, ok := meta[info.Architecture+".context_length"].(uint64); ok {
+		info.ContextLength = int(n)
+	}
+	arch := func(key string) int {
+		n, _ := meta[info.Architecture+"."+key].(uint64)
+		return int(n)
+	}
+	info.Layers = arch("block_count")
+	info.EmbeddingLength = arch("embedding_length")
+	info.HeadCount = arch("attention.head_count")
+	info.HeadCountKV = arch("attention.head_count_kv")
+	if info.HeadCountKV == 0 {
+		info.HeadCountKV = info.HeadCount
+	}
+	if n, ok := meta[info.Architecture+".vocab_size"].(uint64); ok {
+		info.VocabSize = int(n)
+	} else {
+		info.VocabSize = int(header.arrayLens["tokenizer.ggml.tokens"])
+	}
+	if ft, ok := meta["general.file_type"].(uint64); ok {
+		info.Quantization = ggufFileTypes[ft]
+		if info.Quantization == "" {
+			info.Quantization = fmt.Sprintf("type %d", ft)
+		}
+	}
+	if stat, err := os.Stat(path); err == nil {
+		info.FileSize = stat.Size()
+	}
+	return info, nil
+}
+
+// String summarizes the model, e.g. "qwen3 1.7B Q8_0, 40960 ctx, chatml"
+func (m *ModelInfo) String() string {
+	return fmt.Sprintf("%s %s %s, %d ctx, %s", m.Architecture, formatParameters(m.Parameters),
+		m.Quantization, m.ContextLength, m.ChatTemplate)
+}
+
+// formatParameters renders a parameter count as "7.2B" or "350M"
+func formatParameters(n uint64) string {
+	switch {
+	case n >= 1e9:
+		return fmt.Sprintf("%.1fB", float64(n)/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%.0fM", float64(n)/1e6)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// ContextSize returns requested, capped at the model's trained context
+// length; 0 requests the full trained context
+func (m *ModelInfo) ContextSize(requested int) int {
+	if m.ContextLength == 0 {
+		return requested
+	}
+	if requested == 0 || requested > m.ContextLength {
+		return m.ContextLength
+	}
+	return requested
+}
+
+// Warnings lists problems running the model with config, e.g. a context
+// larger than the model was trained for or an unrecognized chat template
+func (m *ModelInfo) Warnings(config LlamaCppConfig) []string {
+	var warnings []string
+	if m.ContextLength > 0 && config.ContextSize > m.ContextLength {
+		warnings = append(warnings, fmt.Sprintf("context size %d exceeds the %d tokens the model was trained with",
+			config.ContextSize, m.ContextLength))
+	}
+	if config.ChatTemplate == "" && m.RawChatTemplate != "" && m.ChatTemplate == TemplatePlain {
+		warnings = append(warnings, "the model's chat template is not recognized; prompts use the plain layout")
+	}
+	if m.Architecture == "" {
+		warnings = append(warnings, "the model does not declare its architecture")
+	}
+	if m.Quantization == "" {
+		warnings = append(warnings, "the model does not declare its file type")
+	}
+	return warnings
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	llama "github.com/go-skynet/go-llama.cpp"
 	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
@@ -16,11 +18,17 @@ type LlamaCppLLM struct {
 	model          *llama.LLama
 	modelPath      string
 	contextSize    int
+	maxTokens      int
 	temperature    float32
 	topP           float32
 	topK           int
 	threads        int
 	systemPrompt   string
+	sessionDir     string
+	stop           []string
+	formatter      PromptFormatter
+	// loadConfig is kept to reload the model in ApplyLoRA
+	loadConfig     LlamaCppConfig
 }
 
 // LlamaCppConfig holds configuration for LlamaCpp LLM
@@ -32,6 +40,71 @@ type LlamaCppConfig struct {
 	TopK         int
 	Threads      int
 	SystemPrompt string
+	// MaxTokens caps the tokens generated per call (default 512, at most
+	// half the context); the prompt must fit in the rest of the context
+	MaxTokens int
+	// SessionDir enables per-session KV cache files; see WithSession
+	SessionDir string
+	// GPULayers is the number of layers offloaded to the GPU (0 = CPU only)
+	GPULayers int
+	// MainGPU is the device used for small tensors and scratch buffers ("0", "1", ...)
+	MainGPU string
+	// TensorSplit spreads layers across GPUs, e.g. "3,1"
+	TensorSplit string
+	// UseMMap memory-maps the model file; nil keeps llama.cpp's default (on)
+	UseMMap *bool
+	// UseMLock locks the model in RAM so it is never swapped out
+	UseMLock bool
+	// BatchSize is the number of prompt tokens evaluated per batch (default 512)
+	BatchSize int
+	// Stop lists strings that end generation, e.g. "Observation:" for ReAct
+	Stop []string
+	// ChatTemplate is the prompt layout: "plain", "chatml", "llama3" or
+	// "mistral". Empty detects it from the model's GGUF metadata.
+	ChatTemplate string
+	// LoraPath is a LoRA adapter applied on top of the model at load time
+	LoraPath string
+	// LoraBase is an optional f16 base model the adapter is computed
+	// against, for better quality when ModelPath is quantized
+	LoraBase string
+	// LoraScale is the adapter strength. go-llama.cpp applies adapters at
+	// full strength, so only 0 (default) and 1 are accepted.
+	LoraScale float32
+	// Mirostat enables Mirostat sampling (1 or 2; 0 = off), which targets
+	// a constant perplexity instead of using top-k/top-p
+	Mirostat int
+	// MirostatTau is the target entropy (default 5.0)
+	MirostatTau float32
+	// MirostatEta is the learning rate (default 0.1)
+	MirostatEta float32
+	// TypicalP enables locally typical sampling when below 1
+	TypicalP float32
+	// TailFreeZ enables tail-free sampling when below 1
+	TailFreeZ float32
+	// MinP drops tokens less likely than MinP times the top token. It is
+	// not supported by the bundled go-llama.cpp and must be left at 0.
+	MinP float32
+	// RepeatPenalty penalizes recently generated tokens (1 = off,
+	// go-llama.cpp defaults to 1.1); raise it when a small model keeps
+	// repeating the same Thought
+	RepeatPenalty float32
+	// RepeatLastN is how many recent tokens RepeatPenalty looks at
+	// (default 64, -1 = the whole context)
+	RepeatLastN int
+	// FrequencyPenalty lowers a token's score by its count in the output
+	FrequencyPenalty float32
+	// PresencePenalty lowers the score of any token already in the output
+	PresencePenalty float32
+	// LoadTimeout bounds how long loading the model may take (default
+	// 10m), since llama.cpp can hang on files it does not support
+	LoadTimeout time.Duration
+	// SkipDiagnostics loads the model without the format and memory
+	// checks of DiagnoseModel
+	SkipDiagnostics bool
+	// OnMemoryWarning, when set, is called with the estimate of a model
+	// that may not fit in memory, which is then loaded anyway; by default
+	// such a model is refused with ErrInsufficientMemory
+	OnMemoryWarning func(estimate *MemoryEstimate)
 }
 
 // NewLlamaCppLLM creates a new LlamaCpp LLM instance
@@ -40,6 +113,12 @@ func NewLlamaCppLLM(config LlamaCppConfig) (*LlamaCppLLM, error) {
 	if config.ContextSize == 0 {
 		config.ContextSize = 2048
 	}
+	if config.MaxTokens == 0 {
+		config.MaxTokens = min(512, config.ContextSize/2)
+	}
+	if config.MaxTokens >= config.ContextSize {
+		return nil, fmt.Errorf("max tokens (%d) must be smaller than the context size (%d)", config.MaxTokens, config.ContextSize)
+	}
 	if config.Temperature == 0 {
 		config.Temperature = 0.7
 	}
@@ -52,32 +131,68 @@ func NewLlamaCppLLM(config LlamaCppConfig) (*LlamaCppLLM, error) {
 	if config.Threads == 0 {
 		config.Threads = 4
 	}
+	if config.BatchSize == 0 {
+		config.BatchSize = 512
+	}
+	if config.LoadTimeout == 0 {
+		config.LoadTimeout = 10 * time.Minute
+	}
+	if err := checkLoRA(config.LoraPath, config.LoraScale); err != nil {
+		return nil, err
+	}
+	if err := checkSampling(config); err != nil {
+		return nil, err
+	}
 
 	// Check if model file exists
 	if _, err := os.Stat(config.ModelPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("model file not found: %s", config.ModelPath)
 	}
+	if config.SessionDir != "" {
+		if err := os.MkdirAll(config.SessionDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create session dir: %w", err)
+		}
+	}
+
+	if config.ChatTemplate == "" {
+		// An unreadable header is reported by llama.New below
+		config.ChatTemplate, _ = DetectChatTemplate(config.ModelPath)
+	}
+	formatter, err := FormatterFor(config.ChatTemplate)
+	if err != nil {
+		return nil, err
+	}
 
 	l := &LlamaCppLLM{
 		BaseRunnable: core.NewBaseRunnable("LlamaCppLLM"),
 		modelPath:    config.ModelPath,
 		contextSize:  config.ContextSize,
+		maxTokens:    config.MaxTokens,
 		temperature:  config.Temperature,
 		topP:         config.TopP,
 		topK:         config.TopK,
 		threads:      config.Threads,
 		systemPrompt: config.SystemPrompt,
+		sessionDir:   config.SessionDir,
+		stop:         append(append([]string{}, config.Stop...), formatter.Stop()...),
+		formatter:    formatter,
+		loadConfig:   config,
+	}
+
+	if !config.SkipDiagnostics {
+		info, err := DiagnoseModel(config)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Loading model from: %s (%s, %s)\n", config.ModelPath, info, formatBytes(uint64(info.FileSize)))
+	} else {
+		fmt.Printf("Loading model from: %s\n", config.ModelPath)
 	}
 
 	// Load the model with go-llama.cpp
-	fmt.Printf("Loading model from: %s\n", config.ModelPath)
-	model, err := llama.New(
-		config.ModelPath,
-		llama.SetContext(config.ContextSize),
-		llama.SetThreads(config.Threads),
-	)
+	model, err := loadModel(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load model: %w", err)
+		return nil, err
 	}
 	l.model = model
 
@@ -85,53 +200,280 @@ func NewLlamaCppLLM(config LlamaCppConfig) (*LlamaCppLLM, error) {
 	return l, nil
 }
 
-// Invoke generates a response for the given prompt
-func (l *LlamaCppLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
-	prompt, ok := input.(string)
-	if !ok {
-		// Try to convert from messages
-		if messages, ok := input.([]core.Message); ok {
-			prompt = l.messagesToPrompt(messages)
-		} else {
-			return nil, fmt.Errorf("input must be a string or []core.Message")
+// loadModel loads the model with go-llama.cpp, printing progress every 10
+// seconds and giving up after config.LoadTimeout. go-llama.cpp cannot
+// cancel a load, so an abandoned one finishes in the background and the
+// model is freed then.
+func loadModel(config LlamaCppConfig) (*llama.LLama, error) {
+	type result struct {
+		model *llama.LLama
+		err   error
+	}
+	done := make(chan result, 1)
+	start := time.Now()
+	go func() {
+		model, err := llama.New(config.ModelPath, modelOptions(config)...)
+		done <- result{model, err}
+	}()
+
+	timeout := time.NewTimer(config.LoadTimeout)
+	defer timeout.Stop()
+	tick := time.NewTicker(10 * time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case r := <-done:
+			if r.err != nil {
+				return nil, fmt.Errorf("failed to load model: %w", r.err)
+			}
+			return r.model, nil
+		case <-tick.C:
+			fmt.Printf("Still loading model (%s)...\n", time.Since(start).Round(time.Second))
+		case <-timeout.C:
+			go func() {
+				if r := <-done; r.err == nil {
+					r.model.Free()
+				}
+			}()
+			return nil, fmt.Errorf("%w: %s did not load within %s; the model may be incompatible with the bundled llama.cpp or too large for this machine",
+				ErrLoadTimeout, config.ModelPath, config.LoadTimeout)
 		}
 	}
+}
 
-	// Add system prompt if set
-	if l.systemPrompt != "" {
-		prompt = fmt.Sprintf("System: %s\n\nUser: %s\n\nAssistant:", l.systemPrompt, prompt)
+// modelOptions translates the config into go-llama.cpp load options
+func modelOptions(config LlamaCppConfig) []llama.ModelOption {
+	opts := []llama.ModelOption{
+		llama.SetContext(config.ContextSize),
+		llama.SetNBatch(config.BatchSize),
+		llama.SetGPULayers(config.GPULayers),
 	}
+	if config.MainGPU != "" {
+		opts = append(opts, llama.SetMainGPU(config.MainGPU))
+	}
+	if config.TensorSplit != "" {
+		opts = append(opts, llama.SetTensorSplit(config.TensorSplit))
+	}
+	if config.UseMMap != nil {
+		opts = append(opts, llama.SetMMap(*config.UseMMap))
+	}
+	if config.UseMLock {
+		opts = append(opts, llama.EnableMLock)
+	}
+	if config.LoraPath != "" {
+		opts = append(opts, llama.SetLoraAdapter(config.LoraPath))
+		if config.LoraBase != "" {
+			opts = append(opts, llama.SetLoraBase(config.LoraBase))
+		}
+	}
+	return opts
+}
+
+// checkSampling validates the advanced sampling and penalty options
+func checkSampling(config LlamaCppConfig) error {
+	if config.Mirostat < 0 || config.Mirostat > 2 {
+		return fmt.Errorf("mirostat must be 0, 1 or 2, got %d", config.Mirostat)
+	}
+	if config.MinP != 0 {
+		return fmt.Errorf("min-p sampling is not supported by go-llama.cpp")
+	}
+	if config.RepeatPenalty < 0 {
+		return fmt.Errorf("repeat penalty must not be negative, got %g", config.RepeatPenalty)
+	}
+	if config.RepeatLastN < -1 {
+		return fmt.Errorf("repeat last n must be -1 or more, got %d", config.RepeatLastN)
+	}
+	return nil
+}
+
+// samplingOptions translates the advanced sampling options; unset options
+// keep go-llama.cpp's defaults
+func samplingOptions(config LlamaCppConfig) []llama.PredictOption {
+	var opts []llama.PredictOption
+	if config.Mirostat != 0 {
+		opts = append(opts, llama.SetMirostat(config.Mirostat))
+		if config.MirostatTau != 0 {
+			opts = append(opts, llama.SetMirostatTAU(config.MirostatTau))
+		}
+		if config.MirostatEta != 0 {
+			opts = append(opts, llama.SetMirostatETA(config.MirostatEta))
+		}
+	}
+	if config.TypicalP != 0 {
+		opts = append(opts, llama.SetTypicalP(config.TypicalP))
+	}
+	if config.TailFreeZ != 0 {
+		opts = append(opts, llama.SetTailFreeSamplingZ(config.TailFreeZ))
+	}
+	if config.RepeatPenalty != 0 {
+		opts = append(opts, llama.SetPenalty(config.RepeatPenalty))
+	}
+	if config.RepeatLastN != 0 {
+		opts = append(opts, llama.SetRepeat(config.RepeatLastN))
+	}
+	if config.FrequencyPenalty != 0 {
+		opts = append(opts, llama.SetFrequencyPenalty(config.FrequencyPenalty))
+	}
+	if config.PresencePenalty != 0 {
+		opts = append(opts, llama.SetPresencePenalty(config.PresencePenalty))
+	}
+	return opts
+}
 
-	// Generate response using go-llama.cpp
-	result, err := l.model.Predict(
-		prompt,
-		llama.SetTemperature(float64(l.temperature)),
-		llama.SetTopP(float64(l.topP)),
+// logitBiasOptions translates the invocation's logit bias. go-llama.cpp
+// takes a single "id+bias" entry, so at most one token can be biased.
+func (l *LlamaCppLLM) logitBiasOptions(config *core.Config) ([]llama.PredictOption, error) {
+	if config == nil || len(config.LogitBias) == 0 {
+		return nil, nil
+	}
+	bias, err := tokenBias(config.LogitBias, l.Tokenize)
+	if err != nil {
+		return nil, err
+	}
+	if len(bias) > 1 {
+		return nil, fmt.Errorf("go-llama.cpp supports a single logit bias, got %d", len(bias))
+	}
+	var opts []llama.PredictOption
+	for id, b := range bias {
+		opts = append(opts, llama.SetLogitBias(fmt.Sprintf("%d%+g", id, b)))
+	}
+	return opts, nil
+}
+
+// grammarOptions constrains sampling to the invocation's grammar, if any
+func grammarOptions(config *core.Config) []llama.PredictOption {
+	if grammar(config) == "" {
+		return nil
+	}
+	return []llama.PredictOption{llama.WithGrammar(config.Grammar)}
+}
+
+// checkLoRA validates an adapter path and scale
+func checkLoRA(path string, scale float32) error {
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("LoRA adapter not found: %w", err)
+	}
+	if scale != 0 && scale != 1 {
+		return fmt.Errorf("LoRA scale %g not supported: go-llama.cpp applies adapters at full strength", scale)
+	}
+	return nil
+}
+
+// ApplyLoRA specializes the base model with the adapter at path, e.g. a
+// tool-calling fine-tune; an empty path goes back to the plain model.
+// llama.cpp cannot unload an adapter, so the model is reloaded; the old one
+// is kept if loading fails. Do not call it while a generation is running.
+func (l *LlamaCppLLM) ApplyLoRA(path string, scale float32) error {
+	if err := checkLoRA(path, scale); err != nil {
+		return err
+	}
+	config := l.loadConfig
+	config.LoraPath, config.LoraScale = path, scale
+
+	model, err := llama.New(config.ModelPath, modelOptions(config)...)
+	if err != nil {
+		return fmt.Errorf("failed to load model with LoRA adapter: %w", err)
+	}
+	if l.model != nil {
+		l.model.Free()
+	}
+	l.model = model
+	l.loadConfig = config
+	return nil
+}
+
+// Invoke generates a response for the given prompt. Generation stops at the
+// next token once ctx is cancelled or its deadline passes; prompt
+// evaluation itself cannot be interrupted.
+func (l *LlamaCppLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	prompt, err := buildPrompt(input, l.systemPrompt, l.formatter)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsDryRun(ctx) {
+		return l.dryRun(prompt), nil
+	}
+
+	bias, err := l.logitBiasOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate response using go-llama.cpp. Stop sequences are matched here
+	// rather than with SetStopWords, whose TrimRight strips any trailing
+	// letters of a stop word from the answer ("Paris" becomes "P").
+	scanner := &stopScanner{stops: stopSequences(l.stop, config)}
+	var text strings.Builder
+	completionTokens := 0
+	start := time.Now()
+	opts := append([]llama.PredictOption{
+		llama.SetTokenCallback(func(token string) bool {
+			// Returning false stops generation once the context ends
+			if ctx.Err() != nil {
+				return false
+			}
+			completionTokens++
+			emit, stopped := scanner.push(token)
+			text.WriteString(emit)
+			return !stopped
+		}),
+		llama.SetTemperature(l.temperature),
+		llama.SetTopP(l.topP),
 		llama.SetTopK(l.topK),
 		llama.SetThreads(l.threads),
-		llama.SetTokens(l.contextSize),
-	)
+		llama.SetTokens(l.maxTokens),
+	}, l.sessionOptions(ctx)...)
+	opts = append(opts, samplingOptions(l.loadConfig)...)
+	opts = append(opts, bias...)
+	opts = append(opts, grammarOptions(config)...)
+	_, err = l.model.Predict(prompt, opts...)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, fmt.Errorf("prediction aborted: %w", ctxErr)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("prediction failed: %w", err)
 	}
+	text.WriteString(scanner.flush())
 
-	return result, nil
+	gen := &core.Generation{
+		// The prompt ends with the assistant cue, so the answer usually
+		// starts with a space or newline
+		Text:             strings.TrimLeft(text.String(), " \n"),
+		Model:            filepath.Base(l.modelPath),
+		CompletionTokens: completionTokens,
+		Duration:         time.Since(start),
+		FinishReason:     core.FinishStop,
+	}
+	if count, _, err := l.model.TokenizeString(prompt); err == nil {
+		gen.PromptTokens = int(count)
+	}
+	if !scanner.stopped && completionTokens >= l.maxTokens {
+		gen.FinishReason = core.FinishLength
+	}
+	return gen, nil
 }
 
 // Stream generates a response and streams tokens
 func (l *LlamaCppLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
-	prompt, ok := input.(string)
-	if !ok {
-		if messages, ok := input.([]core.Message); ok {
-			prompt = l.messagesToPrompt(messages)
-		} else {
-			return nil, fmt.Errorf("input must be a string or []core.Message")
-		}
+	prompt, err := buildPrompt(input, l.systemPrompt, l.formatter)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsDryRun(ctx) {
+		return dryRunStream(l.dryRun(prompt)), nil
 	}
 
-	// Add system prompt if set
-	if l.systemPrompt != "" {
-		prompt = fmt.Sprintf("System: %s\n\nUser: %s\n\nAssistant:", l.systemPrompt, prompt)
+	bias, err := l.logitBiasOptions(config)
+	if err != nil {
+		return nil, err
 	}
 
 	out := make(chan interface{}, 10)
@@ -139,26 +481,40 @@ func (l *LlamaCppLLM) Stream(ctx context.Context, input interface{}, config *cor
 	go func() {
 		defer close(out)
 
-		// Stream response using go-llama.cpp
-		err := l.model.Predict(
-			prompt,
-			func(token string) bool {
-				select {
-				case <-ctx.Done():
-					return false
-				case out <- token:
-					return true
-				}
-			},
-			llama.SetTemperature(float64(l.temperature)),
-			llama.SetTopP(float64(l.topP)),
+		// Stream response using go-llama.cpp, holding back text that may
+		// be the start of a stop sequence so the stop text is never sent
+		scanner := &stopScanner{stops: stopSequences(l.stop, config)}
+		send := func(text string) bool {
+			if text == "" {
+				return true
+			}
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- text:
+				return true
+			}
+		}
+		opts := append([]llama.PredictOption{
+			llama.SetTokenCallback(func(token string) bool {
+				emit, stopped := scanner.push(token)
+				return send(emit) && !stopped
+			}),
+			llama.SetTemperature(l.temperature),
+			llama.SetTopP(l.topP),
 			llama.SetTopK(l.topK),
 			llama.SetThreads(l.threads),
-			llama.SetTokens(l.contextSize),
-		)
+			llama.SetTokens(l.maxTokens),
+		}, l.sessionOptions(ctx)...)
+		opts = append(opts, samplingOptions(l.loadConfig)...)
+		opts = append(opts, bias...)
+		opts = append(opts, grammarOptions(config)...)
+		_, err := l.model.Predict(prompt, opts...)
 		if err != nil {
 			out <- fmt.Errorf("streaming failed: %w", err)
+			return
 		}
+		send(scanner.flush())
 	}()
 
 	return out, nil
@@ -166,20 +522,7 @@ func (l *LlamaCppLLM) Stream(ctx context.Context, input interface{}, config *cor
 
 // messagesToPrompt converts messages to a prompt string
 func (l *LlamaCppLLM) messagesToPrompt(messages []core.Message) string {
-	prompt := ""
-	for _, msg := range messages {
-		switch msg.GetType() {
-		case core.MessageTypeSystem:
-			prompt += fmt.Sprintf("System: %s\n\n", msg.GetContent())
-		case core.MessageTypeHuman:
-			prompt += fmt.Sprintf("User: %s\n\n", msg.GetContent())
-		case core.MessageTypeAI:
-			prompt += fmt.Sprintf("Assistant: %s\n\n", msg.GetContent())
-		case core.MessageTypeTool:
-			prompt += fmt.Sprintf("Tool: %s\n\n", msg.GetContent())
-		}
-	}
-	return prompt + "Assistant:"
+	return l.formatter.Format(messages)
 }
 
 // Close releases model resources
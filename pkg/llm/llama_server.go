@@ -0,0 +1,176 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// LlamaServerLLM talks to a running llama.cpp server, e.g.
+// llama-server -m model.gguf --port 8080, so no cgo build is needed and the
+// model can live on another machine. It uses the /v1/chat/completions
+// endpoint, where the server applies the model's own chat template and
+// tools are supported; when config.ChatTemplate is set it formats prompts
+// itself and uses /completion instead.
+type LlamaServerLLM struct {
+	*core.BaseRunnable
+	config     HTTPBackendConfig
+	completion *LlamafileLLM
+	chat       *OpenAIChatLLM
+}
+
+// NewLlamaServerLLM creates a client for the server at config.BaseURL,
+// without the /v1 suffix
+func NewLlamaServerLLM(config HTTPBackendConfig) *LlamaServerLLM {
+	config.BaseURL = strings.TrimSuffix(strings.TrimRight(config.BaseURL, "/"), "/v1")
+	chatConfig := config
+	chatConfig.BaseURL += "/v1"
+	chat := NewOpenAIChatLLM(chatConfig)
+	chat.grammar = true
+	return &LlamaServerLLM{
+		BaseRunnable: core.NewBaseRunnable("LlamaServerLLM"),
+		config:       config.withDefaults(),
+		completion:   NewLlamafileLLM(config),
+		chat:         chat,
+	}
+}
+
+// WithTools sets the tool definitions (OpenAI format) offered to the model;
+// use Chat to read the resulting tool calls. Tools need the chat endpoint,
+// so they are ignored when a ChatTemplate is set.
+func (l *LlamaServerLLM) WithTools(tools []map[string]interface{}) *LlamaServerLLM {
+	l.chat.WithTools(tools)
+	return l
+}
+
+// useCompletion reports whether requests go to /completion
+func (l *LlamaServerLLM) useCompletion() bool {
+	return l.config.ChatTemplate != ""
+}
+
+// Health returns nil once the server has loaded its model
+func (l *LlamaServerLLM) Health(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.config.BaseURL+"/health", nil)
+	if err != nil {
+		return err
+	}
+	if l.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.config.APIKey)
+	}
+	resp, err := (&http.Client{Timeout: l.config.Timeout}).Do(req)
+	if err != nil {
+		return fmt.Errorf("server not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// 503 while the model is still loading
+		return fmt.Errorf("server not ready: %s", resp.Status)
+	}
+	return nil
+}
+
+// Chat sends the conversation and returns the assistant message, including
+// any tool calls the model made
+func (l *LlamaServerLLM) Chat(ctx context.Context, input interface{}) (*core.AIMessage, error) {
+	gen, err := l.chat.chat(ctx, input, nil)
+	if err != nil {
+		return nil, err
+	}
+	return gen.Message(), nil
+}
+
+// chatConfig resolves text keys of the logit bias to token ids with the
+// server's tokenizer, since the chat endpoint only accepts ids
+func (l *LlamaServerLLM) chatConfig(config *core.Config) (*core.Config, error) {
+	if config == nil || len(config.LogitBias) == 0 {
+		return config, nil
+	}
+	bias, err := tokenBias(config.LogitBias, l.Tokenize)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *config
+	resolved.LogitBias = make(map[string]float32, len(bias))
+	for id, b := range bias {
+		resolved.LogitBias[strconv.Itoa(id)] = b
+	}
+	return &resolved, nil
+}
+
+// Invoke generates a response for the given prompt
+func (l *LlamaServerLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	if l.useCompletion() {
+		return l.completion.Invoke(ctx, input, config)
+	}
+	config, err := l.chatConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return l.chat.Invoke(ctx, input, config)
+}
+
+// Stream generates a response and streams tokens
+func (l *LlamaServerLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	if l.useCompletion() {
+		return l.completion.Stream(ctx, input, config)
+	}
+	config, err := l.chatConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return l.chat.Stream(ctx, input, config)
+}
+
+// Tokenize returns the token ids of text using the server's /tokenize endpoint
+func (l *LlamaServerLLM) Tokenize(text string) ([]int, error) {
+	return l.completion.Tokenize(text)
+}
+
+// CountTokens returns the number of prompt tokens messages occupy. On the
+// chat endpoint the server renders them with /apply-template first.
+func (l *LlamaServerLLM) CountTokens(messages []core.Message) (int, error) {
+	if l.useCompletion() {
+		return l.completion.CountTokens(messages)
+	}
+	req, err := l.chat.request(messages, false, nil)
+	if err != nil {
+		return 0, err
+	}
+	var resp struct {
+		Prompt string `json:"prompt"`
+	}
+	body := map[string]interface{}{"messages": req.Messages}
+	if err := l.config.postJSON(context.Background(), "/apply-template", body, &resp); err != nil {
+		return 0, fmt.Errorf("tokenization failed: %w", err)
+	}
+	tokens, err := l.Tokenize(resp.Prompt)
+	if err != nil {
+		return 0, err
+	}
+	return len(tokens), nil
+}
+
+// Batch generates a response for each input in turn
+func (l *LlamaServerLLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := l.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the model with another runnable
+func (l *LlamaServerLLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{l, other})
+}
+
+// Close is a no-op; the server owns the model
+func (l *LlamaServerLLM) Close() {}
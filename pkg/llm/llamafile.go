@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// LlamafileLLM talks to the server built into a llamafile (the llama.cpp
+// server's /completion API), e.g. ./model.llamafile --server --port 8080
+type LlamafileLLM struct {
+	*core.BaseRunnable
+	config HTTPBackendConfig
+}
+
+// NewLlamafileLLM creates a client for the llamafile server at config.BaseURL
+func NewLlamafileLLM(config HTTPBackendConfig) *LlamafileLLM {
+	return &LlamafileLLM{
+		BaseRunnable: core.NewBaseRunnable("LlamafileLLM"),
+		config:       config.withDefaults(),
+	}
+}
+
+// completionRequest is the body of /completion
+type completionRequest struct {
+	Prompt      string   `json:"prompt"`
+	NPredict    int      `json:"n_predict"`
+	Temperature float32  `json:"temperature"`
+	TopP        float32  `json:"top_p"`
+	TopK        int      `json:"top_k"`
+	Stop        []string `json:"stop,omitempty"`
+	Stream      bool     `json:"stream"`
+	CachePrompt bool     `json:"cache_prompt"`
+	// LogitBias holds [token id, bias] pairs; a bias of false bans the token
+	LogitBias [][2]interface{} `json:"logit_bias,omitempty"`
+	Grammar   string           `json:"grammar,omitempty"`
+}
+
+// completionChunk is a /completion response, or one streamed event of it
+type completionChunk struct {
+	Content         string `json:"content"`
+	Stop            bool   `json:"stop"`
+	Model           string `json:"model"`
+	TokensPredicted int    `json:"tokens_predicted"`
+	TokensEvaluated int    `json:"tokens_evaluated"`
+	StoppedLimit    bool   `json:"stopped_limit"`
+}
+
+// request builds the completion request for input
+func (l *LlamafileLLM) request(input interface{}, stream bool, config *core.Config) (completionRequest, error) {
+	prompt, stop, err := l.config.completionPrompt(input)
+	if err != nil {
+		return completionRequest{}, err
+	}
+	var logitBias [][2]interface{}
+	if config != nil && len(config.LogitBias) > 0 {
+		bias, err := tokenBias(config.LogitBias, l.Tokenize)
+		if err != nil {
+			return completionRequest{}, err
+		}
+		for _, id := range sortedTokens(bias) {
+			var b interface{} = bias[id]
+			if math.IsInf(float64(bias[id]), -1) {
+				b = false
+			}
+			logitBias = append(logitBias, [2]interface{}{id, b})
+		}
+	}
+	return completionRequest{
+		Prompt:      prompt,
+		NPredict:    l.config.MaxTokens,
+		Temperature: l.config.Temperature,
+		TopP:        l.config.TopP,
+		TopK:        l.config.TopK,
+		Stop:        stopSequences(stop, config),
+		Stream:      stream,
+		CachePrompt: true,
+		LogitBias:   logitBias,
+		Grammar:     grammar(config),
+	}, nil
+}
+
+// Invoke generates a response for the given prompt
+func (l *LlamafileLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	req, err := l.request(input, false, config)
+	if err != nil {
+		return nil, err
+	}
+	if IsDryRun(ctx) {
+		return estimatedDryRun(l.Name(), req.Prompt), nil
+	}
+
+	var resp completionChunk
+	start := time.Now()
+	if err := l.config.postJSON(ctx, "/completion", req, &resp); err != nil {
+		return nil, fmt.Errorf("prediction failed: %w", err)
+	}
+
+	gen := &core.Generation{
+		Text:             resp.Content,
+		Model:            resp.Model,
+		PromptTokens:     resp.TokensEvaluated,
+		CompletionTokens: resp.TokensPredicted,
+		Duration:         time.Since(start),
+		FinishReason:     core.FinishStop,
+	}
+	if resp.StoppedLimit {
+		gen.FinishReason = core.FinishLength
+	}
+	return gen, nil
+}
+
+// Stream generates a response and streams tokens
+func (l *LlamafileLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	req, err := l.request(input, true, config)
+	if err != nil {
+		return nil, err
+	}
+	if IsDryRun(ctx) {
+		return dryRunStream(estimatedDryRun(l.Name(), req.Prompt)), nil
+	}
+
+	return l.config.streamEvents(ctx, "/completion", req, func(data []byte) (string, bool, error) {
+		var chunk completionChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return "", false, err
+		}
+		return chunk.Content, !chunk.Stop, nil
+	})
+}
+
+// Batch generates a response for each input in turn
+func (l *LlamafileLLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := l.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the model with another runnable
+func (l *LlamafileLLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{l, other})
+}
+
+// Close is a no-op; the server owns the model
+func (l *LlamafileLLM) Close() {}
@@ -0,0 +1,42 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// tokenBias resolves the keys of a logit bias to token ids: numeric keys are
+// ids, other keys are tokenized and their first token is biased. tokenize
+// may be nil for backends that only accept ids.
+func tokenBias(bias map[string]float32, tokenize func(string) ([]int, error)) (map[int]float32, error) {
+	out := make(map[int]float32, len(bias))
+	for key, b := range bias {
+		if id, err := strconv.Atoi(key); err == nil {
+			out[id] = b
+			continue
+		}
+		if tokenize == nil {
+			return nil, fmt.Errorf("logit bias key %q must be a token id for this backend", key)
+		}
+		tokens, err := tokenize(key)
+		if err != nil {
+			return nil, fmt.Errorf("logit bias key %q: %w", key, err)
+		}
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("logit bias key %q has no tokens", key)
+		}
+		out[tokens[0]] = b
+	}
+	return out, nil
+}
+
+// sortedTokens returns the token ids of a bias in ascending order
+func sortedTokens(bias map[int]float32) []int {
+	ids := make([]int, 0, len(bias))
+	for id := range bias {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
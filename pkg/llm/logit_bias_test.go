@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestTokenBias(t *testing.T) {
+	vocab := map[string][]int{"yes": {9891}, " no": {912, 13}, "": {}}
+	tokenize := func(s string) ([]int, error) {
+		tokens, ok := vocab[s]
+		if !ok {
+			return nil, errors.New("unknown word")
+		}
+		return tokens, nil
+	}
+
+	tests := []struct {
+		name     string
+		bias     map[string]float32
+		tokenize func(string) ([]int, error)
+		want     map[int]float32
+		wantErr  string
+	}{
+		{
+			name:     "ids and words",
+			bias:     map[string]float32{"50256": -100, "yes": 5, " no": 2},
+			tokenize: tokenize,
+			want:     map[int]float32{50256: -100, 9891: 5, 912: 2},
+		},
+		{
+			name: "ids without a tokenizer",
+			bias: map[string]float32{"1": 1, "2": -1},
+			want: map[int]float32{1: 1, 2: -1},
+		},
+		{
+			name:    "word without a tokenizer",
+			bias:    map[string]float32{"yes": 5},
+			wantErr: `logit bias key "yes" must be a token id`,
+		},
+		{
+			name:     "tokenizer error",
+			bias:     map[string]float32{"maybe": 1},
+			tokenize: tokenize,
+			wantErr:  `logit bias key "maybe": unknown word`,
+		},
+		{
+			name:     "no tokens",
+			bias:     map[string]float32{"": 1},
+			tokenize: tokenize,
+			wantErr:  `logit bias key "" has no tokens`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tokenBias(tt.bias, tt.tokenize)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("tokenBias() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("tokenBias() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenBias() = %v, want %v", got, tt.want)
+			}
+			if ids := sortedTokens(got); !sort.IntsAreSorted(ids) || len(ids) != len(got) {
+				t.Errorf("sortedTokens() = %v", ids)
+			}
+		})
+	}
+}
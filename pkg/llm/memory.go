@@ -0,0 +1,212 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MemoryEstimate is the memory a model needs with a given context size and
+// GPU offload, and how much the system has available
+type MemoryEstimate struct {
+	Model       *ModelInfo
+	ContextSize int
+	GPULayers   int
+	// Weights and KVCache are the sizes of the model weights and of an f16
+	// KV cache for ContextSize tokens; Overhead covers compute buffers
+	Weights  uint64
+	KVCache  uint64
+	Overhead uint64
+	// RAM and VRAM split the total between system and GPU memory by the
+	// share of layers offloaded
+	RAM  uint64
+	VRAM uint64
+	// AvailableRAM and AvailableVRAM are 0 when they cannot be determined,
+	// e.g. without an NVIDIA GPU, and are then not checked
+	AvailableRAM  uint64
+	AvailableVRAM uint64
+}
+
+// computeOverhead approximates llama.cpp's scratch and compute buffers
+const computeOverhead = 256 << 20
+
+// EstimateMemory estimates the memory needed to run the GGUF model at path
+// with contextSize tokens (0 = the model's training context) and gpuLayers
+// layers offloaded to the GPU (a negative value offloads all of them). It
+// only reads the model's header.
+func EstimateMemory(path string, contextSize, gpuLayers int) (*MemoryEstimate, error) {
+	info, err := InspectModel(path)
+	if err != nil {
+		return nil, err
+	}
+	return estimateMemory(info, info.ContextSize(contextSize), gpuLayers), nil
+}
+
+// estimateMemory sizes the weights and KV cache of info, splits them
+// between RAM and VRAM and looks up the memory available
+func estimateMemory(info *ModelInfo, contextSize, gpuLayers int) *MemoryEstimate {
+	e := &MemoryEstimate{
+		Model:       info,
+		ContextSize: contextSize,
+		GPULayers:   gpuLayers,
+		Weights:     uint64(info.FileSize),
+		Overhead:    computeOverhead,
+	}
+	if info.Layers > 0 && info.EmbeddingLength > 0 && info.HeadCount > 0 {
+		kvWidth := uint64(info.EmbeddingLength) * uint64(info.HeadCountKV) / uint64(info.HeadCount)
+		// Keys and values, 2 bytes each
+		e.KVCache = 2 * 2 * uint64(info.Layers) * uint64(contextSize) * kvWidth
+	}
+
+	// Offloaded layers keep their weights and KV cache in VRAM
+	offloaded := e.Weights + e.KVCache
+	switch {
+	case gpuLayers == 0 || info.Layers == 0:
+		offloaded = 0
+	case gpuLayers > 0 && gpuLayers < info.Layers:
+		offloaded = offloaded * uint64(gpuLayers) / uint64(info.Layers)
+	}
+	e.VRAM = offloaded
+	e.RAM = e.Weights + e.KVCache + e.Overhead - offloaded
+
+	e.AvailableRAM = availableMemory()
+	if e.VRAM > 0 {
+		e.AvailableVRAM = availableVRAM()
+	}
+	return e
+}
+
+// Fits reports whether the estimate fits in the memory known to be available
+func (e *MemoryEstimate) Fits() bool {
+	return e.Check() == nil
+}
+
+// Check returns an error wrapping ErrInsufficientMemory when the estimate
+// exceeds the available RAM or VRAM
+func (e *MemoryEstimate) Check() error {
+	if e.AvailableRAM > 0 && e.RAM > e.AvailableRAM {
+		return fmt.Errorf("%w: %s needs about %s of RAM with a %d-token context, but only %s is available; use a smaller quantization or context, or offload layers with GPULayers",
+			ErrInsufficientMemory, e.Model, formatBytes(e.RAM), e.ContextSize, formatBytes(e.AvailableRAM))
+	}
+	if e.AvailableVRAM > 0 && e.VRAM > e.AvailableVRAM {
+		return fmt.Errorf("%w: %s needs about %s of VRAM for %s offloaded layers, but only %s is free; offload fewer layers with GPULayers",
+			ErrInsufficientMemory, e.Model, formatBytes(e.VRAM), e.offloadedLayers(), formatBytes(e.AvailableVRAM))
+	}
+	return nil
+}
+
+func (e *MemoryEstimate) offloadedLayers() string {
+	if e.GPULayers < 0 || (e.Model.Layers > 0 && e.GPULayers >= e.Model.Layers) {
+		return "all"
+	}
+	return strconv.Itoa(e.GPULayers)
+}
+
+// String summarizes the estimate, e.g. "4.6 GB RAM (5.3 GB available)"
+func (e *MemoryEstimate) String() string {
+	s := formatBytes(e.RAM) + " RAM"
+	if e.AvailableRAM > 0 {
+		s += fmt.Sprintf(" (%s available)", formatBytes(e.AvailableRAM))
+	}
+	if e.VRAM > 0 {
+		s += ", " + formatBytes(e.VRAM) + " VRAM"
+		if e.AvailableVRAM > 0 {
+			s += fmt.Sprintf(" (%s free)", formatBytes(e.AvailableVRAM))
+		}
+	}
+	return s
+}
+
+// availableMemory returns the memory available to a new allocation, or 0
+// when it cannot be determined on this system. In a container it is
+// capped by the cgroup limit, which is what the OOM killer enforces.
+func availableMemory() uint64 {
+	if runtime.GOOS != "linux" {
+		return 0
+	}
+	available := meminfoAvailable()
+	if limit := cgroupAvailable(); limit > 0 && (available == 0 || limit < available) {
+		available = limit
+	}
+	return available
+}
+
+// meminfoAvailable reads MemAvailable from /proc/meminfo
+func meminfoAvailable() uint64 {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemAvailable:" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb * 1024
+		}
+	}
+	return 0
+}
+
+// cgroupAvailable returns the room left under a cgroup v2 memory limit, or
+// 0 when there is no limit
+func cgroupAvailable() uint64 {
+	read := func(name string) (uint64, bool) {
+		data, err := os.ReadFile("/sys/fs/cgroup/" + name)
+		if err != nil {
+			return 0, false
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		return n, err == nil
+	}
+	limit, ok := read("memory.max") // "max" when unlimited
+	if !ok {
+		return 0
+	}
+	current, _ := read("memory.current")
+	if current >= limit {
+		return 1
+	}
+	return limit - current
+}
+
+// availableVRAM returns the free memory of the NVIDIA GPUs reported by
+// nvidia-smi, or 0 when there are none
+func availableVRAM() uint64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "nvidia-smi", "--query-gpu=memory.free", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return 0
+	}
+	var free uint64
+	for _, line := range strings.Split(string(out), "\n") {
+		if mib, err := strconv.ParseUint(strings.TrimSpace(line), 10, 64); err == nil {
+			free += mib << 20
+		}
+	}
+	return free
+}
+
+// formatBytes renders a size as "4.2 GB" or "512 MB"
+func formatBytes(n uint64) string {
+	if n >= 1<<30 {
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	}
+	return fmt.Sprintf("%d MB", n>>20)
+}
+
+// WarnMemory is an OnMemoryWarning callback printing the problem to stderr
+func WarnMemory(estimate *MemoryEstimate) {
+	fmt.Fprintf(os.Stderr, "warning: %v; loading anyway\n", estimate.Check())
+}
@@ -0,0 +1,197 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// ChatModel is a text generation backend. Invoke takes a prompt string or
+// []core.Message and returns the completion as a *core.Generation; Stream
+// emits the completion as string tokens, or an error as the last chunk.
+type ChatModel interface {
+	core.Runnable
+	// Close releases the backend's resources
+	Close()
+}
+
+var (
+	_ ChatModel = (*LlamaCppLLM)(nil)
+	_ ChatModel = (*TGILLM)(nil)
+	_ ChatModel = (*LlamafileLLM)(nil)
+	_ ChatModel = (*OpenAIChatLLM)(nil)
+	_ ChatModel = (*LlamaServerLLM)(nil)
+	// A guarded model is still closed through the breaker
+	_ ChatModel = (*core.BreakerRunnable)(nil)
+)
+
+// formatMessages renders a conversation in the System:/User:/Assistant:
+// layout shared by the completion backends
+func formatMessages(messages []core.Message) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		switch msg.GetType() {
+		case core.MessageTypeSystem:
+			fmt.Fprintf(&b, "System: %s\n\n", msg.GetContent())
+		case core.MessageTypeHuman:
+			fmt.Fprintf(&b, "User: %s\n\n", msg.GetContent())
+		case core.MessageTypeAI:
+			fmt.Fprintf(&b, "Assistant: %s\n\n", msg.GetContent())
+		case core.MessageTypeTool:
+			fmt.Fprintf(&b, "Tool: %s\n\n", msg.GetContent())
+		}
+	}
+	return b.String() + "Assistant:"
+}
+
+// checkAttachments rejects human messages with attachments, which the
+// backends cannot read; chains.AttachmentResolver folds them into the text
+func checkAttachments(messages []core.Message) error {
+	for _, msg := range messages {
+		if m, ok := msg.(*core.HumanMessage); ok && len(m.Attachments) > 0 {
+			return fmt.Errorf("message %s has %d unresolved attachments; resolve them with chains.AttachmentResolver first", m.ID, len(m.Attachments))
+		}
+	}
+	return nil
+}
+
+// buildPrompt turns a string or []core.Message into a prompt, adding the
+// system prompt when one is set. With the plain template (or a nil
+// formatter) a string is sent as-is; chat templates wrap it as a user turn.
+func buildPrompt(input interface{}, systemPrompt string, formatter PromptFormatter) (string, error) {
+	if _, plain := formatter.(plainFormatter); formatter == nil || plain {
+		prompt, ok := input.(string)
+		if !ok {
+			messages, ok := input.([]core.Message)
+			if !ok {
+				return "", fmt.Errorf("input must be a string or []core.Message")
+			}
+			if err := checkAttachments(messages); err != nil {
+				return "", err
+			}
+			prompt = formatMessages(messages)
+		}
+		if systemPrompt != "" {
+			prompt = fmt.Sprintf("System: %s\n\nUser: %s\n\nAssistant:", systemPrompt, prompt)
+		}
+		return prompt, nil
+	}
+
+	var messages []core.Message
+	switch v := input.(type) {
+	case string:
+		messages = []core.Message{core.NewHumanMessage(v, nil)}
+	case []core.Message:
+		if err := checkAttachments(v); err != nil {
+			return "", err
+		}
+		messages = v
+	default:
+		return "", fmt.Errorf("input must be a string or []core.Message")
+	}
+	if systemPrompt != "" && (len(messages) == 0 || messages[0].GetType() != core.MessageTypeSystem) {
+		messages = append([]core.Message{core.NewSystemMessage(systemPrompt, nil)}, messages...)
+	}
+	return formatter.Format(messages), nil
+}
+
+// stopSequences merges the model's stop sequences with those of the
+// invocation config, dropping duplicates
+func stopSequences(model []string, config *core.Config) []string {
+	if config == nil || len(config.Stop) == 0 {
+		return model
+	}
+	stops := append([]string{}, model...)
+	for _, s := range config.Stop {
+		dup := false
+		for _, existing := range stops {
+			if existing == s {
+				dup = true
+				break
+			}
+		}
+		if !dup && s != "" {
+			stops = append(stops, s)
+		}
+	}
+	return stops
+}
+
+// grammar returns the invocation's grammar, or "" when there is none
+func grammar(config *core.Config) string {
+	if config == nil {
+		return ""
+	}
+	return config.Grammar
+}
+
+// stopScanner finds stop sequences in generated tokens, for backends that
+// report tokens one by one. Text that could be the start of a stop sequence
+// is held back until later tokens settle it, so the stop sequence itself is
+// never emitted.
+type stopScanner struct {
+	stops   []string
+	pending string
+	stopped bool
+}
+
+// push adds a token and returns the text that is now safe to emit. stopped
+// is true once a stop sequence was found; generation should end there.
+func (s *stopScanner) push(token string) (emit string, stopped bool) {
+	if s.stopped {
+		return "", true
+	}
+	s.pending += token
+
+	cut := -1
+	for _, stop := range s.stops {
+		if stop == "" {
+			continue
+		}
+		if i := strings.Index(s.pending, stop); i >= 0 && (cut < 0 || i < cut) {
+			cut = i
+		}
+	}
+	if cut >= 0 {
+		emit, s.pending, s.stopped = s.pending[:cut], "", true
+		return emit, true
+	}
+
+	// Hold back the longest end of the text that begins a stop sequence
+	hold := 0
+	for _, stop := range s.stops {
+		for n := min(len(stop)-1, len(s.pending)); n > hold; n-- {
+			if strings.HasSuffix(s.pending, stop[:n]) {
+				hold = n
+				break
+			}
+		}
+	}
+	emit = s.pending[:len(s.pending)-hold]
+	s.pending = s.pending[len(s.pending)-hold:]
+	return emit, false
+}
+
+// flush returns the text held back when generation ended without a stop
+// sequence
+func (s *stopScanner) flush() string {
+	rest := s.pending
+	s.pending = ""
+	return rest
+}
+
+// trimStop cuts text at the first stop sequence, for backends that include
+// the matched stop sequence in their output
+func trimStop(text string, stops []string) string {
+	cut := len(text)
+	for _, s := range stops {
+		if s == "" {
+			continue
+		}
+		if i := strings.Index(text, s); i >= 0 && i < cut {
+			cut = i
+		}
+	}
+	return text[:cut]
+}
@@ -0,0 +1,348 @@
+// Package modelhub downloads GGUF models from Hugging Face into a local
+// cache, resuming interrupted downloads and verifying checksums.
+package modelhub
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Model identifies a GGUF file in a Hugging Face repository
+type Model struct {
+	Name     string
+	Repo     string // e.g. "Qwen/Qwen3-1.7B-GGUF"
+	File     string // e.g. "Qwen3-1.7B-Q8_0.gguf"
+	Revision string // branch, tag or commit; "main" by default
+	// SHA256 is the expected checksum; when empty, the LFS checksum
+	// Hugging Face reports for the file is used
+	SHA256 string
+}
+
+// Known lists the models used by the examples, by name
+var Known = map[string]Model{
+	"Qwen3-1.7B-Q8_0": {
+		Name: "Qwen3-1.7B-Q8_0",
+		Repo: "Qwen/Qwen3-1.7B-GGUF",
+		File: "Qwen3-1.7B-Q8_0.gguf",
+	},
+	"gpt-oss-20b-MXFP4": {
+		Name: "gpt-oss-20b-MXFP4",
+		Repo: "giladgd/gpt-oss-20b-GGUF",
+		File: "gpt-oss-20b.MXFP4.gguf",
+	},
+	"DeepSeek-R1-0528-Qwen3-8B-Q6_K": {
+		Name: "DeepSeek-R1-0528-Qwen3-8B-Q6_K",
+		Repo: "unsloth/DeepSeek-R1-0528-Qwen3-8B-GGUF",
+		File: "DeepSeek-R1-0528-Qwen3-8B-Q6_K.gguf",
+	},
+}
+
+// Resolve turns a reference into a model: a name from Known, or
+// "hf:owner/repo/file.gguf" with an optional "@revision"
+func Resolve(ref string) (Model, error) {
+	if m, ok := Known[ref]; ok {
+		return m, nil
+	}
+	if !strings.HasPrefix(ref, "hf:") {
+		return Model{}, fmt.Errorf("unknown model %q (use a known name or hf:owner/repo/file.gguf)", ref)
+	}
+
+	spec, revision := strings.TrimPrefix(ref, "hf:"), ""
+	if i := strings.LastIndex(spec, "@"); i != -1 {
+		spec, revision = spec[:i], spec[i+1:]
+	}
+	parts := strings.SplitN(spec, "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || !strings.HasSuffix(parts[2], ".gguf") {
+		return Model{}, fmt.Errorf("invalid model reference %q (expected hf:owner/repo/file.gguf)", ref)
+	}
+	return Model{
+		Name:     strings.TrimSuffix(filepath.Base(parts[2]), ".gguf"),
+		Repo:     parts[0] + "/" + parts[1],
+		File:     parts[2],
+		Revision: revision,
+	}, nil
+}
+
+// DefaultDir returns $MODELS_DIR, or "models" where the examples look for
+// their models when run from the repository root
+func DefaultDir() string {
+	if dir := os.Getenv("MODELS_DIR"); dir != "" {
+		return dir
+	}
+	return "models"
+}
+
+// ProgressFunc reports download progress; total is -1 when unknown
+type ProgressFunc func(file string, done, total int64)
+
+// Client downloads models into Dir
+type Client struct {
+	Dir        string
+	BaseURL    string // https://huggingface.co by default
+	Token      string // for gated repositories; $HF_TOKEN by default
+	HTTPClient *http.Client
+	Progress   ProgressFunc
+	// AllowUnverified keeps downloading, with a warning, a model whose
+	// checksum is neither set nor reported by the server; by default the
+	// download is refused
+	AllowUnverified bool
+}
+
+// NewClient creates a client caching models in dir; an empty dir uses
+// DefaultDir
+func NewClient(dir string) *Client {
+	if dir == "" {
+		dir = DefaultDir()
+	}
+	return &Client{
+		Dir:        dir,
+		BaseURL:    "https://huggingface.co",
+		Token:      os.Getenv("HF_TOKEN"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Path returns where the model is stored in the cache
+func (c *Client) Path(m Model) string {
+	return filepath.Join(c.Dir, filepath.Base(m.File))
+}
+
+// url returns the download URL of a model
+func (c *Client) url(m Model) string {
+	revision := m.Revision
+	if revision == "" {
+		revision = "main"
+	}
+	return fmt.Sprintf("%s/%s/resolve/%s/%s", strings.TrimRight(c.BaseURL, "/"), m.Repo, revision, m.File)
+}
+
+// Pull downloads the model referenced by ref unless it is already cached,
+// and returns its path
+func (c *Client) Pull(ctx context.Context, ref string) (string, error) {
+	m, err := Resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	path := c.Path(m)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create model dir: %w", err)
+	}
+
+	expected := m.SHA256
+	if expected == "" {
+		if expected, err = c.checksum(ctx, m); err != nil {
+			return "", err
+		}
+	}
+	if expected == "" {
+		if !c.AllowUnverified {
+			return "", fmt.Errorf("no checksum available for %s; set Model.SHA256 or allow unverified downloads", m.File)
+		}
+		fmt.Fprintf(os.Stderr, "warning: no checksum available for %s; the download will not be verified\n", m.File)
+	}
+
+	partial := path + ".partial"
+	if err := c.download(ctx, m, partial); err != nil {
+		return "", err
+	}
+	if expected != "" {
+		if err := verify(partial, expected); err != nil {
+			os.Remove(partial)
+			return "", err
+		}
+	}
+	if err := os.Rename(partial, path); err != nil {
+		return "", fmt.Errorf("failed to move model into place: %w", err)
+	}
+	return path, nil
+}
+
+// sha256Pattern matches the LFS checksum Hugging Face sends as ETag
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// checksum returns the SHA-256 of an LFS file, or "" when the server does
+// not report one. Hugging Face sends it as X-Linked-Etag on the redirect to
+// the storage backend, so the redirect is not followed.
+func (c *Client) checksum(ctx context.Context, m Model) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.url(m), nil)
+	if err != nil {
+		return "", err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := *c.HTTPClient
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("checksum lookup failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("checksum lookup for %s failed: %s", m.File, resp.Status)
+	}
+	return etagChecksum(resp), nil
+}
+
+// download fetches the model into partial, resuming from its current size
+func (c *Client) download(ctx context.Context, m Model, partial string) error {
+	f, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open download file: %w", err)
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(m), nil)
+	if err != nil {
+		return err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		// The server ignored the range; start over
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Already complete
+		return nil
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("download of %s failed: %s: %s", m.File, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	w := io.Writer(f)
+	if c.Progress != nil {
+		w = &progressWriter{w: f, file: m.File, done: offset, total: total, report: c.Progress}
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("download of %s interrupted (run again to resume): %w", m.File, err)
+	}
+	return nil
+}
+
+// etagChecksum returns the SHA-256 Hugging Face reports for LFS files
+func etagChecksum(resp *http.Response) string {
+	for _, h := range []string{"X-Linked-Etag", "ETag"} {
+		etag := strings.Trim(strings.TrimPrefix(resp.Header.Get(h), "W/"), `"`)
+		if sha256Pattern.MatchString(etag) {
+			return etag
+		}
+	}
+	return ""
+}
+
+// verify checks the SHA-256 of the file at path
+func verify(path, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != strings.ToLower(expected) {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", strings.TrimSuffix(filepath.Base(path), ".partial"), got, expected)
+	}
+	return nil
+}
+
+// progressInterval is how many bytes are written between progress reports
+const progressInterval = 1 << 20
+
+// progressWriter reports the bytes written through it
+type progressWriter struct {
+	w      io.Writer
+	file   string
+	done   int64
+	total  int64
+	last   int64
+	report ProgressFunc
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.done += int64(n)
+	if p.done-p.last >= progressInterval || p.done == p.total {
+		p.last = p.done
+		p.report(p.file, p.done, p.total)
+	}
+	return n, err
+}
+
+// LocalModel is a model file in the cache
+type LocalModel struct {
+	Name string
+	Path string
+	Size int64
+}
+
+// List returns the models in the cache, sorted by name
+func (c *Client) List() ([]LocalModel, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+
+	var models []LocalModel
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".gguf") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		models = append(models, LocalModel{
+			Name: strings.TrimSuffix(e.Name(), ".gguf"),
+			Path: filepath.Join(c.Dir, e.Name()),
+			Size: info.Size(),
+		})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	return models, nil
+}
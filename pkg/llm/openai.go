@@ -0,0 +1,264 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// OpenAIChatLLM talks to any OpenAI-compatible chat completions endpoint
+// (OpenAI, LM Studio, llama.cpp server, vLLM, ...). BaseURL includes the
+// API version, e.g. https://api.openai.com/v1 or http://localhost:1234/v1.
+type OpenAIChatLLM struct {
+	*core.BaseRunnable
+	config HTTPBackendConfig
+	tools  []map[string]interface{}
+	// grammar sends core.Config.Grammar, which only llama.cpp servers accept
+	grammar bool
+}
+
+// NewOpenAIChatLLM creates a client for the endpoint at config.BaseURL
+func NewOpenAIChatLLM(config HTTPBackendConfig) *OpenAIChatLLM {
+	return &OpenAIChatLLM{
+		BaseRunnable: core.NewBaseRunnable("OpenAIChatLLM"),
+		config:       config.withDefaults(),
+	}
+}
+
+// WithTools sets the tool definitions (OpenAI format) offered to the model;
+// use Chat to read the resulting tool calls
+func (o *OpenAIChatLLM) WithTools(tools []map[string]interface{}) *OpenAIChatLLM {
+	o.tools = tools
+	return o
+}
+
+// chatMessage is a message in the chat completions wire format
+type chatMessage struct {
+	Role       string         `json:"role"`
+	Content    string         `json:"content"`
+	ToolCalls  []chatToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+}
+
+type chatToolCall struct {
+	ID       string                `json:"id"`
+	Type     string                `json:"type"`
+	Function core.ToolCallFunction `json:"function"`
+}
+
+type chatRequest struct {
+	Model       string                   `json:"model,omitempty"`
+	Messages    []chatMessage            `json:"messages"`
+	MaxTokens   int                      `json:"max_tokens"`
+	Temperature float32                  `json:"temperature"`
+	TopP        float32                  `json:"top_p"`
+	Stop        []string                 `json:"stop,omitempty"`
+	Tools       []map[string]interface{} `json:"tools,omitempty"`
+	Stream      bool                     `json:"stream,omitempty"`
+	LogitBias   map[string]float32       `json:"logit_bias,omitempty"`
+	Grammar     string                   `json:"grammar,omitempty"`
+}
+
+// request builds the chat completions request for a string or []core.Message
+func (o *OpenAIChatLLM) request(input interface{}, stream bool, config *core.Config) (chatRequest, error) {
+	var messages []chatMessage
+	if o.config.SystemPrompt != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: o.config.SystemPrompt})
+	}
+	switch v := input.(type) {
+	case string:
+		messages = append(messages, chatMessage{Role: "user", Content: v})
+	case []core.Message:
+		if err := checkAttachments(v); err != nil {
+			return chatRequest{}, err
+		}
+		for _, msg := range v {
+			messages = append(messages, toChatMessage(msg))
+		}
+	default:
+		return chatRequest{}, fmt.Errorf("input must be a string or []core.Message")
+	}
+
+	var logitBias map[string]float32
+	if config != nil && len(config.LogitBias) > 0 {
+		// The API has no tokenize endpoint, so only token ids are accepted
+		bias, err := tokenBias(config.LogitBias, nil)
+		if err != nil {
+			return chatRequest{}, err
+		}
+		logitBias = make(map[string]float32, len(bias))
+		for id, b := range bias {
+			// The API takes biases between -100 (ban) and 100
+			logitBias[strconv.Itoa(id)] = float32(math.Max(-100, math.Min(100, float64(b))))
+		}
+	}
+
+	req := chatRequest{
+		Model:       o.config.Model,
+		Messages:    messages,
+		MaxTokens:   o.config.MaxTokens,
+		Temperature: o.config.Temperature,
+		TopP:        o.config.TopP,
+		Stop:        stopSequences(o.config.Stop, config),
+		Tools:       o.tools,
+		Stream:      stream,
+		LogitBias:   logitBias,
+	}
+	if o.grammar {
+		req.Grammar = grammar(config)
+	}
+	return req, nil
+}
+
+// toChatMessage converts a message to the wire format
+func toChatMessage(msg core.Message) chatMessage {
+	switch m := msg.(type) {
+	case *core.SystemMessage:
+		return chatMessage{Role: "system", Content: m.Content}
+	case *core.AIMessage:
+		out := chatMessage{Role: "assistant", Content: m.Content}
+		for _, call := range m.ToolCalls {
+			out.ToolCalls = append(out.ToolCalls, chatToolCall{ID: call.ID, Type: "function", Function: call.Function})
+		}
+		return out
+	case *core.ToolMessage:
+		return chatMessage{Role: "tool", Content: m.Content, ToolCallID: m.ToolCallID}
+	default:
+		return chatMessage{Role: "user", Content: msg.GetContent()}
+	}
+}
+
+// Chat sends the conversation and returns the assistant message, including
+// any tool calls the model made
+func (o *OpenAIChatLLM) Chat(ctx context.Context, input interface{}) (*core.AIMessage, error) {
+	gen, err := o.chat(ctx, input, nil)
+	if err != nil {
+		return nil, err
+	}
+	return gen.Message(), nil
+}
+
+// chat sends the conversation with the invocation config
+func (o *OpenAIChatLLM) chat(ctx context.Context, input interface{}, config *core.Config) (*core.Generation, error) {
+	req, err := o.request(input, false, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message      chatMessage `json:"message"`
+			FinishReason string      `json:"finish_reason"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	start := time.Now()
+	if err := o.config.postJSON(ctx, "/chat/completions", req, &resp); err != nil {
+		return nil, fmt.Errorf("prediction failed: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("prediction failed: no choices in response")
+	}
+
+	choice := resp.Choices[0]
+	gen := &core.Generation{
+		Text:             choice.Message.Content,
+		Model:            resp.Model,
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+		Duration:         time.Since(start),
+		FinishReason:     choice.FinishReason,
+	}
+	for _, call := range choice.Message.ToolCalls {
+		gen.ToolCalls = append(gen.ToolCalls, core.ToolCall{ID: call.ID, Type: "function", Function: call.Function})
+	}
+	return gen, nil
+}
+
+// dryRun renders the request body that would be sent, tools included
+func (o *OpenAIChatLLM) dryRun(input interface{}, config *core.Config) (DryRunResult, error) {
+	req, err := o.request(input, false, config)
+	if err != nil {
+		return DryRunResult{}, err
+	}
+	body, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return DryRunResult{}, err
+	}
+	model := o.config.Model
+	if model == "" {
+		model = o.Name()
+	}
+	return estimatedDryRun(model, string(body)), nil
+}
+
+// Invoke generates a response for the given prompt
+func (o *OpenAIChatLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	if IsDryRun(ctx) {
+		return o.dryRun(input, config)
+	}
+	return o.chat(ctx, input, config)
+}
+
+// Stream generates a response and streams tokens
+func (o *OpenAIChatLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	if IsDryRun(ctx) {
+		result, err := o.dryRun(input, config)
+		if err != nil {
+			return nil, err
+		}
+		return dryRunStream(result), nil
+	}
+	req, err := o.request(input, true, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.config.streamEvents(ctx, "/chat/completions", req, func(data []byte) (string, bool, error) {
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+				FinishReason *string `json:"finish_reason"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return "", false, err
+		}
+		if len(chunk.Choices) == 0 {
+			return "", true, nil
+		}
+		return chunk.Choices[0].Delta.Content, chunk.Choices[0].FinishReason == nil, nil
+	})
+}
+
+// Batch generates a response for each input in turn
+func (o *OpenAIChatLLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := o.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the model with another runnable
+func (o *OpenAIChatLLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{o, other})
+}
+
+// Close is a no-op; the server owns the model
+func (o *OpenAIChatLLM) Close() {}
@@ -0,0 +1,377 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// ProcessConfig configures a model served by a llama.cpp server binary
+// started and stopped by the package
+type ProcessConfig struct {
+	// Binary is a llama-server or llamafile executable; when empty,
+	// llama-server and then llamafile are looked up on PATH
+	Binary      string
+	ModelPath   string
+	ContextSize int
+	Threads     int
+	GPULayers   int
+	// Args are extra command-line flags for the server
+	Args []string
+	Host string // 127.0.0.1 by default
+	Port int    // a free port by default
+	// StartTimeout bounds the wait for the model to load (default 2m)
+	StartTimeout time.Duration
+	// MaxRestarts is how often a crashed server is started again (default
+	// 3); a negative value never restarts it
+	MaxRestarts int
+	// Download fetches the latest llama.cpp release into DownloadDir when
+	// no binary is found
+	Download    bool
+	DownloadDir string
+	// HTTP holds the sampling settings; its BaseURL is set to the server
+	HTTP HTTPBackendConfig
+}
+
+// ProcessLLM runs a llama.cpp server as a child process and talks to it
+// over HTTP. It needs no cgo, so it works where the go-llama.cpp bindings
+// cannot be built, e.g. on Windows or with newer llama.cpp releases. A
+// server that crashes is restarted on the same port; requests made
+// meanwhile wait for it to be ready again.
+type ProcessLLM struct {
+	*core.BaseRunnable
+	config  ProcessConfig
+	binary  string
+	baseURL string
+	llm     *LlamafileLLM
+	logs    *tailBuffer
+
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	exited   chan struct{}
+	exitErr  error
+	ready    chan struct{}
+	restarts int
+	closed   bool
+	failure  error
+}
+
+var _ ChatModel = (*ProcessLLM)(nil)
+
+// NewProcessLLM starts the server and waits until the model is loaded
+func NewProcessLLM(config ProcessConfig) (*ProcessLLM, error) {
+	if config.ModelPath == "" {
+		return nil, fmt.Errorf("model path is required")
+	}
+	binary, err := findServerBinary(config.Binary)
+	if err != nil && config.Download && config.Binary == "" {
+		binary, err = FetchLlamaServer(context.Background(), config.DownloadDir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if config.Host == "" {
+		config.Host = "127.0.0.1"
+	}
+	if config.Port == 0 {
+		if config.Port, err = freePort(config.Host); err != nil {
+			return nil, fmt.Errorf("failed to pick a server port: %w", err)
+		}
+	}
+	if config.StartTimeout == 0 {
+		config.StartTimeout = 2 * time.Minute
+	}
+	if config.MaxRestarts == 0 {
+		config.MaxRestarts = 3
+	}
+
+	p := &ProcessLLM{
+		BaseRunnable: core.NewBaseRunnable("ProcessLLM"),
+		config:       config,
+		binary:       binary,
+		baseURL:      fmt.Sprintf("http://%s", net.JoinHostPort(config.Host, strconv.Itoa(config.Port))),
+		logs:         &tailBuffer{max: 4096},
+	}
+	httpConfig := config.HTTP
+	httpConfig.BaseURL = p.baseURL
+	p.llm = NewLlamafileLLM(httpConfig)
+
+	fmt.Printf("Starting %s for %s\n", filepath.Base(binary), config.ModelPath)
+	if err := p.start(); err != nil {
+		p.Close()
+		return nil, err
+	}
+	go p.supervise()
+	return p, nil
+}
+
+// findServerBinary resolves the configured binary or looks one up on PATH
+func findServerBinary(binary string) (string, error) {
+	if binary != "" {
+		return exec.LookPath(binary)
+	}
+	for _, name := range []string{"llama-server", "llamafile"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no llama-server or llamafile binary found on PATH")
+}
+
+// serverArgs builds the command line for binary
+func serverArgs(binary string, config ProcessConfig) []string {
+	var args []string
+	if strings.Contains(strings.ToLower(filepath.Base(binary)), "llamafile") {
+		args = append(args, "--server", "--nobrowser")
+	}
+	args = append(args, "-m", config.ModelPath, "--host", config.Host, "--port", strconv.Itoa(config.Port))
+	if config.ContextSize > 0 {
+		args = append(args, "-c", strconv.Itoa(config.ContextSize))
+	}
+	if config.Threads > 0 {
+		args = append(args, "-t", strconv.Itoa(config.Threads))
+	}
+	if config.GPULayers > 0 {
+		args = append(args, "-ngl", strconv.Itoa(config.GPULayers))
+	}
+	return append(args, config.Args...)
+}
+
+// freePort asks the OS for a port that is free on host
+func freePort(host string) (int, error) {
+	l, err := net.Listen("tcp", net.JoinHostPort(host, "0"))
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// start launches the server process and waits until it is ready
+func (p *ProcessLLM) start() error {
+	cmd := exec.Command(p.binary, serverArgs(p.binary, p.config)...)
+	cmd.Stdout = p.logs
+	cmd.Stderr = p.logs
+	// Shared libraries of downloaded releases sit next to the binary
+	libPath := filepath.Dir(p.binary)
+	if existing := os.Getenv("LD_LIBRARY_PATH"); existing != "" {
+		libPath += string(os.PathListSeparator) + existing
+	}
+	cmd.Env = append(os.Environ(), "LD_LIBRARY_PATH="+libPath)
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return fmt.Errorf("server closed")
+	}
+	if err := cmd.Start(); err != nil {
+		p.mu.Unlock()
+		return fmt.Errorf("failed to start %s: %w", p.binary, err)
+	}
+	exited := make(chan struct{})
+	p.cmd, p.exited, p.exitErr = cmd, exited, nil
+	if p.ready == nil {
+		p.ready = make(chan struct{})
+	}
+	ready := p.ready
+	p.mu.Unlock()
+
+	go func() {
+		err := cmd.Wait()
+		p.mu.Lock()
+		p.exitErr = err
+		p.mu.Unlock()
+		close(exited)
+	}()
+
+	if err := p.waitReady(exited); err != nil {
+		cmd.Process.Kill()
+		return err
+	}
+	close(ready)
+	return nil
+}
+
+// waitReady polls /health until the server reports the model loaded, the
+// process exits or the start timeout passes
+func (p *ProcessLLM) waitReady(exited <-chan struct{}) error {
+	client := &http.Client{Timeout: 2 * time.Second}
+	deadline := time.After(p.config.StartTimeout)
+	tick := time.NewTicker(250 * time.Millisecond)
+	defer tick.Stop()
+	for {
+		resp, err := client.Get(p.baseURL + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		select {
+		case <-exited:
+			return fmt.Errorf("server exited while loading the model: %v\n%s", p.lastExit(), p.logs)
+		case <-deadline:
+			return fmt.Errorf("server not ready after %s\n%s", p.config.StartTimeout, p.logs)
+		case <-tick.C:
+		}
+	}
+}
+
+// supervise restarts the server when it exits unexpectedly, until it has
+// been restarted MaxRestarts times
+func (p *ProcessLLM) supervise() {
+	for {
+		p.mu.Lock()
+		exited := p.exited
+		p.mu.Unlock()
+		<-exited
+
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return
+		}
+		crash := fmt.Errorf("server process exited: %v\n%s", p.exitErr, p.logs)
+		if p.config.MaxRestarts < 0 || p.restarts >= p.config.MaxRestarts {
+			p.failure = crash
+			p.mu.Unlock()
+			return
+		}
+		p.restarts++
+		p.ready = make(chan struct{})
+		p.mu.Unlock()
+
+		fmt.Printf("Restarting %s after crash (%d/%d): %v\n",
+			filepath.Base(p.binary), p.restarts, p.config.MaxRestarts, p.lastExit())
+		if err := p.start(); err != nil {
+			p.mu.Lock()
+			p.failure = err
+			p.mu.Unlock()
+			return
+		}
+	}
+}
+
+// lastExit returns why the latest process exited
+func (p *ProcessLLM) lastExit() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.exitErr == nil {
+		return errors.New("exit status 0")
+	}
+	return p.exitErr
+}
+
+// available waits until the server is ready, or returns why it is not
+func (p *ProcessLLM) available(ctx context.Context) error {
+	for {
+		p.mu.Lock()
+		ready, failure, closed := p.ready, p.failure, p.closed
+		p.mu.Unlock()
+		switch {
+		case closed:
+			return fmt.Errorf("server closed")
+		case failure != nil:
+			return failure
+		}
+		select {
+		case <-ready:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			// Check again, in case the restart failed
+		}
+	}
+}
+
+// Invoke generates a completion with the server
+func (p *ProcessLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	if err := p.available(ctx); err != nil {
+		return nil, err
+	}
+	return p.llm.Invoke(ctx, input, config)
+}
+
+// Stream streams a completion from the server
+func (p *ProcessLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	if err := p.available(ctx); err != nil {
+		return nil, err
+	}
+	return p.llm.Stream(ctx, input, config)
+}
+
+// Batch generates completions for every input with the server
+func (p *ProcessLLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	if err := p.available(ctx); err != nil {
+		return nil, err
+	}
+	return p.llm.Batch(ctx, inputs, config)
+}
+
+// Pipe composes the model with another runnable
+func (p *ProcessLLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{p, other})
+}
+
+// Close stops the server, asking it to shut down first and killing it if
+// it has not exited within five seconds
+func (p *ProcessLLM) Close() {
+	p.mu.Lock()
+	p.closed = true
+	cmd, exited := p.cmd, p.exited
+	p.mu.Unlock()
+	if cmd == nil || cmd.Process == nil {
+		return
+	}
+
+	select {
+	case <-exited:
+		return
+	default:
+	}
+	if runtime.GOOS == "windows" || cmd.Process.Signal(os.Interrupt) != nil {
+		cmd.Process.Kill()
+	}
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		cmd.Process.Kill()
+		<-exited
+	}
+}
+
+// tailBuffer keeps the last max bytes written to it, for error reports
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (t *tailBuffer) Write(b []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, b...)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+	}
+	return len(b), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.TrimSpace(string(t.buf))
+}
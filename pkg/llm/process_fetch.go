@@ -0,0 +1,249 @@
+package llm
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// llamaCppReleasesURL is the GitHub API endpoint of the latest llama.cpp release
+const llamaCppReleasesURL = "https://api.github.com/repos/ggml-org/llama.cpp/releases/latest"
+
+// serverBinaryName is the file name of llama-server on this platform
+func serverBinaryName() string {
+	if runtime.GOOS == "windows" {
+		return "llama-server.exe"
+	}
+	return "llama-server"
+}
+
+// FetchLlamaServer returns a llama-server binary in dir, downloading the
+// latest llama.cpp release build for this platform if none was fetched
+// before. The download is checked against the SHA-256 digest GitHub
+// publishes for the release asset, and refused when there is none. An
+// empty dir uses the user cache directory.
+func FetchLlamaServer(ctx context.Context, dir string) (string, error) {
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("no download dir: %w", err)
+		}
+		dir = filepath.Join(cache, "ai-agents-from-scratch", "llama.cpp")
+	}
+
+	// Releases are kept in one directory per tag; reuse the newest one
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*", serverBinaryName())); len(matches) > 0 {
+		sort.Slice(matches, func(i, j int) bool {
+			return buildNumber(matches[i]) < buildNumber(matches[j])
+		})
+		return matches[len(matches)-1], nil
+	}
+
+	tag, url, digest, err := latestServerAsset(ctx)
+	if err != nil {
+		return "", err
+	}
+	target := filepath.Join(dir, tag)
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create download dir: %w", err)
+	}
+
+	fmt.Printf("Downloading llama.cpp %s from %s\n", tag, url)
+	archive, err := download(ctx, url, filepath.Join(target, filepath.Base(url)), digest)
+	if err != nil {
+		os.RemoveAll(target)
+		return "", err
+	}
+	defer os.Remove(archive)
+	if err := extractFlat(archive, target); err != nil {
+		os.RemoveAll(target)
+		return "", fmt.Errorf("failed to extract %s: %w", filepath.Base(archive), err)
+	}
+
+	binary := filepath.Join(target, serverBinaryName())
+	if _, err := os.Stat(binary); err != nil {
+		os.RemoveAll(target)
+		return "", fmt.Errorf("release %s has no %s", tag, serverBinaryName())
+	}
+	return binary, os.Chmod(binary, 0o755)
+}
+
+// buildNumber reads the build number of a release directory such as
+// .../b4567/llama-server, so that b1000 sorts after b999; -1 if there is none
+func buildNumber(binary string) int {
+	tag := filepath.Base(filepath.Dir(binary))
+	n, err := strconv.Atoi(strings.TrimPrefix(tag, "b"))
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// latestServerAsset finds the release build matching this OS and CPU and
+// returns its tag, download URL and SHA-256 digest
+func latestServerAsset(ctx context.Context) (string, string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, llamaCppReleasesURL, nil)
+	if err != nil {
+		return "", "", "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to query llama.cpp releases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", "", fmt.Errorf("llama.cpp releases returned %s", resp.Status)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name   string `json:"name"`
+			URL    string `json:"browser_download_url"`
+			Digest string `json:"digest"` // "sha256:<hex>"
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", "", "", fmt.Errorf("failed to parse llama.cpp releases: %w", err)
+	}
+
+	osName := map[string]string{"linux": "ubuntu", "darwin": "macos", "windows": "win"}[runtime.GOOS]
+	arch := map[string]string{"amd64": "x64", "arm64": "arm64"}[runtime.GOARCH]
+	if osName == "" || arch == "" {
+		return "", "", "", fmt.Errorf("no llama.cpp release build for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	// Prefer the plain build, then the CPU-only one (Windows)
+	for _, suffix := range []string{
+		fmt.Sprintf("-bin-%s-%s.", osName, arch),
+		fmt.Sprintf("-bin-%s-cpu-%s.", osName, arch),
+	} {
+		for _, asset := range release.Assets {
+			if strings.Contains(asset.Name, suffix) &&
+				(strings.HasSuffix(asset.Name, ".zip") || strings.HasSuffix(asset.Name, ".tar.gz")) {
+				digest, ok := strings.CutPrefix(asset.Digest, "sha256:")
+				if !ok || len(digest) != sha256.Size*2 {
+					return "", "", "", fmt.Errorf("llama.cpp %s publishes no SHA-256 digest for %s; refusing to run an unverified binary", release.TagName, asset.Name)
+				}
+				return release.TagName, asset.URL, strings.ToLower(digest), nil
+			}
+		}
+	}
+	return "", "", "", fmt.Errorf("llama.cpp %s has no build for %s/%s", release.TagName, runtime.GOOS, runtime.GOARCH)
+}
+
+// download saves url to path, checking that its SHA-256 is digest
+func download(ctx context.Context, url, path, digest string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download of %s returned %s", url, resp.Status)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), resp.Body); err != nil {
+		return "", fmt.Errorf("download of %s failed: %w", url, err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != digest {
+		return "", fmt.Errorf("checksum mismatch for %s: got %s, want %s", filepath.Base(path), got, digest)
+	}
+	return path, nil
+}
+
+// extractFlat writes the regular files of a .zip or .tar.gz archive into
+// dir, dropping their directories: releases keep the binaries and shared
+// libraries under build/bin
+func extractFlat(archive, dir string) error {
+	write := func(name string, mode os.FileMode, r io.Reader) error {
+		f, err := os.OpenFile(filepath.Join(dir, filepath.Base(name)), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode|0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(f, r)
+		return err
+	}
+
+	if strings.HasSuffix(archive, ".zip") {
+		zr, err := zip.OpenReader(archive)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		for _, file := range zr.File {
+			if !file.Mode().IsRegular() {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return err
+			}
+			err = write(file.Name, file.Mode().Perm(), rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeSymlink {
+			// Shared libraries link their versioned names
+			link := filepath.Join(dir, filepath.Base(header.Name))
+			os.Remove(link)
+			if err := os.Symlink(filepath.Base(header.Linkname), link); err != nil {
+				return err
+			}
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := write(header.Name, os.FileMode(header.Mode).Perm(), tr); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,427 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// ToolCallExample is one few-shot demonstration for PromptedToolsLLM: a
+// request answered either by calling Tool with Args or directly with Answer
+type ToolCallExample struct {
+	Request string
+	Tool    string
+	Args    map[string]interface{}
+	Answer  string
+}
+
+// toolEnvelope is the JSON the model must answer with: tool calls, or a
+// final answer
+type toolEnvelope struct {
+	ToolCalls []envelopeCall `json:"tool_calls,omitempty"`
+	Answer    *string        `json:"answer,omitempty"`
+}
+
+type envelopeCall struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// PromptedToolsLLM adds tool calling to models that were not trained for
+// it, so tool-using agents work with any GGUF. The tools and a strict JSON
+// envelope are described in the system prompt and demonstrated with
+// few-shot examples, a GBNF grammar keeps the output to that envelope on
+// backends that support grammars, and the reply is parsed into
+// core.ToolCall objects as a native function-calling model would return
+// them. Tool results in the conversation are shown to the model as user
+// turns, since plain chat templates have no tool role.
+type PromptedToolsLLM struct {
+	*core.BaseRunnable
+	llm      core.Runnable
+	tools    []map[string]interface{}
+	examples []ToolCallExample
+	grammar  bool
+
+	mu    sync.Mutex
+	calls int
+}
+
+var _ ChatModel = (*PromptedToolsLLM)(nil)
+
+// WithPromptedTools wraps llm to call tools, given as OpenAI-style function
+// definitions such as ToolRegistry.GetFunctionDefinitions returns
+func WithPromptedTools(llm core.Runnable, tools []map[string]interface{}) *PromptedToolsLLM {
+	return &PromptedToolsLLM{
+		BaseRunnable: core.NewBaseRunnable("PromptedToolsLLM"),
+		llm:          llm,
+		tools:        tools,
+		grammar:      true,
+	}
+}
+
+// WithExamples replaces the default few-shot examples, which call the
+// first tool with placeholder arguments and answer a question directly
+func (p *PromptedToolsLLM) WithExamples(examples ...ToolCallExample) *PromptedToolsLLM {
+	p.examples = examples
+	return p
+}
+
+// WithGrammar turns the output grammar on or off (default on); turn it off
+// when the wrapped model rejects grammars
+func (p *PromptedToolsLLM) WithGrammar(on bool) *PromptedToolsLLM {
+	p.grammar = on
+	return p
+}
+
+// toolFunction returns the function part of a definition, which may also
+// be given without the {"type": "function"} wrapper
+func toolFunction(def map[string]interface{}) map[string]interface{} {
+	if fn, ok := def["function"].(map[string]interface{}); ok {
+		return fn
+	}
+	return def
+}
+
+func (p *PromptedToolsLLM) toolNames() []string {
+	var names []string
+	for _, def := range p.tools {
+		if name, ok := toolFunction(def)["name"].(string); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// defaultExamples demonstrates a call to the first tool, with a placeholder
+// for each required argument, and a direct answer
+func (p *PromptedToolsLLM) defaultExamples() []ToolCallExample {
+	var examples []ToolCallExample
+	if len(p.tools) > 0 {
+		fn := toolFunction(p.tools[0])
+		name, _ := fn["name"].(string)
+		args := map[string]interface{}{}
+		params, _ := fn["parameters"].(map[string]interface{})
+		props, _ := params["properties"].(map[string]interface{})
+		required := stringSlice(params["required"])
+		if len(required) == 0 {
+			for prop := range props {
+				required = append(required, prop)
+			}
+			sort.Strings(required)
+		}
+		for _, prop := range required {
+			schema, _ := props[prop].(map[string]interface{})
+			args[prop] = placeholder(schema)
+		}
+		examples = append(examples, ToolCallExample{
+			Request: fmt.Sprintf("Please use the %s tool.", name),
+			Tool:    name,
+			Args:    args,
+		})
+	}
+	return append(examples, ToolCallExample{
+		Request: "Thanks, that is all I needed.",
+		Answer:  "You're welcome! Let me know if you need anything else.",
+	})
+}
+
+// stringSlice reads a JSON array of strings
+func stringSlice(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		var out []string
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// placeholder is an example value for an argument of the given schema
+func placeholder(schema map[string]interface{}) interface{} {
+	if values, ok := schema["enum"].([]interface{}); ok && len(values) > 0 {
+		return values[0]
+	}
+	switch schema["type"] {
+	case "integer", "number":
+		return 1
+	case "boolean":
+		return true
+	case "array":
+		return []interface{}{}
+	case "object":
+		return map[string]interface{}{}
+	default:
+		return "..."
+	}
+}
+
+// envelopeJSON renders a tool call or answer as the model should write it
+func envelopeJSON(env toolEnvelope) string {
+	data, _ := json.Marshal(env)
+	return string(data)
+}
+
+// systemPrompt describes the tools and the reply format
+func (p *PromptedToolsLLM) systemPrompt() string {
+	var b strings.Builder
+	b.WriteString("You can call these tools:\n\n")
+	for _, def := range p.tools {
+		fn := toolFunction(def)
+		params, _ := json.Marshal(fn["parameters"])
+		fmt.Fprintf(&b, "- %v: %v\n  arguments (JSON schema): %s\n", fn["name"], fn["description"], params)
+	}
+	b.WriteString(`
+Reply with exactly one JSON object and nothing else.
+To call tools: {"tool_calls": [{"name": "<tool name>", "arguments": {<arguments>}}]}
+To answer the user: {"answer": "<your answer>"}
+Call a tool when you need information or an action it provides; once you have the tool results, answer.`)
+	return b.String()
+}
+
+// messages builds the conversation sent to the model: the tool
+// instructions merged into the system prompt, the few-shot examples, and
+// the input with tool calls and results rewritten in the envelope format
+func (p *PromptedToolsLLM) messages(input interface{}) ([]core.Message, error) {
+	var history []core.Message
+	switch v := input.(type) {
+	case string:
+		history = []core.Message{core.NewHumanMessage(v, nil)}
+	case []core.Message:
+		history = v
+	default:
+		return nil, fmt.Errorf("input must be a string or []core.Message")
+	}
+
+	system := p.systemPrompt()
+	if len(history) > 0 && history[0].GetType() == core.MessageTypeSystem {
+		system = history[0].GetContent() + "\n\n" + system
+		history = history[1:]
+	}
+	messages := []core.Message{core.NewSystemMessage(system, nil)}
+
+	examples := p.examples
+	if examples == nil {
+		examples = p.defaultExamples()
+	}
+	for _, ex := range examples {
+		reply := toolEnvelope{Answer: &ex.Answer}
+		if ex.Tool != "" {
+			reply = toolEnvelope{ToolCalls: []envelopeCall{{Name: ex.Tool, Arguments: ex.Args}}}
+		}
+		messages = append(messages,
+			core.NewHumanMessage(ex.Request, nil),
+			core.NewAIMessage(envelopeJSON(reply), nil))
+	}
+
+	names := map[string]string{}
+	for _, msg := range history {
+		switch m := msg.(type) {
+		case *core.AIMessage:
+			if len(m.ToolCalls) == 0 {
+				answer := m.Content
+				messages = append(messages, core.NewAIMessage(envelopeJSON(toolEnvelope{Answer: &answer}), nil))
+				continue
+			}
+			var env toolEnvelope
+			for _, call := range m.ToolCalls {
+				names[call.ID] = call.Function.Name
+				args := call.Args
+				if args == nil {
+					json.Unmarshal([]byte(call.Function.Arguments), &args)
+				}
+				env.ToolCalls = append(env.ToolCalls, envelopeCall{Name: call.Function.Name, Arguments: args})
+			}
+			messages = append(messages, core.NewAIMessage(envelopeJSON(env), nil))
+		case *core.ToolMessage:
+			name := names[m.ToolCallID]
+			if name == "" {
+				name = "the tool"
+			}
+			messages = append(messages, core.NewHumanMessage(fmt.Sprintf("Result of %s: %s", name, m.Content), nil))
+		default:
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// invocationConfig adds the envelope grammar to config
+func (p *PromptedToolsLLM) invocationConfig(config *core.Config) *core.Config {
+	if !p.grammar {
+		return config
+	}
+	c := core.NewConfig()
+	if config != nil {
+		copied := *config
+		c = &copied
+	}
+	c.Grammar = envelopeGrammar(p.toolNames())
+	return c
+}
+
+// envelopeGrammar is a GBNF grammar accepting a final answer or tool calls
+// to one of names with any JSON object as arguments
+func envelopeGrammar(names []string) string {
+	root := `root ::= ws answer ws`
+	var rules string
+	if len(names) > 0 {
+		root = `root ::= ws (calls | answer) ws`
+		literals := make([]string, len(names))
+		for i, name := range names {
+			quoted, _ := json.Marshal(name)
+			literal, _ := json.Marshal(string(quoted))
+			literals[i] = string(literal)
+		}
+		rules = `calls ::= "{" ws "\"tool_calls\"" ws ":" ws "[" ws call (ws "," ws call)* ws "]" ws "}"
+call ::= "{" ws "\"name\"" ws ":" ws name ws "," ws "\"arguments\"" ws ":" ws object ws "}"
+name ::= ` + strings.Join(literals, " | ") + "\n"
+	}
+	return root + "\n" + rules + `answer ::= "{" ws "\"answer\"" ws ":" ws string ws "}"
+value ::= object | array | string | number | "true" | "false" | "null"
+object ::= "{" ws (string ws ":" ws value (ws "," ws string ws ":" ws value)*)? ws "}"
+array ::= "[" ws (value (ws "," ws value)*)? ws "]"
+string ::= "\"" ([^"\\\x7F\x00-\x1F] | "\\" (["\\/bfnrt] | "u" [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F] [0-9a-fA-F]))* "\""
+number ::= "-"? ([0-9] | [1-9] [0-9]*) ("." [0-9]+)? ([eE] [-+]? [0-9]+)?
+ws ::= [ \t\n]*
+`
+}
+
+// parse turns the model's reply into a generation with tool calls or the
+// answer text. A reply that is not a valid envelope is kept as the answer,
+// so models that ignore the format still get through.
+func (p *PromptedToolsLLM) parse(gen *core.Generation) *core.Generation {
+	text := strings.TrimSpace(gen.Text)
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start == -1 || end < start {
+		return gen
+	}
+	var env toolEnvelope
+	if err := json.Unmarshal([]byte(text[start:end+1]), &env); err != nil {
+		return gen
+	}
+
+	out := *gen
+	switch {
+	case len(env.ToolCalls) > 0:
+		out.Text = ""
+		out.ToolCalls = nil
+		for _, call := range env.ToolCalls {
+			if call.Arguments == nil {
+				call.Arguments = map[string]interface{}{}
+			}
+			arguments, _ := json.Marshal(call.Arguments)
+			out.ToolCalls = append(out.ToolCalls, core.ToolCall{
+				ID:       p.nextCallID(),
+				Type:     "function",
+				Function: core.ToolCallFunction{Name: call.Name, Arguments: string(arguments)},
+				Args:     call.Arguments,
+			})
+		}
+		out.FinishReason = core.FinishToolCalls
+	case env.Answer != nil:
+		out.Text = *env.Answer
+	}
+	return &out
+}
+
+// nextCallID numbers the tool calls made through this model
+func (p *PromptedToolsLLM) nextCallID() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	return fmt.Sprintf("call_%d", p.calls)
+}
+
+// Invoke asks the model for tool calls or an answer and returns them as a
+// *core.Generation; tool calls have FinishReason core.FinishToolCalls
+func (p *PromptedToolsLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	messages, err := p.messages(input)
+	if err != nil {
+		return nil, err
+	}
+	output, err := p.llm.Invoke(ctx, messages, p.invocationConfig(config))
+	if err != nil {
+		return nil, err
+	}
+	switch v := output.(type) {
+	case *core.Generation:
+		return p.parse(v), nil
+	case string:
+		return p.parse(&core.Generation{Text: v}), nil
+	default:
+		// Dry runs and other results pass through
+		return output, nil
+	}
+}
+
+// Chat sends the conversation and returns the assistant message, including
+// any tool calls the model made
+func (p *PromptedToolsLLM) Chat(ctx context.Context, input interface{}) (*core.AIMessage, error) {
+	output, err := p.Invoke(ctx, input, nil)
+	if err != nil {
+		return nil, err
+	}
+	gen, ok := output.(*core.Generation)
+	if !ok {
+		return core.NewAIMessage(fmt.Sprint(output), nil), nil
+	}
+	return gen.Message(), nil
+}
+
+// Stream emits the answer as a single chunk once the envelope is complete;
+// tool calls are not streamed, use Invoke or Chat to read them
+func (p *PromptedToolsLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		output, err := p.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		if gen, ok := output.(*core.Generation); ok {
+			if gen.Text != "" {
+				out <- gen.Text
+			}
+			return
+		}
+		out <- output
+	}()
+	return out, nil
+}
+
+// Batch answers every input in turn
+func (p *PromptedToolsLLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := p.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the model with another runnable
+func (p *PromptedToolsLLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{p, other})
+}
+
+// Close closes the wrapped model if it holds resources
+func (p *PromptedToolsLLM) Close() {
+	if m, ok := p.llm.(ChatModel); ok {
+		m.Close()
+	}
+}
@@ -0,0 +1,345 @@
+package llm
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// Priority orders requests waiting for the model
+type Priority int
+
+const (
+	PriorityBatch       Priority = 0
+	PriorityNormal      Priority = 1
+	PriorityInteractive Priority = 2
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose LLM requests are queued with priority p
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the request priority, PriorityNormal by default
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+type queueSessionKey struct{}
+
+// WithQueueSession returns a context whose LLM requests are accounted to
+// session id, so a QueuedLLM shares the model fairly between sessions
+func WithQueueSession(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, queueSessionKey{}, id)
+}
+
+// QueueSessionFromContext returns the session id of requests, "" by default
+func QueueSessionFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(queueSessionKey{}).(string)
+	return id
+}
+
+// QueueStatus describes a request waiting for the model
+type QueueStatus struct {
+	// Position is 1 for the next request to run
+	Position int
+	// ETA estimates the wait from the average time requests hold the model
+	ETA time.Duration
+}
+
+// QueueObserver is told the position of a waiting request each time the
+// queue changes. It runs with the queue locked and must not call into it.
+type QueueObserver func(QueueStatus)
+
+type queueObserverKey struct{}
+
+// WithQueueObserver returns a context whose queued LLM requests report
+// their position to observe, e.g. to show "3rd in line, ~20s" to a client
+func WithQueueObserver(ctx context.Context, observe QueueObserver) context.Context {
+	return context.WithValue(ctx, queueObserverKey{}, observe)
+}
+
+// sessionUsage is the model time a session received, used to order its
+// waiting requests
+type sessionUsage struct {
+	served time.Duration
+	// active counts the session's waiting and running requests
+	active int
+}
+
+// ticket is a request waiting for a model slot
+type ticket struct {
+	priority Priority
+	seq      uint64
+	usage    *sessionUsage
+	observe  QueueObserver
+	ready    chan struct{}
+	index    int
+}
+
+// ticketHeap pops the highest priority first; within a priority, the
+// session that used the model least goes first, FIFO within a session
+type ticketHeap []*ticket
+
+func (h ticketHeap) Len() int           { return len(h) }
+func (h ticketHeap) Less(i, j int) bool { return h[i].before(h[j]) }
+
+// before reports whether t runs before o
+func (t *ticket) before(o *ticket) bool {
+	if t.priority != o.priority {
+		return t.priority > o.priority
+	}
+	if t.usage.served != o.usage.served {
+		return t.usage.served < o.usage.served
+	}
+	return t.seq < o.seq
+}
+func (h ticketHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *ticketHeap) Push(x interface{}) {
+	t := x.(*ticket)
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+func (h *ticketHeap) Pop() interface{} {
+	old := *h
+	t := old[len(old)-1]
+	*h = old[:len(old)-1]
+	t.index = -1
+	return t
+}
+
+// QueuedLLM serializes access to a model that cannot run concurrent generations.
+// At most `workers` requests run at once; the rest wait in a priority queue, so
+// an interactive chat request overtakes any queued (but not running) batch jobs.
+// Requests of the same priority from different sessions (see WithQueueSession)
+// are ordered by how much model time each session already used, so a session
+// sending long or many requests cannot keep the others waiting.
+type QueuedLLM struct {
+	*core.BaseRunnable
+	llm     core.Runnable
+	workers int
+
+	mu         sync.Mutex
+	running    int
+	waiting    ticketHeap
+	seq        uint64
+	sessions   map[string]*sessionUsage
+	avgService time.Duration
+}
+
+var _ ChatModel = (*QueuedLLM)(nil)
+
+// NewQueuedLLM wraps llm so that at most workers requests run concurrently
+func NewQueuedLLM(llm core.Runnable, workers int) *QueuedLLM {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &QueuedLLM{
+		BaseRunnable: core.NewBaseRunnable("QueuedLLM"),
+		llm:          llm,
+		workers:      workers,
+		sessions:     make(map[string]*sessionUsage),
+	}
+}
+
+// session returns the usage of session id, counting one more active
+// request. A new session starts level with the least served active one,
+// so it neither jumps ahead of nor lags behind the sessions already queued.
+func (q *QueuedLLM) session(id string) *sessionUsage {
+	u, ok := q.sessions[id]
+	if !ok {
+		u = &sessionUsage{}
+		first := true
+		for _, other := range q.sessions {
+			if first || other.served < u.served {
+				u.served = other.served
+				first = false
+			}
+		}
+		q.sessions[id] = u
+	}
+	u.active++
+	return u
+}
+
+// done records that a request of session id used the model for elapsed
+func (q *QueuedLLM) done(id string, elapsed time.Duration) {
+	u := q.sessions[id]
+	u.served += elapsed
+	if u.active--; u.active == 0 {
+		delete(q.sessions, id)
+	}
+	if q.avgService == 0 {
+		q.avgService = elapsed
+	} else {
+		q.avgService = (q.avgService*4 + elapsed) / 5
+	}
+}
+
+// acquire blocks until a slot is free for a request of the given priority
+func (q *QueuedLLM) acquire(ctx context.Context) error {
+	q.mu.Lock()
+	usage := q.session(QueueSessionFromContext(ctx))
+	if q.running < q.workers && q.waiting.Len() == 0 {
+		q.running++
+		q.mu.Unlock()
+		return nil
+	}
+
+	observe, _ := ctx.Value(queueObserverKey{}).(QueueObserver)
+	t := &ticket{priority: PriorityFromContext(ctx), seq: q.seq, usage: usage, observe: observe, ready: make(chan struct{})}
+	q.seq++
+	heap.Push(&q.waiting, t)
+	q.notifyLocked()
+	q.mu.Unlock()
+
+	select {
+	case <-t.ready:
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if t.index >= 0 {
+			heap.Remove(&q.waiting, t.index)
+			q.done(QueueSessionFromContext(ctx), 0)
+			q.notifyLocked()
+			return ctx.Err()
+		}
+		// The slot was handed to us just as we gave up; pass it on
+		q.releaseLocked(QueueSessionFromContext(ctx), 0)
+		return ctx.Err()
+	}
+}
+
+// release hands the slot of a request that ran for elapsed to the next
+// waiting request, if any
+func (q *QueuedLLM) release(ctx context.Context, elapsed time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.releaseLocked(QueueSessionFromContext(ctx), elapsed)
+}
+
+// releaseLocked is release with q.mu already held
+func (q *QueuedLLM) releaseLocked(session string, elapsed time.Duration) {
+	q.done(session, elapsed)
+	if q.waiting.Len() > 0 {
+		// Usage changed, so the order of the waiting requests may have too
+		heap.Init(&q.waiting)
+		t := heap.Pop(&q.waiting).(*ticket)
+		close(t.ready)
+		q.notifyLocked()
+		return
+	}
+	q.running--
+}
+
+// notifyLocked reports their position to the waiting requests that observe it
+func (q *QueuedLLM) notifyLocked() {
+	order := make([]*ticket, len(q.waiting))
+	copy(order, q.waiting)
+	sort.Slice(order, func(i, j int) bool { return order[i].before(order[j]) })
+	for i, t := range order {
+		if t.observe == nil {
+			continue
+		}
+		rounds := i/q.workers + 1
+		t.observe(QueueStatus{Position: i + 1, ETA: time.Duration(rounds) * q.avgService})
+	}
+}
+
+// QueueLength returns the number of requests waiting for a slot
+func (q *QueuedLLM) QueueLength() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.waiting.Len()
+}
+
+// Invoke waits for a slot and runs the wrapped model
+func (q *QueuedLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	if err := q.acquire(ctx); err != nil {
+		return nil, fmt.Errorf("request abandoned while queued: %w", err)
+	}
+	start := time.Now()
+	defer func() { q.release(ctx, time.Since(start)) }()
+	return q.llm.Invoke(ctx, input, config)
+}
+
+// Stream waits for a slot and holds it until the stream is drained
+func (q *QueuedLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	if err := q.acquire(ctx); err != nil {
+		return nil, fmt.Errorf("request abandoned while queued: %w", err)
+	}
+	start := time.Now()
+
+	in, err := q.llm.Stream(ctx, input, config)
+	if err != nil {
+		q.release(ctx, time.Since(start))
+		return nil, err
+	}
+
+	out := make(chan interface{}, 10)
+	go func() {
+		defer close(out)
+		defer func() { q.release(ctx, time.Since(start)) }()
+		for chunk := range in {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				// Let the producer finish before giving up the slot
+				for range in {
+				}
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Batch submits every input to the queue; they run as slots become free
+func (q *QueuedLLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	errs := make([]error, len(inputs))
+
+	var wg sync.WaitGroup
+	for i, input := range inputs {
+		wg.Add(1)
+		go func(idx int, inp interface{}) {
+			defer wg.Done()
+			results[idx], errs[idx] = q.Invoke(ctx, inp, config)
+		}(i, input)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// Pipe composes the queued model with another runnable
+func (q *QueuedLLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{q, other})
+}
+
+// Close closes the wrapped model if it holds resources
+func (q *QueuedLLM) Close() {
+	if m, ok := q.llm.(ChatModel); ok {
+		m.Close()
+	}
+}
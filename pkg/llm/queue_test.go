@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// holdingModel blocks requests for "hold" until release is closed and
+// answers anything else with its input
+func holdingModel(release chan struct{}) *stubModel {
+	return newStubModel(func(call int, input interface{}) (interface{}, error) {
+		if input == "hold" {
+			<-release
+		}
+		return input, nil
+	})
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the queue")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQueuedLLMOrder(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	model := holdingModel(release)
+	q := NewQueuedLLM(model, 1)
+
+	var wg sync.WaitGroup
+	invoke := func(ctx context.Context, input string) {
+		defer wg.Done()
+		if _, err := q.Invoke(ctx, input, nil); err != nil {
+			t.Errorf("Invoke(%q) error = %v", input, err)
+		}
+	}
+
+	// Hold the only slot so every request below has to queue
+	wg.Add(1)
+	go invoke(ctx, "hold")
+	waitFor(t, func() bool { return len(model.calls()) == 1 })
+
+	requests := []struct {
+		name     string
+		priority Priority
+	}{
+		{"batch 1", PriorityBatch},
+		{"normal 1", PriorityNormal},
+		{"batch 2", PriorityBatch},
+		{"interactive", PriorityInteractive},
+		{"normal 2", PriorityNormal},
+	}
+	for i, r := range requests {
+		wg.Add(1)
+		go invoke(WithPriority(ctx, r.priority), r.name)
+		// Queue one at a time so the arrival order is known
+		waitFor(t, func() bool { return q.QueueLength() == i+1 })
+	}
+
+	close(release)
+	wg.Wait()
+
+	want := []interface{}{"hold", "interactive", "normal 1", "normal 2", "batch 1", "batch 2"}
+	if got := model.calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("served %v, want %v", got, want)
+	}
+}
+
+func TestQueuedLLMAbandoned(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	model := holdingModel(release)
+	q := NewQueuedLLM(model, 1)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Invoke(ctx, "hold", nil)
+	}()
+	waitFor(t, func() bool { return len(model.calls()) == 1 })
+
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.Invoke(cctx, "hi", nil); err == nil {
+		t.Fatal("Invoke() error = nil, want the request abandoned")
+	}
+	if n := q.QueueLength(); n != 0 {
+		t.Errorf("QueueLength() = %d after abandoning, want 0", n)
+	}
+
+	close(release)
+	<-done
+	if _, err := q.Invoke(ctx, "hi", nil); err != nil {
+		t.Errorf("Invoke() after release error = %v", err)
+	}
+}
+
+func TestQueuedLLMClose(t *testing.T) {
+	model := holdingModel(nil)
+	NewQueuedLLM(model, 1).Close()
+	if n := model.closeCount(); n != 1 {
+		t.Errorf("wrapped model closed %d times, want 1", n)
+	}
+}
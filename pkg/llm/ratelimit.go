@@ -0,0 +1,235 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// bucket is a token bucket refilled continuously up to its capacity. Its
+// level may go negative: a reservation that does not fit is granted a
+// place in line and waits until the refill covers it.
+type bucket struct {
+	capacity float64
+	perSec   float64
+	level    float64
+	last     time.Time
+}
+
+func newBucket(perMinute int, now time.Time) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &bucket{
+		capacity: float64(perMinute),
+		perSec:   float64(perMinute) / 60,
+		level:    float64(perMinute),
+		last:     now,
+	}
+}
+
+// take reserves n units and returns how long to wait before using them
+func (b *bucket) take(n float64, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.level += now.Sub(b.last).Seconds() * b.perSec
+	if b.level > b.capacity {
+		b.level = b.capacity
+	}
+	b.last = now
+	b.level -= n
+	if b.level >= 0 {
+		return 0
+	}
+	return time.Duration(-b.level / b.perSec * float64(time.Second))
+}
+
+// give returns n units, e.g. when a reservation is cancelled
+func (b *bucket) give(n float64) {
+	if b != nil {
+		b.level += n
+	}
+}
+
+// RateLimiter keeps calls within a requests-per-minute and a
+// tokens-per-minute budget. It is safe for concurrent use, and several
+// models sharing one hosted account can share one limiter.
+type RateLimiter struct {
+	mu       sync.Mutex
+	requests *bucket
+	tokens   *bucket
+}
+
+// NewRateLimiter creates a limiter allowing requestsPerMinute calls and
+// tokensPerMinute prompt and completion tokens; 0 leaves that budget
+// unlimited. Each budget can be used in a burst when it is full.
+func NewRateLimiter(requestsPerMinute, tokensPerMinute int) *RateLimiter {
+	now := time.Now()
+	return &RateLimiter{
+		requests: newBucket(requestsPerMinute, now),
+		tokens:   newBucket(tokensPerMinute, now),
+	}
+}
+
+// Wait blocks until one request using tokens tokens fits the budget. When
+// ctx is done first, the reservation is released and ctx's error returned.
+func (r *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	r.mu.Lock()
+	now := time.Now()
+	wait := r.requests.take(1, now)
+	if d := r.tokens.take(float64(tokens), now); d > wait {
+		wait = d
+	}
+	r.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.mu.Lock()
+		r.requests.give(1)
+		r.tokens.give(float64(tokens))
+		r.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Adjust corrects the token budget once a call's real usage is known:
+// delta is the used tokens minus those reserved with Wait
+func (r *RateLimiter) Adjust(delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens.give(float64(-delta))
+}
+
+// RateLimitedLLM waits for its rate limiter before each call to a model,
+// so batch jobs against a hosted endpoint stay under its quota instead of
+// failing with 429 Too Many Requests. Token usage is estimated from the
+// prompt and the expected completion length, then corrected with the
+// counts the backend reports.
+type RateLimitedLLM struct {
+	*core.BaseRunnable
+	llm        core.Runnable
+	limiter    *RateLimiter
+	completion int
+}
+
+var _ ChatModel = (*RateLimitedLLM)(nil)
+
+// WithRateLimit wraps llm so that its calls respect limiter
+func WithRateLimit(llm core.Runnable, limiter *RateLimiter) *RateLimitedLLM {
+	return &RateLimitedLLM{
+		BaseRunnable: core.NewBaseRunnable("RateLimitedLLM"),
+		llm:          llm,
+		limiter:      limiter,
+		completion:   256,
+	}
+}
+
+// WithCompletionEstimate sets how many completion tokens are reserved per
+// call before the real count is known (default 256); the model's
+// MaxTokens is a safe upper bound
+func (r *RateLimitedLLM) WithCompletionEstimate(tokens int) *RateLimitedLLM {
+	r.completion = tokens
+	return r
+}
+
+// cacheIdentity is that of the wrapped model, since limiting does not
+// change what it generates
+func (r *RateLimitedLLM) cacheIdentity() string {
+	if id, ok := r.llm.(cacheIdentity); ok {
+		return id.cacheIdentity()
+	}
+	return ""
+}
+
+// reserve waits for the budget of one call and returns the tokens reserved
+func (r *RateLimitedLLM) reserve(ctx context.Context, input interface{}) (int, error) {
+	tokens := EstimateTokens(promptText(input)) + r.completion
+	return tokens, r.limiter.Wait(ctx, tokens)
+}
+
+// Invoke calls the model once the budget allows it
+func (r *RateLimitedLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	reserved, err := r.reserve(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	output, err := r.llm.Invoke(ctx, input, config)
+	if gen, ok := output.(*core.Generation); ok && err == nil && gen.PromptTokens+gen.CompletionTokens > 0 {
+		r.limiter.Adjust(gen.PromptTokens + gen.CompletionTokens - reserved)
+	}
+	return output, err
+}
+
+// Stream streams from the model once the budget allows it, correcting the
+// token budget with the length of the streamed text when it ends
+func (r *RateLimitedLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	reserved, err := r.reserve(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := r.llm.Stream(ctx, input, config)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		var text strings.Builder
+		defer func() {
+			used := EstimateTokens(promptText(input)) + EstimateTokens(text.String())
+			r.limiter.Adjust(used - reserved)
+		}()
+		for chunk := range chunks {
+			if s, ok := chunk.(string); ok {
+				text.WriteString(s)
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				go func() {
+					for range chunks {
+					}
+				}()
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Batch answers every input in turn, each waiting for its share of the budget
+func (r *RateLimitedLLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := r.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the rate-limited model with another runnable
+func (r *RateLimitedLLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{r, other})
+}
+
+// Close closes the wrapped model if it holds resources
+func (r *RateLimitedLLM) Close() {
+	if m, ok := r.llm.(ChatModel); ok {
+		m.Close()
+	}
+}
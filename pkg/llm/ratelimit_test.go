@@ -0,0 +1,45 @@
+package llm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	start := time.Unix(0, 0)
+	b := newBucket(60, start)
+
+	if wait := b.take(60, start); wait != 0 {
+		t.Errorf("take within capacity waits %v, want 0", wait)
+	}
+	if wait := b.take(2, start); wait != 2*time.Second {
+		t.Errorf("take beyond capacity waits %v, want 2s", wait)
+	}
+	// The next reservation queues behind the previous one
+	if wait := b.take(1, start); wait != 3*time.Second {
+		t.Errorf("queued take waits %v, want 3s", wait)
+	}
+	// Refilled for 10s: back to 7 units
+	if wait := b.take(7, start.Add(10*time.Second)); wait != 0 {
+		t.Errorf("take after refill waits %v, want 0", wait)
+	}
+	// The level never refills beyond capacity
+	if wait := b.take(61, start.Add(time.Hour)); wait != time.Second {
+		t.Errorf("take after a long idle waits %v, want 1s", wait)
+	}
+	b.give(1)
+	if wait := b.take(0, start.Add(time.Hour)); wait != 0 {
+		t.Errorf("take after give waits %v, want 0", wait)
+	}
+}
+
+func TestBucketUnlimited(t *testing.T) {
+	b := newBucket(0, time.Now())
+	if b != nil {
+		t.Fatalf("newBucket(0) = %+v, want nil", b)
+	}
+	if wait := b.take(1000, time.Now()); wait != 0 {
+		t.Errorf("unlimited take waits %v, want 0", wait)
+	}
+	b.give(1)
+}
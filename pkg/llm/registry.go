@@ -0,0 +1,164 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ModelSpec registers a model under a short name
+type ModelSpec struct {
+	Name string
+	// File is the GGUF file; a relative name is looked up in the
+	// registry's model directories
+	File string
+	// Defaults holds the settings the model is created with; its ModelPath
+	// is filled in by the registry. Defaults.ChatTemplate is the prompt
+	// format, detected from the file when empty.
+	Defaults LlamaCppConfig
+	// Download is the cmd/models reference that fetches the file, shown
+	// when the file is missing
+	Download string
+}
+
+// Registry resolves model names to configured models, so callers ask for
+// "qwen3-small" instead of a path relative to where they run
+type Registry struct {
+	mu    sync.RWMutex
+	specs map[string]ModelSpec
+	dirs  []string
+}
+
+// NewRegistry creates an empty registry searching dirs for model files.
+// Without dirs it searches $MODELS_DIR, then a "models" directory in the
+// working directory or any of its parents.
+func NewRegistry(dirs ...string) *Registry {
+	return &Registry{specs: make(map[string]ModelSpec), dirs: dirs}
+}
+
+// Register adds or replaces a model
+func (r *Registry) Register(spec ModelSpec) error {
+	if spec.Name == "" || spec.File == "" {
+		return fmt.Errorf("model spec needs a name and a file")
+	}
+	if _, err := FormatterFor(spec.Defaults.ChatTemplate); err != nil {
+		return fmt.Errorf("model %s: %w", spec.Name, err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.specs[spec.Name] = spec
+	return nil
+}
+
+// Lookup returns the spec registered under name
+func (r *Registry) Lookup(name string) (ModelSpec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	spec, ok := r.specs[name]
+	return spec, ok
+}
+
+// Names returns the registered model names, sorted
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.specs))
+	for name := range r.specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Path returns the absolute path of a registered model's file
+func (r *Registry) Path(name string) (string, error) {
+	spec, ok := r.Lookup(name)
+	if !ok {
+		return "", fmt.Errorf("unknown model %q (registered: %v)", name, r.Names())
+	}
+	if filepath.IsAbs(spec.File) {
+		return spec.File, nil
+	}
+
+	dirs := r.searchDirs()
+	for _, dir := range dirs {
+		path := filepath.Join(dir, spec.File)
+		if _, err := os.Stat(path); err == nil {
+			return filepath.Abs(path)
+		}
+	}
+	hint := ""
+	if spec.Download != "" {
+		hint = fmt.Sprintf("; download it with: go run ./cmd/models pull %s", spec.Download)
+	}
+	return "", fmt.Errorf("model file %s not found in %v%s", spec.File, dirs, hint)
+}
+
+// searchDirs lists the directories searched for model files
+func (r *Registry) searchDirs() []string {
+	if len(r.dirs) > 0 {
+		return r.dirs
+	}
+	var dirs []string
+	if dir := os.Getenv("MODELS_DIR"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return append(dirs, "models")
+	}
+	for dir := wd; ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, filepath.Join(dir, "models"))
+		if filepath.Dir(dir) == dir {
+			return dirs
+		}
+	}
+}
+
+// Config returns the registered defaults of a model with its path resolved,
+// ready to adjust and pass to NewLlamaCppLLM
+func (r *Registry) Config(name string) (LlamaCppConfig, error) {
+	path, err := r.Path(name)
+	if err != nil {
+		return LlamaCppConfig{}, err
+	}
+	spec, _ := r.Lookup(name)
+	config := spec.Defaults
+	config.ModelPath = path
+	return config, nil
+}
+
+// New creates the model registered under name with its defaults
+func (r *Registry) New(name string) (*LlamaCppLLM, error) {
+	config, err := r.Config(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewLlamaCppLLM(config)
+}
+
+// DefaultRegistry holds the models used by the examples
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	defaults := LlamaCppConfig{ContextSize: 2048, Temperature: 0.7, Threads: 4}
+	for _, spec := range []ModelSpec{
+		{Name: "qwen3-small", File: "Qwen3-1.7B-Q8_0.gguf", Defaults: defaults, Download: "Qwen3-1.7B-Q8_0"},
+		{Name: "gpt-oss-20b", File: "gpt-oss-20b.MXFP4.gguf", Defaults: defaults, Download: "gpt-oss-20b-MXFP4"},
+		{Name: "deepseek-r1-8b", File: "DeepSeek-R1-0528-Qwen3-8B-Q6_K.gguf", Defaults: defaults, Download: "DeepSeek-R1-0528-Qwen3-8B-Q6_K"},
+	} {
+		DefaultRegistry.Register(spec)
+	}
+}
+
+// RegisterModel adds a model to DefaultRegistry
+func RegisterModel(spec ModelSpec) error {
+	return DefaultRegistry.Register(spec)
+}
+
+// FromRegistry creates a model registered in DefaultRegistry
+func FromRegistry(name string) (*LlamaCppLLM, error) {
+	return DefaultRegistry.New(name)
+}
@@ -0,0 +1,231 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// IsTransient reports whether err is worth retrying: rate limiting,
+// overloaded or restarting HTTP servers, dropped connections and failed
+// llama.cpp predictions. Cancellation and invalid requests are not.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.Code {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	// The go-llama.cpp bindings return untyped errors
+	msg := err.Error()
+	return strings.Contains(msg, "prediction failed") || strings.Contains(msg, "streaming failed")
+}
+
+// RetryingLLM retries failed calls to a model with exponential backoff.
+// The number of retries is the MaxRetries of each call's config (3 with a
+// nil config).
+type RetryingLLM struct {
+	*core.BaseRunnable
+	llm       core.Runnable
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	retryable func(error) bool
+}
+
+var _ ChatModel = (*RetryingLLM)(nil)
+
+// WithRetry wraps llm so that transient failures are retried
+func WithRetry(llm core.Runnable) *RetryingLLM {
+	return &RetryingLLM{
+		BaseRunnable: core.NewBaseRunnable("Retry"),
+		llm:          llm,
+		baseDelay:    500 * time.Millisecond,
+		maxDelay:     30 * time.Second,
+		retryable:    IsTransient,
+	}
+}
+
+// WithBackoff sets the delay before the first retry, doubled on each
+// further retry up to max (default 500ms and 30s)
+func (r *RetryingLLM) WithBackoff(base, max time.Duration) *RetryingLLM {
+	r.baseDelay = base
+	r.maxDelay = max
+	return r
+}
+
+// WithRetryOn sets which errors are retried (default IsTransient)
+func (r *RetryingLLM) WithRetryOn(retryable func(error) bool) *RetryingLLM {
+	r.retryable = retryable
+	return r
+}
+
+// maxRetries returns the retries allowed by config
+func maxRetries(config *core.Config) int {
+	if config == nil {
+		return core.NewConfig().MaxRetries
+	}
+	return config.MaxRetries
+}
+
+// delay returns the wait before retry n (0-based): the exponential
+// backoff with jitter, or what the server asked for if that is longer
+func (r *RetryingLLM) delay(n int, err error) time.Duration {
+	d := r.baseDelay << n
+	if d > r.maxDelay || d <= 0 {
+		d = r.maxDelay
+	}
+	// Jitter keeps concurrent callers from retrying in lockstep
+	if d > 1 {
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)))
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > d {
+		d = statusErr.RetryAfter
+	}
+	return d
+}
+
+// wait sleeps before retry n, returning early with an error when ctx is done
+func (r *RetryingLLM) wait(ctx context.Context, n int, err error) error {
+	timer := time.NewTimer(r.delay(n, err))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Invoke calls the model, retrying transient failures
+func (r *RetryingLLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	retries := maxRetries(config)
+	for n := 0; ; n++ {
+		output, err := r.llm.Invoke(ctx, input, config)
+		if err == nil {
+			return output, nil
+		}
+		if n >= retries || ctx.Err() != nil || !r.retryable(err) {
+			if n > 0 {
+				return nil, fmt.Errorf("failed after %d attempts: %w", n+1, err)
+			}
+			return nil, err
+		}
+		if werr := r.wait(ctx, n, err); werr != nil {
+			return nil, err
+		}
+	}
+}
+
+// Stream retries until the model produces its first chunk. Failures after
+// that are forwarded as-is, since a restarted stream would repeat text.
+func (r *RetryingLLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	retries := maxRetries(config)
+	for n := 0; ; n++ {
+		chunks, first, err := r.openStream(ctx, input, config)
+		if err == nil {
+			return forwardStream(ctx, first, chunks), nil
+		}
+		if n >= retries || ctx.Err() != nil || !r.retryable(err) {
+			return nil, err
+		}
+		if werr := r.wait(ctx, n, err); werr != nil {
+			return nil, err
+		}
+	}
+}
+
+// openStream starts a stream and reads its first chunk; an error chunk is
+// returned as the error
+func (r *RetryingLLM) openStream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, interface{}, error) {
+	chunks, err := r.llm.Stream(ctx, input, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	first, ok := <-chunks
+	if !ok {
+		return chunks, nil, nil
+	}
+	if err, isErr := first.(error); isErr {
+		go func() {
+			for range chunks {
+			}
+		}()
+		return nil, nil, err
+	}
+	return chunks, first, nil
+}
+
+// forwardStream re-emits first, if any, followed by the rest of chunks
+func forwardStream(ctx context.Context, first interface{}, chunks <-chan interface{}) <-chan interface{} {
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		if first == nil {
+			return
+		}
+		// Drain what is left if the consumer goes away, so the producer exits
+		defer func() {
+			go func() {
+				for range chunks {
+				}
+			}()
+		}()
+		select {
+		case out <- first:
+		case <-ctx.Done():
+			return
+		}
+		for chunk := range chunks {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Batch answers every input in turn, each with its own retries
+func (r *RetryingLLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := r.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the retrying model with another runnable
+func (r *RetryingLLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{r, other})
+}
+
+// Close closes the wrapped model if it holds resources
+func (r *RetryingLLM) Close() {
+	if m, ok := r.llm.(ChatModel); ok {
+		m.Close()
+	}
+}
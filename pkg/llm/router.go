@@ -0,0 +1,245 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// RouteRule decides whether a request goes to a route
+type RouteRule func(ctx context.Context, prompt string, config *core.Config) bool
+
+// MatchTag routes requests whose config carries tag
+func MatchTag(tag string) RouteRule {
+	return func(ctx context.Context, prompt string, config *core.Config) bool {
+		if config == nil {
+			return false
+		}
+		for _, t := range config.Tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchMinLength routes prompts of at least chars characters
+func MatchMinLength(chars int) RouteRule {
+	return func(ctx context.Context, prompt string, config *core.Config) bool {
+		return utf8.RuneCountInString(prompt) >= chars
+	}
+}
+
+// MatchPattern routes prompts matching the regular expression; it panics
+// if pattern does not compile
+func MatchPattern(pattern string) RouteRule {
+	re := regexp.MustCompile(pattern)
+	return func(ctx context.Context, prompt string, config *core.Config) bool {
+		return re.MatchString(prompt)
+	}
+}
+
+// RouteClassifier names the route for a prompt when no rule matched; an
+// unknown or empty name selects the default route
+type RouteClassifier func(ctx context.Context, prompt string) (string, error)
+
+// route is a named backend and the rules selecting it
+type route struct {
+	name        string
+	llm         core.Runnable
+	rules       []RouteRule
+	description string
+}
+
+// Router sends each request to one of several models and presents them as
+// a single runnable. Routes are tried in the order they were added and the
+// first one with a matching rule wins; when none matches, the classifier,
+// if any, picks a route, and otherwise the default model answers.
+type Router struct {
+	*core.BaseRunnable
+	fallback   route
+	routes     []route
+	classifier RouteClassifier
+}
+
+var _ ChatModel = (*Router)(nil)
+
+// NewRouter creates a router answering with fallback, named name, until
+// routes are added
+func NewRouter(name string, fallback core.Runnable) *Router {
+	return &Router{
+		BaseRunnable: core.NewBaseRunnable("Router"),
+		fallback:     route{name: name, llm: fallback},
+	}
+}
+
+// Route adds a named route taken when any of rules matches; a route
+// without rules is only reachable through the classifier
+func (r *Router) Route(name string, llm core.Runnable, rules ...RouteRule) *Router {
+	r.routes = append(r.routes, route{name: name, llm: llm, rules: rules})
+	return r
+}
+
+// Describe sets what a route is for, as shown to an LLM classifier
+func (r *Router) Describe(name, description string) *Router {
+	if r.fallback.name == name {
+		r.fallback.description = description
+	}
+	for i := range r.routes {
+		if r.routes[i].name == name {
+			r.routes[i].description = description
+		}
+	}
+	return r
+}
+
+// WithClassifier sets how requests no rule matched are routed
+func (r *Router) WithClassifier(classifier RouteClassifier) *Router {
+	r.classifier = classifier
+	return r
+}
+
+// WithLLMClassifier has model pick the route of unmatched requests from
+// the route descriptions
+func (r *Router) WithLLMClassifier(model core.Runnable) *Router {
+	return r.WithClassifier(func(ctx context.Context, prompt string) (string, error) {
+		var routes strings.Builder
+		for _, rt := range append([]route{r.fallback}, r.routes...) {
+			fmt.Fprintf(&routes, "- %s: %s\n", rt.name, rt.description)
+		}
+		request := fmt.Sprintf(`Choose the assistant best suited to the request.
+
+Assistants:
+%s
+Request:
+%s
+
+Answer with the assistant name only.`, routes.String(), prompt)
+
+		response, err := model.Invoke(ctx, request, nil)
+		if err != nil {
+			return "", fmt.Errorf("route classifier failed: %w", err)
+		}
+		return strings.Trim(strings.TrimSpace(fmt.Sprint(response)), "`\"'."), nil
+	})
+}
+
+// Routes returns the route names, the default first
+func (r *Router) Routes() []string {
+	names := []string{r.fallback.name}
+	for _, rt := range r.routes {
+		names = append(names, rt.name)
+	}
+	return names
+}
+
+// Select returns the name and model of the route for input
+func (r *Router) Select(ctx context.Context, input interface{}, config *core.Config) (string, core.Runnable, error) {
+	prompt := promptText(input)
+	for _, rt := range r.routes {
+		for _, rule := range rt.rules {
+			if rule(ctx, prompt, config) {
+				return rt.name, rt.llm, nil
+			}
+		}
+	}
+
+	if r.classifier != nil {
+		name, err := r.classifier(ctx, prompt)
+		if err != nil {
+			return "", nil, err
+		}
+		for _, rt := range r.routes {
+			if strings.EqualFold(rt.name, name) {
+				return rt.name, rt.llm, nil
+			}
+		}
+	}
+	return r.fallback.name, r.fallback.llm, nil
+}
+
+// Invoke answers with the selected route
+func (r *Router) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	name, target, err := r.Select(ctx, input, config)
+	if err != nil {
+		return nil, err
+	}
+	output, err := target.Invoke(ctx, input, config)
+	if err != nil {
+		return nil, fmt.Errorf("route %s failed: %w", name, err)
+	}
+	return output, nil
+}
+
+// Stream streams from the selected route
+func (r *Router) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	name, target, err := r.Select(ctx, input, config)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := target.Stream(ctx, input, config)
+	if err != nil {
+		return nil, fmt.Errorf("route %s failed: %w", name, err)
+	}
+	return stream, nil
+}
+
+// Batch routes every input independently and runs each route's inputs as
+// one batch
+func (r *Router) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	groups := make(map[string][]int)
+	targets := make(map[string]core.Runnable)
+	for i, input := range inputs {
+		name, target, err := r.Select(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		groups[name] = append(groups[name], i)
+		targets[name] = target
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]interface{}, len(inputs))
+	for _, name := range names {
+		idx := groups[name]
+		batch := make([]interface{}, len(idx))
+		for j, i := range idx {
+			batch[j] = inputs[i]
+		}
+		outputs, err := targets[name].Batch(ctx, batch, config)
+		if err != nil {
+			return nil, fmt.Errorf("route %s failed: %w", name, err)
+		}
+		for j, i := range idx {
+			results[i] = outputs[j]
+		}
+	}
+	return results, nil
+}
+
+// Pipe composes the router with another runnable
+func (r *Router) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{r, other})
+}
+
+// Close closes every routed model that holds resources
+func (r *Router) Close() {
+	seen := make(map[core.Runnable]bool)
+	for _, rt := range append([]route{r.fallback}, r.routes...) {
+		if m, ok := rt.llm.(ChatModel); ok && !seen[rt.llm] {
+			seen[rt.llm] = true
+			m.Close()
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	llama "github.com/go-skynet/go-llama.cpp"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+type sessionKey struct{}
+
+// WithSession returns a context whose LLM requests reuse the KV cache of session id
+func WithSession(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, id)
+}
+
+// SessionFromContext returns the session id, or "" when none is set
+func SessionFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(sessionKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
+
+var unsafeSessionChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// sessionPath returns the cache file for a session, or "" if persistence is off
+func (l *LlamaCppLLM) sessionPath(id string) string {
+	if l.sessionDir == "" || id == "" {
+		return ""
+	}
+	return filepath.Join(l.sessionDir, unsafeSessionChars.ReplaceAllString(id, "_")+".session")
+}
+
+// sessionOptions adds the prompt cache options for the session in ctx.
+// llama.cpp loads the file, skips the prompt prefix it already evaluated,
+// and writes the updated KV cache back once generation is done.
+func (l *LlamaCppLLM) sessionOptions(ctx context.Context) []llama.PredictOption {
+	path := l.sessionPath(SessionFromContext(ctx))
+	if path == "" {
+		return nil
+	}
+	return []llama.PredictOption{
+		llama.SetPathPromptCache(path),
+		llama.EnablePromptCacheAll,
+	}
+}
+
+// SaveSession writes the current model state to the session's cache file
+func (l *LlamaCppLLM) SaveSession(id string) error {
+	path := l.sessionPath(id)
+	if path == "" {
+		return fmt.Errorf("session persistence is not enabled")
+	}
+	if err := l.model.SaveState(path); err != nil {
+		return fmt.Errorf("failed to save session %q: %w", id, err)
+	}
+	return nil
+}
+
+// LoadSession restores the model state from the session's cache file
+func (l *LlamaCppLLM) LoadSession(id string) error {
+	path := l.sessionPath(id)
+	if path == "" {
+		return fmt.Errorf("session persistence is not enabled")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("session %q not found: %w", id, err)
+	}
+	if err := l.model.LoadState(path); err != nil {
+		return fmt.Errorf("failed to load session %q: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteSession removes the session's cache file, if any
+func (l *LlamaCppLLM) DeleteSession(id string) error {
+	path := l.sessionPath(id)
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete session %q: %w", id, err)
+	}
+	return nil
+}
+
+// Session is a multi-turn conversation that keeps its KV cache warm between
+// turns. Each turn sends the whole history, which renders to the same
+// prompt prefix as the previous turn, so llama.cpp only evaluates the new
+// message tokens instead of re-ingesting the conversation.
+type Session struct {
+	llm      *LlamaCppLLM
+	id       string
+	mu       sync.Mutex
+	messages []core.Message
+}
+
+// NewSession starts a conversation with its own KV cache. Without a
+// configured SessionDir the cache files go to a temporary directory.
+func (l *LlamaCppLLM) NewSession(id string, history ...core.Message) (*Session, error) {
+	if l.sessionDir == "" {
+		dir, err := os.MkdirTemp("", "llama-sessions-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create session dir: %w", err)
+		}
+		l.sessionDir = dir
+	}
+	if id == "" {
+		return nil, fmt.Errorf("session id is required")
+	}
+	return &Session{llm: l, id: id, messages: append([]core.Message{}, history...)}, nil
+}
+
+// ID returns the session id
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Send adds a user message, generates the reply and adds it to the history
+func (s *Session) Send(ctx context.Context, content string, config *core.Config) (*core.Generation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := append(s.messages, core.NewHumanMessage(content, nil))
+	output, err := s.llm.Invoke(WithSession(ctx, s.id), messages, config)
+	if err != nil {
+		return nil, err
+	}
+	gen, ok := output.(*core.Generation)
+	if !ok {
+		// Dry runs report the prompt without changing the history
+		return &core.Generation{Text: fmt.Sprint(output), Model: s.llm.Name()}, nil
+	}
+	s.messages = append(messages, core.NewAIMessage(gen.Text, nil))
+	return gen, nil
+}
+
+// Messages returns a copy of the conversation so far
+func (s *Session) Messages() []core.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]core.Message{}, s.messages...)
+}
+
+// Close deletes the session's KV cache file
+func (s *Session) Close() error {
+	return s.llm.DeleteSession(s.id)
+}
@@ -0,0 +1,74 @@
+package llm
+
+import "testing"
+
+func TestStopScanner(t *testing.T) {
+	tests := []struct {
+		name        string
+		stops       []string
+		tokens      []string
+		want        string
+		wantStopped bool
+	}{
+		{
+			name:        "answer ends with letters of the stop word",
+			stops:       []string{"Observation:"},
+			tokens:      []string{" The", " capital", " is", " Par", "is", "\n", "Observ", "ation", ":", " more"},
+			want:        " The capital is Paris\n",
+			wantStopped: true,
+		},
+		{
+			name:   "no stop sequence",
+			stops:  []string{"Observation:"},
+			tokens: []string{"Paris", " is", " nice"},
+			want:   "Paris is nice",
+		},
+		{
+			name:   "held back prefix that is not a stop",
+			stops:  []string{"Observation:"},
+			tokens: []string{"Obs", "erve", " the", " sky", " Ob"},
+			want:   "Observe the sky Ob",
+		},
+		{
+			name:        "stop inside one token",
+			stops:       []string{"\nUser:"},
+			tokens:      []string{"Done.\nUser: next"},
+			want:        "Done.",
+			wantStopped: true,
+		},
+		{
+			name:        "earliest of several stops",
+			stops:       []string{"</s>", "\n\n"},
+			tokens:      []string{"a", "\n", "\n", "b</s>"},
+			want:        "a",
+			wantStopped: true,
+		},
+		{
+			name:   "no stops configured",
+			tokens: []string{"Par", "is"},
+			want:   "Paris",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := &stopScanner{stops: tt.stops}
+			got := ""
+			stopped := false
+			for _, token := range tt.tokens {
+				emit, s := scanner.push(token)
+				got += emit
+				if s {
+					stopped = true
+					break
+				}
+			}
+			if !stopped {
+				got += scanner.flush()
+			}
+			if got != tt.want || stopped != tt.wantStopped {
+				t.Errorf("scanned %q (stopped %v), want %q (stopped %v)", got, stopped, tt.want, tt.wantStopped)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package llm
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// stubModel answers each request with reply, given the call number and
+// input, and records every input it receives
+type stubModel struct {
+	*core.BaseRunnable
+	reply func(call int, input interface{}) (interface{}, error)
+
+	mu     sync.Mutex
+	inputs []interface{}
+	closed int
+}
+
+func newStubModel(reply func(call int, input interface{}) (interface{}, error)) *stubModel {
+	return &stubModel{BaseRunnable: core.NewBaseRunnable("stub"), reply: reply}
+}
+
+func (s *stubModel) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	s.mu.Lock()
+	call := len(s.inputs)
+	s.inputs = append(s.inputs, input)
+	s.mu.Unlock()
+	return s.reply(call, input)
+}
+
+// calls returns the inputs received so far
+func (s *stubModel) calls() []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]interface{}(nil), s.inputs...)
+}
+
+func (s *stubModel) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed++
+}
+
+// closeCount returns how many times Close was called
+func (s *stubModel) closeCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
@@ -0,0 +1,147 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// Chat template names accepted by FormatterFor
+const (
+	TemplatePlain   = "plain"
+	TemplateChatML  = "chatml"
+	TemplateLlama3  = "llama3"
+	TemplateMistral = "mistral"
+)
+
+// PromptFormatter renders a conversation in the layout a model was trained
+// on, ending with the cue for the assistant's turn
+type PromptFormatter interface {
+	Format(messages []core.Message) string
+	// Stop returns the end-of-turn markers that end generation
+	Stop() []string
+}
+
+// FormatterFor returns the formatter of a named chat template
+func FormatterFor(name string) (PromptFormatter, error) {
+	switch strings.ToLower(name) {
+	case TemplatePlain, "":
+		return plainFormatter{}, nil
+	case TemplateChatML:
+		return chatMLFormatter{}, nil
+	case TemplateLlama3:
+		return llama3Formatter{}, nil
+	case TemplateMistral:
+		return mistralFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown chat template %q", name)
+	}
+}
+
+// DetectChatTemplate reads the chat template embedded in a GGUF model and
+// returns the name of the matching built-in template, or TemplatePlain when
+// the model has none or it is not recognized
+func DetectChatTemplate(modelPath string) (string, error) {
+	metadata, err := ReadGGUFMetadata(modelPath)
+	if err != nil {
+		return "", err
+	}
+	template, _ := metadata["tokenizer.chat_template"].(string)
+	return chatTemplateName(template), nil
+}
+
+// chatTemplateName maps a model's Jinja chat template to the closest
+// built-in template
+func chatTemplateName(template string) string {
+	switch {
+	case strings.Contains(template, "<|im_start|>"):
+		return TemplateChatML
+	case strings.Contains(template, "<|start_header_id|>"):
+		return TemplateLlama3
+	case strings.Contains(template, "[INST]"):
+		return TemplateMistral
+	default:
+		return TemplatePlain
+	}
+}
+
+// plainFormatter is the System:/User:/Assistant: layout
+type plainFormatter struct{}
+
+func (plainFormatter) Format(messages []core.Message) string { return formatMessages(messages) }
+func (plainFormatter) Stop() []string                        { return nil }
+
+// chatMLFormatter renders ChatML, used by Qwen, Yi, Hermes and others
+type chatMLFormatter struct{}
+
+func (chatMLFormatter) Format(messages []core.Message) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "<|im_start|>%s\n%s<|im_end|>\n", roleName(msg, "tool"), msg.GetContent())
+	}
+	b.WriteString("<|im_start|>assistant\n")
+	return b.String()
+}
+
+func (chatMLFormatter) Stop() []string { return []string{"<|im_end|>"} }
+
+// llama3Formatter renders the Llama 3 header layout; llama.cpp adds the
+// <|begin_of_text|> token itself
+type llama3Formatter struct{}
+
+func (llama3Formatter) Format(messages []core.Message) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "<|start_header_id|>%s<|end_header_id|>\n\n%s<|eot_id|>", roleName(msg, "ipython"), msg.GetContent())
+	}
+	b.WriteString("<|start_header_id|>assistant<|end_header_id|>\n\n")
+	return b.String()
+}
+
+func (llama3Formatter) Stop() []string { return []string{"<|eot_id|>"} }
+
+// mistralFormatter renders [INST] blocks. Mistral has no system role, so
+// system messages are prepended to the next user turn and tool results are
+// passed as user turns.
+type mistralFormatter struct{}
+
+func (mistralFormatter) Format(messages []core.Message) string {
+	var b strings.Builder
+	var system []string
+	for _, msg := range messages {
+		switch msg.GetType() {
+		case core.MessageTypeSystem:
+			system = append(system, msg.GetContent())
+		case core.MessageTypeAI:
+			fmt.Fprintf(&b, " %s</s>", msg.GetContent())
+		default:
+			content := msg.GetContent()
+			if len(system) > 0 {
+				content = strings.Join(system, "\n\n") + "\n\n" + content
+				system = nil
+			}
+			fmt.Fprintf(&b, "[INST] %s [/INST]", content)
+		}
+	}
+	if len(system) > 0 {
+		fmt.Fprintf(&b, "[INST] %s [/INST]", strings.Join(system, "\n\n"))
+	}
+	return b.String()
+}
+
+func (mistralFormatter) Stop() []string { return nil }
+
+// roleName maps a message type to a chat role
+func roleName(msg core.Message, toolRole string) string {
+	switch msg.GetType() {
+	case core.MessageTypeSystem:
+		return "system"
+	case core.MessageTypeAI:
+		return "assistant"
+	case core.MessageTypeTool:
+		return toolRole
+	default:
+		return "user"
+	}
+}
@@ -0,0 +1,153 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// TGILLM talks to a Hugging Face text-generation-inference server
+type TGILLM struct {
+	*core.BaseRunnable
+	config HTTPBackendConfig
+}
+
+// NewTGILLM creates a client for the TGI server at config.BaseURL
+func NewTGILLM(config HTTPBackendConfig) *TGILLM {
+	return &TGILLM{
+		BaseRunnable: core.NewBaseRunnable("TGILLM"),
+		config:       config.withDefaults(),
+	}
+}
+
+// tgiRequest is the body of /generate and /generate_stream
+type tgiRequest struct {
+	Inputs     string        `json:"inputs"`
+	Parameters tgiParameters `json:"parameters"`
+}
+
+type tgiParameters struct {
+	MaxNewTokens int      `json:"max_new_tokens"`
+	Temperature  float32  `json:"temperature"`
+	TopP         float32  `json:"top_p"`
+	TopK         int      `json:"top_k"`
+	DoSample     bool     `json:"do_sample"`
+	Stop         []string `json:"stop,omitempty"`
+	Details      bool     `json:"details"`
+}
+
+// request builds the generation request for input
+func (t *TGILLM) request(input interface{}, config *core.Config) (tgiRequest, error) {
+	prompt, stop, err := t.config.completionPrompt(input)
+	if err != nil {
+		return tgiRequest{}, err
+	}
+	if config != nil && len(config.LogitBias) > 0 {
+		return tgiRequest{}, fmt.Errorf("TGI does not support logit bias")
+	}
+	return tgiRequest{
+		Inputs: prompt,
+		Parameters: tgiParameters{
+			MaxNewTokens: t.config.MaxTokens,
+			Temperature:  t.config.Temperature,
+			TopP:         t.config.TopP,
+			TopK:         t.config.TopK,
+			DoSample:     true,
+			Stop:         stopSequences(stop, config),
+			Details:      true,
+		},
+	}, nil
+}
+
+// Invoke generates a response for the given prompt
+func (t *TGILLM) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	req, err := t.request(input, config)
+	if err != nil {
+		return nil, err
+	}
+	if IsDryRun(ctx) {
+		return estimatedDryRun(t.Name(), req.Inputs), nil
+	}
+
+	var resp struct {
+		GeneratedText string `json:"generated_text"`
+		Details       struct {
+			FinishReason    string `json:"finish_reason"`
+			GeneratedTokens int    `json:"generated_tokens"`
+		} `json:"details"`
+	}
+	start := time.Now()
+	if err := t.config.postJSON(ctx, "/generate", req, &resp); err != nil {
+		return nil, fmt.Errorf("prediction failed: %w", err)
+	}
+
+	gen := &core.Generation{
+		// TGI keeps the matched stop sequence in the generated text
+		Text:             trimStop(resp.GeneratedText, req.Parameters.Stop),
+		Model:            t.config.Model,
+		CompletionTokens: resp.Details.GeneratedTokens,
+		Duration:         time.Since(start),
+		FinishReason:     core.FinishStop,
+	}
+	if resp.Details.FinishReason == "length" {
+		gen.FinishReason = core.FinishLength
+	}
+	return gen, nil
+}
+
+// Stream generates a response and streams tokens
+func (t *TGILLM) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	req, err := t.request(input, config)
+	if err != nil {
+		return nil, err
+	}
+	if IsDryRun(ctx) {
+		return dryRunStream(estimatedDryRun(t.Name(), req.Inputs)), nil
+	}
+
+	return t.config.streamEvents(ctx, "/generate_stream", req, func(data []byte) (string, bool, error) {
+		var event struct {
+			Token struct {
+				Text    string `json:"text"`
+				Special bool   `json:"special"`
+			} `json:"token"`
+			GeneratedText *string `json:"generated_text"`
+			Error         string  `json:"error"`
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return "", false, err
+		}
+		if event.Error != "" {
+			return "", false, fmt.Errorf("%s", event.Error)
+		}
+		if event.Token.Special {
+			return "", event.GeneratedText == nil, nil
+		}
+		// The final event carries the full text alongside the last token
+		return event.Token.Text, event.GeneratedText == nil, nil
+	})
+}
+
+// Batch generates a response for each input in turn
+func (t *TGILLM) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := t.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the model with another runnable
+func (t *TGILLM) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{t, other})
+}
+
+// Close is a no-op; the server owns the model
+func (t *TGILLM) Close() {}
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// Tokenizer is implemented by backends that can tokenize with the model's
+// own vocabulary, so prompts can be truncated to an exact token budget
+type Tokenizer interface {
+	// Tokenize returns the token ids of text
+	Tokenize(text string) ([]int, error)
+	// CountTokens returns the number of prompt tokens messages occupy,
+	// rendered as the backend would send them
+	CountTokens(messages []core.Message) (int, error)
+}
+
+var (
+	_ Tokenizer = (*LlamaCppLLM)(nil)
+	_ Tokenizer = (*TGILLM)(nil)
+	_ Tokenizer = (*LlamafileLLM)(nil)
+	_ Tokenizer = (*LlamaServerLLM)(nil)
+)
+
+// CountTokens counts the tokens of messages with model's tokenizer, falling
+// back to EstimateTokens when the backend cannot tokenize. estimated reports
+// whether the fallback was used.
+func CountTokens(model ChatModel, messages []core.Message) (n int, estimated bool) {
+	if t, ok := model.(Tokenizer); ok {
+		if n, err := t.CountTokens(messages); err == nil {
+			return n, false
+		}
+	}
+	return EstimateTokens(formatMessages(messages)), true
+}
+
+// Tokenize returns the token ids of text
+func (l *LlamaCppLLM) Tokenize(text string) ([]int, error) {
+	_, tokens, err := l.model.TokenizeString(text)
+	if err != nil {
+		return nil, fmt.Errorf("tokenization failed: %w", err)
+	}
+	ids := make([]int, len(tokens))
+	for i, t := range tokens {
+		ids[i] = int(t)
+	}
+	return ids, nil
+}
+
+// CountTokens returns the number of prompt tokens messages occupy
+func (l *LlamaCppLLM) CountTokens(messages []core.Message) (int, error) {
+	prompt, err := buildPrompt(messages, l.systemPrompt, l.formatter)
+	if err != nil {
+		return 0, err
+	}
+	tokens, err := l.Tokenize(prompt)
+	if err != nil {
+		return 0, err
+	}
+	return len(tokens), nil
+}
+
+// Tokenize returns the token ids of text using the server's /tokenize endpoint
+func (t *TGILLM) Tokenize(text string) ([]int, error) {
+	var resp []struct {
+		ID int `json:"id"`
+	}
+	req := map[string]interface{}{"inputs": text}
+	if err := t.config.postJSON(context.Background(), "/tokenize", req, &resp); err != nil {
+		return nil, fmt.Errorf("tokenization failed: %w", err)
+	}
+	ids := make([]int, len(resp))
+	for i, tok := range resp {
+		ids[i] = tok.ID
+	}
+	return ids, nil
+}
+
+// CountTokens returns the number of prompt tokens messages occupy
+func (t *TGILLM) CountTokens(messages []core.Message) (int, error) {
+	prompt, _, err := t.config.completionPrompt(messages)
+	if err != nil {
+		return 0, err
+	}
+	tokens, err := t.Tokenize(prompt)
+	if err != nil {
+		return 0, err
+	}
+	return len(tokens), nil
+}
+
+// Tokenize returns the token ids of text using the server's /tokenize endpoint
+func (l *LlamafileLLM) Tokenize(text string) ([]int, error) {
+	var resp struct {
+		Tokens []int `json:"tokens"`
+	}
+	req := map[string]interface{}{"content": text}
+	if err := l.config.postJSON(context.Background(), "/tokenize", req, &resp); err != nil {
+		return nil, fmt.Errorf("tokenization failed: %w", err)
+	}
+	return resp.Tokens, nil
+}
+
+// CountTokens returns the number of prompt tokens messages occupy
+func (l *LlamafileLLM) CountTokens(messages []core.Message) (int, error) {
+	prompt, _, err := l.config.completionPrompt(messages)
+	if err != nil {
+		return 0, err
+	}
+	tokens, err := l.Tokenize(prompt)
+	if err != nil {
+		return 0, err
+	}
+	return len(tokens), nil
+}
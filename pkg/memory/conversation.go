@@ -0,0 +1,440 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// MainBranch is the branch a new conversation starts on
+const MainBranch = "main"
+
+// Branch describes one line of a conversation
+type Branch struct {
+	Name   string `json:"name"`
+	Head   string `json:"head"`             // ID of the last message, "" when empty
+	Parent string `json:"parent,omitempty"` // branch it was forked from
+	Length int    `json:"length"`
+}
+
+// turn is a message and the ID of the message before it
+type turn struct {
+	msg    core.Message
+	parent string
+}
+
+// Conversation is a tree of messages. Branches share the prefix they were
+// forked from, so "try a different phrasing from turn 3" keeps both versions
+// of the dialogue without copying the first turns.
+type Conversation struct {
+	mu           sync.RWMutex
+	turns        map[string]turn
+	branches     map[string]*Branch
+	current      string
+	onInvalidate []func(removed []core.Message)
+}
+
+// NewConversation creates an empty conversation on MainBranch
+func NewConversation() *Conversation {
+	return &Conversation{
+		turns:    make(map[string]turn),
+		branches: map[string]*Branch{MainBranch: {Name: MainBranch}},
+		current:  MainBranch,
+	}
+}
+
+// Append adds messages at the head of the current branch
+func (c *Conversation) Append(messages ...core.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	branch := c.branches[c.current]
+	for _, msg := range messages {
+		if _, exists := c.turns[msg.GetID()]; exists {
+			return fmt.Errorf("message %s is already in the conversation", msg.GetID())
+		}
+		c.turns[msg.GetID()] = turn{msg: msg, parent: branch.Head}
+		branch.Head = msg.GetID()
+		branch.Length++
+	}
+	return nil
+}
+
+// Messages returns the messages of the current branch, oldest first
+func (c *Conversation) Messages() []core.Message {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.path(c.branches[c.current].Head)
+}
+
+// BranchMessages returns the messages of the named branch, oldest first
+func (c *Conversation) BranchMessages(name string) ([]core.Message, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	branch, ok := c.branches[name]
+	if !ok {
+		return nil, fmt.Errorf("branch not found: %s", name)
+	}
+	return c.path(branch.Head), nil
+}
+
+// path walks from head back to the root
+func (c *Conversation) path(head string) []core.Message {
+	var messages []core.Message
+	for id := head; id != ""; id = c.turns[id].parent {
+		messages = append(messages, c.turns[id].msg)
+	}
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages
+}
+
+// Fork creates branch name ending at messageID, so it shares every message
+// up to and including it, and switches to it. An empty messageID forks
+// before the first message.
+func (c *Conversation) Fork(messageID, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fork(messageID, name)
+}
+
+func (c *Conversation) fork(messageID, name string) error {
+	if name == "" {
+		return fmt.Errorf("branch name is required")
+	}
+	if _, exists := c.branches[name]; exists {
+		return fmt.Errorf("branch already exists: %s", name)
+	}
+	if messageID != "" {
+		if _, ok := c.turns[messageID]; !ok {
+			return fmt.Errorf("message not found: %s", messageID)
+		}
+	}
+	c.branches[name] = &Branch{
+		Name:   name,
+		Head:   messageID,
+		Parent: c.current,
+		Length: len(c.path(messageID)),
+	}
+	c.current = name
+	return nil
+}
+
+// Switch makes name the current branch
+func (c *Conversation) Switch(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.branches[name]; !ok {
+		return fmt.Errorf("branch not found: %s", name)
+	}
+	c.current = name
+	return nil
+}
+
+// CurrentBranch returns the name of the current branch
+func (c *Conversation) CurrentBranch() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.current
+}
+
+// Branches lists all branches by name
+func (c *Conversation) Branches() []Branch {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]Branch, 0, len(c.branches))
+	for _, b := range c.branches {
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// DeleteBranch removes a branch and the messages no other branch uses.
+// The current branch cannot be deleted.
+func (c *Conversation) DeleteBranch(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.branches[name]; !ok {
+		return fmt.Errorf("branch not found: %s", name)
+	}
+	if name == c.current {
+		return fmt.Errorf("cannot delete the current branch")
+	}
+	delete(c.branches, name)
+	c.collect()
+	return nil
+}
+
+// collect drops the messages no branch reaches anymore
+func (c *Conversation) collect() {
+	reachable := make(map[string]bool)
+	for _, b := range c.branches {
+		for id := b.Head; id != "" && !reachable[id]; id = c.turns[id].parent {
+			reachable[id] = true
+		}
+	}
+	for id := range c.turns {
+		if !reachable[id] {
+			delete(c.turns, id)
+		}
+	}
+}
+
+// OnInvalidate registers fn to be called with the messages that an Edit or
+// Regenerate removed from the current branch, so memories derived from them
+// (e.g. KnowledgeGraph.RemoveSources) can be retracted
+func (c *Conversation) OnInvalidate(fn func(removed []core.Message)) *Conversation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onInvalidate = append(c.onInvalidate, fn)
+	return c
+}
+
+// rewind moves the current branch head back to the parent of messageID.
+// With keepAs, the old line is first saved as a new branch. It returns the
+// messages removed from the current branch.
+func (c *Conversation) rewind(messageID, keepAs string) ([]core.Message, error) {
+	branch := c.branches[c.current]
+	messages := c.path(branch.Head)
+	idx := -1
+	for i, msg := range messages {
+		if msg.GetID() == messageID {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return nil, fmt.Errorf("message %s is not on branch %s", messageID, c.current)
+	}
+
+	if keepAs != "" {
+		if _, exists := c.branches[keepAs]; exists {
+			return nil, fmt.Errorf("branch already exists: %s", keepAs)
+		}
+		c.branches[keepAs] = &Branch{Name: keepAs, Head: branch.Head, Parent: c.current, Length: branch.Length}
+	}
+	branch.Head = c.turns[messageID].parent
+	branch.Length = idx
+	return messages[idx:], nil
+}
+
+// invalidated collects unreachable messages and notifies the handlers.
+// It must be called without holding the lock.
+func (c *Conversation) invalidated(removed []core.Message) {
+	c.mu.Lock()
+	c.collect()
+	handlers := append([]func([]core.Message){}, c.onInvalidate...)
+	c.mu.Unlock()
+	for _, fn := range handlers {
+		fn(removed)
+	}
+}
+
+// Edit replaces a human message of the current branch with new content.
+// Every message after it is invalidated; with keepAs, the previous version
+// of the conversation stays available as that branch.
+func (c *Conversation) Edit(messageID, content, keepAs string) (*core.HumanMessage, error) {
+	c.mu.Lock()
+	t, ok := c.turns[messageID]
+	if !ok {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("message not found: %s", messageID)
+	}
+	old, ok := t.msg.(*core.HumanMessage)
+	if !ok {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("only human messages can be edited, %s is %s", messageID, t.msg.GetType())
+	}
+	removed, err := c.rewind(messageID, keepAs)
+	if err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	edited := core.NewHumanMessage(content, old.AdditionalKwargs)
+	edited.Attachments = old.Attachments
+	branch := c.branches[c.current]
+	c.turns[edited.ID] = turn{msg: edited, parent: branch.Head}
+	branch.Head = edited.ID
+	branch.Length++
+	c.mu.Unlock()
+
+	c.invalidated(removed)
+	return edited, nil
+}
+
+// Regenerate replaces the last AI message of the current branch with a new
+// response from llm. With keepAs, the previous response stays available as
+// that branch.
+func (c *Conversation) Regenerate(ctx context.Context, llm core.Runnable, keepAs string, config *core.Config) (*core.AIMessage, error) {
+	c.mu.RLock()
+	messages := c.path(c.branches[c.current].Head)
+	c.mu.RUnlock()
+	if len(messages) == 0 || messages[len(messages)-1].GetType() != core.MessageTypeAI {
+		return nil, fmt.Errorf("the last message of branch %s is not an AI message", c.CurrentBranch())
+	}
+	last := messages[len(messages)-1]
+
+	output, err := llm.Invoke(ctx, messages[:len(messages)-1], config)
+	if err != nil {
+		return nil, fmt.Errorf("regeneration failed: %w", err)
+	}
+	var response *core.AIMessage
+	switch v := output.(type) {
+	case *core.AIMessage:
+		response = v
+	case *core.Generation:
+		response = v.Message()
+	default:
+		response = core.NewAIMessage(fmt.Sprint(output), nil)
+	}
+
+	c.mu.Lock()
+	branch := c.branches[c.current]
+	if branch.Head != last.GetID() {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("branch %s changed during regeneration", c.current)
+	}
+	removed, err := c.rewind(last.GetID(), keepAs)
+	if err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	c.turns[response.ID] = turn{msg: response, parent: branch.Head}
+	branch.Head = response.ID
+	branch.Length++
+	c.mu.Unlock()
+
+	c.invalidated(removed)
+	return response, nil
+}
+
+// storedMessage is the JSON form of a message in a saved conversation
+type storedMessage struct {
+	ID          string                 `json:"id"`
+	Parent      string                 `json:"parent,omitempty"`
+	Type        core.MessageType       `json:"type"`
+	Content     string                 `json:"content"`
+	Timestamp   int64                  `json:"timestamp"`
+	Kwargs      map[string]interface{} `json:"additional_kwargs,omitempty"`
+	ToolCalls   []core.ToolCall        `json:"tool_calls,omitempty"`
+	ToolCallID  string                 `json:"tool_call_id,omitempty"`
+	Attachments []core.Attachment      `json:"attachments,omitempty"`
+}
+
+// storedConversation is the JSON form of a conversation
+type storedConversation struct {
+	Current  string          `json:"current"`
+	Branches []Branch        `json:"branches"`
+	Messages []storedMessage `json:"messages"`
+}
+
+// Save writes the conversation and all its branches to a JSON file
+func (c *Conversation) Save(path string) error {
+	c.mu.RLock()
+	stored := storedConversation{Current: c.current}
+	for _, b := range c.branches {
+		stored.Branches = append(stored.Branches, *b)
+	}
+	for id, t := range c.turns {
+		stored.Messages = append(stored.Messages, toStoredMessage(id, t))
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(stored.Branches, func(i, j int) bool { return stored.Branches[i].Name < stored.Branches[j].Name })
+	sort.Slice(stored.Messages, func(i, j int) bool {
+		if stored.Messages[i].Timestamp != stored.Messages[j].Timestamp {
+			return stored.Messages[i].Timestamp < stored.Messages[j].Timestamp
+		}
+		return stored.Messages[i].ID < stored.Messages[j].ID
+	})
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadConversation reads a conversation previously written with Save
+func LoadConversation(path string) (*Conversation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversation: %w", err)
+	}
+	var stored storedConversation
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse conversation: %w", err)
+	}
+
+	c := &Conversation{
+		turns:    make(map[string]turn, len(stored.Messages)),
+		branches: make(map[string]*Branch, len(stored.Branches)),
+		current:  stored.Current,
+	}
+	for _, m := range stored.Messages {
+		c.turns[m.ID] = turn{msg: fromStoredMessage(m), parent: m.Parent}
+	}
+	for i := range stored.Branches {
+		b := stored.Branches[i]
+		c.branches[b.Name] = &b
+	}
+	if _, ok := c.branches[c.current]; !ok {
+		return nil, fmt.Errorf("failed to parse conversation: unknown current branch %q", c.current)
+	}
+	return c, nil
+}
+
+func toStoredMessage(id string, t turn) storedMessage {
+	s := storedMessage{
+		ID:        id,
+		Parent:    t.parent,
+		Type:      t.msg.GetType(),
+		Content:   t.msg.GetContent(),
+		Timestamp: t.msg.GetTimestamp(),
+	}
+	switch m := t.msg.(type) {
+	case *core.SystemMessage:
+		s.Kwargs = m.AdditionalKwargs
+	case *core.HumanMessage:
+		s.Kwargs = m.AdditionalKwargs
+		s.Attachments = m.Attachments
+	case *core.AIMessage:
+		s.Kwargs = m.AdditionalKwargs
+		s.ToolCalls = m.ToolCalls
+	case *core.ToolMessage:
+		s.Kwargs = m.AdditionalKwargs
+		s.ToolCallID = m.ToolCallID
+	}
+	return s
+}
+
+func fromStoredMessage(s storedMessage) core.Message {
+	var base *core.BaseMessage
+	var msg core.Message
+	switch s.Type {
+	case core.MessageTypeSystem:
+		m := core.NewSystemMessage(s.Content, s.Kwargs)
+		base, msg = m.BaseMessage, m
+	case core.MessageTypeAI:
+		m := core.NewAIMessage(s.Content, s.Kwargs)
+		if s.ToolCalls != nil {
+			m.ToolCalls = s.ToolCalls
+		}
+		base, msg = m.BaseMessage, m
+	case core.MessageTypeTool:
+		m := core.NewToolMessage(s.Content, s.ToolCallID, s.Kwargs)
+		base, msg = m.BaseMessage, m
+	default:
+		m := core.NewHumanMessage(s.Content, s.Kwargs)
+		m.Attachments = s.Attachments
+		base, msg = m.BaseMessage, m
+	}
+	base.ID, base.Timestamp = s.ID, s.Timestamp
+	return msg
+}
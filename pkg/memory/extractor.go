@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// TripleExtractor is a Runnable that extracts facts from text with an LLM
+// and adds them to a knowledge graph.
+// Input is a string or []core.Message; output is the []Triple extracted.
+type TripleExtractor struct {
+	*core.BaseRunnable
+	llm   core.Runnable
+	graph *KnowledgeGraph
+}
+
+// NewTripleExtractor creates a new extractor; graph may be nil to only return triples
+func NewTripleExtractor(llm core.Runnable, graph *KnowledgeGraph) *TripleExtractor {
+	return &TripleExtractor{
+		BaseRunnable: core.NewBaseRunnable("TripleExtractor"),
+		llm:          llm,
+		graph:        graph,
+	}
+}
+
+// Invoke extracts triples from the input and stores them in the graph
+func (e *TripleExtractor) Invoke(ctx context.Context, input interface{}, config *core.Config) (interface{}, error) {
+	var text, source string
+	switch v := input.(type) {
+	case string:
+		text = v
+	case []core.Message:
+		lines := make([]string, 0, len(v))
+		for _, msg := range v {
+			if msg.GetType() == core.MessageTypeSystem {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s: %s", msg.GetType(), msg.GetContent()))
+		}
+		text = strings.Join(lines, "\n")
+		if len(v) > 0 {
+			source = v[len(v)-1].GetID()
+		}
+	default:
+		return nil, fmt.Errorf("input must be a string or []core.Message")
+	}
+
+	prompt := fmt.Sprintf(`Extract factual relationships from the text below.
+Return one JSON object per line with the keys "subject", "predicate" and "object".
+Use short snake_case predicates (e.g. works_at, lives_in, likes). Return nothing else.
+
+Text:
+%s
+
+Facts:`, text)
+
+	response, err := e.llm.Invoke(ctx, prompt, config)
+	if err != nil {
+		return nil, fmt.Errorf("triple extraction failed: %w", err)
+	}
+
+	triples := ParseTriples(fmt.Sprint(response))
+	for i := range triples {
+		triples[i].Source = source
+	}
+	if e.graph != nil {
+		e.graph.Add(triples...)
+	}
+	return triples, nil
+}
+
+// Stream extracts the triples and emits them as a single chunk
+func (e *TripleExtractor) Stream(ctx context.Context, input interface{}, config *core.Config) (<-chan interface{}, error) {
+	out := make(chan interface{}, 1)
+	go func() {
+		defer close(out)
+		result, err := e.Invoke(ctx, input, config)
+		if err != nil {
+			out <- err
+			return
+		}
+		out <- result
+	}()
+	return out, nil
+}
+
+// Batch extracts triples from every input in turn
+func (e *TripleExtractor) Batch(ctx context.Context, inputs []interface{}, config *core.Config) ([]interface{}, error) {
+	results := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		result, err := e.Invoke(ctx, input, config)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// Pipe composes the extractor with another runnable
+func (e *TripleExtractor) Pipe(other core.Runnable) core.Runnable {
+	return core.NewRunnableSequence([]core.Runnable{e, other})
+}
+
+// ParseTriples reads JSON triples from model output, one object per line
+// or a single JSON array, skipping anything that does not parse
+func ParseTriples(output string) []Triple {
+	output = strings.TrimSpace(output)
+
+	var triples []Triple
+	if strings.HasPrefix(output, "[") {
+		if err := json.Unmarshal([]byte(output), &triples); err == nil {
+			return triples
+		}
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-"))
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var t Triple
+		if err := json.Unmarshal([]byte(strings.TrimSuffix(line, ",")), &t); err != nil {
+			continue
+		}
+		if t.Subject != "" && t.Predicate != "" && t.Object != "" {
+			triples = append(triples, t)
+		}
+	}
+	return triples
+}
@@ -0,0 +1,214 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Triple is a subject-predicate-object fact
+type Triple struct {
+	Subject   string `json:"subject"`
+	Predicate string `json:"predicate"`
+	Object    string `json:"object"`
+	Source    string `json:"source,omitempty"` // where the fact came from (message ID, document, ...)
+}
+
+// String renders the triple as "subject predicate object"
+func (t Triple) String() string {
+	return fmt.Sprintf("%s %s %s", t.Subject, t.Predicate, t.Object)
+}
+
+// KnowledgeGraph is a small in-memory triple store.
+// It answers relational questions ("who manages Alice?") that vector
+// similarity handles poorly.
+type KnowledgeGraph struct {
+	mu      sync.RWMutex
+	triples []Triple
+	index   map[string]bool
+}
+
+// NewKnowledgeGraph creates an empty knowledge graph
+func NewKnowledgeGraph() *KnowledgeGraph {
+	return &KnowledgeGraph{
+		index: make(map[string]bool),
+	}
+}
+
+// normalizeTerm lowercases and trims a term so "Alice " and "alice" match
+func normalizeTerm(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// tripleKey identifies a fact regardless of its source
+func tripleKey(t Triple) string {
+	return normalizeTerm(t.Subject) + "\x00" + normalizeTerm(t.Predicate) + "\x00" + normalizeTerm(t.Object)
+}
+
+// Add asserts facts, ignoring ones already known. It returns how many were new.
+func (g *KnowledgeGraph) Add(triples ...Triple) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	added := 0
+	for _, t := range triples {
+		if t.Subject == "" || t.Predicate == "" || t.Object == "" {
+			continue
+		}
+		key := tripleKey(t)
+		if g.index[key] {
+			continue
+		}
+		g.index[key] = true
+		g.triples = append(g.triples, t)
+		added++
+	}
+	return added
+}
+
+// Remove retracts every fact matching the pattern and returns how many were removed
+func (g *KnowledgeGraph) Remove(subject, predicate, object string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	kept := g.triples[:0]
+	removed := 0
+	for _, t := range g.triples {
+		if matches(t, subject, predicate, object) {
+			delete(g.index, tripleKey(t))
+			removed++
+			continue
+		}
+		kept = append(kept, t)
+	}
+	g.triples = kept
+	return removed
+}
+
+// RemoveSources retracts every fact extracted from one of the given sources
+// and returns how many were removed
+func (g *KnowledgeGraph) RemoveSources(sources ...string) int {
+	drop := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		drop[s] = true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	kept := g.triples[:0]
+	removed := 0
+	for _, t := range g.triples {
+		if t.Source != "" && drop[t.Source] {
+			delete(g.index, tripleKey(t))
+			removed++
+			continue
+		}
+		kept = append(kept, t)
+	}
+	g.triples = kept
+	return removed
+}
+
+// Query returns facts matching the pattern; empty strings act as wildcards
+func (g *KnowledgeGraph) Query(subject, predicate, object string) []Triple {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var out []Triple
+	for _, t := range g.triples {
+		if matches(t, subject, predicate, object) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Neighbors returns every fact in which entity appears as subject or object
+func (g *KnowledgeGraph) Neighbors(entity string) []Triple {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	e := normalizeTerm(entity)
+	var out []Triple
+	for _, t := range g.triples {
+		if normalizeTerm(t.Subject) == e || normalizeTerm(t.Object) == e {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Entities returns all distinct subjects and objects, sorted
+func (g *KnowledgeGraph) Entities() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var out []string
+	for _, t := range g.triples {
+		for _, e := range []string{t.Subject, t.Object} {
+			if !seen[normalizeTerm(e)] {
+				seen[normalizeTerm(e)] = true
+				out = append(out, e)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Len returns the number of facts
+func (g *KnowledgeGraph) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.triples)
+}
+
+// Save writes the graph to a JSON file
+func (g *KnowledgeGraph) Save(path string) error {
+	g.mu.RLock()
+	data, err := json.MarshalIndent(g.triples, "", "  ")
+	g.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadKnowledgeGraph reads a graph previously written with Save
+func LoadKnowledgeGraph(path string) (*KnowledgeGraph, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read knowledge graph: %w", err)
+	}
+	var triples []Triple
+	if err := json.Unmarshal(data, &triples); err != nil {
+		return nil, fmt.Errorf("failed to parse knowledge graph: %w", err)
+	}
+	g := NewKnowledgeGraph()
+	g.Add(triples...)
+	return g, nil
+}
+
+// matches reports whether t fits the pattern; empty fields match anything
+func matches(t Triple, subject, predicate, object string) bool {
+	return (subject == "" || normalizeTerm(t.Subject) == normalizeTerm(subject)) &&
+		(predicate == "" || normalizeTerm(t.Predicate) == normalizeTerm(predicate)) &&
+		(object == "" || normalizeTerm(t.Object) == normalizeTerm(object))
+}
+
+// FormatTriples renders facts one per line for use in prompts
+func FormatTriples(triples []Triple) string {
+	if len(triples) == 0 {
+		return "No matching facts."
+	}
+	lines := make([]string, len(triples))
+	for i, t := range triples {
+		lines[i] = "- " + t.String()
+	}
+	return strings.Join(lines, "\n")
+}
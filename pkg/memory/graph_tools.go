@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/tools"
+)
+
+// AssertFactTool lets an agent store facts in a knowledge graph
+type AssertFactTool struct {
+	*tools.BaseTool
+	graph *KnowledgeGraph
+}
+
+// NewAssertFactTool creates a new AssertFactTool
+func NewAssertFactTool(graph *KnowledgeGraph) *AssertFactTool {
+	return &AssertFactTool{
+		BaseTool: tools.NewBaseTool(
+			"assert_fact",
+			"Remember a fact as a subject-predicate-object triple, e.g. Alice / works_at / Acme.",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"subject":   map[string]interface{}{"type": "string"},
+					"predicate": map[string]interface{}{"type": "string"},
+					"object":    map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"subject", "predicate", "object"},
+			},
+		),
+		graph: graph,
+	}
+}
+
+// Execute stores the fact
+func (t *AssertFactTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	triple := Triple{Source: "agent"}
+	triple.Subject, _ = args["subject"].(string)
+	triple.Predicate, _ = args["predicate"].(string)
+	triple.Object, _ = args["object"].(string)
+	if triple.Subject == "" || triple.Predicate == "" || triple.Object == "" {
+		return "", fmt.Errorf("subject, predicate and object are required")
+	}
+
+	if t.graph.Add(triple) == 0 {
+		return fmt.Sprintf("Already known: %s", triple), nil
+	}
+	return fmt.Sprintf("Remembered: %s", triple), nil
+}
+
+// QueryFactsTool lets an agent look up facts in a knowledge graph
+type QueryFactsTool struct {
+	*tools.BaseTool
+	graph *KnowledgeGraph
+}
+
+// NewQueryFactsTool creates a new QueryFactsTool
+func NewQueryFactsTool(graph *KnowledgeGraph) *QueryFactsTool {
+	return &QueryFactsTool{
+		BaseTool: tools.NewBaseTool(
+			"query_facts",
+			"Look up remembered facts. Leave a field empty to match anything, or pass only entity to get everything about it.",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"subject":   map[string]interface{}{"type": "string"},
+					"predicate": map[string]interface{}{"type": "string"},
+					"object":    map[string]interface{}{"type": "string"},
+					"entity": map[string]interface{}{
+						"type":        "string",
+						"description": "Return all facts mentioning this entity",
+					},
+				},
+			},
+		),
+		graph: graph,
+	}
+}
+
+// Execute runs the query
+func (t *QueryFactsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	if entity, ok := args["entity"].(string); ok && entity != "" {
+		return FormatTriples(t.graph.Neighbors(entity)), nil
+	}
+
+	subject, _ := args["subject"].(string)
+	predicate, _ := args["predicate"].(string)
+	object, _ := args["object"].(string)
+	return FormatTriples(t.graph.Query(subject, predicate, object)), nil
+}
+
+// ReadOnly lets the query run in simulation mode
+func (t *QueryFactsTool) ReadOnly() bool { return true }
@@ -0,0 +1,318 @@
+package memory
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// ImportedConversation is one conversation read from an external archive
+type ImportedConversation struct {
+	Title        string
+	Created      time.Time
+	Conversation *Conversation
+}
+
+// chatGPTConversation is an entry of conversations.json in a ChatGPT data export
+type chatGPTConversation struct {
+	Title       string                 `json:"title"`
+	CreateTime  float64                `json:"create_time"`
+	CurrentNode string                 `json:"current_node"`
+	Mapping     map[string]chatGPTNode `json:"mapping"`
+}
+
+type chatGPTNode struct {
+	ID       string          `json:"id"`
+	Parent   string          `json:"parent"`
+	Children []string        `json:"children"`
+	Message  *chatGPTMessage `json:"message"`
+}
+
+type chatGPTMessage struct {
+	ID     string `json:"id"`
+	Author struct {
+		Role string `json:"role"`
+		Name string `json:"name"`
+	} `json:"author"`
+	CreateTime float64 `json:"create_time"`
+	Content    struct {
+		ContentType string        `json:"content_type"`
+		Parts       []interface{} `json:"parts"`
+		Text        string        `json:"text"`
+	} `json:"content"`
+}
+
+// ImportChatGPTExport reads a ChatGPT data export, either the zip archive or
+// the conversations.json file inside it
+func ImportChatGPTExport(path string) ([]ImportedConversation, error) {
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		archive, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open export: %w", err)
+		}
+		defer archive.Close()
+		for _, f := range archive.File {
+			if filepath.Base(f.Name) != "conversations.json" {
+				continue
+			}
+			r, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("failed to open export: %w", err)
+			}
+			defer r.Close()
+			return ParseChatGPTConversations(r)
+		}
+		return nil, fmt.Errorf("no conversations.json in %s", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	defer f.Close()
+	return ParseChatGPTConversations(f)
+}
+
+// ParseChatGPTConversations converts conversations.json. The regenerated and
+// edited alternatives of the export become branches; the branch the user
+// last viewed is MainBranch.
+func ParseChatGPTConversations(r io.Reader) ([]ImportedConversation, error) {
+	var raw []chatGPTConversation
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to parse conversations: %w", err)
+	}
+
+	out := make([]ImportedConversation, 0, len(raw))
+	for _, rc := range raw {
+		out = append(out, ImportedConversation{
+			Title:        rc.Title,
+			Created:      unixSeconds(rc.CreateTime),
+			Conversation: rc.toConversation(),
+		})
+	}
+	return out, nil
+}
+
+// toConversation rebuilds the message tree, skipping empty and hidden nodes
+func (rc chatGPTConversation) toConversation() *Conversation {
+	c := NewConversation()
+
+	// visible returns the closest ancestor-or-self node that has a message
+	var visible func(id string) string
+	visible = func(id string) string {
+		for id != "" {
+			node, ok := rc.Mapping[id]
+			if !ok {
+				return ""
+			}
+			if _, ok := c.turns[id]; ok {
+				return id
+			}
+			id = node.Parent
+		}
+		return ""
+	}
+
+	// Parents before children so every parent link resolves
+	ids := make([]string, 0, len(rc.Mapping))
+	depth := make(map[string]int, len(rc.Mapping))
+	for id := range rc.Mapping {
+		ids = append(ids, id)
+		depth[id] = rc.depth(id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if depth[ids[i]] != depth[ids[j]] {
+			return depth[ids[i]] < depth[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		node := rc.Mapping[id]
+		msg := node.Message.toMessage(id)
+		if msg == nil {
+			continue
+		}
+		c.turns[id] = turn{msg: msg, parent: visible(node.Parent)}
+	}
+
+	var leaves []string
+	for _, id := range ids {
+		if len(rc.Mapping[id].Children) == 0 {
+			leaves = append(leaves, id)
+		}
+	}
+
+	main := c.branches[MainBranch]
+	main.Head = visible(rc.CurrentNode)
+	main.Length = len(c.path(main.Head))
+	n := 1
+	for _, leaf := range leaves {
+		head := visible(leaf)
+		if head == "" || head == main.Head || c.onPath(head, main.Head) {
+			continue
+		}
+		name := fmt.Sprintf("alt-%d", n)
+		n++
+		c.branches[name] = &Branch{Name: name, Head: head, Parent: MainBranch, Length: len(c.path(head))}
+	}
+	c.collect()
+	return c
+}
+
+// depth is the number of ancestors of a node
+func (rc chatGPTConversation) depth(id string) int {
+	d := 0
+	for node, ok := rc.Mapping[id]; ok && node.Parent != ""; node, ok = rc.Mapping[node.Parent] {
+		d++
+	}
+	return d
+}
+
+// onPath reports whether id is an ancestor-or-self of head
+func (c *Conversation) onPath(id, head string) bool {
+	for cur := head; cur != ""; cur = c.turns[cur].parent {
+		if cur == id {
+			return true
+		}
+	}
+	return false
+}
+
+// toMessage converts an export message, or returns nil for empty ones
+func (m *chatGPTMessage) toMessage(id string) core.Message {
+	if m == nil {
+		return nil
+	}
+	var parts []string
+	for _, p := range m.Content.Parts {
+		switch v := p.(type) {
+		case string:
+			if v != "" {
+				parts = append(parts, v)
+			}
+		case map[string]interface{}:
+			parts = append(parts, fmt.Sprintf("[%v]", v["content_type"]))
+		}
+	}
+	if m.Content.Text != "" {
+		parts = append(parts, m.Content.Text)
+	}
+	content := strings.Join(parts, "\n")
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+
+	var msg core.Message
+	var base *core.BaseMessage
+	switch m.Author.Role {
+	case "user":
+		h := core.NewHumanMessage(content, nil)
+		msg, base = h, h.BaseMessage
+	case "assistant":
+		a := core.NewAIMessage(content, nil)
+		msg, base = a, a.BaseMessage
+	case "system":
+		s := core.NewSystemMessage(content, nil)
+		msg, base = s, s.BaseMessage
+	case "tool":
+		t := core.NewToolMessage(content, "", map[string]interface{}{"name": m.Author.Name})
+		msg, base = t, t.BaseMessage
+	default:
+		return nil
+	}
+	base.ID = id
+	if m.CreateTime > 0 {
+		base.Timestamp = unixSeconds(m.CreateTime).UnixMilli()
+	}
+	return msg
+}
+
+// ParseOpenAIThread converts the message list of an OpenAI Assistants thread
+// (GET /v1/threads/{id}/messages) into a conversation
+func ParseOpenAIThread(r io.Reader) (*Conversation, error) {
+	var thread struct {
+		Data []struct {
+			ID        string `json:"id"`
+			Role      string `json:"role"`
+			CreatedAt int64  `json:"created_at"`
+			Content   []struct {
+				Type string `json:"type"`
+				Text struct {
+					Value string `json:"value"`
+				} `json:"text"`
+			} `json:"content"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r).Decode(&thread); err != nil {
+		return nil, fmt.Errorf("failed to parse thread: %w", err)
+	}
+
+	// The API lists newest first by default
+	sort.SliceStable(thread.Data, func(i, j int) bool { return thread.Data[i].CreatedAt < thread.Data[j].CreatedAt })
+
+	c := NewConversation()
+	for _, m := range thread.Data {
+		var parts []string
+		for _, part := range m.Content {
+			if part.Type == "text" {
+				parts = append(parts, part.Text.Value)
+			} else {
+				parts = append(parts, "["+part.Type+"]")
+			}
+		}
+		content := strings.Join(parts, "\n")
+
+		var msg core.Message
+		var base *core.BaseMessage
+		if m.Role == "assistant" {
+			a := core.NewAIMessage(content, nil)
+			msg, base = a, a.BaseMessage
+		} else {
+			h := core.NewHumanMessage(content, nil)
+			msg, base = h, h.BaseMessage
+		}
+		base.ID, base.Timestamp = m.ID, m.CreatedAt*1000
+		if err := c.Append(msg); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// ExtractFacts runs extractor over every user/assistant exchange of the main
+// branch of each conversation, storing facts in the extractor's graph with
+// the assistant message as source. It returns the number of facts found.
+func ExtractFacts(ctx context.Context, extractor *TripleExtractor, conversations []ImportedConversation, config *core.Config) (int, error) {
+	total := 0
+	for _, ic := range conversations {
+		messages, err := ic.Conversation.BranchMessages(MainBranch)
+		if err != nil {
+			return total, err
+		}
+		for i := 1; i < len(messages); i++ {
+			if messages[i-1].GetType() != core.MessageTypeHuman || messages[i].GetType() != core.MessageTypeAI {
+				continue
+			}
+			triples, err := extractor.Invoke(ctx, messages[i-1:i+1], config)
+			if err != nil {
+				return total, fmt.Errorf("fact extraction for %q failed: %w", ic.Title, err)
+			}
+			total += len(triples.([]Triple))
+		}
+	}
+	return total, nil
+}
+
+// unixSeconds converts fractional Unix seconds to a time
+func unixSeconds(s float64) time.Time {
+	return time.UnixMilli(int64(s * 1000))
+}
@@ -0,0 +1,324 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// Segment is a run of messages about one topic
+type Segment struct {
+	Start int    `json:"start"` // index of the first message
+	End   int    `json:"end"`   // index after the last message
+	Title string `json:"title"`
+}
+
+// Summary is the title and short summary of a whole conversation, e.g.
+// for a session list
+type Summary struct {
+	Title    string    `json:"title"`
+	Summary  string    `json:"summary"`
+	Segments []Segment `json:"segments"`
+}
+
+// TopicSegmenter splits long conversations into topics and titles them
+// with an LLM. Conversations longer than the window are read in windows
+// that overlap by a few messages, so a topic change at a window edge is
+// still seen in context.
+type TopicSegmenter struct {
+	llm      core.Runnable
+	window   int
+	maxChars int
+}
+
+// NewTopicSegmenter creates a segmenter backed by llm
+func NewTopicSegmenter(llm core.Runnable) *TopicSegmenter {
+	return &TopicSegmenter{llm: llm, window: 40, maxChars: 300}
+}
+
+// WithWindow sets how many messages the LLM reads at once (default 40)
+func (s *TopicSegmenter) WithWindow(n int) *TopicSegmenter {
+	if n > 4 {
+		s.window = n
+	}
+	return s
+}
+
+var (
+	// topicStartLine matches "12: Title" lines of the segmentation answer
+	topicStartLine     = regexp.MustCompile(`(?m)^\D{0,3}(\d+)\s*[:.)-]\s*(.+)$`)
+	summaryTitleLine   = regexp.MustCompile(`(?im)^\W*title\W*:\s*(.+)$`)
+	summarySummaryLine = regexp.MustCompile(`(?im)^\W*summary\W*:\s*(.+)$`)
+)
+
+// Segment returns the topics of messages in order. System messages are
+// skipped but keep their index, so segments can be mapped back onto the
+// conversation.
+func (s *TopicSegmenter) Segment(ctx context.Context, messages []core.Message, config *core.Config) ([]Segment, error) {
+	var indices []int
+	for i, msg := range messages {
+		if msg.GetType() != core.MessageTypeSystem {
+			indices = append(indices, i)
+		}
+	}
+	if len(indices) == 0 {
+		return nil, nil
+	}
+
+	starts := map[int]string{}
+	overlap := s.window / 4
+	for from := 0; from < len(indices); from += s.window - overlap {
+		to := from + s.window
+		if to > len(indices) {
+			to = len(indices)
+		}
+		found, err := s.boundaries(ctx, messages, indices[from:to], config)
+		if err != nil {
+			return nil, err
+		}
+		for n, title := range found {
+			// Starts in the overlap were judged with more context by the
+			// previous window
+			idx := indices[from+n]
+			if _, seen := starts[idx]; !seen || n >= overlap {
+				starts[idx] = title
+			}
+		}
+		if to == len(indices) {
+			break
+		}
+	}
+	if _, ok := starts[indices[0]]; !ok {
+		starts[indices[0]] = ""
+	}
+
+	positions := make([]int, 0, len(starts))
+	for idx := range starts {
+		positions = append(positions, idx)
+	}
+	sort.Ints(positions)
+	// A topic starts at the first message, including skipped system ones
+	positions[0] = 0
+	starts[0] = starts[indices[0]]
+
+	segments := make([]Segment, len(positions))
+	for i, start := range positions {
+		end := len(messages)
+		if i+1 < len(positions) {
+			end = positions[i+1]
+		}
+		segments[i] = Segment{Start: start, End: end, Title: starts[start]}
+	}
+	return segments, nil
+}
+
+// boundaries asks the LLM where topics start among the messages at
+// indices, returning their positions in indices and titles
+func (s *TopicSegmenter) boundaries(ctx context.Context, messages []core.Message, indices []int, config *core.Config) (map[int]string, error) {
+	var transcript strings.Builder
+	for n, idx := range indices {
+		content := strings.Join(strings.Fields(messages[idx].GetContent()), " ")
+		if runes := []rune(content); len(runes) > s.maxChars {
+			content = string(runes[:s.maxChars]) + "..."
+		}
+		fmt.Fprintf(&transcript, "%d. %s: %s\n", n+1, messages[idx].GetType(), content)
+	}
+
+	prompt := fmt.Sprintf(`Below is part of a conversation with numbered messages. Split it into topics:
+find the messages where the conversation moves to a new subject.
+
+%s
+List the number of the message where each topic starts, including message 1, with a title of 2 to 6 words.
+Answer with one line per topic, e.g.:
+1: Planning a trip to Lisbon
+7: Packing list`, transcript.String())
+
+	response, err := s.llm.Invoke(ctx, prompt, config)
+	if err != nil {
+		return nil, fmt.Errorf("topic segmentation failed: %w", err)
+	}
+
+	found := make(map[int]string)
+	for _, m := range topicStartLine.FindAllStringSubmatch(fmt.Sprint(response), -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n < 1 || n > len(indices) {
+			continue
+		}
+		found[n-1] = cleanTitle(m[2])
+	}
+	return found, nil
+}
+
+// Summarize titles and summarizes a conversation and its topics
+func (s *TopicSegmenter) Summarize(ctx context.Context, messages []core.Message, config *core.Config) (*Summary, error) {
+	segments, err := s.Segment(ctx, messages, config)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return &Summary{}, nil
+	}
+
+	var topics strings.Builder
+	for _, seg := range segments {
+		fmt.Fprintf(&topics, "- %s\n", seg.Title)
+	}
+	var opening strings.Builder
+	for _, msg := range messages[segments[0].Start:segments[0].End] {
+		if msg.GetType() == core.MessageTypeHuman {
+			fmt.Fprintf(&opening, "%s\n", msg.GetContent())
+		}
+		if opening.Len() > 2*s.maxChars {
+			break
+		}
+	}
+
+	prompt := fmt.Sprintf(`A conversation covered these topics, in order:
+%s
+It opened with:
+%s
+Give the conversation a title and a one-sentence summary. Answer with exactly two lines:
+title: <3 to 8 words>
+summary: <one sentence>`, topics.String(), strings.TrimSpace(opening.String()))
+
+	response, err := s.llm.Invoke(ctx, prompt, config)
+	if err != nil {
+		return nil, fmt.Errorf("conversation summary failed: %w", err)
+	}
+	text := fmt.Sprint(response)
+	summary := &Summary{Segments: segments}
+	if m := summaryTitleLine.FindStringSubmatch(text); m != nil {
+		summary.Title = cleanTitle(m[1])
+	} else {
+		summary.Title = segments[0].Title
+	}
+	if m := summarySummaryLine.FindStringSubmatch(text); m != nil {
+		summary.Summary = strings.TrimSpace(m[1])
+	}
+	return summary, nil
+}
+
+// cleanTitle strips the quotes and Markdown models wrap titles in
+func cleanTitle(title string) string {
+	return strings.TrimSpace(strings.Trim(strings.TrimSpace(title), "\"'*`#"))
+}
+
+// FallbackTitle is a title for a conversation not summarized yet: the
+// start of its first user message
+func FallbackTitle(messages []core.Message) string {
+	for _, msg := range messages {
+		if msg.GetType() != core.MessageTypeHuman {
+			continue
+		}
+		title := strings.Join(strings.Fields(msg.GetContent()), " ")
+		if runes := []rune(title); len(runes) > 50 {
+			title = strings.TrimSpace(string(runes[:50])) + "..."
+		}
+		return title
+	}
+	return "New conversation"
+}
+
+// SessionTitles titles conversations lazily, for session lists that must
+// render right away: Get answers from its cache or with FallbackTitle, and
+// summarizes the conversation in the background so a later Get has the
+// real title. A conversation is summarized again once it has grown by the
+// number of messages set with WithRefreshAfter.
+type SessionTitles struct {
+	segmenter    *TopicSegmenter
+	refreshAfter int
+
+	mu      sync.Mutex
+	entries map[string]*titleEntry
+	wg      sync.WaitGroup
+}
+
+type titleEntry struct {
+	summary *Summary
+	length  int // messages summarized
+	running bool
+	err     error
+}
+
+// NewSessionTitles creates a lazy titler summarizing with segmenter
+func NewSessionTitles(segmenter *TopicSegmenter) *SessionTitles {
+	return &SessionTitles{
+		segmenter:    segmenter,
+		refreshAfter: 10,
+		entries:      make(map[string]*titleEntry),
+	}
+}
+
+// WithRefreshAfter sets how many new messages make a title stale (default 10)
+func (t *SessionTitles) WithRefreshAfter(n int) *SessionTitles {
+	t.refreshAfter = n
+	return t
+}
+
+// Get returns the title known for session id and schedules a summary if
+// there is none yet or it is stale
+func (t *SessionTitles) Get(id string, conv *Conversation) string {
+	messages := conv.Messages()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[id]
+	if !ok {
+		entry = &titleEntry{}
+		t.entries[id] = entry
+	}
+	stale := entry.summary == nil || len(messages)-entry.length >= t.refreshAfter
+	if stale && !entry.running && entry.err == nil && len(messages) > 0 {
+		entry.running = true
+		t.wg.Add(1)
+		go t.summarize(entry, messages)
+	}
+	if entry.summary != nil && entry.summary.Title != "" {
+		return entry.summary.Title
+	}
+	return FallbackTitle(messages)
+}
+
+// Summary returns the latest summary of session id, or nil
+func (t *SessionTitles) Summary(id string) *Summary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if entry, ok := t.entries[id]; ok {
+		return entry.summary
+	}
+	return nil
+}
+
+// Forget drops what is known about session id, e.g. after it was deleted
+func (t *SessionTitles) Forget(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, id)
+}
+
+// Wait blocks until the summaries in progress are done
+func (t *SessionTitles) Wait() {
+	t.wg.Wait()
+}
+
+func (t *SessionTitles) summarize(entry *titleEntry, messages []core.Message) {
+	defer t.wg.Done()
+	summary, err := t.segmenter.Summarize(context.Background(), messages, nil)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry.running = false
+	if err != nil {
+		// Keep the fallback title instead of calling a failing model on every Get
+		entry.err = err
+		return
+	}
+	entry.summary = summary
+	entry.length = len(messages)
+}
@@ -0,0 +1,262 @@
+// Package telemetry counts which parts of the library are used, without
+// recording any content. It is off unless enabled, and it is a plain
+// core.Callback plus an event subscriber, so what it sees is what the
+// callback interface passes and removing it is removing the callback.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// modulePath prefixes the packages whose runnables are counted by type
+const modulePath = "github.com/mgreau/ai-agents-from-scratch-go/"
+
+// customFeature counts runnables defined outside the library
+const customFeature = "custom"
+
+// Usage is an anonymous usage report: how often each library runnable ran
+// or failed and how often each event type was published. It holds type
+// names and counts only; inputs, outputs, error messages, tool arguments
+// and runnable names chosen by the application are never recorded.
+type Usage struct {
+	Since  time.Time        `json:"since"`
+	Until  time.Time        `json:"until"`
+	Runs   map[string]int64 `json:"runs"`
+	Errors map[string]int64 `json:"errors"`
+	Events map[string]int64 `json:"events"`
+	GoOS   string           `json:"goos"`
+	GoArch string           `json:"goarch"`
+}
+
+// Exporter sends a usage report somewhere
+type Exporter func(ctx context.Context, usage Usage) error
+
+// Collector counts feature usage. Add it to core.Config.Callbacks and
+// subscribe it to an event bus; while disabled it records nothing.
+type Collector struct {
+	mu      sync.Mutex
+	enabled bool
+	epsilon float64
+	rng     *rand.Rand
+	since   time.Time
+	runs    map[string]int64
+	errors  map[string]int64
+	events  map[string]int64
+}
+
+var _ core.Callback = (*Collector)(nil)
+
+// NewCollector creates a collector, enabled only when AGENT_TELEMETRY is
+// "1" or "true"
+func NewCollector() *Collector {
+	c := &Collector{rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	c.Reset()
+	switch strings.ToLower(os.Getenv("AGENT_TELEMETRY")) {
+	case "1", "true", "on":
+		c.Enable()
+	}
+	return c
+}
+
+// optedOut reports whether DO_NOT_TRACK is set, which wins over Enable
+func optedOut() bool {
+	v := os.Getenv("DO_NOT_TRACK")
+	return v != "" && v != "0"
+}
+
+// Enable starts counting, unless DO_NOT_TRACK is set
+func (c *Collector) Enable() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.enabled = !optedOut()
+}
+
+// Disable stops counting and drops what was counted
+func (c *Collector) Disable() {
+	c.mu.Lock()
+	c.enabled = false
+	c.mu.Unlock()
+	c.Reset()
+}
+
+// Enabled reports whether the collector is counting
+func (c *Collector) Enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enabled
+}
+
+// WithNoise adds Laplace noise of scale 1/epsilon to every reported count,
+// so a single run cannot be inferred from a report (epsilon-differential
+// privacy per count). Smaller epsilon means more noise; 0 disables it.
+func (c *Collector) WithNoise(epsilon float64) *Collector {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.epsilon = epsilon
+	return c
+}
+
+// Reset drops the counts and starts a new reporting period
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.since = time.Now()
+	c.runs = make(map[string]int64)
+	c.errors = make(map[string]int64)
+	c.events = make(map[string]int64)
+}
+
+// Count records one use of a named feature, for code paths that do not go
+// through callbacks or events. The name must not contain user data.
+func (c *Collector) Count(feature string) {
+	c.add(c.runs, feature)
+}
+
+// add increments a counter when enabled
+func (c *Collector) add(counts map[string]int64, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.enabled {
+		counts[key]++
+	}
+}
+
+// feature names a runnable by its Go type, e.g. "chains.ReportGenerator";
+// types defined by the application are all counted as "custom"
+func feature(runnable core.Runnable) string {
+	t := reflect.TypeOf(runnable)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || !strings.HasPrefix(t.PkgPath(), modulePath) {
+		return customFeature
+	}
+	return t.String()
+}
+
+// OnStart counts a run of the runnable's type; the input is not read
+func (c *Collector) OnStart(ctx context.Context, runnable core.Runnable, input interface{}) error {
+	c.add(c.runs, feature(runnable))
+	return nil
+}
+
+// OnEnd does nothing; runs are counted when they start
+func (c *Collector) OnEnd(ctx context.Context, runnable core.Runnable, output interface{}) error {
+	return nil
+}
+
+// OnError counts a failure of the runnable's type; the error is not read
+func (c *Collector) OnError(ctx context.Context, runnable core.Runnable, err error) error {
+	c.add(c.errors, feature(runnable))
+	return nil
+}
+
+// Subscribe counts the events published on bus by type and returns a
+// function that unsubscribes
+func (c *Collector) Subscribe(bus *core.EventBus) func() {
+	return bus.Subscribe(func(ctx context.Context, event core.Event) {
+		c.add(c.events, event.EventType())
+	})
+}
+
+// Usage returns the counts of the current period, with noise if set.
+// Times are rounded to the hour.
+func (c *Collector) Usage() Usage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Usage{
+		Since:  c.since.UTC().Truncate(time.Hour),
+		Until:  time.Now().UTC().Truncate(time.Hour),
+		Runs:   c.noisy(c.runs),
+		Errors: c.noisy(c.errors),
+		Events: c.noisy(c.events),
+		GoOS:   runtime.GOOS,
+		GoArch: runtime.GOARCH,
+	}
+}
+
+// noisy copies counts, adding Laplace noise when epsilon is set. Counts
+// that end up below 1 are left out.
+func (c *Collector) noisy(counts map[string]int64) map[string]int64 {
+	out := make(map[string]int64, len(counts))
+	for k, n := range counts {
+		if c.epsilon > 0 {
+			u := c.rng.Float64() - 0.5
+			noise := -math.Copysign(1, u) * math.Log(1-2*math.Abs(u)) / c.epsilon
+			n = int64(math.Round(float64(n) + noise))
+		}
+		if n > 0 {
+			out[k] = n
+		}
+	}
+	return out
+}
+
+// Export sends the current usage with exporter and starts a new period. It
+// does nothing while the collector is disabled.
+func (c *Collector) Export(ctx context.Context, exporter Exporter) error {
+	if !c.Enabled() {
+		return nil
+	}
+	if err := exporter(ctx, c.Usage()); err != nil {
+		return fmt.Errorf("telemetry export failed: %w", err)
+	}
+	c.Reset()
+	return nil
+}
+
+// FileExporter appends each report as a JSON line to path, so what would be
+// shared can be read first
+func FileExporter(path string) Exporter {
+	return func(ctx context.Context, usage Usage) error {
+		data, err := json.Marshal(usage)
+		if err != nil {
+			return err
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.Write(append(data, '\n'))
+		return err
+	}
+}
+
+// HTTPExporter posts each report as JSON to url
+func HTTPExporter(url string) Exporter {
+	return func(ctx context.Context, usage Usage) error {
+		data, err := json.Marshal(usage)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+		}
+		return nil
+	}
+}
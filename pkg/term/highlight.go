@@ -0,0 +1,105 @@
+package term
+
+import (
+	"strings"
+	"unicode"
+)
+
+// keywords lists the highlighted keywords per fence language
+var keywords = map[string][]string{
+	"go": {"break", "case", "chan", "const", "continue", "default", "defer", "else",
+		"fallthrough", "for", "func", "go", "goto", "if", "import", "interface", "map",
+		"package", "range", "return", "select", "struct", "switch", "type", "var",
+		"nil", "true", "false"},
+	"python": {"and", "as", "assert", "async", "await", "break", "class", "continue",
+		"def", "del", "elif", "else", "except", "finally", "for", "from", "if", "import",
+		"in", "is", "lambda", "not", "or", "pass", "raise", "return", "try", "while",
+		"with", "yield", "None", "True", "False"},
+	"javascript": {"async", "await", "break", "case", "catch", "class", "const",
+		"continue", "default", "else", "export", "extends", "for", "function", "if",
+		"import", "let", "new", "return", "switch", "this", "throw", "try", "var",
+		"while", "null", "undefined", "true", "false"},
+	"bash": {"case", "do", "done", "elif", "else", "esac", "export", "fi", "for",
+		"function", "if", "in", "local", "return", "then", "while"},
+}
+
+// languageAliases maps fence labels to the keys of keywords
+var languageAliases = map[string]string{
+	"golang":     "go",
+	"py":         "python",
+	"js":         "javascript",
+	"ts":         "javascript",
+	"typescript": "javascript",
+	"sh":         "bash",
+	"shell":      "bash",
+	"zsh":        "bash",
+}
+
+// lineComment returns the line comment marker of a language
+func lineComment(lang string) string {
+	switch lang {
+	case "python", "bash":
+		return "#"
+	default:
+		return "//"
+	}
+}
+
+// highlight colors keywords, strings, numbers and line comments in one line
+// of code. Constructs spanning lines, like block comments, are not tracked.
+func highlight(line, lang string) string {
+	if alias, ok := languageAliases[lang]; ok {
+		lang = alias
+	}
+	words := make(map[string]bool)
+	for _, k := range keywords[lang] {
+		words[k] = true
+	}
+	comment := lineComment(lang)
+
+	var b strings.Builder
+	runes := []rune(line)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case strings.HasPrefix(string(runes[i:]), comment):
+			b.WriteString(gray + string(runes[i:]) + reset)
+			return b.String()
+		case c == '"' || c == '\'' || c == '`':
+			j := i + 1
+			for j < len(runes) && runes[j] != c {
+				if runes[j] == '\\' && c != '`' {
+					j++
+				}
+				j++
+			}
+			if j >= len(runes) {
+				j = len(runes) - 1
+			}
+			b.WriteString(green + string(runes[i:j+1]) + reset)
+			i = j + 1
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			word := string(runes[i:j])
+			if words[word] {
+				word = magenta + word + reset
+			}
+			b.WriteString(word)
+			i = j
+		case unicode.IsDigit(c):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.' || runes[j] == 'x' || runes[j] == '_') {
+				j++
+			}
+			b.WriteString(red + string(runes[i:j]) + reset)
+			i = j
+		default:
+			b.WriteRune(c)
+			i++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,220 @@
+// Package term renders model output for terminals.
+package term
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// ANSI escape sequences used by the renderer
+const (
+	reset     = "\033[0m"
+	bold      = "\033[1m"
+	italic    = "\033[3m"
+	underline = "\033[4m"
+	red       = "\033[31m"
+	green     = "\033[32m"
+	yellow    = "\033[33m"
+	magenta   = "\033[35m"
+	cyan      = "\033[36m"
+	gray      = "\033[90m"
+)
+
+// IsTerminal reports whether w is a terminal that accepts colors; it is
+// false when NO_COLOR is set
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+var (
+	fenceLine    = regexp.MustCompile("^[ \t]*(```|~~~)[ \t]*([\\w+-]*)")
+	headingLine  = regexp.MustCompile(`^(#{1,6})[ \t]+(.*)$`)
+	bulletLine   = regexp.MustCompile(`^([ \t]*)[*+-][ \t]+(.*)$`)
+	quoteLine    = regexp.MustCompile(`^>[ \t]?(.*)$`)
+	ruleLine     = regexp.MustCompile(`^[ \t]*([-*_][ \t]*){3,}$`)
+	inlineCode   = regexp.MustCompile("`([^`]+)`")
+	strongText   = regexp.MustCompile(`(\*\*|__)([^\s*_].*?)(\*\*|__)`)
+	emphasisText = regexp.MustCompile(`(^|[^\w*])[*_]([^*_\s][^*_\n]*?)[*_]([^\w*]|$)`)
+	linkText     = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+)
+
+// MarkdownRenderer renders Markdown written to it, one line at a time, so
+// it can sit at the end of a token stream: each line is printed as soon as
+// it is complete. Headings, lists, quotes, rules, bold, emphasis, inline
+// code and links are styled; fenced code blocks get keyword, string and
+// comment highlighting. Without color the markup is stripped instead.
+type MarkdownRenderer struct {
+	w     io.Writer
+	color bool
+	line  []byte
+	// fence is the open code fence ("```" or "~~~"), empty outside code
+	fence string
+	lang  string
+}
+
+// NewMarkdownRenderer creates a renderer writing to w, with colors when w
+// is a terminal
+func NewMarkdownRenderer(w io.Writer) *MarkdownRenderer {
+	return &MarkdownRenderer{w: w, color: IsTerminal(w)}
+}
+
+// WithColor forces colors on or off
+func (r *MarkdownRenderer) WithColor(color bool) *MarkdownRenderer {
+	r.color = color
+	return r
+}
+
+// Write renders the complete lines in p and buffers the rest
+func (r *MarkdownRenderer) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b != '\n' {
+			r.line = append(r.line, b)
+			continue
+		}
+		if err := r.renderLine(string(r.line)); err != nil {
+			return 0, err
+		}
+		r.line = r.line[:0]
+	}
+	return len(p), nil
+}
+
+// WriteString renders s like Write
+func (r *MarkdownRenderer) WriteString(s string) (int, error) {
+	return r.Write([]byte(s))
+}
+
+// Flush renders the buffered partial line and closes an unterminated code
+// block
+func (r *MarkdownRenderer) Flush() error {
+	if len(r.line) > 0 {
+		if err := r.renderLine(string(r.line)); err != nil {
+			return err
+		}
+		r.line = r.line[:0]
+	}
+	r.fence, r.lang = "", ""
+	return nil
+}
+
+// RenderStream renders the chunks of a runnable's stream as they arrive
+// and returns the whole text. Strings, messages and generations are
+// rendered; an error chunk stops rendering and is returned.
+func (r *MarkdownRenderer) RenderStream(ctx context.Context, chunks <-chan interface{}) (string, error) {
+	var text strings.Builder
+	for {
+		select {
+		case <-ctx.Done():
+			r.Flush()
+			return text.String(), ctx.Err()
+		case chunk, ok := <-chunks:
+			if !ok {
+				return text.String(), r.Flush()
+			}
+			var s string
+			switch v := chunk.(type) {
+			case error:
+				r.Flush()
+				return text.String(), v
+			case string:
+				s = v
+			case core.Message:
+				s = v.GetContent()
+			default:
+				s = fmt.Sprint(v)
+			}
+			text.WriteString(s)
+			if _, err := r.WriteString(s); err != nil {
+				return text.String(), err
+			}
+		}
+	}
+}
+
+// renderLine writes one styled line
+func (r *MarkdownRenderer) renderLine(line string) error {
+	_, err := io.WriteString(r.w, r.style(line)+"\n")
+	return err
+}
+
+// style renders one line of Markdown
+func (r *MarkdownRenderer) style(line string) string {
+	if m := fenceLine.FindStringSubmatch(line); m != nil && (r.fence == "" || m[1] == r.fence) {
+		if r.fence == "" {
+			r.fence, r.lang = m[1], strings.ToLower(m[2])
+			if !r.color {
+				return ""
+			}
+			return gray + "┌─ " + r.lang + reset
+		}
+		r.fence, r.lang = "", ""
+		if !r.color {
+			return ""
+		}
+		return gray + "└─" + reset
+	}
+	if r.fence != "" {
+		if !r.color {
+			return line
+		}
+		return gray + "│ " + reset + highlight(line, r.lang)
+	}
+
+	switch {
+	case ruleLine.MatchString(line):
+		return r.paint(gray, strings.Repeat("─", 40))
+	case headingLine.MatchString(line):
+		m := headingLine.FindStringSubmatch(line)
+		return r.paint(bold+cyan, r.inline(m[2]))
+	case bulletLine.MatchString(line):
+		m := bulletLine.FindStringSubmatch(line)
+		return m[1] + r.paint(cyan, "•") + " " + r.inline(m[2])
+	case quoteLine.MatchString(line):
+		m := quoteLine.FindStringSubmatch(line)
+		return r.paint(gray, "│ ") + r.paint(italic, r.inline(m[1]))
+	}
+	return r.inline(line)
+}
+
+// inline styles inline code, links, bold and emphasis
+func (r *MarkdownRenderer) inline(text string) string {
+	// Set code spans aside so their content is not treated as markup
+	var spans []string
+	text = inlineCode.ReplaceAllStringFunc(text, func(span string) string {
+		spans = append(spans, inlineCode.FindStringSubmatch(span)[1])
+		return fmt.Sprintf("\x00%d\x00", len(spans)-1)
+	})
+
+	if r.color {
+		text = linkText.ReplaceAllString(text, "$1 ("+underline+"$2"+reset+")")
+		text = strongText.ReplaceAllString(text, bold+"$2"+reset)
+		text = emphasisText.ReplaceAllString(text, "$1"+italic+"$2"+reset+"$3")
+	} else {
+		text = linkText.ReplaceAllString(text, "$1 ($2)")
+		text = strongText.ReplaceAllString(text, "$2")
+		text = emphasisText.ReplaceAllString(text, "$1$2$3")
+	}
+	for i, span := range spans {
+		text = strings.Replace(text, fmt.Sprintf("\x00%d\x00", i), r.paint(yellow, span), 1)
+	}
+	return text
+}
+
+// paint wraps text in an ANSI style when colors are on
+func (r *MarkdownRenderer) paint(style, text string) string {
+	if !r.color || text == "" {
+		return text
+	}
+	return style + text + reset
+}
@@ -57,7 +57,7 @@ func ToFunctionDefinition(tool Tool) map[string]interface{} {
 		"function": map[string]interface{}{
 			"name":        tool.Name(),
 			"description": tool.Description(),
-			"parameters":  tool.ArgsSchema(),
+			"parameters":  NormalizeSchema(tool.ArgsSchema()),
 		},
 	}
 }
@@ -126,7 +126,8 @@ func (t *CalculatorTool) Execute(ctx context.Context, args map[string]interface{
 
 // ToolRegistry manages available tools
 type ToolRegistry struct {
-	tools map[string]Tool
+	tools    map[string]Tool
+	simulate bool
 }
 
 // NewToolRegistry creates a new tool registry
@@ -165,23 +166,54 @@ func (r *ToolRegistry) GetFunctionDefinitions() []map[string]interface{} {
 	return defs
 }
 
+// SetSimulation turns simulation mode on or off. In simulation mode tools
+// implementing ToolSimulator return their simulated response instead of
+// executing, and read-only tools (see ReadOnlyTool) run normally. Any other
+// tool may have side effects, so it is not run and reports a generic
+// simulated result.
+func (r *ToolRegistry) SetSimulation(on bool) *ToolRegistry {
+	r.simulate = on
+	return r
+}
+
+// Simulating reports whether simulation mode is on
+func (r *ToolRegistry) Simulating() bool {
+	return r.simulate
+}
+
 // ExecuteTool executes a tool by name with given arguments
 func (r *ToolRegistry) ExecuteTool(ctx context.Context, name string, argsJSON string) (string, error) {
+	tool, args, err := r.prepare(name, argsJSON)
+	if err != nil {
+		return "", err
+	}
+	return r.execute(ctx, tool, args)
+}
+
+// prepare looks up a tool and parses its JSON arguments
+func (r *ToolRegistry) prepare(name string, argsJSON string) (Tool, map[string]interface{}, error) {
 	tool, ok := r.Get(name)
 	if !ok {
-		return "", fmt.Errorf("tool not found: %s", name)
+		return nil, nil, fmt.Errorf("tool not found: %s", name)
 	}
 
 	// Parse arguments
 	var args map[string]interface{}
 	if argsJSON != "" {
 		if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-			return "", fmt.Errorf("failed to parse arguments: %w", err)
+			return nil, nil, fmt.Errorf("failed to parse arguments: %w", err)
 		}
-	} else {
+	}
+	if args == nil {
 		args = make(map[string]interface{})
 	}
+	return tool, args, nil
+}
 
-	// Execute tool
+// execute runs the tool, or simulates it in simulation mode
+func (r *ToolRegistry) execute(ctx context.Context, tool Tool, args map[string]interface{}) (string, error) {
+	if r.simulate {
+		return simulate(ctx, tool, args)
+	}
 	return tool.Execute(ctx, args)
 }
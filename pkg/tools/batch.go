@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// BatchResult is the outcome of one call in a batch
+type BatchResult struct {
+	Output string
+	Err    error
+}
+
+// BatchExecutor is implemented by tools that can serve many calls more
+// cheaply together than one by one (a single query, deduplicated requests)
+type BatchExecutor interface {
+	// ExecuteBatch returns one result per args, in order. The error is for
+	// failures of the whole batch; per-call failures go in BatchResult.Err.
+	ExecuteBatch(ctx context.Context, args []map[string]interface{}) ([]BatchResult, error)
+}
+
+// ExecuteBatch runs tool once per args, using the tool's own ExecuteBatch
+// when it has one and calling Execute in turn otherwise
+func ExecuteBatch(ctx context.Context, tool Tool, args []map[string]interface{}) ([]BatchResult, error) {
+	if b, ok := tool.(BatchExecutor); ok {
+		results, err := b.ExecuteBatch(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+		if len(results) != len(args) {
+			return nil, fmt.Errorf("tool %s returned %d results for %d calls", tool.Name(), len(results), len(args))
+		}
+		return results, nil
+	}
+
+	results := make([]BatchResult, len(args))
+	for i, a := range args {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		results[i].Output, results[i].Err = tool.Execute(ctx, a)
+	}
+	return results, nil
+}
+
+// ExecuteToolCalls runs the tool calls of an assistant message and returns
+// one tool message per call, in order. Outside simulation mode, several
+// calls to a tool that implements BatchExecutor are sent to it as a single
+// batch; other calls run one by one as with ExecuteTool. Failed
+// calls are reported to the model as "Error: ..." content rather than
+// aborting the others. Input requests are not supported: a tool asking for
+// input (see ExecuteToolInteractive) is reported as failed, so run such
+// tools with ExecuteToolInteractive instead.
+func (r *ToolRegistry) ExecuteToolCalls(ctx context.Context, calls []core.ToolCall) []*core.ToolMessage {
+	outputs := make([]string, len(calls))
+	groups := make(map[string][]int)
+	var order []string
+	parsed := make([]map[string]interface{}, len(calls))
+
+	for i, call := range calls {
+		args := call.Args
+		if args == nil {
+			args = make(map[string]interface{})
+			if call.Function.Arguments != "" {
+				if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+					outputs[i] = fmt.Sprintf("Error: failed to parse arguments: %v", err)
+					continue
+				}
+			}
+		}
+		if _, ok := r.Get(call.Function.Name); !ok {
+			outputs[i] = fmt.Sprintf("Error: tool not found: %s", call.Function.Name)
+			continue
+		}
+		parsed[i] = args
+		if _, ok := groups[call.Function.Name]; !ok {
+			order = append(order, call.Function.Name)
+		}
+		groups[call.Function.Name] = append(groups[call.Function.Name], i)
+	}
+
+	for _, name := range order {
+		tool, _ := r.Get(name)
+		indices := groups[name]
+		batch := make([]map[string]interface{}, len(indices))
+		for j, i := range indices {
+			batch[j] = parsed[i]
+		}
+
+		var results []BatchResult
+		var err error
+		_, batches := tool.(BatchExecutor)
+		if batches && len(batch) > 1 && !r.simulate {
+			results, err = ExecuteBatch(ctx, tool, batch)
+		} else {
+			results = make([]BatchResult, len(batch))
+			for j, args := range batch {
+				results[j].Output, results[j].Err = r.execute(ctx, tool, args)
+			}
+		}
+
+		for j, i := range indices {
+			switch {
+			case err != nil:
+				outputs[i] = fmt.Sprintf("Error: %v", err)
+			case results[j].Err != nil:
+				outputs[i] = fmt.Sprintf("Error: %v", results[j].Err)
+			default:
+				outputs[i] = results[j].Output
+			}
+		}
+	}
+
+	messages := make([]*core.ToolMessage, len(calls))
+	for i, call := range calls {
+		messages[i] = core.NewToolMessage(outputs[i], call.ID, map[string]interface{}{"name": call.Function.Name})
+	}
+	return messages
+}
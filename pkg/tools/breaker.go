@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// BreakerTool guards a tool with a circuit breaker, so an agent gets an
+// immediate error instead of waiting on a dependency that keeps failing
+type BreakerTool struct {
+	Tool
+	breaker *core.CircuitBreaker
+}
+
+// WithCircuitBreaker wraps tool with breaker
+func WithCircuitBreaker(tool Tool, breaker *core.CircuitBreaker) *BreakerTool {
+	return &BreakerTool{Tool: tool, breaker: breaker}
+}
+
+// Execute runs the tool unless the breaker is open
+func (t *BreakerTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	var result string
+	err := t.breaker.Execute(func() error {
+		var err error
+		result, err = t.Tool.Execute(ctx, args)
+		return err
+	})
+	return result, err
+}
+
+// Simulate simulates the wrapped tool; a read-only one runs behind the breaker
+func (t *BreakerTool) Simulate(ctx context.Context, args map[string]interface{}) (string, error) {
+	if _, ok := t.Tool.(ToolSimulator); !ok {
+		if ro, ok := t.Tool.(ReadOnlyTool); ok && ro.ReadOnly() {
+			return t.Execute(ctx, args)
+		}
+	}
+	return simulate(ctx, t.Tool, args)
+}
@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// BrowserConfig configures the headless browser tool
+type BrowserConfig struct {
+	AllowedDomains []string // navigation outside these domains (and their subdomains) is refused
+	ScreenshotDir  string
+	Timeout        time.Duration // per action
+	ShowWindow     bool          // run with a visible window instead of headless
+	MaxTextLength  int
+}
+
+// BrowserTool drives a headless Chrome for pages that need interaction.
+// The browser session is kept between calls so an agent can navigate,
+// fill a form and click through in several steps.
+type BrowserTool struct {
+	*BaseTool
+	config BrowserConfig
+
+	mu          sync.Mutex
+	browserCtx  context.Context
+	cancelAlloc context.CancelFunc
+	cancelTab   context.CancelFunc
+}
+
+// NewBrowserTool creates a browser tool; Chrome is started lazily on first use
+func NewBrowserTool(config BrowserConfig) *BrowserTool {
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+	if config.ScreenshotDir == "" {
+		config.ScreenshotDir = os.TempDir()
+	}
+	if config.MaxTextLength == 0 {
+		config.MaxTextLength = 4000
+	}
+	return &BrowserTool{
+		BaseTool: NewBaseTool(
+			"browser",
+			"Control a web browser. Actions: navigate (url), read (optional selector), click (selector), fill (selector, value), screenshot. "+
+				"Allowed domains: "+strings.Join(config.AllowedDomains, ", "),
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type": "string",
+						"enum": []string{"navigate", "read", "click", "fill", "screenshot"},
+					},
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "URL to open (navigate)",
+					},
+					"selector": map[string]interface{}{
+						"type":        "string",
+						"description": "CSS selector of the element (read, click, fill)",
+					},
+					"value": map[string]interface{}{
+						"type":        "string",
+						"description": "Text to type (fill)",
+					},
+				},
+				"required": []string{"action"},
+			},
+		),
+		config: config,
+	}
+}
+
+// session returns the shared browser context, starting Chrome if needed.
+// Chrome is started by a first Run on the browser context itself: a run on
+// a derived context with a timeout would tie the browser's lifetime to it.
+func (t *BrowserTool) session() (context.Context, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.browserCtx == nil {
+		opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.Flag("headless", !t.config.ShowWindow))
+		allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+		browserCtx, cancelTab := chromedp.NewContext(allocCtx)
+		if err := chromedp.Run(browserCtx); err != nil {
+			cancelTab()
+			cancelAlloc()
+			return nil, fmt.Errorf("failed to start browser: %w", err)
+		}
+		t.browserCtx, t.cancelAlloc, t.cancelTab = browserCtx, cancelAlloc, cancelTab
+	}
+	return t.browserCtx, nil
+}
+
+// Close shuts down the browser
+func (t *BrowserTool) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cancelTab != nil {
+		t.cancelTab()
+		t.cancelAlloc()
+		t.browserCtx, t.cancelAlloc, t.cancelTab = nil, nil, nil
+	}
+}
+
+// IsAllowed reports whether rawURL points to an allowlisted domain
+func (t *BrowserTool) IsAllowed(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range t.config.AllowedDomains {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// Execute performs one browser action
+func (t *BrowserTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	action, _ := args["action"].(string)
+	selector, _ := args["selector"].(string)
+
+	// Run on the browser session, but stop when either the caller's context
+	// or the per-action timeout ends
+	browserCtx, err := t.session()
+	if err != nil {
+		return "", err
+	}
+	runCtx, cancel := context.WithTimeout(browserCtx, t.config.Timeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	var result string
+	switch action {
+	case "navigate":
+		target, _ := args["url"].(string)
+		if !t.IsAllowed(target) {
+			return "", fmt.Errorf("navigation to %s is not allowed", target)
+		}
+		if err := chromedp.Run(runCtx, chromedp.Navigate(target)); err != nil {
+			return "", fmt.Errorf("navigate failed: %w", err)
+		}
+		result = "Opened " + target
+
+	case "read":
+		if selector == "" {
+			selector = "body"
+		}
+		var text string
+		if err := chromedp.Run(runCtx, chromedp.Text(selector, &text, chromedp.ByQuery)); err != nil {
+			return "", fmt.Errorf("read failed: %w", err)
+		}
+		text = strings.Join(strings.Fields(text), " ")
+		if len(text) > t.config.MaxTextLength {
+			text = text[:t.config.MaxTextLength] + "..."
+		}
+		result = text
+
+	case "click":
+		if selector == "" {
+			return "", fmt.Errorf("selector is required for click")
+		}
+		if err := chromedp.Run(runCtx, chromedp.Click(selector, chromedp.ByQuery)); err != nil {
+			return "", fmt.Errorf("click failed: %w", err)
+		}
+		result = "Clicked " + selector
+
+	case "fill":
+		value, _ := args["value"].(string)
+		if selector == "" {
+			return "", fmt.Errorf("selector is required for fill")
+		}
+		if err := chromedp.Run(runCtx, chromedp.SetValue(selector, "", chromedp.ByQuery), chromedp.SendKeys(selector, value, chromedp.ByQuery)); err != nil {
+			return "", fmt.Errorf("fill failed: %w", err)
+		}
+		result = fmt.Sprintf("Filled %s", selector)
+
+	case "screenshot":
+		var buf []byte
+		if err := chromedp.Run(runCtx, chromedp.FullScreenshot(&buf, 90)); err != nil {
+			return "", fmt.Errorf("screenshot failed: %w", err)
+		}
+		if err := os.MkdirAll(t.config.ScreenshotDir, 0o755); err != nil {
+			return "", err
+		}
+		path := filepath.Join(t.config.ScreenshotDir, fmt.Sprintf("screenshot_%d.jpg", time.Now().UnixNano()))
+		if err := os.WriteFile(path, buf, 0o644); err != nil {
+			return "", fmt.Errorf("failed to save screenshot: %w", err)
+		}
+		result = "Screenshot saved to " + path
+
+	default:
+		return "", fmt.Errorf("unknown browser action: %s", action)
+	}
+
+	// Clicks and form submissions can navigate away; keep the agent inside the allowlist
+	var location string
+	if err := chromedp.Run(runCtx, chromedp.Location(&location)); err == nil && location != "about:blank" && !t.IsAllowed(location) {
+		chromedp.Run(runCtx, chromedp.Navigate("about:blank"))
+		return "", fmt.Errorf("page navigated to %s which is not allowed", location)
+	}
+
+	return result, nil
+}
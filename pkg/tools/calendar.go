@@ -0,0 +1,477 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CalendarEvent is a single calendar entry
+type CalendarEvent struct {
+	UID      string
+	Summary  string
+	Start    time.Time
+	End      time.Time
+	Location string
+}
+
+// Calendar is a source of events that can also create new ones
+type Calendar interface {
+	ListEvents(ctx context.Context, from, to time.Time) ([]CalendarEvent, error)
+	CreateEvent(ctx context.Context, event CalendarEvent) (CalendarEvent, error)
+}
+
+// CalDAVConfig configures access to a CalDAV calendar collection
+type CalDAVConfig struct {
+	// CalendarURL is the calendar collection URL, e.g.
+	// https://apidata.googleusercontent.com/caldav/v2/<calendar-id>/events/
+	CalendarURL string
+	Username    string
+	Password    string
+	BearerToken string // used instead of basic auth when set (e.g. Google OAuth)
+	Timeout     time.Duration
+}
+
+// CalDAVCalendar talks to a CalDAV server (Nextcloud, Fastmail, Google, ...)
+type CalDAVCalendar struct {
+	config CalDAVConfig
+	client *http.Client
+}
+
+// NewCalDAVCalendar creates a new CalDAV calendar client
+func NewCalDAVCalendar(config CalDAVConfig) *CalDAVCalendar {
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+	if !strings.HasSuffix(config.CalendarURL, "/") {
+		config.CalendarURL += "/"
+	}
+	return &CalDAVCalendar{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// do sends an authenticated request to the calendar server
+func (c *CalDAVCalendar) do(ctx context.Context, method, url string, headers map[string]string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if c.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.BearerToken)
+	} else if c.config.Username != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+	return c.client.Do(req)
+}
+
+// ListEvents returns the events overlapping [from, to)
+func (c *CalDAVCalendar) ListEvents(ctx context.Context, from, to time.Time) ([]CalendarEvent, error) {
+	query := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><c:calendar-data/></d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VEVENT">
+        <c:time-range start="%s" end="%s"/>
+      </c:comp-filter>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`, formatICalTime(from), formatICalTime(to))
+
+	resp, err := c.do(ctx, "REPORT", c.config.CalendarURL, map[string]string{
+		"Content-Type": "application/xml; charset=utf-8",
+		"Depth":        "1",
+	}, []byte(query))
+	if err != nil {
+		return nil, fmt.Errorf("CalDAV request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMultiStatus && resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("CalDAV server returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var multistatus struct {
+		Responses []struct {
+			CalendarData string `xml:"propstat>prop>calendar-data"`
+		} `xml:"response"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&multistatus); err != nil {
+		return nil, fmt.Errorf("failed to parse CalDAV response: %w", err)
+	}
+
+	var events []CalendarEvent
+	for _, r := range multistatus.Responses {
+		events = append(events, ParseICalEvents(r.CalendarData)...)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	return events, nil
+}
+
+// CreateEvent stores a new event in the calendar
+func (c *CalDAVCalendar) CreateEvent(ctx context.Context, event CalendarEvent) (CalendarEvent, error) {
+	if event.UID == "" {
+		buf := make([]byte, 16)
+		rand.Read(buf)
+		event.UID = fmt.Sprintf("%x@ai-agents-from-scratch", buf)
+	}
+
+	ics := fmt.Sprintf("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//ai-agents-from-scratch//EN\r\n"+
+		"BEGIN:VEVENT\r\nUID:%s\r\nDTSTAMP:%s\r\nDTSTART:%s\r\nDTEND:%s\r\nSUMMARY:%s\r\nLOCATION:%s\r\n"+
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+		event.UID, formatICalTime(time.Now()), formatICalTime(event.Start), formatICalTime(event.End),
+		escapeICalText(event.Summary), escapeICalText(event.Location))
+
+	resp, err := c.do(ctx, http.MethodPut, c.config.CalendarURL+event.UID+".ics", map[string]string{
+		"Content-Type":  "text/calendar; charset=utf-8",
+		"If-None-Match": "*",
+	}, []byte(ics))
+	if err != nil {
+		return event, fmt.Errorf("CalDAV request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return event, fmt.Errorf("CalDAV server returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return event, nil
+}
+
+// ParseICalEvents extracts VEVENT entries from iCalendar text
+func ParseICalEvents(ics string) []CalendarEvent {
+	// Unfold continuation lines (RFC 5545 section 3.1)
+	ics = strings.ReplaceAll(ics, "\r\n", "\n")
+	ics = strings.ReplaceAll(ics, "\n ", "")
+	ics = strings.ReplaceAll(ics, "\n\t", "")
+
+	var events []CalendarEvent
+	var current *CalendarEvent
+	for _, line := range strings.Split(ics, "\n") {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		params := ""
+		if i := strings.Index(name, ";"); i >= 0 {
+			name, params = name[:i], name[i+1:]
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &CalendarEvent{}
+		case name == "END" && value == "VEVENT" && current != nil:
+			if current.End.IsZero() {
+				current.End = current.Start
+			}
+			events = append(events, *current)
+			current = nil
+		case current == nil:
+			continue
+		case name == "UID":
+			current.UID = value
+		case name == "SUMMARY":
+			current.Summary = unescapeICalText(value)
+		case name == "LOCATION":
+			current.Location = unescapeICalText(value)
+		case name == "DTSTART":
+			current.Start = parseICalTime(value, params)
+		case name == "DTEND":
+			current.End = parseICalTime(value, params)
+		}
+	}
+	return events
+}
+
+// parseICalTime parses DATE, floating DATE-TIME and UTC DATE-TIME values
+func parseICalTime(value, params string) time.Time {
+	loc := time.Local
+	for _, p := range strings.Split(params, ";") {
+		if tzid, ok := strings.CutPrefix(p, "TZID="); ok {
+			if l, err := time.LoadLocation(tzid); err == nil {
+				loc = l
+			}
+		}
+	}
+
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t
+	}
+	for _, layout := range []string{"20060102T150405", "20060102"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// formatICalTime formats t as a UTC iCalendar DATE-TIME
+func formatICalTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// escapeICalText escapes TEXT property values; CRLF and lone CR become
+// line breaks too, so they cannot end the content line early
+func escapeICalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// unescapeICalText reverses escapeICalText
+func unescapeICalText(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+// FindFreeSlots returns gaps of at least duration between events within [from, to)
+func FindFreeSlots(events []CalendarEvent, from, to time.Time, duration time.Duration) []CalendarEvent {
+	sorted := append([]CalendarEvent{}, events...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	var slots []CalendarEvent
+	cursor := from
+	for _, e := range sorted {
+		if e.End.Before(cursor) || !e.Start.Before(to) {
+			continue
+		}
+		if e.Start.Sub(cursor) >= duration {
+			slots = append(slots, CalendarEvent{Summary: "free", Start: cursor, End: e.Start})
+		}
+		if e.End.After(cursor) {
+			cursor = e.End
+		}
+	}
+	if to.Sub(cursor) >= duration {
+		slots = append(slots, CalendarEvent{Summary: "free", Start: cursor, End: to})
+	}
+	return slots
+}
+
+// parseToolTime parses the RFC 3339 times used in calendar tool arguments
+func parseToolTime(args map[string]interface{}, key string) (time.Time, error) {
+	s, ok := args[key].(string)
+	if !ok || s == "" {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time string", key)
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return t, nil
+}
+
+// formatEvents renders events one per line for the model
+func formatEvents(events []CalendarEvent) string {
+	if len(events) == 0 {
+		return "No events."
+	}
+	var b strings.Builder
+	for _, e := range events {
+		fmt.Fprintf(&b, "- %s to %s: %s", e.Start.Format(time.RFC3339), e.End.Format(time.RFC3339), e.Summary)
+		if e.Location != "" {
+			fmt.Fprintf(&b, " (%s)", e.Location)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+var timeRangeSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"from": map[string]interface{}{
+			"type":        "string",
+			"description": "Start of the range (RFC 3339, e.g. 2024-05-01T09:00:00Z)",
+		},
+		"to": map[string]interface{}{
+			"type":        "string",
+			"description": "End of the range (RFC 3339)",
+		},
+	},
+	"required": []string{"from", "to"},
+}
+
+// ListEventsTool lists calendar events in a time range
+type ListEventsTool struct {
+	*BaseTool
+	calendar Calendar
+}
+
+// NewListEventsTool creates a new ListEventsTool
+func NewListEventsTool(calendar Calendar) *ListEventsTool {
+	return &ListEventsTool{
+		BaseTool: NewBaseTool("list_events", "List calendar events between two times.", timeRangeSchema),
+		calendar: calendar,
+	}
+}
+
+// Execute lists the events
+func (t *ListEventsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	from, err := parseToolTime(args, "from")
+	if err != nil {
+		return "", err
+	}
+	to, err := parseToolTime(args, "to")
+	if err != nil {
+		return "", err
+	}
+
+	events, err := t.calendar.ListEvents(ctx, from, to)
+	if err != nil {
+		return "", err
+	}
+	return formatEvents(events), nil
+}
+
+// CreateEventTool adds an event to the calendar
+type CreateEventTool struct {
+	*BaseTool
+	calendar Calendar
+}
+
+// NewCreateEventTool creates a new CreateEventTool
+func NewCreateEventTool(calendar Calendar) *CreateEventTool {
+	return &CreateEventTool{
+		BaseTool: NewBaseTool(
+			"create_event",
+			"Create a calendar event.",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"summary": map[string]interface{}{
+						"type":        "string",
+						"description": "Event title",
+					},
+					"start": map[string]interface{}{
+						"type":        "string",
+						"description": "Start time (RFC 3339)",
+					},
+					"end": map[string]interface{}{
+						"type":        "string",
+						"description": "End time (RFC 3339)",
+					},
+					"location": map[string]interface{}{
+						"type":        "string",
+						"description": "Where the event takes place (optional)",
+					},
+				},
+				"required": []string{"summary", "start", "end"},
+			},
+		),
+		calendar: calendar,
+	}
+}
+
+// Execute creates the event
+func (t *CreateEventTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	event, err := eventFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	event, err = t.calendar.CreateEvent(ctx, event)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Created event %q from %s to %s", event.Summary, event.Start.Format(time.RFC3339), event.End.Format(time.RFC3339)), nil
+}
+
+// eventFromArgs validates the create_event arguments, asking the user for
+// the title or times when the model left them out
+func eventFromArgs(args map[string]interface{}) (CalendarEvent, error) {
+	summary, _ := args["summary"].(string)
+	if summary == "" {
+		return CalendarEvent{}, NeedsInput("What should the event be called?", "summary")
+	}
+	for _, key := range []string{"start", "end"} {
+		if s, _ := args[key].(string); s == "" {
+			return CalendarEvent{}, NeedsInput(fmt.Sprintf("When should the event %s? (e.g. 2024-05-01T14:00:00+02:00)", key), key)
+		}
+	}
+	start, err := parseToolTime(args, "start")
+	if err != nil {
+		return CalendarEvent{}, err
+	}
+	end, err := parseToolTime(args, "end")
+	if err != nil {
+		return CalendarEvent{}, err
+	}
+	if !end.After(start) {
+		return CalendarEvent{}, fmt.Errorf("end must be after start")
+	}
+	location, _ := args["location"].(string)
+	return CalendarEvent{Summary: summary, Start: start, End: end, Location: location}, nil
+}
+
+// FindFreeSlotsTool finds open time slots in the calendar
+type FindFreeSlotsTool struct {
+	*BaseTool
+	calendar Calendar
+}
+
+// NewFindFreeSlotsTool creates a new FindFreeSlotsTool
+func NewFindFreeSlotsTool(calendar Calendar) *FindFreeSlotsTool {
+	return &FindFreeSlotsTool{
+		BaseTool: NewBaseTool(
+			"find_free_slots",
+			"Find free time slots of at least the given length between two times.",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Start of the search window (RFC 3339)",
+					},
+					"to": map[string]interface{}{
+						"type":        "string",
+						"description": "End of the search window (RFC 3339)",
+					},
+					"duration_minutes": map[string]interface{}{
+						"type":        "integer",
+						"description": "Minimum slot length in minutes (default 30)",
+					},
+				},
+				"required": []string{"from", "to"},
+			},
+		),
+		calendar: calendar,
+	}
+}
+
+// Execute computes the free slots
+func (t *FindFreeSlotsTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	from, err := parseToolTime(args, "from")
+	if err != nil {
+		return "", err
+	}
+	to, err := parseToolTime(args, "to")
+	if err != nil {
+		return "", err
+	}
+	duration := 30 * time.Minute
+	if n, ok := args["duration_minutes"].(float64); ok && n > 0 {
+		duration = time.Duration(n) * time.Minute
+	}
+
+	events, err := t.calendar.ListEvents(ctx, from, to)
+	if err != nil {
+		return "", err
+	}
+
+	slots := FindFreeSlots(events, from, to, duration)
+	if len(slots) == 0 {
+		return "No free slots found.", nil
+	}
+	return formatEvents(slots), nil
+}
@@ -0,0 +1,22 @@
+package tools
+
+import "testing"
+
+func TestEscapeICalText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: "plain", want: "plain"},
+		{in: `a\b;c,d`, want: `a\\b\;c\,d`},
+		{in: "one\ntwo", want: `one\ntwo`},
+		{in: "one\r\ntwo", want: `one\ntwo`},
+		{in: "one\rtwo", want: `one\ntwo`},
+		{in: "Lunch\r\nATTENDEE:mailto:x@example.com", want: `Lunch\nATTENDEE:mailto:x@example.com`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeICalText(tt.in); got != tt.want {
+			t.Errorf("escapeICalText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
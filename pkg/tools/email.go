@@ -0,0 +1,476 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/emersion/go-imap"
+	imapclient "github.com/emersion/go-imap/client"
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// EmailAccount holds the mail server settings for the email tools
+type EmailAccount struct {
+	Address  string
+	Username string
+	Password string
+	SMTPHost string
+	SMTPPort int
+	IMAPHost string
+	IMAPPort int
+}
+
+// EmailTemplate renders subject and bodies from template data.
+// Text uses text/template and HTML uses html/template syntax.
+type EmailTemplate struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Email is a rendered message ready to send
+type Email struct {
+	From        string
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []string
+}
+
+// String returns a human-readable preview of the email
+func (e *Email) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\nTo: %s\nSubject: %s\n", e.From, strings.Join(e.To, ", "), e.Subject)
+	if len(e.Attachments) > 0 {
+		fmt.Fprintf(&b, "Attachments: %s\n", strings.Join(e.Attachments, ", "))
+	}
+	body := e.Text
+	if body == "" {
+		body = e.HTML
+	}
+	fmt.Fprintf(&b, "\n%s", body)
+	return b.String()
+}
+
+// ApprovalFunc asks a human whether an email may actually be sent
+type ApprovalFunc func(ctx context.Context, email *Email) (bool, error)
+
+// SendEmailTool sends email over SMTP, with templates, attachments and approval
+type SendEmailTool struct {
+	*BaseTool
+	account   EmailAccount
+	templates map[string]EmailTemplate
+	approve   ApprovalFunc
+	dryRun    bool
+	attachDir string
+}
+
+// NewSendEmailTool creates a send tool; it starts in dry-run mode
+func NewSendEmailTool(account EmailAccount) *SendEmailTool {
+	if account.SMTPPort == 0 {
+		account.SMTPPort = 587
+	}
+	return &SendEmailTool{
+		BaseTool: NewBaseTool(
+			"send_email",
+			"Send an email. Either provide subject and body, or a template name with data.",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"to": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Recipient email addresses",
+					},
+					"subject": map[string]interface{}{
+						"type":        "string",
+						"description": "Email subject",
+					},
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "Plain text body",
+					},
+					"template": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of a registered template to render",
+					},
+					"data": map[string]interface{}{
+						"type":        "object",
+						"description": "Values for the template",
+					},
+					"attachments": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Paths of files to attach, relative to the attachment directory",
+					},
+				},
+				"required": []string{"to"},
+			},
+		),
+		account:   account,
+		templates: make(map[string]EmailTemplate),
+		dryRun:    true,
+	}
+}
+
+// WithTemplate registers a named email template
+func (t *SendEmailTool) WithTemplate(name string, tmpl EmailTemplate) *SendEmailTool {
+	t.templates[name] = tmpl
+	return t
+}
+
+// WithApproval sets the function asked before every send, in or out of dry-run mode
+func (t *SendEmailTool) WithApproval(approve ApprovalFunc) *SendEmailTool {
+	t.approve = approve
+	return t
+}
+
+// WithAttachmentDir allows attaching files from dir; without it attachments are refused
+func (t *SendEmailTool) WithAttachmentDir(dir string) *SendEmailTool {
+	t.attachDir = dir
+	return t
+}
+
+// WithDryRun enables or disables dry-run mode.
+// In dry-run mode without an approval function the tool only returns a
+// preview. An approval function, when set, is asked before every send
+// whatever the mode.
+func (t *SendEmailTool) WithDryRun(dryRun bool) *SendEmailTool {
+	t.dryRun = dryRun
+	return t
+}
+
+// Execute renders and sends the email
+func (t *SendEmailTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	email, err := t.buildEmail(args)
+	if err != nil {
+		return "", err
+	}
+
+	if t.dryRun && t.approve == nil {
+		return "DRY RUN - email not sent:\n" + email.String(), nil
+	}
+	if t.approve != nil {
+		approved, err := t.approve(ctx, email)
+		if err != nil {
+			return "", fmt.Errorf("approval failed: %w", err)
+		}
+		if !approved {
+			core.Emit(ctx, core.ToolDenied{Tool: t.Name(), Args: args, Reason: "not approved", Time: time.Now()})
+			return "Email was not approved and has not been sent.", nil
+		}
+	}
+
+	msg, err := buildMIMEMessage(email)
+	if err != nil {
+		return "", err
+	}
+
+	addr := fmt.Sprintf("%s:%d", t.account.SMTPHost, t.account.SMTPPort)
+	auth := smtp.PlainAuth("", t.account.Username, t.account.Password, t.account.SMTPHost)
+	if err := smtp.SendMail(addr, auth, email.From, email.To, msg); err != nil {
+		return "", fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return fmt.Sprintf("Email sent to %s", strings.Join(email.To, ", ")), nil
+}
+
+// buildEmail turns tool arguments into an Email, rendering templates if requested
+func (t *SendEmailTool) buildEmail(args map[string]interface{}) (*Email, error) {
+	email := &Email{From: t.account.Address}
+
+	switch to := args["to"].(type) {
+	case string:
+		email.To = []string{to}
+	case []interface{}:
+		for _, addr := range to {
+			email.To = append(email.To, fmt.Sprint(addr))
+		}
+	}
+	if len(email.To) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required")
+	}
+
+	if paths, ok := args["attachments"].([]interface{}); ok {
+		for _, p := range paths {
+			path, err := t.attachmentPath(fmt.Sprint(p))
+			if err != nil {
+				return nil, err
+			}
+			email.Attachments = append(email.Attachments, path)
+		}
+	}
+
+	email.Subject, _ = args["subject"].(string)
+	email.Text, _ = args["body"].(string)
+
+	if name, ok := args["template"].(string); ok && name != "" {
+		tmpl, ok := t.templates[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown email template: %s", name)
+		}
+		data, _ := args["data"].(map[string]interface{})
+		if err := renderEmailTemplate(email, tmpl, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if email.Subject == "" {
+		return nil, fmt.Errorf("subject is required")
+	}
+	return email, nil
+}
+
+// attachmentPath resolves path inside the attachment directory, refusing
+// symlinks so a link cannot point the tool at files outside it
+func (t *SendEmailTool) attachmentPath(path string) (string, error) {
+	if t.attachDir == "" {
+		return "", fmt.Errorf("attachments are not allowed: no attachment directory configured")
+	}
+	full, err := workspacePath(t.attachDir, path)
+	if err != nil {
+		return "", fmt.Errorf("invalid attachment %q: %w", path, err)
+	}
+
+	info, err := os.Lstat(full)
+	if err != nil {
+		return "", fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return "", fmt.Errorf("invalid attachment %q: symlinks are not allowed", path)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("invalid attachment %q: not a regular file", path)
+	}
+
+	// A symlinked parent directory could still lead outside the root
+	root, err := filepath.EvalSymlinks(t.attachDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve attachment directory: %w", err)
+	}
+	resolved, err := filepath.EvalSymlinks(full)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve attachment: %w", err)
+	}
+	if resolved != filepath.Join(root, filepath.Clean(path)) {
+		return "", fmt.Errorf("invalid attachment %q: symlinks are not allowed", path)
+	}
+	return full, nil
+}
+
+// renderEmailTemplate fills subject, text and HTML bodies from tmpl
+func renderEmailTemplate(email *Email, tmpl EmailTemplate, data map[string]interface{}) error {
+	render := func(src string) (string, error) {
+		t, err := texttemplate.New("email").Parse(src)
+		if err != nil {
+			return "", err
+		}
+		var b bytes.Buffer
+		err = t.Execute(&b, data)
+		return b.String(), err
+	}
+
+	var err error
+	if tmpl.Subject != "" {
+		if email.Subject, err = render(tmpl.Subject); err != nil {
+			return fmt.Errorf("failed to render subject: %w", err)
+		}
+	}
+	if tmpl.Text != "" {
+		if email.Text, err = render(tmpl.Text); err != nil {
+			return fmt.Errorf("failed to render text body: %w", err)
+		}
+	}
+	if tmpl.HTML != "" {
+		t, err := htmltemplate.New("email").Parse(tmpl.HTML)
+		if err != nil {
+			return fmt.Errorf("failed to parse HTML body: %w", err)
+		}
+		var b bytes.Buffer
+		if err := t.Execute(&b, data); err != nil {
+			return fmt.Errorf("failed to render HTML body: %w", err)
+		}
+		email.HTML = b.String()
+	}
+	return nil
+}
+
+// buildMIMEMessage encodes the email as a multipart MIME message
+func buildMIMEMessage(email *Email) ([]byte, error) {
+	var b bytes.Buffer
+	mixed := randomBoundary()
+	alt := randomBoundary()
+
+	fmt.Fprintf(&b, "From: %s\r\n", email.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mixed)
+
+	fmt.Fprintf(&b, "--%s\r\nContent-Type: multipart/alternative; boundary=%s\r\n\r\n", mixed, alt)
+	if email.Text != "" || email.HTML == "" {
+		fmt.Fprintf(&b, "--%s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n", alt, email.Text)
+	}
+	if email.HTML != "" {
+		fmt.Fprintf(&b, "--%s\r\nContent-Type: text/html; charset=utf-8\r\n\r\n%s\r\n", alt, email.HTML)
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", alt)
+
+	for _, path := range email.Attachments {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read attachment: %w", err)
+		}
+		contentType := mime.TypeByExtension(filepath.Ext(path))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		fmt.Fprintf(&b, "--%s\r\nContent-Type: %s\r\nContent-Transfer-Encoding: base64\r\n", mixed, contentType)
+		fmt.Fprintf(&b, "Content-Disposition: attachment; filename=%q\r\n\r\n", filepath.Base(path))
+
+		encoded := base64.StdEncoding.EncodeToString(data)
+		for len(encoded) > 76 {
+			fmt.Fprintf(&b, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		fmt.Fprintf(&b, "%s\r\n", encoded)
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", mixed)
+
+	return b.Bytes(), nil
+}
+
+// randomBoundary returns a MIME multipart boundary
+func randomBoundary() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return fmt.Sprintf("boundary_%x", buf)
+}
+
+// ReadEmailTool lists recent messages from an IMAP mailbox
+type ReadEmailTool struct {
+	*BaseTool
+	account EmailAccount
+}
+
+// NewReadEmailTool creates a tool that reads email over IMAP (TLS)
+func NewReadEmailTool(account EmailAccount) *ReadEmailTool {
+	if account.IMAPPort == 0 {
+		account.IMAPPort = 993
+	}
+	return &ReadEmailTool{
+		BaseTool: NewBaseTool(
+			"read_email",
+			"Read the most recent emails from a mailbox.",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"mailbox": map[string]interface{}{
+						"type":        "string",
+						"description": "Mailbox name (default INBOX)",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of emails to return (default 5)",
+					},
+					"unread_only": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only return unread emails",
+					},
+				},
+			},
+		),
+		account: account,
+	}
+}
+
+// Execute fetches and summarizes recent emails
+func (t *ReadEmailTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	mailbox, _ := args["mailbox"].(string)
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	limit := 5
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		limit = int(n)
+	}
+	unreadOnly, _ := args["unread_only"].(bool)
+
+	c, err := imapclient.DialTLS(fmt.Sprintf("%s:%d", t.account.IMAPHost, t.account.IMAPPort), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to IMAP server: %w", err)
+	}
+	defer c.Logout()
+
+	if err := c.Login(t.account.Username, t.account.Password); err != nil {
+		return "", fmt.Errorf("IMAP login failed: %w", err)
+	}
+	if _, err := c.Select(mailbox, true); err != nil {
+		return "", fmt.Errorf("failed to select mailbox %s: %w", mailbox, err)
+	}
+
+	criteria := imap.NewSearchCriteria()
+	if unreadOnly {
+		criteria.WithoutFlags = []string{imap.SeenFlag}
+	}
+	ids, err := c.Search(criteria)
+	if err != nil {
+		return "", fmt.Errorf("IMAP search failed: %w", err)
+	}
+	if len(ids) == 0 {
+		return "No emails found.", nil
+	}
+	if len(ids) > limit {
+		ids = ids[len(ids)-limit:]
+	}
+
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(ids...)
+	section := &imap.BodySectionName{
+		BodyPartName: imap.BodyPartName{Specifier: imap.TextSpecifier},
+		Peek:         true,
+	}
+
+	messages := make(chan *imap.Message, len(ids))
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Fetch(seqset, []imap.FetchItem{imap.FetchEnvelope, section.FetchItem()}, messages)
+	}()
+
+	var b strings.Builder
+	for msg := range messages {
+		if msg.Envelope == nil {
+			continue
+		}
+		from := ""
+		if len(msg.Envelope.From) > 0 {
+			from = msg.Envelope.From[0].Address()
+		}
+		fmt.Fprintf(&b, "From: %s\nDate: %s\nSubject: %s\n", from, msg.Envelope.Date.Format(time.RFC1123), msg.Envelope.Subject)
+
+		if body := msg.GetBody(section); body != nil {
+			text, _ := io.ReadAll(io.LimitReader(body, 500))
+			fmt.Fprintf(&b, "%s\n", strings.TrimSpace(string(text)))
+		}
+		b.WriteString("---\n")
+	}
+	if err := <-done; err != nil {
+		return "", fmt.Errorf("IMAP fetch failed: %w", err)
+	}
+
+	return b.String(), nil
+}
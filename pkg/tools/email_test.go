@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSendEmailAttachments(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "report.txt"), []byte("report"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(dir, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	args := func(path string) map[string]interface{} {
+		return map[string]interface{}{
+			"to":          []interface{}{"bob@example.com"},
+			"subject":     "Report",
+			"attachments": []interface{}{path},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		path    string
+		wantErr bool
+	}{
+		{name: "file in the directory", dir: dir, path: "report.txt"},
+		{name: "no attachment directory", path: "report.txt", wantErr: true},
+		{name: "absolute path", dir: dir, path: filepath.Join(outside, "secret.txt"), wantErr: true},
+		{name: "escapes the directory", dir: dir, path: "../" + filepath.Base(outside) + "/secret.txt", wantErr: true},
+		{name: "symlinked file", dir: dir, path: "link.txt", wantErr: true},
+		{name: "symlinked parent", dir: dir, path: "linked/secret.txt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := NewSendEmailTool(EmailAccount{Address: "me@example.com"})
+			if tt.dir != "" {
+				tool.WithAttachmentDir(tt.dir)
+			}
+			out, err := tool.Execute(context.Background(), args(tt.path))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !strings.HasPrefix(out, "DRY RUN") {
+				t.Errorf("Execute() = %q, want a dry-run preview", out)
+			}
+		})
+	}
+}
+
+func TestSendEmailApproval(t *testing.T) {
+	asked := 0
+	tool := NewSendEmailTool(EmailAccount{Address: "me@example.com"}).
+		WithDryRun(false).
+		WithApproval(func(ctx context.Context, email *Email) (bool, error) {
+			asked++
+			return false, nil
+		})
+
+	out, err := tool.Execute(context.Background(), map[string]interface{}{
+		"to":      []interface{}{"bob@example.com"},
+		"subject": "Hello",
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if asked != 1 {
+		t.Errorf("approval asked %d times, want 1", asked)
+	}
+	if !strings.Contains(out, "not approved") {
+		t.Errorf("Execute() = %q, want the email refused", out)
+	}
+}
@@ -0,0 +1,245 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mgreau/ai-agents-from-scratch-go/pkg/core"
+)
+
+// FineTuneFormat selects the chat layout written by ExportFineTune
+type FineTuneFormat string
+
+const (
+	// FormatHermes writes ShareGPT-style "conversations" with the Hermes
+	// <tools>, <tool_call> and <tool_response> tags inlined in the text.
+	FormatHermes FineTuneFormat = "hermes"
+	// FormatQwen writes "messages" plus "tools" as expected by the Qwen chat
+	// template, with structured tool_calls on assistant turns.
+	FormatQwen FineTuneFormat = "qwen"
+)
+
+// ToolConversation is a recorded conversation together with the tools that
+// were available to the model while it ran
+type ToolConversation struct {
+	Messages []core.Message
+	Tools    []map[string]interface{}
+}
+
+// NewToolConversation records messages with the function definitions of registry
+func NewToolConversation(messages []core.Message, registry *ToolRegistry) ToolConversation {
+	return ToolConversation{Messages: messages, Tools: registry.GetFunctionDefinitions()}
+}
+
+// Redact returns a copy of the conversation with messages scrubbed by r,
+// to be applied before exporting transcripts that may hold personal data
+func (c ToolConversation) Redact(r *core.Redactor) ToolConversation {
+	return ToolConversation{Messages: r.RedactMessages(c.Messages), Tools: c.Tools}
+}
+
+// ExportFineTune writes one JSON line per conversation in the given format.
+// Conversations without any tool call, or with a call whose arguments are not
+// valid JSON, are skipped since they teach nothing useful about tool use; the
+// number of exported conversations is returned.
+func ExportFineTune(w io.Writer, conversations []ToolConversation, format FineTuneFormat) (int, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+
+	written := 0
+	for i, conv := range conversations {
+		if !usableForTraining(conv.Messages) {
+			continue
+		}
+
+		var record interface{}
+		var err error
+		switch format {
+		case FormatHermes:
+			record, err = hermesRecord(conv)
+		case FormatQwen:
+			record, err = qwenRecord(conv)
+		default:
+			return written, fmt.Errorf("unknown fine-tune format: %s", format)
+		}
+		if err != nil {
+			return written, fmt.Errorf("conversation %d: %w", i, err)
+		}
+		if err := enc.Encode(record); err != nil {
+			return written, err
+		}
+		written++
+	}
+	return written, bw.Flush()
+}
+
+// ExportFineTuneFile writes the conversations as a JSONL file at path
+func ExportFineTuneFile(path string, conversations []ToolConversation, format FineTuneFormat) (int, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	n, err := ExportFineTune(f, conversations, format)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+// usableForTraining reports whether the conversation has tool calls and all
+// of them carry well-formed arguments
+func usableForTraining(messages []core.Message) bool {
+	calls := 0
+	for _, msg := range messages {
+		ai, ok := msg.(*core.AIMessage)
+		if !ok {
+			continue
+		}
+		for _, call := range ai.ToolCalls {
+			if _, err := callArguments(call); err != nil {
+				return false
+			}
+			calls++
+		}
+	}
+	return calls > 0
+}
+
+// callArguments returns the arguments of a tool call as a JSON object
+func callArguments(call core.ToolCall) (json.RawMessage, error) {
+	if call.Function.Arguments != "" {
+		raw := json.RawMessage(call.Function.Arguments)
+		if !json.Valid(raw) {
+			return nil, fmt.Errorf("tool call %s has invalid arguments: %s", call.Function.Name, call.Function.Arguments)
+		}
+		return raw, nil
+	}
+	if call.Args == nil {
+		return json.RawMessage("{}"), nil
+	}
+	return json.Marshal(call.Args)
+}
+
+// toolNames maps tool call IDs to the name of the called tool
+func toolNames(messages []core.Message) map[string]string {
+	names := make(map[string]string)
+	for _, msg := range messages {
+		if ai, ok := msg.(*core.AIMessage); ok {
+			for _, call := range ai.ToolCalls {
+				names[call.ID] = call.Function.Name
+			}
+		}
+	}
+	return names
+}
+
+type shareGPTTurn struct {
+	From  string `json:"from"`
+	Value string `json:"value"`
+}
+
+// hermesSystemPrompt is the tool-use system prompt used by Hermes function-calling data
+const hermesSystemPrompt = `You are a function calling AI model. You are provided with function signatures within <tools></tools> XML tags. You may call one or more functions to assist with the user query. Don't make assumptions about what values to plug into functions. Here are the available tools: <tools>%s</tools> For each function call return a json object with function name and arguments within <tool_call></tool_call> XML tags as follows:
+<tool_call>
+{"name": <function-name>, "arguments": <args-dict>}
+</tool_call>`
+
+// hermesRecord converts a conversation to the Hermes ShareGPT layout
+func hermesRecord(conv ToolConversation) (interface{}, error) {
+	toolsJSON, err := json.Marshal(conv.Tools)
+	if err != nil {
+		return nil, err
+	}
+
+	system := fmt.Sprintf(hermesSystemPrompt, toolsJSON)
+	turns := []shareGPTTurn{}
+	names := toolNames(conv.Messages)
+
+	for _, msg := range conv.Messages {
+		switch m := msg.(type) {
+		case *core.SystemMessage:
+			// Keep the recorded instructions after the tool preamble
+			system += "\n\n" + m.GetContent()
+		case *core.HumanMessage:
+			turns = append(turns, shareGPTTurn{From: "human", Value: m.GetContent()})
+		case *core.AIMessage:
+			var parts []string
+			if text := strings.TrimSpace(m.GetContent()); text != "" {
+				parts = append(parts, text)
+			}
+			for _, call := range m.ToolCalls {
+				args, err := callArguments(call)
+				if err != nil {
+					return nil, err
+				}
+				payload, err := json.Marshal(map[string]interface{}{"name": call.Function.Name, "arguments": args})
+				if err != nil {
+					return nil, err
+				}
+				parts = append(parts, fmt.Sprintf("<tool_call>\n%s\n</tool_call>", payload))
+			}
+			turns = append(turns, shareGPTTurn{From: "gpt", Value: strings.Join(parts, "\n")})
+		case *core.ToolMessage:
+			payload, err := json.Marshal(map[string]string{"name": names[m.ToolCallID], "content": m.GetContent()})
+			if err != nil {
+				return nil, err
+			}
+			turns = append(turns, shareGPTTurn{From: "tool", Value: fmt.Sprintf("<tool_response>\n%s\n</tool_response>", payload)})
+		}
+	}
+
+	return map[string]interface{}{
+		"conversations": append([]shareGPTTurn{{From: "system", Value: system}}, turns...),
+		"tools":         string(toolsJSON),
+	}, nil
+}
+
+// qwenRecord converts a conversation to the Qwen messages/tools layout
+func qwenRecord(conv ToolConversation) (interface{}, error) {
+	messages := []map[string]interface{}{}
+	names := toolNames(conv.Messages)
+
+	for _, msg := range conv.Messages {
+		switch m := msg.(type) {
+		case *core.SystemMessage:
+			messages = append(messages, map[string]interface{}{"role": "system", "content": m.GetContent()})
+		case *core.HumanMessage:
+			messages = append(messages, map[string]interface{}{"role": "user", "content": m.GetContent()})
+		case *core.AIMessage:
+			turn := map[string]interface{}{"role": "assistant", "content": m.GetContent()}
+			if m.HasToolCalls() {
+				calls := make([]map[string]interface{}, 0, len(m.ToolCalls))
+				for _, call := range m.ToolCalls {
+					args, err := callArguments(call)
+					if err != nil {
+						return nil, err
+					}
+					calls = append(calls, map[string]interface{}{
+						"type": "function",
+						"function": map[string]interface{}{
+							"name":      call.Function.Name,
+							"arguments": args,
+						},
+					})
+				}
+				turn["tool_calls"] = calls
+			}
+			messages = append(messages, turn)
+		case *core.ToolMessage:
+			messages = append(messages, map[string]interface{}{
+				"role":    "tool",
+				"name":    names[m.ToolCallID],
+				"content": m.GetContent(),
+			})
+		}
+	}
+
+	return map[string]interface{}{
+		"messages": messages,
+		"tools":    conv.Tools,
+	}, nil
+}
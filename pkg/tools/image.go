@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ImageBackendConfig configures an OpenAI-compatible image/vision endpoint
+// (OpenAI, LocalAI, llama.cpp server with a multimodal model, ...)
+type ImageBackendConfig struct {
+	BaseURL   string // e.g. http://localhost:8080/v1
+	APIKey    string
+	Model     string
+	OutputDir string // where generated images are written
+	Timeout   time.Duration
+}
+
+// postJSON sends a JSON request to the backend and decodes the JSON response
+func (c ImageBackendConfig) postJSON(ctx context.Context, path string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.BaseURL, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Minute
+	}
+	resp, err := (&http.Client{Timeout: timeout}).Do(req)
+	if err != nil {
+		return fmt.Errorf("image backend request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("image backend returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// ImageGenerationTool generates an image from a text prompt
+type ImageGenerationTool struct {
+	*BaseTool
+	config ImageBackendConfig
+}
+
+// NewImageGenerationTool creates a tool that calls an /images/generations endpoint
+func NewImageGenerationTool(config ImageBackendConfig) *ImageGenerationTool {
+	if config.OutputDir == "" {
+		config.OutputDir = os.TempDir()
+	}
+	return &ImageGenerationTool{
+		BaseTool: NewBaseTool(
+			"generate_image",
+			"Generate an image from a text description. Returns the path of the saved image file.",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"prompt": map[string]interface{}{
+						"type":        "string",
+						"description": "Detailed description of the image to generate",
+					},
+					"size": map[string]interface{}{
+						"type":        "string",
+						"description": "Image size such as 512x512 (optional)",
+					},
+				},
+				"required": []string{"prompt"},
+			},
+		),
+		config: config,
+	}
+}
+
+// Execute generates the image and saves it to the output directory
+func (t *ImageGenerationTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	prompt, ok := args["prompt"].(string)
+	if !ok || prompt == "" {
+		return "", fmt.Errorf("prompt must be a non-empty string")
+	}
+	size, _ := args["size"].(string)
+	if size == "" {
+		size = "512x512"
+	}
+
+	var resp struct {
+		Data []struct {
+			B64JSON string `json:"b64_json"`
+		} `json:"data"`
+	}
+	err := t.config.postJSON(ctx, "/images/generations", map[string]interface{}{
+		"model":           t.config.Model,
+		"prompt":          prompt,
+		"size":            size,
+		"n":               1,
+		"response_format": "b64_json",
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Data) == 0 || resp.Data[0].B64JSON == "" {
+		return "", fmt.Errorf("image backend returned no image")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(resp.Data[0].B64JSON)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	if err := os.MkdirAll(t.config.OutputDir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(t.config.OutputDir, fmt.Sprintf("image_%d.png", time.Now().UnixNano()))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to save image: %w", err)
+	}
+
+	return fmt.Sprintf("Image saved to %s", path), nil
+}
+
+// ImageDescribeTool describes an image using a multimodal chat model
+type ImageDescribeTool struct {
+	*BaseTool
+	config ImageBackendConfig
+}
+
+// NewImageDescribeTool creates a tool that sends images to a vision-capable /chat/completions endpoint
+func NewImageDescribeTool(config ImageBackendConfig) *ImageDescribeTool {
+	return &ImageDescribeTool{
+		BaseTool: NewBaseTool(
+			"describe_image",
+			"Describe the contents of a local image file, optionally answering a question about it.",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path to the image file",
+					},
+					"question": map[string]interface{}{
+						"type":        "string",
+						"description": "What to look for in the image (optional)",
+					},
+				},
+				"required": []string{"path"},
+			},
+		),
+		config: config,
+	}
+}
+
+// Execute reads the image and asks the vision model about it
+func (t *ImageDescribeTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	path, ok := args["path"].(string)
+	if !ok || path == "" {
+		return "", fmt.Errorf("path must be a non-empty string")
+	}
+	question, _ := args["question"].(string)
+	if question == "" {
+		question = "Describe this image in detail."
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	mimeType := mime.TypeByExtension(filepath.Ext(path))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	dataURL := fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(data))
+
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	err = t.config.postJSON(ctx, "/chat/completions", map[string]interface{}{
+		"model": t.config.Model,
+		"messages": []map[string]interface{}{
+			{
+				"role": "user",
+				"content": []map[string]interface{}{
+					{"type": "text", "text": question},
+					{"type": "image_url", "image_url": map[string]string{"url": dataURL}},
+				},
+			},
+		},
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("vision backend returned no choices")
+	}
+
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// InputRequest is returned as the error of a tool that cannot proceed
+// without asking the user, e.g. to resolve an ambiguous parameter
+type InputRequest struct {
+	Question string
+	// Field is the argument the answer is stored in when the tool is
+	// invoked again; empty means "clarification"
+	Field string
+}
+
+// Error returns the question
+func (r *InputRequest) Error() string {
+	return "needs input: " + r.Question
+}
+
+// NeedsInput returns an InputRequest asking question, whose answer becomes
+// argument field on the next call
+func NeedsInput(question, field string) error {
+	return &InputRequest{Question: question, Field: field}
+}
+
+// AsInputRequest returns the InputRequest in err's chain, if any
+func AsInputRequest(err error) (*InputRequest, bool) {
+	var req *InputRequest
+	ok := errors.As(err, &req)
+	return req, ok
+}
+
+// InputFunc asks the user a question and returns the answer
+type InputFunc func(ctx context.Context, question string) (string, error)
+
+// PromptInput asks questions on w and reads one-line answers from r, for
+// command-line agents
+func PromptInput(r io.Reader, w io.Writer) InputFunc {
+	reader := bufio.NewReader(r)
+	return func(ctx context.Context, question string) (string, error) {
+		fmt.Fprintf(w, "%s\n> ", question)
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read answer: %w", err)
+		}
+		return strings.TrimSpace(line), nil
+	}
+}
+
+// maxInputRounds bounds how often a tool may ask for input in one call
+const maxInputRounds = 3
+
+// ExecuteToolInteractive executes a tool like ExecuteTool, but when the tool
+// asks for input, it gets the answer from ask and invokes the tool again
+// with the answer added to the arguments
+func (r *ToolRegistry) ExecuteToolInteractive(ctx context.Context, name string, argsJSON string, ask InputFunc) (string, error) {
+	tool, args, err := r.prepare(name, argsJSON)
+	if err != nil {
+		return "", err
+	}
+
+	for round := 0; ; round++ {
+		result, err := r.execute(ctx, tool, args)
+		req, ok := AsInputRequest(err)
+		if !ok || ask == nil || round == maxInputRounds {
+			return result, err
+		}
+
+		answer, err := ask(ctx, req.Question)
+		if err != nil {
+			return "", fmt.Errorf("asking for input failed: %w", err)
+		}
+		field := req.Field
+		if field == "" {
+			field = "clarification"
+		}
+		args[field] = answer
+	}
+}
@@ -0,0 +1,418 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Hunk is one @@ section of a unified diff. Lines keep their ' ', '-' or '+' prefix.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []string
+}
+
+// oldBlock returns the lines the hunk expects to find in the original file
+func (h Hunk) oldBlock() []string {
+	var out []string
+	for _, l := range h.Lines {
+		if l[0] != '+' {
+			out = append(out, l[1:])
+		}
+	}
+	return out
+}
+
+// newBlock returns the lines that replace the old block
+func (h Hunk) newBlock() []string {
+	var out []string
+	for _, l := range h.Lines {
+		if l[0] != '-' {
+			out = append(out, l[1:])
+		}
+	}
+	return out
+}
+
+// counts returns the number of old and new lines in the hunk body
+func (h Hunk) counts() (oldN, newN int) {
+	for _, l := range h.Lines {
+		switch l[0] {
+		case ' ':
+			oldN++
+			newN++
+		case '-':
+			oldN++
+		case '+':
+			newN++
+		}
+	}
+	return oldN, newN
+}
+
+// complete reports whether the hunk body has all the lines its header announced
+func (h Hunk) complete() bool {
+	oldN, newN := h.counts()
+	return oldN >= h.OldLines && newN >= h.NewLines
+}
+
+// FilePatch is the set of hunks for one file
+type FilePatch struct {
+	OldPath string // "" when the file is created
+	NewPath string // "" when the file is deleted
+	Hunks   []Hunk
+}
+
+// Path returns the workspace path the patch applies to
+func (p FilePatch) Path() string {
+	if p.NewPath != "" {
+		return p.NewPath
+	}
+	return p.OldPath
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParsePatch parses a unified diff, such as the output of `git diff` or a
+// model reply containing one (```diff fences are ignored). Hunks are checked
+// against their header line counts so truncated model output is rejected.
+func ParsePatch(text string) ([]FilePatch, error) {
+	var patches []FilePatch
+	var cur *FilePatch
+	var hunk *Hunk
+
+	flushHunk := func() error {
+		if hunk == nil {
+			return nil
+		}
+		oldN, newN := hunk.counts()
+		if oldN != hunk.OldLines || newN != hunk.NewLines {
+			return fmt.Errorf("%s: hunk @@ -%d,%d +%d,%d @@ has %d old and %d new lines",
+				cur.Path(), hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines, oldN, newN)
+		}
+		cur.Hunks = append(cur.Hunks, *hunk)
+		hunk = nil
+		return nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case (hunk == nil || hunk.complete()) && strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if err := flushHunk(); err != nil {
+				return nil, err
+			}
+			patches = append(patches, FilePatch{
+				OldPath: patchPath(line[4:]),
+				NewPath: patchPath(lines[i+1][4:]),
+			})
+			cur = &patches[len(patches)-1]
+			i++
+		case strings.HasPrefix(line, "@@"):
+			if cur == nil {
+				return nil, fmt.Errorf("hunk before any file header: %s", line)
+			}
+			if err := flushHunk(); err != nil {
+				return nil, err
+			}
+			m := hunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("malformed hunk header: %s", line)
+			}
+			hunk = &Hunk{
+				OldStart: atoiDefault(m[1], 0),
+				OldLines: atoiDefault(m[2], 1),
+				NewStart: atoiDefault(m[3], 0),
+				NewLines: atoiDefault(m[4], 1),
+			}
+		case hunk != nil && line != "" && strings.ContainsRune(" -+", rune(line[0])):
+			hunk.Lines = append(hunk.Lines, line)
+		case hunk != nil && line == "" && !hunk.complete():
+			// Models and editors often strip the space of empty context lines
+			hunk.Lines = append(hunk.Lines, " ")
+		case strings.HasPrefix(line, `\ No newline`):
+			// Ignored: files are always written with a trailing newline
+		default:
+			if err := flushHunk(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flushHunk(); err != nil {
+		return nil, err
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no file patches found")
+	}
+	return patches, nil
+}
+
+// patchPath strips the a/ b/ prefixes and timestamps from a header path
+func patchPath(s string) string {
+	if i := strings.IndexByte(s, '\t'); i != -1 {
+		s = s[:i]
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		s = s[2:]
+	}
+	return s
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// PatchConflict describes a hunk that could not be applied
+type PatchConflict struct {
+	Path   string
+	Hunk   int // 1-based, 0 for file-level problems
+	Reason string
+}
+
+func (c PatchConflict) String() string {
+	if c.Hunk == 0 {
+		return fmt.Sprintf("%s: %s", c.Path, c.Reason)
+	}
+	return fmt.Sprintf("%s hunk %d: %s", c.Path, c.Hunk, c.Reason)
+}
+
+// PatchReport lists the files changed by ApplyPatch and the conflicts found
+type PatchReport struct {
+	Applied   []string
+	Conflicts []PatchConflict
+}
+
+// OK reports whether every file patch applied cleanly
+func (r *PatchReport) OK() bool {
+	return len(r.Conflicts) == 0
+}
+
+// String summarizes the report for a model or a log
+func (r *PatchReport) String() string {
+	var b strings.Builder
+	for _, p := range r.Applied {
+		fmt.Fprintf(&b, "applied: %s\n", p)
+	}
+	for _, c := range r.Conflicts {
+		fmt.Fprintf(&b, "conflict: %s\n", c)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// ApplyPatch applies patches to the files under root. Each file is patched
+// all-or-nothing: if any of its hunks conflicts the file is left untouched and
+// the conflict is reported. Hunks are located by their context, starting at
+// the line number in the header, since models often get line numbers wrong.
+// With dryRun set nothing is written.
+func ApplyPatch(root string, patches []FilePatch, dryRun bool) (*PatchReport, error) {
+	report := &PatchReport{}
+	for _, p := range patches {
+		path := p.Path()
+		full, err := workspacePath(root, path)
+		if err != nil {
+			report.Conflicts = append(report.Conflicts, PatchConflict{Path: path, Reason: err.Error()})
+			continue
+		}
+
+		var lines []string
+		if p.OldPath != "" {
+			data, err := os.ReadFile(full)
+			if err != nil {
+				report.Conflicts = append(report.Conflicts, PatchConflict{Path: path, Reason: "cannot read file: " + err.Error()})
+				continue
+			}
+			lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		} else if _, err := os.Stat(full); err == nil {
+			report.Conflicts = append(report.Conflicts, PatchConflict{Path: path, Reason: "file to create already exists"})
+			continue
+		}
+
+		patched, conflicts := applyHunks(path, lines, p.Hunks)
+		if len(conflicts) > 0 {
+			report.Conflicts = append(report.Conflicts, conflicts...)
+			continue
+		}
+		report.Applied = append(report.Applied, path)
+		if dryRun {
+			continue
+		}
+
+		if p.NewPath == "" {
+			if err := os.Remove(full); err != nil {
+				return report, fmt.Errorf("failed to delete %s: %w", path, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			return report, err
+		}
+		content := strings.Join(patched, "\n")
+		if len(patched) > 0 {
+			content += "\n"
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			return report, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return report, nil
+}
+
+// applyHunks applies hunks in order, tracking the offset earlier hunks introduced
+func applyHunks(path string, lines []string, hunks []Hunk) ([]string, []PatchConflict) {
+	var conflicts []PatchConflict
+	out := append([]string(nil), lines...)
+	offset := 0
+	minPos := 0
+
+	for i, h := range hunks {
+		old := h.oldBlock()
+		want := h.OldStart - 1 + offset
+		if h.OldLines == 0 {
+			// Pure insertion: OldStart is the line after which to insert
+			want = h.OldStart + offset
+		}
+		pos := findBlock(out, old, want, minPos)
+		if pos < 0 {
+			conflicts = append(conflicts, PatchConflict{Path: path, Hunk: i + 1, Reason: "context does not match the file"})
+			continue
+		}
+
+		repl := h.newBlock()
+		out = append(out[:pos], append(repl, out[pos+len(old):]...)...)
+		offset += len(repl) - len(old)
+		minPos = pos + len(repl)
+	}
+	return out, conflicts
+}
+
+// findBlock returns where block occurs in lines at or after minPos, preferring
+// the occurrence closest to want; trailing whitespace is ignored as a fallback
+func findBlock(lines, block []string, want, minPos int) int {
+	if len(block) == 0 {
+		if want < minPos {
+			want = minPos
+		}
+		if want > len(lines) {
+			want = len(lines)
+		}
+		return want
+	}
+
+	for _, eq := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") },
+	} {
+		best := -1
+		for pos := minPos; pos+len(block) <= len(lines); pos++ {
+			if !blockAt(lines, block, pos, eq) {
+				continue
+			}
+			if best < 0 || absInt(pos-want) < absInt(best-want) {
+				best = pos
+			}
+		}
+		if best >= 0 {
+			return best
+		}
+	}
+	return -1
+}
+
+func blockAt(lines, block []string, pos int, eq func(a, b string) bool) bool {
+	for j, l := range block {
+		if !eq(lines[pos+j], l) {
+			return false
+		}
+	}
+	return true
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// workspacePath resolves a patch path under root, refusing paths that escape it
+func workspacePath(root, path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("missing file path")
+	}
+	if filepath.IsAbs(path) {
+		return "", fmt.Errorf("absolute paths are not allowed")
+	}
+	full := filepath.Join(root, path)
+	rel, err := filepath.Rel(root, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path escapes the workspace")
+	}
+	return full, nil
+}
+
+// ApplyPatchTool lets a coding agent change workspace files with a unified diff
+type ApplyPatchTool struct {
+	*BaseTool
+	root string
+}
+
+// NewApplyPatchTool creates a patch tool confined to the root directory
+func NewApplyPatchTool(root string) *ApplyPatchTool {
+	return &ApplyPatchTool{
+		BaseTool: NewBaseTool(
+			"apply_patch",
+			"Apply a unified diff to files in the workspace. Prefer small patches with 3 lines of context over rewriting whole files.",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"patch": map[string]interface{}{
+						"type":        "string",
+						"description": "Unified diff with --- a/path and +++ b/path headers",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Only check that the patch applies (optional)",
+					},
+				},
+				"required": []string{"patch"},
+			},
+		),
+		root: root,
+	}
+}
+
+// Execute parses and applies the patch, reporting applied files and conflicts
+func (t *ApplyPatchTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	text, ok := args["patch"].(string)
+	if !ok || text == "" {
+		return "", fmt.Errorf("patch must be a non-empty string")
+	}
+	dryRun, _ := args["dry_run"].(bool)
+
+	patches, err := ParsePatch(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid patch: %w", err)
+	}
+	report, err := ApplyPatch(t.root, patches, dryRun)
+	if err != nil {
+		return "", err
+	}
+	return report.String(), nil
+}
@@ -0,0 +1,225 @@
+package tools
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		want    []FilePatch
+		wantErr string
+	}{
+		{
+			name: "modify",
+			patch: `--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-var x = 1
++var x = 2
+ func main() {}
+`,
+			want: []FilePatch{{
+				OldPath: "main.go",
+				NewPath: "main.go",
+				Hunks: []Hunk{{OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3,
+					Lines: []string{" package main", "-var x = 1", "+var x = 2", " func main() {}"}}},
+			}},
+		},
+		{
+			name: "blank context line without its space",
+			patch: `--- a/f.txt
++++ b/f.txt
+@@ -1,3 +1,3 @@
+ one
+
+-three
++3
+`,
+			want: []FilePatch{{
+				OldPath: "f.txt",
+				NewPath: "f.txt",
+				Hunks: []Hunk{{OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 3,
+					Lines: []string{" one", " ", "-three", "+3"}}},
+			}},
+		},
+		{
+			name: "removed and added lines looking like file headers",
+			patch: `--- a/q.sql
++++ b/q.sql
+@@ -1,2 +1,2 @@
+--- old comment
++++ new comment
+ SELECT 1;
+`,
+			want: []FilePatch{{
+				OldPath: "q.sql",
+				NewPath: "q.sql",
+				Hunks: []Hunk{{OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2,
+					Lines: []string{"--- old comment", "+++ new comment", " SELECT 1;"}}},
+			}},
+		},
+		{
+			name: "create and delete in fenced model output",
+			patch: "Here is the change:\n```diff\n" + `--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++hello
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+` + "```\n",
+			want: []FilePatch{
+				{NewPath: "new.txt", Hunks: []Hunk{{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 1, Lines: []string{"+hello"}}}},
+				{OldPath: "old.txt", Hunks: []Hunk{{OldStart: 1, OldLines: 1, NewStart: 0, NewLines: 0, Lines: []string{"-bye"}}}},
+			},
+		},
+		{
+			name: "truncated hunk",
+			patch: `--- a/f.txt
++++ b/f.txt
+@@ -1,3 +1,3 @@
+ one
+-two
+`,
+			wantErr: "hunk @@ -1,3 +1,3 @@ has 3 old and 2 new lines",
+		},
+		{
+			name:    "hunk before file header",
+			patch:   "@@ -1 +1 @@\n-a\n+b\n",
+			wantErr: "hunk before any file header",
+		},
+		{
+			name:    "no patch",
+			patch:   "just some text",
+			wantErr: "no file patches found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePatch(tt.patch)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParsePatch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePatch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePatch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyHunks(t *testing.T) {
+	file := []string{"a", "b", "c", "d", "e", "f", "g"}
+	tests := []struct {
+		name      string
+		lines     []string
+		hunks     []Hunk
+		want      []string
+		conflicts int
+	}{
+		{
+			name:  "exact position",
+			lines: file,
+			hunks: []Hunk{{OldStart: 2, OldLines: 3, Lines: []string{" b", "-c", "+C", " d"}}},
+			want:  []string{"a", "b", "C", "d", "e", "f", "g"},
+		},
+		{
+			name:  "wrong line number found by context",
+			lines: file,
+			hunks: []Hunk{{OldStart: 1, OldLines: 3, Lines: []string{" e", "-f", "+F", " g"}}},
+			want:  []string{"a", "b", "c", "d", "e", "F", "g"},
+		},
+		{
+			name:  "later hunk shifted by an earlier one",
+			lines: file,
+			hunks: []Hunk{
+				{OldStart: 1, OldLines: 1, Lines: []string{" a", "+a2", "+a3"}},
+				{OldStart: 6, OldLines: 2, Lines: []string{" f", "-g"}},
+			},
+			want: []string{"a", "a2", "a3", "b", "c", "d", "e", "f"},
+		},
+		{
+			name:  "closest of repeated blocks",
+			lines: []string{"x", "y", "x", "y", "x", "y"},
+			hunks: []Hunk{{OldStart: 5, OldLines: 2, Lines: []string{" x", "-y", "+Y"}}},
+			want:  []string{"x", "y", "x", "y", "x", "Y"},
+		},
+		{
+			name:  "trailing whitespace ignored as a fallback",
+			lines: []string{"a  ", "b\t", "c"},
+			hunks: []Hunk{{OldStart: 1, OldLines: 2, Lines: []string{" a", "-b", "+B"}}},
+			want:  []string{"a", "B", "c"},
+		},
+		{
+			name:  "insertion",
+			lines: []string{"a", "b"},
+			hunks: []Hunk{{OldStart: 1, OldLines: 0, Lines: []string{"+between"}}},
+			want:  []string{"a", "between", "b"},
+		},
+		{
+			name:      "context not in file",
+			lines:     file,
+			hunks:     []Hunk{{OldStart: 2, OldLines: 2, Lines: []string{" b", "-z", "+Z"}}},
+			want:      file,
+			conflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := applyHunks("f.txt", tt.lines, tt.hunks)
+			if len(conflicts) != tt.conflicts {
+				t.Fatalf("applyHunks() conflicts = %v, want %d", conflicts, tt.conflicts)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyHunks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkspacePath(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "ws")
+	tests := []struct {
+		path    string
+		want    string
+		wantErr string
+	}{
+		{path: "main.go", want: filepath.Join(root, "main.go")},
+		{path: "pkg/a/b.go", want: filepath.Join(root, "pkg", "a", "b.go")},
+		{path: "pkg/../main.go", want: filepath.Join(root, "main.go")},
+		{path: "", wantErr: "missing file path"},
+		{path: "../secret", wantErr: "escapes the workspace"},
+		{path: "pkg/../../secret", wantErr: "escapes the workspace"},
+		{path: "..", wantErr: "escapes the workspace"},
+		{path: "/etc/passwd", wantErr: "absolute paths are not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := workspacePath(root, tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("workspacePath(%q) = %q, %v; want error %q", tt.path, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("workspacePath(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reminder is a note due at a given time
+type Reminder struct {
+	ID   int       `json:"id"`
+	Text string    `json:"text"`
+	Due  time.Time `json:"due"`
+	Done bool      `json:"done"`
+}
+
+// ReminderStore keeps reminders in a JSON file
+type ReminderStore struct {
+	mu        sync.Mutex
+	path      string
+	reminders []Reminder
+	nextID    int
+}
+
+// NewReminderStore opens (or creates) a reminder store backed by path
+func NewReminderStore(path string) (*ReminderStore, error) {
+	s := &ReminderStore{path: path, nextID: 1}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reminders: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.reminders); err != nil {
+		return nil, fmt.Errorf("failed to parse reminders: %w", err)
+	}
+	for _, r := range s.reminders {
+		if r.ID >= s.nextID {
+			s.nextID = r.ID + 1
+		}
+	}
+	return s, nil
+}
+
+// Add stores a new reminder
+func (s *ReminderStore) Add(text string, due time.Time) (Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := Reminder{ID: s.nextID, Text: text, Due: due}
+	s.nextID++
+	s.reminders = append(s.reminders, r)
+	return r, s.save()
+}
+
+// List returns reminders sorted by due time, optionally including completed ones
+func (s *ReminderStore) List(includeDone bool) []Reminder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Reminder
+	for _, r := range s.reminders {
+		if includeDone || !r.Done {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Due.Before(out[j].Due) })
+	return out
+}
+
+// Due returns open reminders due at or before now
+func (s *ReminderStore) Due(now time.Time) []Reminder {
+	var due []Reminder
+	for _, r := range s.List(false) {
+		if !r.Due.After(now) {
+			due = append(due, r)
+		}
+	}
+	return due
+}
+
+// Complete marks a reminder as done
+func (s *ReminderStore) Complete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.reminders {
+		if s.reminders[i].ID == id {
+			s.reminders[i].Done = true
+			return s.save()
+		}
+	}
+	return fmt.Errorf("reminder not found: %d", id)
+}
+
+// save writes the reminders to disk; callers must hold the lock
+func (s *ReminderStore) save() error {
+	data, err := json.MarshalIndent(s.reminders, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save reminders: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// ReminderTool lets an agent add, list and complete reminders
+type ReminderTool struct {
+	*BaseTool
+	store *ReminderStore
+}
+
+// NewReminderTool creates a new ReminderTool
+func NewReminderTool(store *ReminderStore) *ReminderTool {
+	return &ReminderTool{
+		BaseTool: NewBaseTool(
+			"reminders",
+			"Manage reminders. Actions: add (text, due), list, complete (id).",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type": "string",
+						"enum": []string{"add", "list", "complete"},
+					},
+					"text": map[string]interface{}{
+						"type":        "string",
+						"description": "Reminder text (for add)",
+					},
+					"due": map[string]interface{}{
+						"type":        "string",
+						"description": "When the reminder is due, RFC 3339 (for add)",
+					},
+					"id": map[string]interface{}{
+						"type":        "integer",
+						"description": "Reminder ID (for complete)",
+					},
+				},
+				"required": []string{"action"},
+			},
+		),
+		store: store,
+	}
+}
+
+// Execute performs the requested reminder action
+func (t *ReminderTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	action, _ := args["action"].(string)
+
+	switch action {
+	case "add":
+		text, _ := args["text"].(string)
+		if text == "" {
+			return "", fmt.Errorf("text is required")
+		}
+		due, err := parseToolTime(args, "due")
+		if err != nil {
+			return "", err
+		}
+		r, err := t.store.Add(text, due)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Reminder %d set for %s: %s", r.ID, r.Due.Format(time.RFC3339), r.Text), nil
+
+	case "list":
+		reminders := t.store.List(false)
+		if len(reminders) == 0 {
+			return "No reminders.", nil
+		}
+		var b strings.Builder
+		for _, r := range reminders {
+			fmt.Fprintf(&b, "- [%d] %s: %s\n", r.ID, r.Due.Format(time.RFC3339), r.Text)
+		}
+		return b.String(), nil
+
+	case "complete":
+		id, ok := args["id"].(float64)
+		if !ok {
+			return "", fmt.Errorf("id must be a number")
+		}
+		if err := t.store.Complete(int(id)); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Reminder %d completed", int(id)), nil
+
+	default:
+		return "", fmt.Errorf("unknown action: %s", action)
+	}
+}
@@ -0,0 +1,288 @@
+package tools
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FeedItem is a single entry from an RSS or Atom feed
+type FeedItem struct {
+	Feed      string
+	Title     string
+	Link      string
+	Summary   string
+	Published time.Time
+}
+
+// rssDocument covers RSS 2.0 and Atom in a single decode
+type rssDocument struct {
+	XMLName xml.Name
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"date"`
+}
+
+type atomEntry struct {
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+// FetchFeed downloads and parses an RSS 2.0 or Atom feed, newest items first
+func FetchFeed(ctx context.Context, url string) ([]FeedItem, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "ai-agents-from-scratch feed reader")
+
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed %s returned %s", url, resp.Status)
+	}
+	return ParseFeed(url, resp.Body)
+}
+
+// ParseFeed parses RSS 2.0 or Atom XML, newest items first
+func ParseFeed(feedURL string, r io.Reader) ([]FeedItem, error) {
+	var doc rssDocument
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	var items []FeedItem
+	for _, it := range doc.Channel.Items {
+		date := it.PubDate
+		if date == "" {
+			date = it.Date
+		}
+		items = append(items, FeedItem{
+			Feed:      feedURL,
+			Title:     strings.TrimSpace(it.Title),
+			Link:      strings.TrimSpace(it.Link),
+			Summary:   stripHTML(it.Description),
+			Published: parseFeedTime(date),
+		})
+	}
+	for _, e := range doc.Entries {
+		link := ""
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		summary := e.Summary
+		if summary == "" {
+			summary = e.Content
+		}
+		date := e.Published
+		if date == "" {
+			date = e.Updated
+		}
+		items = append(items, FeedItem{
+			Feed:      feedURL,
+			Title:     strings.TrimSpace(e.Title),
+			Link:      link,
+			Summary:   stripHTML(summary),
+			Published: parseFeedTime(date),
+		})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Published.After(items[j].Published) })
+	return items, nil
+}
+
+// parseFeedTime accepts the date formats commonly found in feeds
+func parseFeedTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123, time.RFC3339, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// stripHTML removes tags and collapses whitespace in feed summaries
+func stripHTML(s string) string {
+	s = htmlTagPattern.ReplaceAllString(s, " ")
+	s = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&#39;", "'", "&nbsp;", " ").Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// RSSTool fetches recent items from an RSS or Atom feed
+type RSSTool struct {
+	*BaseTool
+}
+
+// NewRSSTool creates a new RSSTool
+func NewRSSTool() *RSSTool {
+	return &RSSTool{
+		BaseTool: NewBaseTool(
+			"read_feed",
+			"Fetch the latest items from an RSS or Atom news feed.",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "Feed URL",
+					},
+					"since": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return items published after this time (RFC 3339, optional)",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of items (default 10)",
+					},
+				},
+				"required": []string{"url"},
+			},
+		),
+	}
+}
+
+// feedQuery is a parsed read_feed call
+type feedQuery struct {
+	url   string
+	limit int
+	since time.Time
+}
+
+// parseFeedQuery validates the read_feed arguments
+func parseFeedQuery(args map[string]interface{}) (feedQuery, error) {
+	q := feedQuery{limit: 10}
+	url, ok := args["url"].(string)
+	if !ok || url == "" {
+		return q, fmt.Errorf("url must be a non-empty string")
+	}
+	q.url = url
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		q.limit = int(n)
+	}
+	if s, ok := args["since"].(string); ok && s != "" {
+		var err error
+		if q.since, err = time.Parse(time.RFC3339, s); err != nil {
+			return q, fmt.Errorf("invalid since: %w", err)
+		}
+	}
+	return q, nil
+}
+
+// Execute fetches the feed and lists its items
+func (t *RSSTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	q, err := parseFeedQuery(args)
+	if err != nil {
+		return "", err
+	}
+	items, err := FetchFeed(ctx, q.url)
+	if err != nil {
+		return "", err
+	}
+	return q.render(items), nil
+}
+
+// ExecuteBatch fetches every distinct feed once, concurrently, and answers
+// each call from the downloaded items
+func (t *RSSTool) ExecuteBatch(ctx context.Context, args []map[string]interface{}) ([]BatchResult, error) {
+	type feed struct {
+		items []FeedItem
+		err   error
+	}
+
+	results := make([]BatchResult, len(args))
+	queries := make([]feedQuery, len(args))
+	feeds := make(map[string]*feed)
+	for i, a := range args {
+		queries[i], results[i].Err = parseFeedQuery(a)
+		if results[i].Err == nil {
+			feeds[queries[i].url] = &feed{}
+		}
+	}
+
+	var wg sync.WaitGroup
+	for url, f := range feeds {
+		wg.Add(1)
+		go func(url string, f *feed) {
+			defer wg.Done()
+			f.items, f.err = FetchFeed(ctx, url)
+		}(url, f)
+	}
+	wg.Wait()
+
+	for i, q := range queries {
+		if results[i].Err != nil {
+			continue
+		}
+		f := feeds[q.url]
+		if f.err != nil {
+			results[i].Err = f.err
+			continue
+		}
+		results[i].Output = q.render(f.items)
+	}
+	return results, nil
+}
+
+// render lists the items matching the query
+func (q feedQuery) render(items []FeedItem) string {
+	var b strings.Builder
+	count := 0
+	for _, it := range items {
+		if !q.since.IsZero() && !it.Published.After(q.since) {
+			continue
+		}
+		if count == q.limit {
+			break
+		}
+		count++
+		fmt.Fprintf(&b, "- %s (%s)\n  %s\n", it.Title, it.Published.Format("2006-01-02"), it.Link)
+		if it.Summary != "" {
+			summary := it.Summary
+			if runes := []rune(summary); len(runes) > 300 {
+				summary = string(runes[:300]) + "..."
+			}
+			fmt.Fprintf(&b, "  %s\n", summary)
+		}
+	}
+	if count == 0 {
+		return "No new items."
+	}
+	return b.String()
+}
@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SandboxResult is the outcome of one go command run in the sandbox
+type SandboxResult struct {
+	Command  string
+	Output   string
+	ExitCode int
+	TimedOut bool
+}
+
+// OK reports whether the command succeeded
+func (r SandboxResult) OK() bool {
+	return r.ExitCode == 0 && !r.TimedOut
+}
+
+// GoSandbox runs go commands on model-written code in a throwaway module.
+// Each run gets a fresh temporary directory, no network access to the module
+// proxy and a hard timeout. It is not a security boundary: `go run` and
+// `go test` execute the code with the caller's privileges.
+type GoSandbox struct {
+	GoBin      string
+	ModulePath string
+	Timeout    time.Duration
+}
+
+// NewGoSandbox creates a sandbox using the go binary on PATH
+func NewGoSandbox() *GoSandbox {
+	return &GoSandbox{
+		GoBin:      "go",
+		ModulePath: "sandbox",
+		Timeout:    60 * time.Second,
+	}
+}
+
+// Run writes files (name -> content) into a fresh module and runs `go args...`
+func (s *GoSandbox) Run(ctx context.Context, files map[string]string, args ...string) (SandboxResult, error) {
+	dir, err := os.MkdirTemp("", "go-sandbox-*")
+	if err != nil {
+		return SandboxResult{}, fmt.Errorf("failed to create sandbox dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	gomod := fmt.Sprintf("module %s\n\ngo 1.21\n", s.ModulePath)
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0o644); err != nil {
+		return SandboxResult{}, err
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.Clean("/"+name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return SandboxResult{}, err
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return SandboxResult{}, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, s.GoBin, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOPROXY=off", "GOFLAGS=-mod=mod", "GOWORK=off", "GO111MODULE=on")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	result := SandboxResult{Command: "go " + strings.Join(args, " ")}
+	err = cmd.Run()
+	// Paths in compiler output point into the temp dir; keep them relative
+	result.Output = strings.ReplaceAll(out.String(), dir+string(filepath.Separator), "")
+
+	var exitErr *exec.ExitError
+	switch {
+	case runCtx.Err() == context.DeadlineExceeded:
+		result.TimedOut = true
+		result.ExitCode = -1
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return result, fmt.Errorf("failed to run %s: %w", result.Command, err)
+	}
+	return result, nil
+}
+
+// Check runs `go build` and then `go vet`, returning the first failure
+func (s *GoSandbox) Check(ctx context.Context, files map[string]string) (SandboxResult, error) {
+	result, err := s.Run(ctx, files, "build", "./...")
+	if err != nil || !result.OK() {
+		return result, err
+	}
+	return s.Run(ctx, files, "vet", "./...")
+}
+
+// GoSandboxTool lets an agent build, vet, test or run a Go file
+type GoSandboxTool struct {
+	*BaseTool
+	sandbox *GoSandbox
+}
+
+// NewGoSandboxTool creates a tool backed by sandbox
+func NewGoSandboxTool(sandbox *GoSandbox) *GoSandboxTool {
+	return &GoSandboxTool{
+		BaseTool: NewBaseTool(
+			"go_sandbox",
+			"Compile and check a single Go source file in an isolated module. Reports compiler, vet or test output.",
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"code": map[string]interface{}{
+						"type":        "string",
+						"description": "Complete Go source file",
+					},
+					"command": map[string]interface{}{
+						"type":        "string",
+						"description": "One of check (build + vet, default), build, vet, test, run",
+					},
+				},
+				"required": []string{"code"},
+			},
+		),
+		sandbox: sandbox,
+	}
+}
+
+// Execute runs the requested go command on the code
+func (t *GoSandboxTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	code, ok := args["code"].(string)
+	if !ok || code == "" {
+		return "", fmt.Errorf("code must be a non-empty string")
+	}
+	command, _ := args["command"].(string)
+	files := map[string]string{"main.go": code}
+
+	var result SandboxResult
+	var err error
+	switch command {
+	case "", "check":
+		result, err = t.sandbox.Check(ctx, files)
+	case "build", "vet", "test":
+		result, err = t.sandbox.Run(ctx, files, command, "./...")
+	case "run":
+		result, err = t.sandbox.Run(ctx, files, "run", ".")
+	default:
+		return "", fmt.Errorf("unknown command: %s", command)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	status := "succeeded"
+	if result.TimedOut {
+		status = "timed out"
+	} else if !result.OK() {
+		status = fmt.Sprintf("failed (exit %d)", result.ExitCode)
+	}
+	return fmt.Sprintf("%s %s\n%s", result.Command, status, result.Output), nil
+}
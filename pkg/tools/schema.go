@@ -0,0 +1,150 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ToolFormat is a wire format for tool definitions
+type ToolFormat string
+
+const (
+	FormatOpenAI    ToolFormat = "openai"
+	FormatAnthropic ToolFormat = "anthropic"
+	FormatMCP       ToolFormat = "mcp"
+)
+
+// NormalizeSchema returns a copy of a JSON schema that strict providers
+// accept: objects always have "properties", "required" is a sorted []string
+// listing only declared properties, and enums are []interface{}. Nested
+// properties and array items are normalized too.
+func NormalizeSchema(schema map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(schema)+1)
+	for k, v := range schema {
+		out[k] = v
+	}
+	if out["type"] == nil {
+		if _, ok := out["properties"]; ok {
+			out["type"] = "object"
+		}
+	}
+
+	if out["type"] == "object" {
+		props, _ := out["properties"].(map[string]interface{})
+		normalized := make(map[string]interface{}, len(props))
+		for name, prop := range props {
+			if p, ok := prop.(map[string]interface{}); ok {
+				normalized[name] = NormalizeSchema(p)
+			} else {
+				normalized[name] = prop
+			}
+		}
+		out["properties"] = normalized
+
+		var required []string
+		for _, name := range stringList(out["required"]) {
+			if _, ok := normalized[name]; ok {
+				required = append(required, name)
+			}
+		}
+		if len(required) > 0 {
+			sort.Strings(required)
+			out["required"] = required
+		} else {
+			delete(out, "required")
+		}
+	}
+
+	if items, ok := out["items"].(map[string]interface{}); ok {
+		out["items"] = NormalizeSchema(items)
+	}
+
+	if enum, ok := out["enum"]; ok {
+		switch values := enum.(type) {
+		case []string:
+			list := make([]interface{}, len(values))
+			for i, v := range values {
+				list[i] = v
+			}
+			out["enum"] = list
+		case []interface{}:
+		default:
+			delete(out, "enum")
+		}
+	}
+	return out
+}
+
+// stringList reads a []string or []interface{} of strings
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// ToToolDefinition converts a tool to the given wire format
+func ToToolDefinition(tool Tool, format ToolFormat) (map[string]interface{}, error) {
+	schema := NormalizeSchema(tool.ArgsSchema())
+	switch format {
+	case FormatOpenAI:
+		return map[string]interface{}{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        tool.Name(),
+				"description": tool.Description(),
+				"parameters":  schema,
+			},
+		}, nil
+	case FormatAnthropic:
+		return map[string]interface{}{
+			"name":         tool.Name(),
+			"description":  tool.Description(),
+			"input_schema": schema,
+		}, nil
+	case FormatMCP:
+		return map[string]interface{}{
+			"name":        tool.Name(),
+			"description": tool.Description(),
+			"inputSchema": schema,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown tool format: %s", format)
+	}
+}
+
+// Definitions returns every tool in the given format, sorted by name
+func (r *ToolRegistry) Definitions(format ToolFormat) ([]map[string]interface{}, error) {
+	tools := r.GetAll()
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name() < tools[j].Name() })
+
+	defs := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		def, err := ToToolDefinition(tool, format)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// ExportJSON renders the registry as a JSON array in the given format, ready
+// to send as the "tools" field of a request (or an MCP tools/list result)
+func (r *ToolRegistry) ExportJSON(format ToolFormat) ([]byte, error) {
+	defs, err := r.Definitions(format)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(defs, "", "  ")
+}
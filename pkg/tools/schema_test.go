@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// schemaTool is a tool with a fixed schema that does nothing
+type schemaTool struct {
+	*BaseTool
+}
+
+func newSchemaTool(name string, schema map[string]interface{}) *schemaTool {
+	return &schemaTool{BaseTool: NewBaseTool(name, "Test tool "+name, schema)}
+}
+
+func (t *schemaTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	return "", nil
+}
+
+func TestNormalizeSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{
+			name:   "object without properties",
+			schema: map[string]interface{}{"type": "object"},
+			want:   map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+		},
+		{
+			name: "missing required",
+			schema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"q": map[string]interface{}{"type": "string"}},
+			},
+			want: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"q": map[string]interface{}{"type": "string"}},
+			},
+		},
+		{
+			name: "required sorted and limited to declared properties",
+			schema: map[string]interface{}{
+				"properties": map[string]interface{}{
+					"b": map[string]interface{}{"type": "string"},
+					"a": map[string]interface{}{"type": "string"},
+				},
+				"required": []interface{}{"b", "a", "gone"},
+			},
+			want: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"b": map[string]interface{}{"type": "string"},
+					"a": map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"a", "b"},
+			},
+		},
+		{
+			name: "only undeclared required",
+			schema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+				"required":   []string{"gone"},
+			},
+			want: map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+		},
+		{
+			name: "nested objects and array items",
+			schema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"filter": map[string]interface{}{
+						"properties": map[string]interface{}{
+							"op": map[string]interface{}{"type": "string", "enum": []string{"=", "<"}},
+						},
+						"required": []string{"op", "value"},
+					},
+					"rows": map[string]interface{}{
+						"type":  "array",
+						"items": map[string]interface{}{"type": "object"},
+					},
+				},
+			},
+			want: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"filter": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"op": map[string]interface{}{"type": "string", "enum": []interface{}{"=", "<"}},
+						},
+						"required": []string{"op"},
+					},
+					"rows": map[string]interface{}{
+						"type":  "array",
+						"items": map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+					},
+				},
+			},
+		},
+		{
+			name:   "enum of strings",
+			schema: map[string]interface{}{"type": "string", "enum": []string{"count", "sum"}},
+			want:   map[string]interface{}{"type": "string", "enum": []interface{}{"count", "sum"}},
+		},
+		{
+			name:   "enum of mixed values kept",
+			schema: map[string]interface{}{"enum": []interface{}{1, "two"}},
+			want:   map[string]interface{}{"enum": []interface{}{1, "two"}},
+		},
+		{
+			name:   "invalid enum dropped",
+			schema: map[string]interface{}{"type": "string", "enum": "count"},
+			want:   map[string]interface{}{"type": "string"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeSchema(tt.schema); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeSchema() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToToolDefinition(t *testing.T) {
+	tool := newSchemaTool("search", map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"q": map[string]interface{}{"type": "string"}},
+		"required":   []string{"q"},
+	})
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"q": map[string]interface{}{"type": "string"}},
+		"required":   []string{"q"},
+	}
+
+	tests := []struct {
+		format ToolFormat
+		want   map[string]interface{}
+	}{
+		{
+			format: FormatOpenAI,
+			want: map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        "search",
+					"description": "Test tool search",
+					"parameters":  schema,
+				},
+			},
+		},
+		{
+			format: FormatAnthropic,
+			want: map[string]interface{}{
+				"name":         "search",
+				"description":  "Test tool search",
+				"input_schema": schema,
+			},
+		},
+		{
+			format: FormatMCP,
+			want: map[string]interface{}{
+				"name":        "search",
+				"description": "Test tool search",
+				"inputSchema": schema,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			got, err := ToToolDefinition(tool, tt.format)
+			if err != nil {
+				t.Fatalf("ToToolDefinition() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToToolDefinition() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ToToolDefinition(tool, "gemini"); err == nil {
+		t.Error("ToToolDefinition() with an unknown format: error = nil, want an error")
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.Register(newSchemaTool("zeta", map[string]interface{}{"type": "object"}))
+	registry.Register(newSchemaTool("alpha", map[string]interface{}{"type": "object"}))
+
+	data, err := registry.ExportJSON(FormatMCP)
+	if err != nil {
+		t.Fatalf("ExportJSON() error = %v", err)
+	}
+	var defs []struct {
+		Name        string                 `json:"name"`
+		InputSchema map[string]interface{} `json:"inputSchema"`
+	}
+	if err := json.Unmarshal(data, &defs); err != nil {
+		t.Fatalf("ExportJSON() is not a JSON array: %v", err)
+	}
+	if len(defs) != 2 || defs[0].Name != "alpha" || defs[1].Name != "zeta" {
+		t.Fatalf("ExportJSON() = %s, want alpha then zeta", data)
+	}
+	if _, ok := defs[0].InputSchema["properties"]; !ok {
+		t.Errorf("exported schema %v has no properties", defs[0].InputSchema)
+	}
+
+	if _, err := registry.ExportJSON("gemini"); err == nil {
+		t.Error("ExportJSON() with an unknown format: error = nil, want an error")
+	}
+}
@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ToolSimulator is implemented by tools with side effects. Simulate validates
+// the arguments and returns what Execute would report, without doing it.
+type ToolSimulator interface {
+	Simulate(ctx context.Context, args map[string]interface{}) (string, error)
+}
+
+// ReadOnlyTool is implemented by tools without side effects, which run
+// normally in simulation mode
+type ReadOnlyTool interface {
+	ReadOnly() bool
+}
+
+// readOnlyTool marks any tool as free of side effects
+type readOnlyTool struct {
+	Tool
+}
+
+func (readOnlyTool) ReadOnly() bool { return true }
+
+// WithReadOnly declares that tool has no side effects, so it runs normally
+// in simulation mode
+func WithReadOnly(tool Tool) Tool {
+	return readOnlyTool{Tool: tool}
+}
+
+// simulate runs tool in simulation mode: its own simulation if it has one,
+// the tool itself if it is read-only, and otherwise nothing, since a tool
+// that declares neither may have side effects
+func simulate(ctx context.Context, tool Tool, args map[string]interface{}) (string, error) {
+	if sim, ok := tool.(ToolSimulator); ok {
+		return sim.Simulate(ctx, args)
+	}
+	if ro, ok := tool.(ReadOnlyTool); ok && ro.ReadOnly() {
+		return tool.Execute(ctx, args)
+	}
+	return fmt.Sprintf("SIMULATED - %s was not run, it may have side effects", tool.Name()), nil
+}
+
+// SimulateFunc produces the mock response of a simulated tool
+type SimulateFunc func(ctx context.Context, args map[string]interface{}) (string, error)
+
+// MockResponse returns a SimulateFunc that always answers response
+func MockResponse(response string) SimulateFunc {
+	return func(ctx context.Context, args map[string]interface{}) (string, error) {
+		return response, nil
+	}
+}
+
+// SimulatedTool adds a declared mock response to any tool
+type SimulatedTool struct {
+	Tool
+	simulate SimulateFunc
+}
+
+// WithSimulation declares how tool behaves in simulation mode
+func WithSimulation(tool Tool, simulate SimulateFunc) *SimulatedTool {
+	return &SimulatedTool{Tool: tool, simulate: simulate}
+}
+
+// Simulate returns the declared mock response
+func (t *SimulatedTool) Simulate(ctx context.Context, args map[string]interface{}) (string, error) {
+	return t.simulate(ctx, args)
+}
+
+// Simulate previews the email without sending it
+func (t *SendEmailTool) Simulate(ctx context.Context, args map[string]interface{}) (string, error) {
+	email, err := t.buildEmail(args)
+	if err != nil {
+		return "", err
+	}
+	return "SIMULATED - email not sent:\n" + email.String(), nil
+}
+
+// Simulate validates the event without creating it
+func (t *CreateEventTool) Simulate(ctx context.Context, args map[string]interface{}) (string, error) {
+	event, err := eventFromArgs(args)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("SIMULATED - event %q from %s to %s not created", event.Summary, event.Start.Format(time.RFC3339), event.End.Format(time.RFC3339)), nil
+}
+
+// Simulate answers list requests from the store and pretends to change it
+func (t *ReminderTool) Simulate(ctx context.Context, args map[string]interface{}) (string, error) {
+	action, _ := args["action"].(string)
+	switch action {
+	case "list":
+		return t.Execute(ctx, args)
+	case "add":
+		text, _ := args["text"].(string)
+		if text == "" {
+			return "", fmt.Errorf("text is required")
+		}
+		due, err := parseToolTime(args, "due")
+		if err != nil {
+			return "", err
+		}
+		t.store.mu.Lock()
+		id := t.store.nextID
+		t.store.mu.Unlock()
+		return fmt.Sprintf("SIMULATED - reminder %d not set for %s: %s", id, due.Format(time.RFC3339), text), nil
+	case "complete":
+		id, ok := args["id"].(float64)
+		if !ok {
+			return "", fmt.Errorf("id must be a number")
+		}
+		return fmt.Sprintf("SIMULATED - reminder %d not completed", int(id)), nil
+	default:
+		return "", fmt.Errorf("unknown action: %s", action)
+	}
+}
+
+// Simulate checks that the patch applies without writing any file
+func (t *ApplyPatchTool) Simulate(ctx context.Context, args map[string]interface{}) (string, error) {
+	dry := make(map[string]interface{}, len(args)+1)
+	for k, v := range args {
+		dry[k] = v
+	}
+	dry["dry_run"] = true
+	return t.Execute(ctx, dry)
+}
+
+// Simulate reports where the image would have been saved
+func (t *ImageGenerationTool) Simulate(ctx context.Context, args map[string]interface{}) (string, error) {
+	prompt, ok := args["prompt"].(string)
+	if !ok || prompt == "" {
+		return "", fmt.Errorf("prompt must be a non-empty string")
+	}
+	return fmt.Sprintf("SIMULATED - image for %q would be saved to %s", prompt, t.config.OutputDir), nil
+}
+
+// The tools below only read, so they run as usual in simulation mode
+
+func (t *GetCurrentTimeTool) ReadOnly() bool { return true }
+func (t *CalculatorTool) ReadOnly() bool     { return true }
+func (t *ListEventsTool) ReadOnly() bool     { return true }
+func (t *FindFreeSlotsTool) ReadOnly() bool  { return true }
+func (t *ReadEmailTool) ReadOnly() bool      { return true }
+func (t *ImageDescribeTool) ReadOnly() bool  { return true }
+func (t *RSSTool) ReadOnly() bool            { return true }
+func (t *TableQueryTool) ReadOnly() bool     { return true }
@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// countingTool records how many times it ran
+type countingTool struct {
+	*BaseTool
+	runs int
+}
+
+func newCountingTool(name string) *countingTool {
+	return &countingTool{BaseTool: NewBaseTool(name, "Test tool "+name, map[string]interface{}{"type": "object"})}
+}
+
+func (t *countingTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	t.runs++
+	return "ran", nil
+}
+
+func TestSimulationMode(t *testing.T) {
+	ctx := context.Background()
+	unknown := newCountingTool("click")
+	readOnly := newCountingTool("lookup")
+
+	registry := NewToolRegistry().SetSimulation(true)
+	registry.Register(unknown)
+	registry.Register(WithReadOnly(readOnly))
+	registry.Register(NewCreateEventTool(nil))
+
+	out, err := registry.ExecuteTool(ctx, "click", "")
+	if err != nil || !strings.HasPrefix(out, "SIMULATED - ") || unknown.runs != 0 {
+		t.Errorf("tool without simulation: %q, %v after %d runs, want a simulated result without running it", out, err, unknown.runs)
+	}
+
+	out, err = registry.ExecuteTool(ctx, "lookup", "")
+	if err != nil || out != "ran" || readOnly.runs != 1 {
+		t.Errorf("read-only tool: %q, %v after %d runs, want it run once", out, err, readOnly.runs)
+	}
+
+	out, err = registry.ExecuteTool(ctx, "create_event", `{"summary":"Lunch","start":"2026-10-16T12:00:00Z","end":"2026-10-16T13:00:00Z"}`)
+	if err != nil || !strings.HasPrefix(out, "SIMULATED - ") {
+		t.Errorf("create_event: %q, %v, want a SIMULATED result", out, err)
+	}
+}
@@ -0,0 +1,407 @@
+package tools
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Table is a small in-memory table of string cells
+type Table struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// LoadCSV reads a CSV file whose first row is the header
+func LoadCSV(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV: %w", err)
+	}
+	defer f.Close()
+	return ReadCSV(f)
+}
+
+// ReadCSV reads CSV data whose first row is the header
+func ReadCSV(r io.Reader) (*Table, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV has no header row")
+	}
+	return &Table{Columns: records[0], Rows: records[1:]}, nil
+}
+
+// ColumnIndex returns the index of a column, or -1 if it does not exist
+func (t *Table) ColumnIndex(name string) int {
+	for i, c := range t.Columns {
+		if strings.EqualFold(c, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Describe returns the column names and a few sample rows for prompting
+func (t *Table) Describe(sampleRows int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Columns: %s\n", strings.Join(t.Columns, ", "))
+	fmt.Fprintf(&b, "Rows: %d\n", len(t.Rows))
+	for i := 0; i < sampleRows && i < len(t.Rows); i++ {
+		fmt.Fprintf(&b, "Sample: %s\n", strings.Join(t.Rows[i], ", "))
+	}
+	return b.String()
+}
+
+// TableFilter is a single column condition
+type TableFilter struct {
+	Column string `json:"column"`
+	Op     string `json:"op"` // =, !=, >, >=, <, <=, contains
+	Value  string `json:"value"`
+}
+
+// TableQuery describes a filter/group/aggregate operation over a table
+type TableQuery struct {
+	Filters   []TableFilter `json:"filters,omitempty"`
+	GroupBy   string        `json:"group_by,omitempty"`
+	Aggregate string        `json:"aggregate,omitempty"` // count, sum, avg, min, max
+	Column    string        `json:"column,omitempty"`    // column to aggregate
+	Select    []string      `json:"select,omitempty"`
+	Limit     int           `json:"limit,omitempty"`
+}
+
+// Query runs q against the table and returns the resulting table
+func (t *Table) Query(q TableQuery) (*Table, error) {
+	rows, err := t.filter(q.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	var out *Table
+	if q.Aggregate != "" {
+		out, err = t.aggregate(rows, q)
+	} else {
+		out, err = t.project(rows, q.Select)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Limit > 0 && len(out.Rows) > q.Limit {
+		out.Rows = out.Rows[:q.Limit]
+	}
+	return out, nil
+}
+
+// filter returns the rows matching every filter
+func (t *Table) filter(filters []TableFilter) ([][]string, error) {
+	idx := make([]int, len(filters))
+	for i, f := range filters {
+		if idx[i] = t.ColumnIndex(f.Column); idx[i] < 0 {
+			return nil, fmt.Errorf("unknown column: %s", f.Column)
+		}
+	}
+
+	var rows [][]string
+	for _, row := range t.Rows {
+		match := true
+		for i, f := range filters {
+			ok, err := compareCell(row[idx[i]], f.Op, f.Value)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				match = false
+				break
+			}
+		}
+		if match {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// project keeps only the selected columns
+func (t *Table) project(rows [][]string, columns []string) (*Table, error) {
+	if len(columns) == 0 {
+		return &Table{Columns: t.Columns, Rows: rows}, nil
+	}
+
+	idx := make([]int, len(columns))
+	for i, c := range columns {
+		if idx[i] = t.ColumnIndex(c); idx[i] < 0 {
+			return nil, fmt.Errorf("unknown column: %s", c)
+		}
+	}
+
+	out := &Table{Columns: columns}
+	for _, row := range rows {
+		projected := make([]string, len(idx))
+		for i, j := range idx {
+			projected[i] = row[j]
+		}
+		out.Rows = append(out.Rows, projected)
+	}
+	return out, nil
+}
+
+// aggregate computes q.Aggregate over q.Column, optionally per q.GroupBy value
+func (t *Table) aggregate(rows [][]string, q TableQuery) (*Table, error) {
+	valueIdx := -1
+	if q.Aggregate != "count" {
+		if valueIdx = t.ColumnIndex(q.Column); valueIdx < 0 {
+			return nil, fmt.Errorf("unknown column: %s", q.Column)
+		}
+	}
+
+	groupIdx := -1
+	if q.GroupBy != "" {
+		if groupIdx = t.ColumnIndex(q.GroupBy); groupIdx < 0 {
+			return nil, fmt.Errorf("unknown column: %s", q.GroupBy)
+		}
+	}
+
+	groups := make(map[string][][]string)
+	var keys []string
+	for _, row := range rows {
+		key := ""
+		if groupIdx >= 0 {
+			key = row[groupIdx]
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 && groupIdx < 0 {
+		keys = []string{""}
+	}
+
+	label := q.Aggregate
+	if q.Column != "" {
+		label = fmt.Sprintf("%s(%s)", q.Aggregate, q.Column)
+	}
+	out := &Table{Columns: []string{label}}
+	if groupIdx >= 0 {
+		out.Columns = []string{q.GroupBy, label}
+	}
+
+	for _, key := range keys {
+		value, err := aggregateRows(groups[key], valueIdx, q.Aggregate)
+		if err != nil {
+			return nil, err
+		}
+		if groupIdx >= 0 {
+			out.Rows = append(out.Rows, []string{key, value})
+		} else {
+			out.Rows = append(out.Rows, []string{value})
+		}
+	}
+	return out, nil
+}
+
+// aggregateRows applies an aggregate function to one column of rows
+func aggregateRows(rows [][]string, col int, fn string) (string, error) {
+	if fn == "count" {
+		return strconv.Itoa(len(rows)), nil
+	}
+
+	values := make([]float64, 0, len(rows))
+	for _, row := range rows {
+		v, err := strconv.ParseFloat(strings.TrimSpace(row[col]), 64)
+		if err != nil {
+			return "", fmt.Errorf("non-numeric value %q in %s", row[col], fn)
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		// An empty sum is 0, but avg, min and max have no value at all
+		if fn == "sum" {
+			return "0", nil
+		}
+		return "", fmt.Errorf("no matching rows to compute %s", fn)
+	}
+
+	var result float64
+	switch fn {
+	case "sum", "avg":
+		for _, v := range values {
+			result += v
+		}
+		if fn == "avg" {
+			result /= float64(len(values))
+		}
+	case "min":
+		result = math.Inf(1)
+		for _, v := range values {
+			result = math.Min(result, v)
+		}
+	case "max":
+		result = math.Inf(-1)
+		for _, v := range values {
+			result = math.Max(result, v)
+		}
+	default:
+		return "", fmt.Errorf("unsupported aggregate: %s", fn)
+	}
+
+	return strconv.FormatFloat(result, 'f', -1, 64), nil
+}
+
+// compareCell evaluates "cell op value", numerically when both sides are numbers
+func compareCell(cell, op, value string) (bool, error) {
+	a, errA := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+	b, errB := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	numeric := errA == nil && errB == nil
+
+	cmp := strings.Compare(strings.ToLower(cell), strings.ToLower(value))
+	if numeric {
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	}
+
+	switch op {
+	case "=", "==":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case "contains":
+		return strings.Contains(strings.ToLower(cell), strings.ToLower(value)), nil
+	default:
+		return false, fmt.Errorf("unsupported filter op: %s", op)
+	}
+}
+
+// String renders the table as CSV
+func (t *Table) String() string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(t.Columns)
+	w.WriteAll(t.Rows)
+	return b.String()
+}
+
+// TableQueryTool lets an agent compute answers over a table instead of guessing numbers
+type TableQueryTool struct {
+	*BaseTool
+	table *Table
+}
+
+// NewTableQueryTool creates a tool exposing filter/group/aggregate operations on table
+func NewTableQueryTool(table *Table) *TableQueryTool {
+	description := "Run a filter/group/aggregate operation on the data table and return the computed rows. " +
+		"Always use this tool for numbers instead of guessing.\n" + table.Describe(3)
+
+	return &TableQueryTool{
+		BaseTool: NewBaseTool(
+			"table_query",
+			description,
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"filters": map[string]interface{}{
+						"type":        "array",
+						"description": "Row conditions, e.g. [{\"column\":\"country\",\"op\":\"=\",\"value\":\"France\"}]. Ops: =, !=, >, >=, <, <=, contains",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"column": map[string]interface{}{"type": "string"},
+								"op":     map[string]interface{}{"type": "string"},
+								"value":  map[string]interface{}{"type": "string"},
+							},
+						},
+					},
+					"group_by": map[string]interface{}{
+						"type":        "string",
+						"description": "Column to group by before aggregating",
+					},
+					"aggregate": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"count", "sum", "avg", "min", "max"},
+						"description": "Aggregate function to apply",
+					},
+					"column": map[string]interface{}{
+						"type":        "string",
+						"description": "Column to aggregate (not needed for count)",
+					},
+					"select": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Columns to return when not aggregating",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of rows to return",
+					},
+				},
+			},
+		),
+		table: table,
+	}
+}
+
+// Execute runs the requested operation on the table
+func (t *TableQueryTool) Execute(ctx context.Context, args map[string]interface{}) (string, error) {
+	q := TableQuery{Limit: 20}
+
+	if filters, ok := args["filters"].([]interface{}); ok {
+		for _, raw := range filters {
+			f, ok := raw.(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf("each filter must be an object")
+			}
+			q.Filters = append(q.Filters, TableFilter{
+				Column: fmt.Sprint(f["column"]),
+				Op:     fmt.Sprint(f["op"]),
+				Value:  fmt.Sprint(f["value"]),
+			})
+		}
+	}
+	if s, ok := args["group_by"].(string); ok {
+		q.GroupBy = s
+	}
+	if s, ok := args["aggregate"].(string); ok {
+		q.Aggregate = strings.ToLower(s)
+	}
+	if s, ok := args["column"].(string); ok {
+		q.Column = s
+	}
+	if cols, ok := args["select"].([]interface{}); ok {
+		for _, c := range cols {
+			q.Select = append(q.Select, fmt.Sprint(c))
+		}
+	}
+	if n, ok := args["limit"].(float64); ok && n > 0 {
+		q.Limit = int(n)
+	}
+
+	result, err := t.table.Query(q)
+	if err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
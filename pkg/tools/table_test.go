@@ -0,0 +1,30 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableAggregateNoRows(t *testing.T) {
+	table, err := ReadCSV(strings.NewReader("country,price\nFrance,10\nSpain,20\n"))
+	if err != nil {
+		t.Fatalf("ReadCSV() error = %v", err)
+	}
+	noRows := []TableFilter{{Column: "country", Op: "=", Value: "Italy"}}
+
+	for _, fn := range []string{"avg", "min", "max"} {
+		if _, err := table.Query(TableQuery{Filters: noRows, Aggregate: fn, Column: "price"}); err == nil {
+			t.Errorf("%s over no rows: error = nil, want an error", fn)
+		}
+	}
+
+	for fn, want := range map[string]string{"sum": "0", "count": "0"} {
+		out, err := table.Query(TableQuery{Filters: noRows, Aggregate: fn, Column: "price"})
+		if err != nil {
+			t.Fatalf("%s over no rows: error = %v", fn, err)
+		}
+		if got := out.Rows[0][0]; got != want {
+			t.Errorf("%s over no rows = %q, want %q", fn, got, want)
+		}
+	}
+}